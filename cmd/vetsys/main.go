@@ -1,47 +1,197 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
+	"log/slog"
 	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+	"vetsys/internal/apierror"
+	"vetsys/internal/config"
 	"vetsys/internal/database"
+	"vetsys/internal/domain"
 	"vetsys/internal/handler"
+	"vetsys/internal/health"
+	"vetsys/internal/jobs"
+	"vetsys/internal/metrics"
+	"vetsys/internal/middleware"
+	"vetsys/internal/notify"
 	"vetsys/internal/router"
 	"vetsys/internal/server"
 
 	"github.com/jmoiron/sqlx"
-	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
 )
 
+const usage = `Usage:
+  vetsys [flags]                 start the HTTP server (applies pending migrations first)
+  vetsys [flags] migrate up      apply all pending migrations
+  vetsys [flags] migrate down    roll back the most recently applied migration
+  vetsys [flags] migrate status  list migrations and whether they are applied
+  vetsys [flags] config print    show the effective configuration, secrets redacted
+
+Run vetsys -h to list the flags.`
+
 func main() {
-	// Load .env file
-	if err := godotenv.Load(); err != nil {
-		log.Println("No .env file found, using environment variables")
+	cfg, args, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		fmt.Fprintln(os.Stderr, usage)
+		return
 	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	slog.SetDefault(cfg.NewLogger(os.Stdout))
+	apierror.HideInternalErrors = cfg.IsProduction()
 
-	dbConnectionString := os.Getenv("DATABASE_URL")
-	if dbConnectionString == "" {
-		log.Fatal("DATABASE_URL environment variable is not set")
+	if len(args) > 0 && args[0] == "config" {
+		if len(args) != 2 || args[1] != "print" {
+			fmt.Fprintln(os.Stderr, usage)
+			os.Exit(2)
+		}
+		if err := cfg.Print(os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
 	}
 
-	sqlxDB, err := sqlx.Connect("postgres", dbConnectionString)
+	sqlxDB, err := sqlx.Connect("postgres", cfg.DatabaseURL)
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
 	defer sqlxDB.Close()
 
-	db := database.NewDataBase(sqlxDB)
-	err = db.Init()
+	migrator, err := database.NewMigrator(sqlxDB)
 	if err != nil {
+		log.Fatalf("Failed to load migrations: %v", err)
+	}
+
+	if len(args) > 0 {
+		if args[0] != "migrate" || len(args) != 2 {
+			fmt.Fprintln(os.Stderr, usage)
+			os.Exit(2)
+		}
+		if err := runMigrate(migrator, args[1]); err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+		return
+	}
+
+	if _, err := migrator.Up(context.Background()); err != nil {
 		log.Fatalf("Failed to initialize database: %v", err)
 	}
 
-	clientHandler := handler.NewClientHandler(db.ClientRepo)
-	consultHandler := handler.NewConsultationHandler(db.ConsultationRepo)
-	patientHandler := handler.NewPatientHandler(db.PatientRepo)
-	userHandler := handler.NewUserHandler(db.UserRepo, db.SessionRepo, db.AllowedRegistrationsRepo)
+	db := database.NewDataBase(sqlxDB, cfg.QueryTimeout)
+	db.RegisterMetrics(metrics.Default)
 
-	r := router.NewRouter(clientHandler, consultHandler, patientHandler, userHandler)
-	srv := server.NewServer("8888", r)
+	auditor := handler.NewAuditor(db.AuditRepo)
+	clientHandler := handler.NewClientHandler(db.ClientRepo, auditor)
+	consultHandler := handler.NewConsultationHandler(db.ConsultationRepo, auditor)
+	patientHandler := handler.NewPatientHandler(db.PatientRepo, auditor)
+	var notifier notify.Notifier = notify.LogNotifier{Logger: slog.Default()}
+	if cfg.NotifyFile != "" {
+		notifier = notify.NewFileNotifier(cfg.NotifyFile)
+	} else if cfg.IsProduction() {
+		slog.Warn("NOTIFY_FILE is not set, so password reset links and security notices are written to the log")
+	}
+	userHandler := handler.NewUserHandler(db.UserRepo, db.SessionRepo, db.AllowedRegistrationsRepo, db.LoginLockoutRepo, db.TwoFactorRepo, db.LoginChallengeRepo, notifier, auditor)
+	userHandler.SessionDuration = cfg.SessionDuration
+	userHandler.SessionMaxLifetime = cfg.SessionMaxLifetime
+	userHandler.BcryptCost = cfg.BcryptCost
+	userHandler.SecureCookies = cfg.IsProduction()
+	userHandler.DNILockout = domain.LockoutPolicy{Threshold: cfg.LoginLockoutThreshold, Duration: cfg.LoginLockoutDuration, MaxDuration: cfg.LoginLockoutMaxDuration}
+	userHandler.IPLockout = domain.LockoutPolicy{Threshold: cfg.LoginLockoutIPThreshold, Duration: cfg.LoginLockoutDuration, MaxDuration: cfg.LoginLockoutMaxDuration}
+	resetHandler := handler.NewPasswordResetHandler(db.UserRepo, db.SessionRepo, db.PasswordResetRepo, notifier, auditor)
+	resetHandler.TokenTTL = cfg.PasswordResetTTL
+	resetHandler.ResetURL = cfg.PublicURL + "/reset-password"
+	resetHandler.BcryptCost = cfg.BcryptCost
+	appointmentHandler := handler.NewAppointmentHandler(db.AppointmentRepo, db.PatientRepo, auditor)
+	registrationHandler := handler.NewRegistrationHandler(db.AllowedRegistrationsRepo, db.UserRepo)
+	auditHandler := handler.NewAuditHandler(db.AuditRepo)
+	searchHandler := handler.NewSearchHandler(db.SearchRepo)
+	vaccinationHandler := handler.NewVaccinationHandler(db.VaccinationRepo, auditor)
+	prescriptionHandler := handler.NewPrescriptionHandler(db.PrescriptionRepo, db.ConsultationRepo, db.PatientRepo, auditor)
+	vitalsHandler := handler.NewVitalsHandler(db.VitalsRepo, db.PatientRepo, db.ConsultationRepo, auditor)
+	billingHandler := handler.NewBillingHandler(db.BillingRepo, db.ClientRepo, db.ConsultationRepo, db.PrescriptionRepo, db.InventoryRepo, auditor)
+	inventoryHandler := handler.NewInventoryHandler(db.InventoryRepo, db.PrescriptionRepo)
+	intakeHandler := handler.NewIntakeHandler(db.UnitOfWork, auditor)
+
+	// Background jobs; register new ones here, before Start.
+	scheduler := jobs.NewScheduler(db.JobRunRepo)
+	scheduler.Register(jobs.PurgeSessions(db.SessionRepo))
+	scheduler.Register(jobs.PurgeLoginLockouts(db.LoginLockoutRepo))
+	scheduler.Register(jobs.PurgePasswordResetTokens(db.PasswordResetRepo))
+	scheduler.Register(jobs.PurgeLoginChallenges(db.LoginChallengeRepo))
+	scheduler.Start()
+
+	checks := health.New()
+	checks.HideErrors = cfg.IsProduction()
+	checks.AddCheck("database", sqlxDB.PingContext)
+	checks.AddCheck("migrations", func(ctx context.Context) error {
+		pending, err := migrator.Pending(ctx)
+		if err != nil {
+			return err
+		}
+		if len(pending) > 0 {
+			return fmt.Errorf("%d migrations pending", len(pending))
+		}
+		return nil
+	})
+	checks.AddCheck("jobs", scheduler.Check)
+
+	r := router.NewRouter(clientHandler, consultHandler, patientHandler, userHandler, appointmentHandler, registrationHandler, auditHandler, searchHandler, vaccinationHandler, prescriptionHandler, vitalsHandler, billingHandler, inventoryHandler, intakeHandler, resetHandler, checks, middleware.NewRateLimitMiddleware(cfg.RateLimitPerMinute))
+	srv := server.NewServer(strconv.Itoa(cfg.Port), r, checks, cfg.ShutdownDrainDelay)
 	srv.StartServer(*r)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := scheduler.Stop(ctx); err != nil {
+		slog.Error("job scheduler forced to stop", "error", err)
+	}
+}
+
+func runMigrate(migrator *database.Migrator, command string) error {
+	ctx := context.Background()
+	switch command {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, migration := range applied {
+			log.Printf("Applied migration %04d_%s", migration.Version, migration.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			log.Println("No pending migrations")
+		}
+	case "down":
+		migration, err := migrator.Down(ctx)
+		if err != nil {
+			return err
+		}
+		log.Printf("Rolled back migration %04d_%s", migration.Version, migration.Name)
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "VERSION\tNAME\tAPPLIED AT")
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(tw, "%04d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf("unknown migrate command %q\n%s", command, usage)
+	}
+	return nil
 }
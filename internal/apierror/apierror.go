@@ -0,0 +1,218 @@
+// Package apierror writes API errors as one JSON envelope:
+//
+//	{"error": {"code": "not_found", "message": "Client not found", "request_id": "..."}}
+//
+// Validation errors add "details", one entry per offending field. Codes are
+// stable and meant for clients to branch on; messages are for people.
+package apierror
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"vetsys/internal/database"
+
+	"github.com/lib/pq"
+)
+
+// RequestIDHeader carries the request ID. The request ID middleware sets it
+// on the response before any handler runs, which is where Write reads it.
+const RequestIDHeader = "X-Request-ID"
+
+const (
+	CodeBadRequest       = "bad_request"
+	CodeValidation       = "validation_failed"
+	CodeUnauthorized     = "unauthorized"
+	CodeForbidden        = "forbidden"
+	CodeNotFound         = "not_found"
+	CodeConflict         = "conflict"
+	CodeAlreadyExists    = "already_exists"
+	CodeInUse            = "in_use"
+	CodeInvalidReference = "invalid_reference"
+	CodeRateLimited      = "rate_limited"
+	CodeTimeout          = "timeout"
+	CodeInternal         = "internal_error"
+)
+
+// internalMessage replaces the text of unexpected errors in production, where
+// it could reveal SQL, table names or other internals.
+const internalMessage = "Internal server error"
+
+// HideInternalErrors makes Respond answer unexpected errors with
+// internalMessage. It is set once at startup, in production.
+var HideInternalErrors bool
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+type Body struct {
+	Code      string       `json:"code"`
+	Message   string       `json:"message"`
+	Details   []FieldError `json:"details,omitempty"`
+	RequestID string       `json:"request_id,omitempty"`
+}
+
+type Response struct {
+	Error Body `json:"error"`
+}
+
+// Write sends the envelope with the given status and code.
+func Write(w http.ResponseWriter, status int, code string, message string, details ...FieldError) {
+	body := Body{
+		Code:      code,
+		Message:   message,
+		Details:   details,
+		RequestID: w.Header().Get(RequestIDHeader),
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(Response{Error: body})
+}
+
+// Error is the envelope counterpart of http.Error: the code follows from the
+// status.
+func Error(w http.ResponseWriter, message string, status int) {
+	Write(w, status, codeForStatus(status), message)
+}
+
+// Validation rejects a request because of one field of its body or query.
+func Validation(w http.ResponseWriter, field string, message string) {
+	Write(w, http.StatusBadRequest, CodeValidation, message, FieldError{Field: field, Message: message})
+}
+
+// errorRecorder is implemented by the request logging middleware's writer,
+// which puts the error on the request's log line.
+type errorRecorder interface {
+	RecordError(err error)
+}
+
+// Respond sends err with the status its sentinel or Postgres error code maps
+// to. Anything unrecognised is a 500 whose text is logged and, in
+// production, withheld from the client.
+func Respond(w http.ResponseWriter, err error) {
+	if mapped, ok := lookup(err); ok {
+		message := mapped.message
+		if message == "" {
+			message = err.Error()
+		}
+		Write(w, mapped.status, mapped.code, message)
+		return
+	}
+
+	if recorder, ok := w.(errorRecorder); ok {
+		recorder.RecordError(err)
+	} else {
+		slog.Error("internal error", "request_id", w.Header().Get(RequestIDHeader), "error", err)
+	}
+	message := err.Error()
+	if HideInternalErrors {
+		message = internalMessage
+	}
+	Write(w, http.StatusInternalServerError, CodeInternal, message)
+}
+
+type mapping struct {
+	status  int
+	code    string
+	message string // empty keeps the error's own text
+}
+
+// sentinels maps repository errors to responses. Handlers may still check a
+// sentinel themselves when the same error means something else in context.
+var sentinels = map[error]mapping{
+	database.ErrClientNotFound:         {http.StatusNotFound, CodeNotFound, ""},
+	database.ErrPatientNotFound:        {http.StatusNotFound, CodeNotFound, ""},
+	database.ErrConsultationNotFound:   {http.StatusNotFound, CodeNotFound, ""},
+	database.ErrAppointmentNotFound:    {http.StatusNotFound, CodeNotFound, ""},
+	database.ErrVetNotFound:            {http.StatusNotFound, CodeNotFound, ""},
+	database.ErrUserNotFound:           {http.StatusNotFound, CodeNotFound, ""},
+	database.ErrSessionNotFound:        {http.StatusNotFound, CodeNotFound, ""},
+	database.ErrDNINotFound:            {http.StatusNotFound, CodeNotFound, ""},
+	database.ErrVaccineNotFound:        {http.StatusNotFound, CodeNotFound, ""},
+	database.ErrVaccineBoosterNotFound: {http.StatusNotFound, CodeNotFound, ""},
+	database.ErrVaccinationNotFound:    {http.StatusNotFound, CodeNotFound, ""},
+	database.ErrMedicationNotFound:     {http.StatusNotFound, CodeNotFound, ""},
+	database.ErrDoseRangeNotFound:      {http.StatusNotFound, CodeNotFound, ""},
+	database.ErrPrescriptionNotFound:   {http.StatusNotFound, CodeNotFound, ""},
+	database.ErrVitalsNotFound:         {http.StatusNotFound, CodeNotFound, ""},
+	database.ErrBillableItemNotFound:   {http.StatusNotFound, CodeNotFound, ""},
+	database.ErrInvoiceNotFound:        {http.StatusNotFound, CodeNotFound, ""},
+	database.ErrProductNotFound:        {http.StatusNotFound, CodeNotFound, ""},
+	database.ErrBatchNotFound:          {http.StatusNotFound, CodeNotFound, ""},
+	database.ErrLockoutNotFound:        {http.StatusNotFound, CodeNotFound, ""},
+	database.ErrTwoFactorNotFound:      {http.StatusNotFound, CodeNotFound, ""},
+
+	database.ErrDNIAlreadyExists: {http.StatusConflict, CodeAlreadyExists, ""},
+	database.ErrVaccineExists:    {http.StatusConflict, CodeAlreadyExists, ""},
+	database.ErrMedicationExists: {http.StatusConflict, CodeAlreadyExists, ""},
+	database.ErrProductExists:    {http.StatusConflict, CodeAlreadyExists, ""},
+
+	database.ErrRecordInUse:              {http.StatusConflict, CodeInUse, ""},
+	database.ErrDNIInvalidOrUsed:         {http.StatusConflict, CodeConflict, ""},
+	database.ErrAppointmentConflict:      {http.StatusConflict, CodeConflict, ""},
+	database.ErrAppointmentStatusChanged: {http.StatusConflict, CodeConflict, ""},
+	database.ErrBillableItemInvoiced:     {http.StatusConflict, CodeConflict, ""},
+	database.ErrNothingToInvoice:         {http.StatusConflict, CodeConflict, ""},
+	database.ErrInvoiceVoid:              {http.StatusConflict, CodeConflict, ""},
+	database.ErrInvoiceHasPayments:       {http.StatusConflict, CodeConflict, ""},
+	database.ErrPaymentExceedsBalance:    {http.StatusConflict, CodeConflict, ""},
+	database.ErrInsufficientStock:        {http.StatusConflict, CodeConflict, ""},
+	database.ErrTwoFactorEnabled:         {http.StatusConflict, CodeConflict, ""},
+
+	database.ErrEmptySearchQuery: {http.StatusBadRequest, CodeValidation, ""},
+}
+
+// pqCodes maps Postgres SQLSTATEs that a client can cause. The messages are
+// fixed because pq's carry constraint and table names.
+var pqCodes = map[pq.ErrorCode]mapping{
+	"23505": {http.StatusConflict, CodeAlreadyExists, "A record with these values already exists"},
+	"23503": {http.StatusUnprocessableEntity, CodeInvalidReference, "A referenced record does not exist"},
+	"23502": {http.StatusBadRequest, CodeValidation, "A required value is missing"},
+	"23514": {http.StatusBadRequest, CodeValidation, "A value is out of the allowed range"},
+	"22P02": {http.StatusBadRequest, CodeValidation, "A value has an invalid format"},
+}
+
+func lookup(err error) (mapping, bool) {
+	for sentinel, mapped := range sentinels {
+		if errors.Is(err, sentinel) {
+			return mapped, true
+		}
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		mapped, ok := pqCodes[pqErr.Code]
+		return mapped, ok
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return mapping{http.StatusGatewayTimeout, CodeTimeout, "The request took too long"}, true
+	}
+	return mapping{}, false
+}
+
+func codeForStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeBadRequest
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusTooManyRequests:
+		return CodeRateLimited
+	case http.StatusGatewayTimeout:
+		return CodeTimeout
+	}
+	if status >= 500 {
+		return CodeInternal
+	}
+	return CodeBadRequest
+}
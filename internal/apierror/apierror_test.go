@@ -0,0 +1,95 @@
+package apierror
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"vetsys/internal/database"
+
+	"github.com/lib/pq"
+)
+
+func decode(t *testing.T, rec *httptest.ResponseRecorder) Body {
+	t.Helper()
+	if contentType := rec.Header().Get("Content-Type"); contentType != "application/json" {
+		t.Fatalf("Expected a JSON response, got %q", contentType)
+	}
+	var response Response
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode error envelope: %v", err)
+	}
+	return response.Error
+}
+
+func TestRespond_Mapping(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		status  int
+		code    string
+		message string
+	}{
+		{"sentinel", database.ErrConsultationNotFound, http.StatusNotFound, CodeNotFound, "Consultation not found"},
+		{"wrapped sentinel", fmt.Errorf("loading: %w", database.ErrClientNotFound), http.StatusNotFound, CodeNotFound, "loading: Client not found"},
+		{"already exists", database.ErrDNIAlreadyExists, http.StatusConflict, CodeAlreadyExists, "dni already exists"},
+		{"in use", database.ErrRecordInUse, http.StatusConflict, CodeInUse, database.ErrRecordInUse.Error()},
+		{"unique violation", &pq.Error{Code: "23505", Message: `duplicate key value violates unique constraint "clients_dni_key"`}, http.StatusConflict, CodeAlreadyExists, "A record with these values already exists"},
+		{"foreign key violation", &pq.Error{Code: "23503", Message: `insert or update on table "consultations" violates foreign key constraint`}, http.StatusUnprocessableEntity, CodeInvalidReference, "A referenced record does not exist"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			Respond(rec, tt.err)
+
+			if rec.Code != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, rec.Code)
+			}
+			body := decode(t, rec)
+			if body.Code != tt.code || body.Message != tt.message {
+				t.Errorf("Expected %s %q, got %s %q", tt.code, tt.message, body.Code, body.Message)
+			}
+		})
+	}
+}
+
+func TestRespond_InternalErrorsHiddenInProduction(t *testing.T) {
+	err := errors.New(`pq: relation "clients" does not exist`)
+
+	rec := httptest.NewRecorder()
+	Respond(rec, err)
+	if body := decode(t, rec); body.Message != err.Error() {
+		t.Errorf("Expected the error text outside production, got %q", body.Message)
+	}
+
+	HideInternalErrors = true
+	defer func() { HideInternalErrors = false }()
+	rec = httptest.NewRecorder()
+	Respond(rec, err)
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status 500, got %d", rec.Code)
+	}
+	body := decode(t, rec)
+	if body.Code != CodeInternal || body.Message != internalMessage {
+		t.Errorf("Expected the generic message in production, got %s %q", body.Code, body.Message)
+	}
+}
+
+func TestValidation(t *testing.T) {
+	rec := httptest.NewRecorder()
+	rec.Header().Set(RequestIDHeader, "req-1")
+	Validation(rec, "dni", "DNI is required")
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", rec.Code)
+	}
+	body := decode(t, rec)
+	if body.Code != CodeValidation || body.RequestID != "req-1" {
+		t.Errorf("Expected validation_failed for req-1, got %s for %q", body.Code, body.RequestID)
+	}
+	if len(body.Details) != 1 || body.Details[0] != (FieldError{Field: "dni", Message: "DNI is required"}) {
+		t.Errorf("Expected one detail for dni, got %+v", body.Details)
+	}
+}
@@ -1,20 +1,482 @@
+// Package config loads the server configuration. Every setting can come from,
+// in increasing order of precedence, its default, a YAML or TOML config file,
+// a .env file, the environment and a command-line flag, and is validated once
+// at startup.
 package config
 
 import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
 	"os"
-	"sync"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/joho/godotenv"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Log formats accepted in LOG_FORMAT.
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+const EnvProduction = "production"
+
+// Sources a setting's value can come from, as shown by Print.
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+	SourceDotEnv  = ".env"
+	SourceEnv     = "env"
+	SourceFlag    = "flag"
 )
 
 type Config struct {
-	DatabaseURL string
 	Environment string
-	mu          sync.RWMutex
+	Port        int
+	DatabaseURL string
+	// QueryTimeout bounds every repository call; zero disables it.
+	QueryTimeout time.Duration
+	LogFormat    string
+	LogLevel     slog.Level
+	// ShutdownDrainDelay is how long the server keeps serving with readiness
+	// failing before it stops accepting connections.
+	ShutdownDrainDelay time.Duration
+	// SessionDuration is how long a session lasts without being used; every
+	// use extends it, up to SessionMaxLifetime after sign-in.
+	SessionDuration    time.Duration
+	SessionMaxLifetime time.Duration
+	BcryptCost         int
+	// RateLimitPerMinute is how many requests a client IP may make to a
+	// rate-limited route per minute, and also the burst it may make at once.
+	RateLimitPerMinute int
+	// LoginLockoutThreshold failed logins for one DNI, or
+	// LoginLockoutIPThreshold from one IP, lock its logins for
+	// LoginLockoutDuration, doubled on each lockout up to
+	// LoginLockoutMaxDuration.
+	LoginLockoutThreshold   int
+	LoginLockoutIPThreshold int
+	LoginLockoutDuration    time.Duration
+	LoginLockoutMaxDuration time.Duration
+	// PasswordResetTTL is how long a password reset link stays valid.
+	PasswordResetTTL time.Duration
+	// PublicURL is where users reach the server, for links sent to them.
+	PublicURL string
+	// NotifyFile is the file messages to users are appended to; when empty
+	// they are logged.
+	NotifyFile string
+
+	// File is the config file read, if any.
+	File string
+	// sources records where each setting's value came from, by key.
+	sources map[string]string
+}
+
+func (config *Config) IsProduction() bool {
+	return config.Environment == EnvProduction
+}
+
+// setting describes one configuration value. Its key is the environment
+// variable; the config file uses the key in lower case and the flag in lower
+// case with dashes.
+type setting struct {
+	key    string
+	usage  string
+	secret bool
+	// def returns the default, which may depend on settings listed earlier.
+	def   func(config *Config) string
+	parse func(config *Config, value string) error
+	get   func(config *Config) string
+}
+
+func (s setting) fileKey() string {
+	return strings.ToLower(s.key)
+}
+
+func (s setting) flagName() string {
+	return strings.ReplaceAll(strings.ToLower(s.key), "_", "-")
+}
+
+func constant(value string) func(*Config) string {
+	return func(*Config) string { return value }
+}
+
+var settings = []setting{
+	{
+		key:   "ENV",
+		usage: "environment name; production enables secure cookies, JSON logs and hidden error details",
+		def:   constant("development"),
+		parse: func(config *Config, value string) error {
+			if value == "" {
+				return errors.New("must not be empty")
+			}
+			config.Environment = value
+			return nil
+		},
+		get: func(config *Config) string { return config.Environment },
+	},
+	{
+		key:   "PORT",
+		usage: "TCP port the HTTP server listens on",
+		def:   constant("8888"),
+		parse: func(config *Config, value string) error {
+			port, err := strconv.Atoi(value)
+			if err != nil || port < 1 || port > 65535 {
+				return errors.New("must be a port number between 1 and 65535")
+			}
+			config.Port = port
+			return nil
+		},
+		get: func(config *Config) string { return strconv.Itoa(config.Port) },
+	},
+	{
+		key:    "DATABASE_URL",
+		usage:  "Postgres connection URL",
+		secret: true,
+		def:    constant(""),
+		parse: func(config *Config, value string) error {
+			if value == "" {
+				return errors.New("is required")
+			}
+			config.DatabaseURL = value
+			return nil
+		},
+		get: func(config *Config) string { return config.DatabaseURL },
+	},
+	{
+		key:   "DB_QUERY_TIMEOUT",
+		usage: "bound on each repository call, such as 5s; 0 disables it",
+		def:   constant("5s"),
+		parse: func(config *Config, value string) (err error) {
+			config.QueryTimeout, err = parseDuration(value, 0)
+			return err
+		},
+		get: func(config *Config) string { return config.QueryTimeout.String() },
+	},
+	{
+		key:   "LOG_FORMAT",
+		usage: "text or json",
+		def: func(config *Config) string {
+			if config.IsProduction() {
+				return LogFormatJSON
+			}
+			return LogFormatText
+		},
+		parse: func(config *Config, value string) error {
+			value = strings.ToLower(value)
+			if value != LogFormatText && value != LogFormatJSON {
+				return errors.New("must be text or json")
+			}
+			config.LogFormat = value
+			return nil
+		},
+		get: func(config *Config) string { return config.LogFormat },
+	},
+	{
+		key:   "LOG_LEVEL",
+		usage: "debug, info, warn or error",
+		def:   constant("info"),
+		parse: func(config *Config, value string) error {
+			if err := config.LogLevel.UnmarshalText([]byte(value)); err != nil {
+				return errors.New("must be debug, info, warn or error")
+			}
+			return nil
+		},
+		get: func(config *Config) string { return strings.ToLower(config.LogLevel.String()) },
+	},
+	{
+		key:   "SHUTDOWN_DRAIN_DELAY",
+		usage: "how long to keep serving with readiness failing before shutting down",
+		def: func(config *Config) string {
+			if config.IsProduction() {
+				return "5s"
+			}
+			return "0s"
+		},
+		parse: func(config *Config, value string) (err error) {
+			config.ShutdownDrainDelay, err = parseDuration(value, 0)
+			return err
+		},
+		get: func(config *Config) string { return config.ShutdownDrainDelay.String() },
+	},
+	{
+		key:   "SESSION_DURATION",
+		usage: "how long a login session lasts without activity",
+		def:   constant("24h"),
+		parse: func(config *Config, value string) (err error) {
+			config.SessionDuration, err = parseDuration(value, time.Minute)
+			return err
+		},
+		get: func(config *Config) string { return config.SessionDuration.String() },
+	},
+	{
+		key:   "SESSION_MAX_LIFETIME",
+		usage: "how long a login session can be kept alive by activity; equal to SESSION_DURATION for fixed expiry",
+		def:   constant("168h"),
+		parse: func(config *Config, value string) (err error) {
+			config.SessionMaxLifetime, err = parseDuration(value, config.SessionDuration)
+			if err != nil {
+				return fmt.Errorf("must be a duration of at least SESSION_DURATION (%s)", config.SessionDuration)
+			}
+			return nil
+		},
+		get: func(config *Config) string { return config.SessionMaxLifetime.String() },
+	},
+	{
+		key:   "BCRYPT_COST",
+		usage: "bcrypt cost of stored password hashes",
+		def:   constant("14"),
+		parse: func(config *Config, value string) error {
+			cost, err := strconv.Atoi(value)
+			if err != nil || cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+				return fmt.Errorf("must be an integer between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+			}
+			config.BcryptCost = cost
+			return nil
+		},
+		get: func(config *Config) string { return strconv.Itoa(config.BcryptCost) },
+	},
+	{
+		key:   "RATE_LIMIT_PER_MINUTE",
+		usage: "requests per minute a client IP may make to rate-limited routes such as login",
+		def:   constant("3"),
+		parse: func(config *Config, value string) (err error) {
+			config.RateLimitPerMinute, err = parsePositive(value)
+			return err
+		},
+		get: func(config *Config) string { return strconv.Itoa(config.RateLimitPerMinute) },
+	},
+	{
+		key:   "LOGIN_LOCKOUT_THRESHOLD",
+		usage: "failed logins for one DNI that lock its logins",
+		def:   constant("5"),
+		parse: func(config *Config, value string) (err error) {
+			config.LoginLockoutThreshold, err = parsePositive(value)
+			return err
+		},
+		get: func(config *Config) string { return strconv.Itoa(config.LoginLockoutThreshold) },
+	},
+	{
+		key:   "LOGIN_LOCKOUT_IP_THRESHOLD",
+		usage: "failed logins from one client IP, for any DNI, that lock its logins",
+		def:   constant("20"),
+		parse: func(config *Config, value string) (err error) {
+			config.LoginLockoutIPThreshold, err = parsePositive(value)
+			return err
+		},
+		get: func(config *Config) string { return strconv.Itoa(config.LoginLockoutIPThreshold) },
+	},
+	{
+		key:   "LOGIN_LOCKOUT_DURATION",
+		usage: "how long the first login lockout lasts; each one after it lasts twice as long as the last",
+		def:   constant("1m"),
+		parse: func(config *Config, value string) (err error) {
+			config.LoginLockoutDuration, err = parseDuration(value, time.Second)
+			return err
+		},
+		get: func(config *Config) string { return config.LoginLockoutDuration.String() },
+	},
+	{
+		key:   "LOGIN_LOCKOUT_MAX_DURATION",
+		usage: "the longest a login lockout lasts",
+		def:   constant("1h"),
+		parse: func(config *Config, value string) (err error) {
+			config.LoginLockoutMaxDuration, err = parseDuration(value, config.LoginLockoutDuration)
+			if err != nil {
+				return fmt.Errorf("must be a duration of at least LOGIN_LOCKOUT_DURATION (%s)", config.LoginLockoutDuration)
+			}
+			return nil
+		},
+		get: func(config *Config) string { return config.LoginLockoutMaxDuration.String() },
+	},
+	{
+		key:   "PASSWORD_RESET_TTL",
+		usage: "how long a password reset link stays valid",
+		def:   constant("1h"),
+		parse: func(config *Config, value string) (err error) {
+			config.PasswordResetTTL, err = parseDuration(value, time.Minute)
+			return err
+		},
+		get: func(config *Config) string { return config.PasswordResetTTL.String() },
+	},
+	{
+		key:   "PUBLIC_URL",
+		usage: "base URL users reach the server at, for links sent to them",
+		def:   func(config *Config) string { return "http://localhost:" + strconv.Itoa(config.Port) },
+		parse: func(config *Config, value string) error {
+			u, err := url.Parse(value)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return errors.New("must be an http or https URL")
+			}
+			config.PublicURL = strings.TrimSuffix(value, "/")
+			return nil
+		},
+		get: func(config *Config) string { return config.PublicURL },
+	},
+	{
+		key:   "NOTIFY_FILE",
+		usage: "file messages to users, such as password reset links, are appended to; empty to log them",
+		def:   constant(""),
+		parse: func(config *Config, value string) error {
+			config.NotifyFile = value
+			return nil
+		},
+		get: func(config *Config) string { return config.NotifyFile },
+	},
+}
+
+func parsePositive(value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return 0, errors.New("must be a positive integer")
+	}
+	return n, nil
+}
+
+func parseDuration(value string, min time.Duration) (time.Duration, error) {
+	d, err := time.ParseDuration(value)
+	if err != nil || d < min {
+		return 0, fmt.Errorf("must be a duration of at least %s, such as 5s", min)
+	}
+	return d, nil
 }
 
-func LoadConfig() *Config {
+// Load reads the configuration for a command line; args excludes the program
+// name. The config file is named by the -config flag or CONFIG_FILE, and .env
+// is read from the working directory if present. It returns the arguments
+// left after the flags, and every invalid setting at once.
+func Load(args []string) (*Config, []string, error) {
+	return load(args, os.LookupEnv, ".env")
+}
+
+func load(args []string, lookupEnv func(string) (string, bool), dotEnvPath string) (*Config, []string, error) {
+	flags := flag.NewFlagSet("vetsys", flag.ContinueOnError)
+	configFile := flags.String("config", "", "path to a YAML or TOML config file")
+	flagValues := make(map[string]*string, len(settings))
+	for _, s := range settings {
+		flagValues[s.key] = flags.String(s.flagName(), "", s.usage)
+	}
+	if err := flags.Parse(args); err != nil {
+		return nil, nil, err
+	}
+	setFlags := map[string]bool{}
+	flags.Visit(func(f *flag.Flag) { setFlags[f.Name] = true })
+
+	dotEnv := map[string]string{}
+	if dotEnvPath != "" {
+		var err error
+		dotEnv, err = godotenv.Read(dotEnvPath)
+		if errors.Is(err, os.ErrNotExist) {
+			dotEnv = map[string]string{}
+		} else if err != nil {
+			return nil, nil, fmt.Errorf("read %s: %w", dotEnvPath, err)
+		}
+	}
+	lookup := func(key string) (string, bool) {
+		if value, ok := lookupEnv(key); ok {
+			return value, true
+		}
+		value, ok := dotEnv[key]
+		return value, ok
+	}
+
+	path := *configFile
+	if path == "" {
+		path, _ = lookup("CONFIG_FILE")
+	}
+	fileValues := map[string]string{}
+	if path != "" {
+		var err error
+		if fileValues, err = readFile(path); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	config := &Config{File: path, sources: make(map[string]string, len(settings))}
+	var errs []error
+	known := make(map[string]bool, len(settings))
+	for _, s := range settings {
+		known[s.fileKey()] = true
+		value, source := s.def(config), SourceDefault
+		if v, ok := fileValues[s.fileKey()]; ok {
+			value, source = v, SourceFile
+		}
+		if v, ok := dotEnv[s.key]; ok {
+			value, source = v, SourceDotEnv
+		}
+		if v, ok := lookupEnv(s.key); ok {
+			value, source = v, SourceEnv
+		}
+		if setFlags[s.flagName()] {
+			value, source = *flagValues[s.key], SourceFlag
+		}
+		config.sources[s.key] = source
+		if err := s.parse(config, value); err != nil {
+			errs = append(errs, fmt.Errorf("%s %s: %w", s.key, describe(s, value, source), err))
+		}
+	}
+	for key := range fileValues {
+		if !known[key] {
+			errs = append(errs, fmt.Errorf("%s: unknown setting %q", path, key))
+		}
+	}
+	if len(errs) > 0 {
+		sort.Slice(errs, func(i, j int) bool { return errs[i].Error() < errs[j].Error() })
+		return nil, nil, fmt.Errorf("invalid configuration:\n%w", errors.Join(errs...))
+	}
+	return config, flags.Args(), nil
+}
+
+// describe quotes an invalid value for an error message, unless it is secret.
+func describe(s setting, value string, source string) string {
+	if s.secret && value != "" {
+		return "from " + source
+	}
+	return fmt.Sprintf("%q from %s", value, source)
+}
+
+// Print writes the effective configuration and where each value came from,
+// with secrets redacted.
+func (config *Config) Print(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if config.File != "" {
+		fmt.Fprintf(tw, "# config file: %s\n", config.File)
+	}
+	fmt.Fprintln(tw, "KEY\tVALUE\tSOURCE")
+	for _, s := range settings {
+		value := s.get(config)
+		if s.secret {
+			value = redact(value)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", s.key, value, config.sources[s.key])
+	}
+	return tw.Flush()
+}
+
+// redact hides the password of a URL, or the whole value if it is not one.
+func redact(value string) string {
+	if value == "" {
+		return ""
+	}
+	if u, err := url.Parse(value); err == nil && u.Scheme != "" && u.Host != "" {
+		return u.Redacted()
+	}
+	return "xxxxx"
+}
 
-	return &Config{
-		DatabaseURL: os.Getenv("DATABASE_URL"),
-		Environment: os.Getenv("ENV"),
+// NewLogger returns a logger writing to w in the configured format and level.
+func (config *Config) NewLogger(w io.Writer) *slog.Logger {
+	options := &slog.HandlerOptions{Level: config.LogLevel}
+	if config.LogFormat == LogFormatJSON {
+		return slog.New(slog.NewJSONHandler(w, options))
 	}
+	return slog.New(slog.NewTextHandler(w, options))
 }
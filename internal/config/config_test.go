@@ -0,0 +1,152 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func env(values map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		value, ok := values[key]
+		return value, ok
+	}
+}
+
+func writeFile(t *testing.T, name string, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write %s: %v", name, err)
+	}
+	return path
+}
+
+func TestLoad_Defaults(t *testing.T) {
+	config, args, err := load(nil, env(map[string]string{"DATABASE_URL": "postgres://localhost/vetsys"}), "")
+	if err != nil {
+		t.Fatalf("Failed to load: %v", err)
+	}
+	if len(args) != 0 {
+		t.Errorf("Expected no arguments left, got %v", args)
+	}
+	if config.Environment != "development" || config.Port != 8888 || config.QueryTimeout != 5*time.Second ||
+		config.LogFormat != LogFormatText || config.ShutdownDrainDelay != 0 || config.SessionDuration != 24*time.Hour ||
+		config.SessionMaxLifetime != 7*24*time.Hour ||
+		config.BcryptCost != 14 || config.RateLimitPerMinute != 3 ||
+		config.LoginLockoutThreshold != 5 || config.LoginLockoutIPThreshold != 20 ||
+		config.LoginLockoutDuration != time.Minute || config.LoginLockoutMaxDuration != time.Hour ||
+		config.PasswordResetTTL != time.Hour || config.PublicURL != "http://localhost:8888" || config.NotifyFile != "" {
+		t.Errorf("Unexpected defaults: %+v", config)
+	}
+	if config.sources["PORT"] != SourceDefault || config.sources["DATABASE_URL"] != SourceEnv {
+		t.Errorf("Unexpected sources: %v", config.sources)
+	}
+}
+
+func TestLoad_ProductionDefaults(t *testing.T) {
+	config, _, err := load(nil, env(map[string]string{"ENV": "production", "DATABASE_URL": "postgres://localhost/vetsys"}), "")
+	if err != nil {
+		t.Fatalf("Failed to load: %v", err)
+	}
+	if !config.IsProduction() || config.LogFormat != LogFormatJSON || config.ShutdownDrainDelay != 5*time.Second {
+		t.Errorf("Unexpected production defaults: %+v", config)
+	}
+}
+
+func TestLoad_Precedence(t *testing.T) {
+	file := writeFile(t, "vetsys.yaml", `
+# Clinic settings
+database_url: "postgres://file@localhost/vetsys"
+port: 9001
+session_duration: 8h
+bcrypt_cost: 12
+rate_limit_per_minute: 10 # per IP
+`)
+	dotEnv := writeFile(t, ".env", "PORT=9002\nSESSION_DURATION=12h\nBCRYPT_COST=11\n")
+	lookup := env(map[string]string{"CONFIG_FILE": file, "PORT": "9003", "SESSION_DURATION": "4h"})
+
+	config, args, err := load([]string{"-port", "9004", "migrate", "up"}, lookup, dotEnv)
+	if err != nil {
+		t.Fatalf("Failed to load: %v", err)
+	}
+	if strings.Join(args, " ") != "migrate up" {
+		t.Errorf("Expected the command to be left, got %v", args)
+	}
+	for _, test := range []struct {
+		key, source string
+		got, want   any
+	}{
+		{"DATABASE_URL", SourceFile, config.DatabaseURL, "postgres://file@localhost/vetsys"},
+		{"RATE_LIMIT_PER_MINUTE", SourceFile, config.RateLimitPerMinute, 10},
+		{"BCRYPT_COST", SourceDotEnv, config.BcryptCost, 11},
+		{"SESSION_DURATION", SourceEnv, config.SessionDuration, 4 * time.Hour},
+		{"PORT", SourceFlag, config.Port, 9004},
+	} {
+		if test.got != test.want || config.sources[test.key] != test.source {
+			t.Errorf("%s: expected %v from %s, got %v from %s", test.key, test.want, test.source, test.got, config.sources[test.key])
+		}
+	}
+}
+
+func TestLoad_TOMLFile(t *testing.T) {
+	file := writeFile(t, "vetsys.toml", "database_url = 'postgres://localhost/vetsys'\nlog_level = \"debug\"\n")
+
+	config, _, err := load([]string{"-config", file}, env(nil), "")
+	if err != nil {
+		t.Fatalf("Failed to load: %v", err)
+	}
+	if config.DatabaseURL != "postgres://localhost/vetsys" || config.LogLevel.String() != "DEBUG" {
+		t.Errorf("Unexpected config from TOML: %+v", config)
+	}
+}
+
+func TestLoad_ReportsEveryInvalidSetting(t *testing.T) {
+	lookup := env(map[string]string{"PORT": "http", "BCRYPT_COST": "3", "DB_QUERY_TIMEOUT": "-1s", "LOG_FORMAT": "xml", "SESSION_MAX_LIFETIME": "1h", "LOGIN_LOCKOUT_THRESHOLD": "0", "LOGIN_LOCKOUT_MAX_DURATION": "30s", "PUBLIC_URL": "vetsys.example.com"})
+
+	_, _, err := load(nil, lookup, "")
+	if err == nil {
+		t.Fatal("Expected invalid settings to be rejected")
+	}
+	for _, key := range []string{"DATABASE_URL", "PORT", "BCRYPT_COST", "DB_QUERY_TIMEOUT", "LOG_FORMAT", "SESSION_MAX_LIFETIME", "LOGIN_LOCKOUT_THRESHOLD", "LOGIN_LOCKOUT_MAX_DURATION", "PUBLIC_URL"} {
+		if !strings.Contains(err.Error(), key) {
+			t.Errorf("Expected the error to mention %s, got:\n%v", key, err)
+		}
+	}
+}
+
+func TestLoad_InvalidFile(t *testing.T) {
+	for name, content := range map[string]string{
+		"unknown.yaml": "database_url: x\nlisten: 80\n",
+		"nested.yaml":  "server:\n  port: 80\n",
+		"section.toml": "[server]\nport = 80\n",
+		"twice.yaml":   "port: 80\nport: 81\n",
+		"vetsys.json":  "{}",
+	} {
+		file := writeFile(t, name, content)
+		if _, _, err := load([]string{"-config", file}, env(nil), ""); err == nil {
+			t.Errorf("Expected %s to be rejected", name)
+		}
+	}
+}
+
+func TestPrint_RedactsSecrets(t *testing.T) {
+	config, _, err := load(nil, env(map[string]string{"DATABASE_URL": "postgres://vetsys:hunter2@db:5432/vetsys"}), "")
+	if err != nil {
+		t.Fatalf("Failed to load: %v", err)
+	}
+
+	var b strings.Builder
+	if err := config.Print(&b); err != nil {
+		t.Fatalf("Failed to print: %v", err)
+	}
+	out := b.String()
+	if strings.Contains(out, "hunter2") {
+		t.Errorf("Expected the password to be redacted, got:\n%s", out)
+	}
+	if !strings.Contains(out, "postgres://vetsys:xxxxx@db:5432/vetsys") || !strings.Contains(out, "BCRYPT_COST") {
+		t.Errorf("Expected every setting with its value, got:\n%s", out)
+	}
+}
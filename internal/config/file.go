@@ -0,0 +1,114 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// readFile reads a config file into setting values by file key. Settings are
+// flat, so the file only needs the flat subset of its format: YAML "key:
+// value" or TOML "key = value" lines, comments and quoted or bare scalars.
+// Nesting, sections and lists are rejected rather than misread.
+func readFile(path string) (map[string]string, error) {
+	var separator string
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		separator = ":"
+	case ".toml":
+		separator = "="
+	default:
+		return nil, fmt.Errorf("config file %s: unsupported format, use .yaml, .yml or .toml", path)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("config file: %w", err)
+	}
+	defer f.Close()
+
+	values := map[string]string{}
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || trimmed == "---" {
+			continue
+		}
+		if line[0] == ' ' || line[0] == '\t' || strings.HasPrefix(trimmed, "[") || strings.HasPrefix(trimmed, "- ") {
+			return nil, fmt.Errorf("config file %s:%d: only top-level key%svalue settings are supported", path, n, separator)
+		}
+		key, raw, ok := strings.Cut(trimmed, separator)
+		if !ok {
+			return nil, fmt.Errorf("config file %s:%d: expected key%svalue", path, n, separator)
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value, err := parseScalar(raw)
+		if err != nil {
+			return nil, fmt.Errorf("config file %s:%d: %s: %w", path, n, key, err)
+		}
+		if _, exists := values[key]; exists {
+			return nil, fmt.Errorf("config file %s:%d: %s is set twice", path, n, key)
+		}
+		values[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("config file %s: %w", path, err)
+	}
+	return values, nil
+}
+
+// parseScalar unquotes a double- or single-quoted value, or trims a bare one
+// up to a trailing comment.
+func parseScalar(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	switch {
+	case strings.HasPrefix(raw, `"`):
+		quoted, rest, err := cutQuoted(raw)
+		if err != nil {
+			return "", err
+		}
+		value, err := strconv.Unquote(quoted)
+		if err != nil {
+			return "", fmt.Errorf("invalid quoted string %s", quoted)
+		}
+		return value, checkTrailing(rest)
+	case strings.HasPrefix(raw, "'"):
+		end := strings.Index(raw[1:], "'")
+		if end < 0 {
+			return "", fmt.Errorf("unterminated string %s", raw)
+		}
+		return raw[1 : end+1], checkTrailing(raw[end+2:])
+	case strings.HasPrefix(raw, "{") || strings.HasPrefix(raw, "["):
+		return "", fmt.Errorf("only scalar values are supported")
+	}
+	if i := strings.Index(raw, " #"); i >= 0 {
+		raw = raw[:i]
+	}
+	return strings.TrimSpace(raw), nil
+}
+
+// cutQuoted splits a double-quoted string, escapes included, from what
+// follows it.
+func cutQuoted(raw string) (string, string, error) {
+	for i := 1; i < len(raw); i++ {
+		switch raw[i] {
+		case '\\':
+			i++
+		case '"':
+			return raw[:i+1], raw[i+1:], nil
+		}
+	}
+	return "", "", fmt.Errorf("unterminated string %s", raw)
+}
+
+func checkTrailing(rest string) error {
+	rest = strings.TrimSpace(rest)
+	if rest != "" && !strings.HasPrefix(rest, "#") {
+		return fmt.Errorf("unexpected %q after value", rest)
+	}
+	return nil
+}
@@ -1,12 +1,22 @@
 package database
 
 import (
+	"context"
+	"database/sql"
 	"errors"
 	"strings"
+	"time"
+	"vetsys/internal/domain"
 
 	"github.com/jmoiron/sqlx"
 )
 
+const allowedRegistrationColumns = `
+	ar.dni, ar.role, ar.used, ar.invited_by, ar.created_at, ar.expires_at, ar.used_at, ar.revoked_at, u.id AS user_id
+	FROM allowed_registrations ar
+	LEFT JOIN users u ON u.dni = ar.dni
+`
+
 var (
 	ErrDNIAlreadyExists = errors.New("dni already exists")
 	ErrDNIInvalidOrUsed = errors.New("dni not allowed or already used")
@@ -14,11 +24,15 @@ var (
 )
 
 type AllowedRegistrationRepository struct {
-	DB *sqlx.DB
+	DB           *sqlx.DB
+	QueryTimeout time.Duration
 }
 
-func (r *AllowedRegistrationRepository) InsertDNI(dni string) error {
-	_, err := r.DB.Exec(`INSERT INTO allowed_registrations (dni) VALUES ($1)`, dni)
+func (r *AllowedRegistrationRepository) InsertDNI(ctx context.Context, dni string) error {
+	ctx, cancel := withQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
+	_, err := r.DB.ExecContext(ctx, `INSERT INTO allowed_registrations (dni) VALUES ($1)`, dni)
 	if err != nil {
 		// Check for unique constraint violation (PostgreSQL error code 23505)
 		if strings.Contains(err.Error(), "duplicate key") || strings.Contains(err.Error(), "unique constraint") {
@@ -29,9 +43,34 @@ func (r *AllowedRegistrationRepository) InsertDNI(dni string) error {
 	return nil
 }
 
-func (r *AllowedRegistrationRepository) UseDNI(dni string) error {
-	query := `UPDATE allowed_registrations SET used = TRUE WHERE dni = $1 AND used = FALSE`
-	result, err := r.DB.Exec(query, dni)
+// UseDNI marks an allowed DNI as used and returns the role granted to it.
+// Revoked and expired invitations cannot be used.
+func (r *AllowedRegistrationRepository) UseDNI(ctx context.Context, dni string) (domain.Role, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
+	query := `
+	UPDATE allowed_registrations SET used = TRUE, used_at = (now() AT TIME ZONE 'UTC')
+	WHERE dni = $1 AND used = FALSE AND revoked_at IS NULL
+	AND (expires_at IS NULL OR expires_at > (now() AT TIME ZONE 'UTC'))
+	RETURNING role
+	`
+	var role domain.Role
+	err := r.DB.GetContext(ctx, &role, query, dni)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", ErrDNIInvalidOrUsed
+		}
+		return "", err
+	}
+	return role, nil
+}
+
+func (r *AllowedRegistrationRepository) DeleteDNI(ctx context.Context, dni string) error {
+	ctx, cancel := withQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
+	result, err := r.DB.ExecContext(ctx, `DELETE FROM allowed_registrations WHERE dni = $1`, dni)
 	if err != nil {
 		return err
 	}
@@ -40,13 +79,122 @@ func (r *AllowedRegistrationRepository) UseDNI(dni string) error {
 		return err
 	}
 	if rows == 0 {
-		return ErrDNIInvalidOrUsed
+		return ErrDNINotFound
 	}
 	return nil
 }
 
-func (r *AllowedRegistrationRepository) DeleteDNI(dni string) error {
-	result, err := r.DB.Exec(`DELETE FROM allowed_registrations WHERE dni = $1`, dni)
+// InsertRegistrations adds invitations in one transaction and returns the DNIs
+// that were created; DNIs that already have an invitation are left untouched.
+func (r *AllowedRegistrationRepository) InsertRegistrations(ctx context.Context, registrations []domain.AllowedRegistration) ([]string, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
+	tx, err := r.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	query := `
+	INSERT INTO allowed_registrations (dni, role, invited_by, created_at, expires_at)
+	VALUES ($1, $2, $3, $4, $5)
+	ON CONFLICT (dni) DO NOTHING
+	RETURNING dni
+	`
+	created := []string{}
+	for _, registration := range registrations {
+		var dni string
+		err := tx.GetContext(ctx, &dni, query, registration.DNI, registration.Role, registration.InvitedBy, registration.CreatedAt, registration.ExpiresAt)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		created = append(created, dni)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+func (r *AllowedRegistrationRepository) GetRegistration(ctx context.Context, dni string) (*domain.AllowedRegistration, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
+	registration := domain.AllowedRegistration{}
+	query := `SELECT ` + allowedRegistrationColumns + ` WHERE ar.dni = $1`
+	err := r.DB.GetContext(ctx, &registration, query, dni)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrDNINotFound
+		}
+		return nil, err
+	}
+	return &registration, nil
+}
+
+// GetRegistrations lists invitations, newest first. A nil used returns both
+// used and unused ones.
+func (r *AllowedRegistrationRepository) GetRegistrations(ctx context.Context, used *bool, limit int, offset int) ([]domain.AllowedRegistration, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
+	query := `SELECT ` + allowedRegistrationColumns + `
+	WHERE ($1::boolean IS NULL OR ar.used = $1)
+	ORDER BY ar.created_at DESC, ar.dni
+	LIMIT $2 OFFSET $3`
+	var registrations []domain.AllowedRegistration
+	err := r.DB.SelectContext(ctx, &registrations, query, used, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return registrations, nil
+}
+
+func (r *AllowedRegistrationRepository) GetRegistrationsCount(ctx context.Context, used *bool) (int64, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
+	var count int64
+	query := `SELECT COUNT(*) FROM allowed_registrations WHERE ($1::boolean IS NULL OR used = $1)`
+	err := r.DB.GetContext(ctx, &count, query, used)
+	return count, err
+}
+
+// RevokeDNI withdraws an unused invitation without deleting its history.
+func (r *AllowedRegistrationRepository) RevokeDNI(ctx context.Context, dni string) error {
+	ctx, cancel := withQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
+	query := `UPDATE allowed_registrations SET revoked_at = (now() AT TIME ZONE 'UTC') WHERE dni = $1 AND used = FALSE AND revoked_at IS NULL`
+	result, err := r.DB.ExecContext(ctx, query, dni)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return r.missingOrUsed(ctx, dni)
+	}
+	return nil
+}
+
+// ReopenDNI makes a used, revoked or expired invitation usable again.
+func (r *AllowedRegistrationRepository) ReopenDNI(ctx context.Context, dni string, invitedBy int64, expiresAt *time.Time) error {
+	ctx, cancel := withQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
+	query := `
+	UPDATE allowed_registrations
+	SET used = FALSE, used_at = NULL, revoked_at = NULL, invited_by = $1, expires_at = $2
+	WHERE dni = $3
+	`
+	result, err := r.DB.ExecContext(ctx, query, invitedBy, expiresAt, dni)
 	if err != nil {
 		return err
 	}
@@ -59,3 +207,15 @@ func (r *AllowedRegistrationRepository) DeleteDNI(dni string) error {
 	}
 	return nil
 }
+
+func (r *AllowedRegistrationRepository) missingOrUsed(ctx context.Context, dni string) error {
+	var exists bool
+	err := r.DB.GetContext(ctx, &exists, `SELECT EXISTS (SELECT 1 FROM allowed_registrations WHERE dni = $1)`, dni)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrDNINotFound
+	}
+	return ErrDNIInvalidOrUsed
+}
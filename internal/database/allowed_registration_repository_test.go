@@ -1,14 +1,17 @@
 package database
 
 import (
+	"context"
 	"testing"
+	"time"
+	"vetsys/internal/domain"
 )
 
 func TestAllowedRegistrationRepository_InsertDNI(t *testing.T) {
 	cleanupTables(testDB)
 
 	dni := "12345678A"
-	err := testDB.AllowedRegistrationsRepo.InsertDNI(dni)
+	err := testDB.AllowedRegistrationsRepo.InsertDNI(context.Background(), dni)
 	if err != nil {
 		t.Fatalf("Failed to insert DNI: %v", err)
 	}
@@ -18,12 +21,12 @@ func TestAllowedRegistrationRepository_InsertDNI_Duplicate(t *testing.T) {
 	cleanupTables(testDB)
 
 	dni := "23456789B"
-	err := testDB.AllowedRegistrationsRepo.InsertDNI(dni)
+	err := testDB.AllowedRegistrationsRepo.InsertDNI(context.Background(), dni)
 	if err != nil {
 		t.Fatalf("Failed to insert DNI first time: %v", err)
 	}
 
-	err = testDB.AllowedRegistrationsRepo.InsertDNI(dni)
+	err = testDB.AllowedRegistrationsRepo.InsertDNI(context.Background(), dni)
 	if err != ErrDNIAlreadyExists {
 		t.Errorf("Expected ErrDNIAlreadyExists, got %v", err)
 	}
@@ -33,25 +36,28 @@ func TestAllowedRegistrationRepository_UseDNI(t *testing.T) {
 	cleanupTables(testDB)
 
 	dni := "34567890C"
-	err := testDB.AllowedRegistrationsRepo.InsertDNI(dni)
+	err := testDB.AllowedRegistrationsRepo.InsertDNI(context.Background(), dni)
 	if err != nil {
 		t.Fatalf("Failed to insert DNI: %v", err)
 	}
 
-	err = testDB.AllowedRegistrationsRepo.UseDNI(dni)
+	role, err := testDB.AllowedRegistrationsRepo.UseDNI(context.Background(), dni)
 	if err != nil {
 		t.Fatalf("Failed to use DNI: %v", err)
 	}
+	if role != domain.RoleVeterinarian {
+		t.Errorf("Expected default role %s, got %s", domain.RoleVeterinarian, role)
+	}
 }
 
 func TestAllowedRegistrationRepository_UseDNI_AlreadyUsed(t *testing.T) {
 	cleanupTables(testDB)
 
 	dni := "45678901D"
-	testDB.AllowedRegistrationsRepo.InsertDNI(dni)
-	testDB.AllowedRegistrationsRepo.UseDNI(dni)
+	testDB.AllowedRegistrationsRepo.InsertDNI(context.Background(), dni)
+	testDB.AllowedRegistrationsRepo.UseDNI(context.Background(), dni)
 
-	err := testDB.AllowedRegistrationsRepo.UseDNI(dni)
+	_, err := testDB.AllowedRegistrationsRepo.UseDNI(context.Background(), dni)
 	if err != ErrDNIInvalidOrUsed {
 		t.Errorf("Expected ErrDNIInvalidOrUsed, got %v", err)
 	}
@@ -60,7 +66,7 @@ func TestAllowedRegistrationRepository_UseDNI_AlreadyUsed(t *testing.T) {
 func TestAllowedRegistrationRepository_UseDNI_NotFound(t *testing.T) {
 	cleanupTables(testDB)
 
-	err := testDB.AllowedRegistrationsRepo.UseDNI("NONEXISTENT")
+	_, err := testDB.AllowedRegistrationsRepo.UseDNI(context.Background(), "NONEXISTENT")
 	if err != ErrDNIInvalidOrUsed {
 		t.Errorf("Expected ErrDNIInvalidOrUsed, got %v", err)
 	}
@@ -70,9 +76,9 @@ func TestAllowedRegistrationRepository_DeleteDNI(t *testing.T) {
 	cleanupTables(testDB)
 
 	dni := "56789012E"
-	testDB.AllowedRegistrationsRepo.InsertDNI(dni)
+	testDB.AllowedRegistrationsRepo.InsertDNI(context.Background(), dni)
 
-	err := testDB.AllowedRegistrationsRepo.DeleteDNI(dni)
+	err := testDB.AllowedRegistrationsRepo.DeleteDNI(context.Background(), dni)
 	if err != nil {
 		t.Fatalf("Failed to delete DNI: %v", err)
 	}
@@ -81,7 +87,7 @@ func TestAllowedRegistrationRepository_DeleteDNI(t *testing.T) {
 func TestAllowedRegistrationRepository_DeleteDNI_NotFound(t *testing.T) {
 	cleanupTables(testDB)
 
-	err := testDB.AllowedRegistrationsRepo.DeleteDNI("NONEXISTENT")
+	err := testDB.AllowedRegistrationsRepo.DeleteDNI(context.Background(), "NONEXISTENT")
 	if err != ErrDNINotFound {
 		t.Errorf("Expected ErrDNINotFound, got %v", err)
 	}
@@ -93,20 +99,132 @@ func TestAllowedRegistrationRepository_Workflow(t *testing.T) {
 	dni := "67890123F"
 
 	// Insert DNI
-	err := testDB.AllowedRegistrationsRepo.InsertDNI(dni)
+	err := testDB.AllowedRegistrationsRepo.InsertDNI(context.Background(), dni)
 	if err != nil {
 		t.Fatalf("Step 1 failed - Insert DNI: %v", err)
 	}
 
 	// Use the DNI
-	err = testDB.AllowedRegistrationsRepo.UseDNI(dni)
+	_, err = testDB.AllowedRegistrationsRepo.UseDNI(context.Background(), dni)
 	if err != nil {
 		t.Fatalf("Step 2 failed - Use DNI: %v", err)
 	}
 
 	// Try to use again (should fail)
-	err = testDB.AllowedRegistrationsRepo.UseDNI(dni)
+	_, err = testDB.AllowedRegistrationsRepo.UseDNI(context.Background(), dni)
 	if err != ErrDNIInvalidOrUsed {
 		t.Error("Step 3 failed - DNI should not be allowed after use")
 	}
 }
+
+func TestAllowedRegistrationRepository_InsertRegistrations(t *testing.T) {
+	cleanupTables(testDB)
+
+	admin := domain.NewUser("11111111A", "admin@example.com", "hashedpassword", "Admin", "admin.jpg")
+	admin.Role = domain.RoleAdmin
+	testDB.UserRepo.CreateUser(context.Background(), admin)
+	testDB.AllowedRegistrationsRepo.InsertDNI(context.Background(), "22222222B")
+
+	expiresAt := time.Now().UTC().Add(24 * time.Hour)
+	registrations := []domain.AllowedRegistration{
+		*domain.NewAllowedRegistration("22222222B", domain.RoleReceptionist, admin.ID, &expiresAt),
+		*domain.NewAllowedRegistration("33333333C", domain.RoleReceptionist, admin.ID, &expiresAt),
+	}
+
+	created, err := testDB.AllowedRegistrationsRepo.InsertRegistrations(context.Background(), registrations)
+	if err != nil {
+		t.Fatalf("Failed to insert registrations: %v", err)
+	}
+	if len(created) != 1 || created[0] != "33333333C" {
+		t.Errorf("Expected only 33333333C to be created, got %v", created)
+	}
+
+	registration, err := testDB.AllowedRegistrationsRepo.GetRegistration(context.Background(), "33333333C")
+	if err != nil {
+		t.Fatalf("Failed to get registration: %v", err)
+	}
+	if registration.InvitedBy == nil || *registration.InvitedBy != admin.ID {
+		t.Errorf("Expected invitation to be recorded as sent by %d", admin.ID)
+	}
+	if registration.Role != domain.RoleReceptionist {
+		t.Errorf("Expected role %s, got %s", domain.RoleReceptionist, registration.Role)
+	}
+}
+
+func TestAllowedRegistrationRepository_GetRegistrations(t *testing.T) {
+	cleanupTables(testDB)
+
+	testDB.AllowedRegistrationsRepo.InsertDNI(context.Background(), "44444444D")
+	testDB.AllowedRegistrationsRepo.InsertDNI(context.Background(), "55555555E")
+	testDB.AllowedRegistrationsRepo.UseDNI(context.Background(), "55555555E")
+
+	used := true
+	registrations, err := testDB.AllowedRegistrationsRepo.GetRegistrations(context.Background(), &used, 20, 0)
+	if err != nil {
+		t.Fatalf("Failed to get registrations: %v", err)
+	}
+	if len(registrations) != 1 || registrations[0].DNI != "55555555E" {
+		t.Errorf("Expected only the used DNI, got %v", registrations)
+	}
+
+	count, err := testDB.AllowedRegistrationsRepo.GetRegistrationsCount(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("Failed to count registrations: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 registrations, got %d", count)
+	}
+}
+
+func TestAllowedRegistrationRepository_UseDNI_Expired(t *testing.T) {
+	cleanupTables(testDB)
+
+	admin := domain.NewUser("11111111A", "admin@example.com", "hashedpassword", "Admin", "admin.jpg")
+	testDB.UserRepo.CreateUser(context.Background(), admin)
+
+	expiresAt := time.Now().UTC().Add(-time.Hour)
+	registration := domain.NewAllowedRegistration("66666666F", domain.RoleVeterinarian, admin.ID, &expiresAt)
+	testDB.AllowedRegistrationsRepo.InsertRegistrations(context.Background(), []domain.AllowedRegistration{*registration})
+
+	_, err := testDB.AllowedRegistrationsRepo.UseDNI(context.Background(), "66666666F")
+	if err != ErrDNIInvalidOrUsed {
+		t.Errorf("Expected ErrDNIInvalidOrUsed for expired invitation, got %v", err)
+	}
+}
+
+func TestAllowedRegistrationRepository_RevokeAndReopen(t *testing.T) {
+	cleanupTables(testDB)
+
+	admin := domain.NewUser("11111111A", "admin@example.com", "hashedpassword", "Admin", "admin.jpg")
+	testDB.UserRepo.CreateUser(context.Background(), admin)
+
+	dni := "77777777G"
+	testDB.AllowedRegistrationsRepo.InsertDNI(context.Background(), dni)
+
+	if err := testDB.AllowedRegistrationsRepo.RevokeDNI(context.Background(), dni); err != nil {
+		t.Fatalf("Failed to revoke DNI: %v", err)
+	}
+	if err := testDB.AllowedRegistrationsRepo.RevokeDNI(context.Background(), dni); err != ErrDNIInvalidOrUsed {
+		t.Errorf("Expected ErrDNIInvalidOrUsed revoking twice, got %v", err)
+	}
+	if _, err := testDB.AllowedRegistrationsRepo.UseDNI(context.Background(), dni); err != ErrDNIInvalidOrUsed {
+		t.Errorf("Expected revoked DNI to be unusable, got %v", err)
+	}
+
+	expiresAt := time.Now().UTC().Add(time.Hour)
+	if err := testDB.AllowedRegistrationsRepo.ReopenDNI(context.Background(), dni, admin.ID, &expiresAt); err != nil {
+		t.Fatalf("Failed to reopen DNI: %v", err)
+	}
+	if _, err := testDB.AllowedRegistrationsRepo.UseDNI(context.Background(), dni); err != nil {
+		t.Errorf("Expected reopened DNI to be usable, got %v", err)
+	}
+}
+
+func TestAllowedRegistrationRepository_RevokeDNI_NotFound(t *testing.T) {
+	cleanupTables(testDB)
+
+	err := testDB.AllowedRegistrationsRepo.RevokeDNI(context.Background(), "NONEXISTENT")
+	if err != ErrDNINotFound {
+		t.Errorf("Expected ErrDNINotFound, got %v", err)
+	}
+}
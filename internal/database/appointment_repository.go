@@ -0,0 +1,239 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+	"vetsys/internal/domain"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type AppointmentRepository struct {
+	DB           *sqlx.DB
+	QueryTimeout time.Duration
+}
+
+var (
+	ErrAppointmentNotFound      = errors.New("Appointment not found")
+	ErrAppointmentConflict      = errors.New("vet already has an appointment in that time slot")
+	ErrAppointmentStatusChanged = errors.New("appointment status changed concurrently")
+	ErrVetNotFound              = errors.New("vet not found")
+)
+
+// AppointmentFilter narrows appointment listings; nil fields are not filtered on.
+type AppointmentFilter struct {
+	VetID     *int64
+	PatientID *int64
+	Status    *domain.AppointmentStatus
+	From      *time.Time
+	To        *time.Time
+}
+
+const appointmentColumns = `id, patient_id, vet_id, starts_at, ends_at, reason, status, consultation_id, created_at, updated_at`
+
+const appointmentFilterWhere = `
+	WHERE ($1::bigint IS NULL OR vet_id = $1)
+	AND ($2::bigint IS NULL OR patient_id = $2)
+	AND ($3::text IS NULL OR status = $3)
+	AND ($4::timestamp IS NULL OR ends_at > $4)
+	AND ($5::timestamp IS NULL OR starts_at < $5)
+`
+
+func (appointmentRepository *AppointmentRepository) CreateAppointment(ctx context.Context, appointment *domain.Appointment) error {
+	ctx, cancel := withQueryTimeout(ctx, appointmentRepository.QueryTimeout)
+	defer cancel()
+
+	tx, err := appointmentRepository.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockVetCalendar(ctx, tx, appointment); err != nil {
+		return err
+	}
+
+	query := `INSERT INTO appointments (patient_id, vet_id, starts_at, ends_at, reason, status, created_at, updated_at)
+	VALUES (:patient_id, :vet_id, :starts_at, :ends_at, :reason, :status, :created_at, :updated_at)
+	RETURNING id`
+	stmt, err := tx.PrepareNamedContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	if err := stmt.GetContext(ctx, &appointment.ID, appointment); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (appointmentRepository *AppointmentRepository) GetAppointmentByID(ctx context.Context, id int64) (*domain.Appointment, error) {
+	ctx, cancel := withQueryTimeout(ctx, appointmentRepository.QueryTimeout)
+	defer cancel()
+
+	appointment := domain.Appointment{}
+	query := `SELECT ` + appointmentColumns + ` FROM appointments WHERE id = $1`
+	err := appointmentRepository.DB.GetContext(ctx, &appointment, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrAppointmentNotFound
+		}
+		return nil, err
+	}
+	return &appointment, nil
+}
+
+func (appointmentRepository *AppointmentRepository) GetAppointments(ctx context.Context, filter AppointmentFilter, limit int, offset int) ([]domain.Appointment, error) {
+	ctx, cancel := withQueryTimeout(ctx, appointmentRepository.QueryTimeout)
+	defer cancel()
+
+	query := `SELECT ` + appointmentColumns + ` FROM appointments` + appointmentFilterWhere + `ORDER BY starts_at LIMIT $6 OFFSET $7`
+	var appointments []domain.Appointment
+	err := appointmentRepository.DB.SelectContext(ctx, &appointments, query, filter.VetID, filter.PatientID, filter.Status, filter.From, filter.To, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return appointments, nil
+}
+
+func (appointmentRepository *AppointmentRepository) GetAppointmentsCount(ctx context.Context, filter AppointmentFilter) (int64, error) {
+	ctx, cancel := withQueryTimeout(ctx, appointmentRepository.QueryTimeout)
+	defer cancel()
+
+	var count int64
+	query := `SELECT COUNT(*) FROM appointments` + appointmentFilterWhere
+	err := appointmentRepository.DB.GetContext(ctx, &count, query, filter.VetID, filter.PatientID, filter.Status, filter.From, filter.To)
+	return count, err
+}
+
+// UpdateAppointment reschedules a booked appointment, re-checking the vet's
+// calendar for overlaps. It fails with ErrAppointmentStatusChanged if the
+// appointment is no longer booked.
+func (appointmentRepository *AppointmentRepository) UpdateAppointment(ctx context.Context, appointment *domain.Appointment) error {
+	ctx, cancel := withQueryTimeout(ctx, appointmentRepository.QueryTimeout)
+	defer cancel()
+
+	tx, err := appointmentRepository.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockVetCalendar(ctx, tx, appointment); err != nil {
+		return err
+	}
+
+	query := `UPDATE appointments SET patient_id = $1, vet_id = $2, starts_at = $3, ends_at = $4, reason = $5, updated_at = $6 WHERE id = $7 AND status = $8`
+	result, err := tx.ExecContext(ctx, query, appointment.PatientID, appointment.VetID, appointment.StartsAt, appointment.EndsAt, appointment.Reason, appointment.UpdatedAt, appointment.ID, domain.AppointmentBooked)
+	if err != nil {
+		return err
+	}
+	if err := appointmentStatusResult(result); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// UpdateAppointmentStatus moves an appointment from one status to another,
+// failing with ErrAppointmentStatusChanged if it is no longer in from.
+func (appointmentRepository *AppointmentRepository) UpdateAppointmentStatus(ctx context.Context, id int64, from domain.AppointmentStatus, to domain.AppointmentStatus) error {
+	ctx, cancel := withQueryTimeout(ctx, appointmentRepository.QueryTimeout)
+	defer cancel()
+
+	query := `UPDATE appointments SET status = $1, updated_at = $2 WHERE id = $3 AND status = $4`
+	result, err := appointmentRepository.DB.ExecContext(ctx, query, to, time.Now().UTC(), id, from)
+	if err != nil {
+		return err
+	}
+	return appointmentStatusResult(result)
+}
+
+// ConvertToConsultation creates the consultation for a checked-in appointment
+// and links it, both in one transaction.
+func (appointmentRepository *AppointmentRepository) ConvertToConsultation(ctx context.Context, appointment *domain.Appointment, consultation *domain.Consultation) error {
+	ctx, cancel := withQueryTimeout(ctx, appointmentRepository.QueryTimeout)
+	defer cancel()
+
+	tx, err := appointmentRepository.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO consultations (patient_id, reason, diagnosis, treatment, severity, is_completed, created_at, updated_at)
+	VALUES (:patient_id, :reason, :diagnosis, :treatment, :severity, :is_completed, :created_at, :updated_at)
+	RETURNING id`
+	stmt, err := tx.PrepareNamedContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	if err := stmt.GetContext(ctx, &consultation.ID, consultation); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	result, err := tx.ExecContext(ctx, `UPDATE appointments SET status = $1, consultation_id = $2, updated_at = $3 WHERE id = $4 AND status = $5`,
+		domain.AppointmentConverted, consultation.ID, now, appointment.ID, appointment.Status)
+	if err != nil {
+		return err
+	}
+	if err := appointmentStatusResult(result); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	appointment.Status = domain.AppointmentConverted
+	appointment.ConsultationID = &consultation.ID
+	appointment.UpdatedAt = now
+	return nil
+}
+
+// lockVetCalendar serialises bookings per vet by locking the vet's user row,
+// then rejects the appointment if the user cannot attend patients or the slot
+// overlaps an active one.
+func lockVetCalendar(ctx context.Context, tx *sqlx.Tx, appointment *domain.Appointment) error {
+	var role domain.Role
+	err := tx.GetContext(ctx, &role, `SELECT role FROM users WHERE id = $1 FOR UPDATE`, appointment.VetID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrVetNotFound
+		}
+		return err
+	}
+	if !role.Can(domain.PermClinicalNotesWrite) {
+		return ErrVetNotFound
+	}
+
+	var overlaps bool
+	query := `SELECT EXISTS (
+		SELECT 1 FROM appointments
+		WHERE vet_id = $1 AND id <> $2
+		AND status IN ($3, $4)
+		AND starts_at < $5 AND ends_at > $6
+	)`
+	err = tx.GetContext(ctx, &overlaps, query, appointment.VetID, appointment.ID,
+		domain.AppointmentBooked, domain.AppointmentCheckedIn, appointment.EndsAt, appointment.StartsAt)
+	if err != nil {
+		return err
+	}
+	if overlaps {
+		return ErrAppointmentConflict
+	}
+	return nil
+}
+
+func appointmentStatusResult(result sql.Result) error {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrAppointmentStatusChanged
+	}
+	return nil
+}
@@ -0,0 +1,196 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+	"vetsys/internal/domain"
+)
+
+func createAppointmentFixtures(t *testing.T) (*domain.User, *domain.Patient) {
+	t.Helper()
+	cleanupTables(testDB)
+
+	vet := domain.NewUser("12345678A", "vet@example.com", "hashedpassword", "Dr. Vet", "vet.jpg")
+	vet.Role = domain.RoleVeterinarian
+	if err := testDB.UserRepo.CreateUser(context.Background(), vet); err != nil {
+		t.Fatalf("Failed to create vet: %v", err)
+	}
+
+	client := domain.NewClient("23456789B", "Jane Smith", "+34600222333")
+	testDB.ClientRepo.CreateClient(context.Background(), client)
+
+	dob := time.Date(2019, 3, 10, 0, 0, 0, 0, time.UTC)
+	patient := domain.NewPatient("Luna", "Cat", "Persian", dob, client.ID)
+	testDB.PatientRepo.CreatePatient(context.Background(), patient)
+
+	return vet, patient
+}
+
+func TestAppointmentRepository_CreateAppointment(t *testing.T) {
+	vet, patient := createAppointmentFixtures(t)
+
+	start := time.Date(2030, 1, 10, 9, 0, 0, 0, time.UTC)
+	appointment := domain.NewAppointment(patient.ID, vet.ID, start, start.Add(30*time.Minute), "Checkup")
+
+	err := testDB.AppointmentRepo.CreateAppointment(context.Background(), appointment)
+	if err != nil {
+		t.Fatalf("Failed to create appointment: %v", err)
+	}
+
+	if appointment.ID == 0 {
+		t.Error("Expected appointment ID to be set after creation")
+	}
+}
+
+func TestAppointmentRepository_CreateAppointment_Overlap(t *testing.T) {
+	vet, patient := createAppointmentFixtures(t)
+
+	start := time.Date(2030, 1, 10, 9, 0, 0, 0, time.UTC)
+	first := domain.NewAppointment(patient.ID, vet.ID, start, start.Add(30*time.Minute), "Checkup")
+	testDB.AppointmentRepo.CreateAppointment(context.Background(), first)
+
+	overlapping := domain.NewAppointment(patient.ID, vet.ID, start.Add(15*time.Minute), start.Add(45*time.Minute), "Vaccination")
+	err := testDB.AppointmentRepo.CreateAppointment(context.Background(), overlapping)
+	if err != ErrAppointmentConflict {
+		t.Errorf("Expected ErrAppointmentConflict, got %v", err)
+	}
+
+	adjacent := domain.NewAppointment(patient.ID, vet.ID, start.Add(30*time.Minute), start.Add(time.Hour), "Vaccination")
+	err = testDB.AppointmentRepo.CreateAppointment(context.Background(), adjacent)
+	if err != nil {
+		t.Errorf("Expected back-to-back appointment to be allowed, got %v", err)
+	}
+}
+
+func TestAppointmentRepository_CreateAppointment_CancelledFreesSlot(t *testing.T) {
+	vet, patient := createAppointmentFixtures(t)
+
+	start := time.Date(2030, 1, 10, 9, 0, 0, 0, time.UTC)
+	first := domain.NewAppointment(patient.ID, vet.ID, start, start.Add(30*time.Minute), "Checkup")
+	testDB.AppointmentRepo.CreateAppointment(context.Background(), first)
+	testDB.AppointmentRepo.UpdateAppointmentStatus(context.Background(), first.ID, domain.AppointmentBooked, domain.AppointmentCancelled)
+
+	second := domain.NewAppointment(patient.ID, vet.ID, start, start.Add(30*time.Minute), "Checkup")
+	err := testDB.AppointmentRepo.CreateAppointment(context.Background(), second)
+	if err != nil {
+		t.Errorf("Expected cancelled slot to be bookable, got %v", err)
+	}
+}
+
+func TestAppointmentRepository_CreateAppointment_UnknownVet(t *testing.T) {
+	_, patient := createAppointmentFixtures(t)
+
+	start := time.Date(2030, 1, 10, 9, 0, 0, 0, time.UTC)
+	appointment := domain.NewAppointment(patient.ID, 99999, start, start.Add(30*time.Minute), "Checkup")
+
+	err := testDB.AppointmentRepo.CreateAppointment(context.Background(), appointment)
+	if err != ErrVetNotFound {
+		t.Errorf("Expected ErrVetNotFound, got %v", err)
+	}
+}
+
+func TestAppointmentRepository_CreateAppointment_NonClinician(t *testing.T) {
+	_, patient := createAppointmentFixtures(t)
+
+	receptionist := domain.NewUser("34567890C", "desk@example.com", "hashedpassword", "Front Desk", "desk.jpg")
+	receptionist.Role = domain.RoleReceptionist
+	testDB.UserRepo.CreateUser(context.Background(), receptionist)
+
+	start := time.Date(2030, 1, 10, 9, 0, 0, 0, time.UTC)
+	appointment := domain.NewAppointment(patient.ID, receptionist.ID, start, start.Add(30*time.Minute), "Checkup")
+
+	err := testDB.AppointmentRepo.CreateAppointment(context.Background(), appointment)
+	if err != ErrVetNotFound {
+		t.Errorf("Expected ErrVetNotFound, got %v", err)
+	}
+}
+
+func TestAppointmentRepository_GetAppointments(t *testing.T) {
+	vet, patient := createAppointmentFixtures(t)
+
+	start := time.Date(2030, 1, 10, 9, 0, 0, 0, time.UTC)
+	testDB.AppointmentRepo.CreateAppointment(context.Background(), domain.NewAppointment(patient.ID, vet.ID, start, start.Add(30*time.Minute), "Checkup"))
+	testDB.AppointmentRepo.CreateAppointment(context.Background(), domain.NewAppointment(patient.ID, vet.ID, start.Add(24*time.Hour), start.Add(25*time.Hour), "Follow-up"))
+
+	from := start.Add(-time.Hour)
+	to := start.Add(time.Hour)
+	filter := AppointmentFilter{VetID: &vet.ID, From: &from, To: &to}
+
+	appointments, err := testDB.AppointmentRepo.GetAppointments(context.Background(), filter, 20, 0)
+	if err != nil {
+		t.Fatalf("Failed to get appointments: %v", err)
+	}
+	if len(appointments) != 1 {
+		t.Errorf("Expected 1 appointment in window, got %d", len(appointments))
+	}
+
+	count, err := testDB.AppointmentRepo.GetAppointmentsCount(context.Background(), AppointmentFilter{VetID: &vet.ID})
+	if err != nil {
+		t.Fatalf("Failed to count appointments: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 appointments for vet, got %d", count)
+	}
+}
+
+func TestAppointmentRepository_UpdateAppointmentStatus(t *testing.T) {
+	vet, patient := createAppointmentFixtures(t)
+
+	start := time.Date(2030, 1, 10, 9, 0, 0, 0, time.UTC)
+	appointment := domain.NewAppointment(patient.ID, vet.ID, start, start.Add(30*time.Minute), "Checkup")
+	testDB.AppointmentRepo.CreateAppointment(context.Background(), appointment)
+
+	err := testDB.AppointmentRepo.UpdateAppointmentStatus(context.Background(), appointment.ID, domain.AppointmentBooked, domain.AppointmentCheckedIn)
+	if err != nil {
+		t.Fatalf("Failed to update appointment status: %v", err)
+	}
+
+	err = testDB.AppointmentRepo.UpdateAppointmentStatus(context.Background(), appointment.ID, domain.AppointmentBooked, domain.AppointmentNoShow)
+	if err != ErrAppointmentStatusChanged {
+		t.Errorf("Expected ErrAppointmentStatusChanged, got %v", err)
+	}
+}
+
+func TestAppointmentRepository_UpdateAppointmentNotBooked(t *testing.T) {
+	vet, patient := createAppointmentFixtures(t)
+
+	start := time.Date(2030, 1, 10, 9, 0, 0, 0, time.UTC)
+	appointment := domain.NewAppointment(patient.ID, vet.ID, start, start.Add(30*time.Minute), "Checkup")
+	testDB.AppointmentRepo.CreateAppointment(context.Background(), appointment)
+	testDB.AppointmentRepo.UpdateAppointmentStatus(context.Background(), appointment.ID, domain.AppointmentBooked, domain.AppointmentCancelled)
+
+	appointment.StartsAt = start.Add(time.Hour)
+	appointment.EndsAt = start.Add(90 * time.Minute)
+	err := testDB.AppointmentRepo.UpdateAppointment(context.Background(), appointment)
+	if err != ErrAppointmentStatusChanged {
+		t.Errorf("Expected ErrAppointmentStatusChanged, got %v", err)
+	}
+}
+
+func TestAppointmentRepository_ConvertToConsultation(t *testing.T) {
+	vet, patient := createAppointmentFixtures(t)
+
+	start := time.Date(2030, 1, 10, 9, 0, 0, 0, time.UTC)
+	appointment := domain.NewAppointment(patient.ID, vet.ID, start, start.Add(30*time.Minute), "Checkup")
+	testDB.AppointmentRepo.CreateAppointment(context.Background(), appointment)
+	testDB.AppointmentRepo.UpdateAppointmentStatus(context.Background(), appointment.ID, domain.AppointmentBooked, domain.AppointmentCheckedIn)
+	appointment.Status = domain.AppointmentCheckedIn
+
+	consultation := domain.NewConsultation(patient.ID, appointment.Reason, "Healthy", "None", domain.SeverityLow)
+	err := testDB.AppointmentRepo.ConvertToConsultation(context.Background(), appointment, consultation)
+	if err != nil {
+		t.Fatalf("Failed to convert appointment: %v", err)
+	}
+
+	retrieved, err := testDB.AppointmentRepo.GetAppointmentByID(context.Background(), appointment.ID)
+	if err != nil {
+		t.Fatalf("Failed to get appointment: %v", err)
+	}
+	if retrieved.Status != domain.AppointmentConverted {
+		t.Errorf("Expected status %s, got %s", domain.AppointmentConverted, retrieved.Status)
+	}
+	if retrieved.ConsultationID == nil || *retrieved.ConsultationID != consultation.ID {
+		t.Errorf("Expected appointment to reference consultation %d", consultation.ID)
+	}
+}
@@ -0,0 +1,67 @@
+package database
+
+import (
+	"context"
+	"time"
+	"vetsys/internal/domain"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// AuditRepository only appends and reads; the audit_log table rejects
+// updates and deletes.
+type AuditRepository struct {
+	DB           *sqlx.DB
+	QueryTimeout time.Duration
+}
+
+// AuditFilter narrows audit listings; nil fields are not filtered on.
+type AuditFilter struct {
+	Entity   *string
+	EntityID *int64
+	ActorID  *int64
+	From     *time.Time
+	To       *time.Time
+}
+
+const auditFilterWhere = `
+	WHERE ($1::text IS NULL OR entity = $1)
+	AND ($2::bigint IS NULL OR entity_id = $2)
+	AND ($3::bigint IS NULL OR actor_id = $3)
+	AND ($4::timestamp IS NULL OR created_at >= $4)
+	AND ($5::timestamp IS NULL OR created_at < $5)
+`
+
+func (auditRepository *AuditRepository) CreateEntry(ctx context.Context, entry *domain.AuditEntry) error {
+	ctx, cancel := withQueryTimeout(ctx, auditRepository.QueryTimeout)
+	defer cancel()
+
+	query := `INSERT INTO audit_log (actor_id, entity, entity_id, action, changes, ip, request_id, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	RETURNING id`
+	// JSONB must be sent as text; lib/pq would encode []byte as bytea.
+	return auditRepository.DB.GetContext(ctx, &entry.ID, query, entry.ActorID, entry.Entity, entry.EntityID, entry.Action, string(entry.Changes), entry.IP, entry.RequestID, entry.CreatedAt)
+}
+
+func (auditRepository *AuditRepository) GetEntries(ctx context.Context, filter AuditFilter, limit int, offset int) ([]domain.AuditEntry, error) {
+	ctx, cancel := withQueryTimeout(ctx, auditRepository.QueryTimeout)
+	defer cancel()
+
+	query := `SELECT id, actor_id, entity, entity_id, action, changes, ip, request_id, created_at FROM audit_log` + auditFilterWhere + `ORDER BY created_at DESC, id DESC LIMIT $6 OFFSET $7`
+	var entries []domain.AuditEntry
+	err := auditRepository.DB.SelectContext(ctx, &entries, query, filter.Entity, filter.EntityID, filter.ActorID, filter.From, filter.To, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (auditRepository *AuditRepository) GetEntriesCount(ctx context.Context, filter AuditFilter) (int64, error) {
+	ctx, cancel := withQueryTimeout(ctx, auditRepository.QueryTimeout)
+	defer cancel()
+
+	var count int64
+	query := `SELECT COUNT(*) FROM audit_log` + auditFilterWhere
+	err := auditRepository.DB.GetContext(ctx, &count, query, filter.Entity, filter.EntityID, filter.ActorID, filter.From, filter.To)
+	return count, err
+}
@@ -0,0 +1,89 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"vetsys/internal/domain"
+)
+
+func TestAuditRepository_CreateEntry(t *testing.T) {
+	cleanupTables(testDB)
+
+	actorID := int64(7)
+	changes := json.RawMessage(`{"name":{"before":"Jane","after":"Janet"}}`)
+	entry := domain.NewAuditEntry(&actorID, domain.AuditEntityClient, 42, domain.AuditUpdate, changes, "127.0.0.1")
+	entry.RequestID = "req-42"
+
+	err := testDB.AuditRepo.CreateEntry(context.Background(), entry)
+	if err != nil {
+		t.Fatalf("Failed to create audit entry: %v", err)
+	}
+	if entry.ID == 0 {
+		t.Error("Expected audit entry ID to be set after creation")
+	}
+
+	entries, err := testDB.AuditRepo.GetEntries(context.Background(), AuditFilter{}, 20, 0)
+	if err != nil {
+		t.Fatalf("Failed to get audit entries: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 audit entry, got %d", len(entries))
+	}
+	if entries[0].RequestID != "req-42" {
+		t.Errorf("Expected request ID req-42, got %q", entries[0].RequestID)
+	}
+	var stored map[string]domain.FieldChange
+	if err := json.Unmarshal(entries[0].Changes, &stored); err != nil {
+		t.Fatalf("Failed to decode stored changes: %v", err)
+	}
+	if stored["name"].After != "Janet" {
+		t.Errorf("Expected name change to Janet, got %v", stored["name"].After)
+	}
+}
+
+func TestAuditRepository_GetEntries_Filter(t *testing.T) {
+	cleanupTables(testDB)
+
+	actorID := int64(7)
+	testDB.AuditRepo.CreateEntry(context.Background(), domain.NewAuditEntry(&actorID, domain.AuditEntityClient, 1, domain.AuditCreate, json.RawMessage(`{}`), ""))
+	testDB.AuditRepo.CreateEntry(context.Background(), domain.NewAuditEntry(&actorID, domain.AuditEntityClient, 1, domain.AuditUpdate, json.RawMessage(`{}`), ""))
+	testDB.AuditRepo.CreateEntry(context.Background(), domain.NewAuditEntry(nil, domain.AuditEntityPatient, 1, domain.AuditCreate, json.RawMessage(`{}`), ""))
+
+	entity := domain.AuditEntityClient
+	entityID := int64(1)
+	filter := AuditFilter{Entity: &entity, EntityID: &entityID}
+
+	entries, err := testDB.AuditRepo.GetEntries(context.Background(), filter, 20, 0)
+	if err != nil {
+		t.Fatalf("Failed to get audit entries: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("Expected 2 client audit entries, got %d", len(entries))
+	}
+
+	count, err := testDB.AuditRepo.GetEntriesCount(context.Background(), AuditFilter{ActorID: &actorID})
+	if err != nil {
+		t.Fatalf("Failed to count audit entries: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 entries by actor, got %d", count)
+	}
+}
+
+func TestAuditRepository_AppendOnly(t *testing.T) {
+	cleanupTables(testDB)
+
+	entry := domain.NewAuditEntry(nil, domain.AuditEntityUser, 1, domain.AuditDelete, json.RawMessage(`{}`), "")
+	testDB.AuditRepo.CreateEntry(context.Background(), entry)
+
+	_, err := testDB.DB.Exec(`UPDATE audit_log SET action = 'CREATE' WHERE id = $1`, entry.ID)
+	if err == nil {
+		t.Error("Expected update of audit entry to fail")
+	}
+
+	_, err = testDB.DB.Exec(`DELETE FROM audit_log WHERE id = $1`, entry.ID)
+	if err == nil {
+		t.Error("Expected delete of audit entry to fail")
+	}
+}
@@ -0,0 +1,336 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+	"vetsys/internal/domain"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type BillingRepository struct {
+	DB           *sqlx.DB
+	QueryTimeout time.Duration
+}
+
+var (
+	ErrBillableItemNotFound  = errors.New("Billable item not found")
+	ErrBillableItemInvoiced  = errors.New("billable item is already invoiced")
+	ErrInvoiceNotFound       = errors.New("Invoice not found")
+	ErrNothingToInvoice      = errors.New("client has no uninvoiced items")
+	ErrInvoiceVoid           = errors.New("invoice is void")
+	ErrInvoiceHasPayments    = errors.New("invoice has payments and cannot be voided")
+	ErrPaymentExceedsBalance = errors.New("payment exceeds the outstanding balance of the invoice")
+)
+
+const billableItemColumns = `id, consultation_id, invoice_id, prescription_id, product_id, kind, description, quantity, unit_price_cents, created_at`
+
+const invoiceColumns = `i.id, i.client_id, i.status, i.total_cents, i.issued_by, i.issued_at, i.voided_at,
+	COALESCE((SELECT SUM(amount_cents) FROM payments WHERE invoice_id = i.id), 0) AS paid_cents`
+
+const paymentColumns = `id, invoice_id, amount_cents, method, reference, recorded_by, paid_at`
+
+// CreateBillableItem adds a charge to a consultation. Items for a product
+// dispense their quantity from stock in the same transaction.
+func (billingRepository *BillingRepository) CreateBillableItem(ctx context.Context, item *domain.BillableItem) error {
+	ctx, cancel := withQueryTimeout(ctx, billingRepository.QueryTimeout)
+	defer cancel()
+
+	tx, err := billingRepository.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO billable_items (consultation_id, prescription_id, product_id, kind, description, quantity, unit_price_cents, created_at)
+	VALUES (:consultation_id, :prescription_id, :product_id, :kind, :description, :quantity, :unit_price_cents, :created_at)
+	RETURNING id`
+	stmt, err := tx.PrepareNamedContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	if err := stmt.GetContext(ctx, &item.ID, item); err != nil {
+		return err
+	}
+	if item.ProductID != nil {
+		if err := dispenseStock(ctx, tx, item); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (billingRepository *BillingRepository) GetBillableItemByID(ctx context.Context, id int64) (*domain.BillableItem, error) {
+	ctx, cancel := withQueryTimeout(ctx, billingRepository.QueryTimeout)
+	defer cancel()
+
+	item := domain.BillableItem{}
+	err := billingRepository.DB.GetContext(ctx, &item, `SELECT `+billableItemColumns+` FROM billable_items WHERE id = $1`, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrBillableItemNotFound
+		}
+		return nil, err
+	}
+	return &item, nil
+}
+
+func (billingRepository *BillingRepository) GetBillableItemsByConsultationID(ctx context.Context, consultationID int64) ([]domain.BillableItem, error) {
+	ctx, cancel := withQueryTimeout(ctx, billingRepository.QueryTimeout)
+	defer cancel()
+
+	items := []domain.BillableItem{}
+	err := billingRepository.DB.SelectContext(ctx, &items, `SELECT `+billableItemColumns+` FROM billable_items WHERE consultation_id = $1 ORDER BY id`, consultationID)
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// DeleteBillableItem removes an item that has not been invoiced yet and
+// returns any stock it dispensed.
+func (billingRepository *BillingRepository) DeleteBillableItem(ctx context.Context, id int64) error {
+	ctx, cancel := withQueryTimeout(ctx, billingRepository.QueryTimeout)
+	defer cancel()
+
+	tx, err := billingRepository.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var invoiceID *int64
+	err = tx.GetContext(ctx, &invoiceID, `SELECT invoice_id FROM billable_items WHERE id = $1 FOR UPDATE`, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrBillableItemNotFound
+		}
+		return err
+	}
+	if invoiceID != nil {
+		return ErrBillableItemInvoiced
+	}
+	if err := restockItem(ctx, tx, id); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM billable_items WHERE id = $1`, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// CreateInvoice bills every uninvoiced item from the consultations of the
+// client's patients. Items claimed by a concurrent invoice are skipped.
+func (billingRepository *BillingRepository) CreateInvoice(ctx context.Context, clientID int64, issuedBy int64) (*domain.Invoice, error) {
+	ctx, cancel := withQueryTimeout(ctx, billingRepository.QueryTimeout)
+	defer cancel()
+
+	tx, err := billingRepository.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	invoice := &domain.Invoice{
+		ClientID: clientID,
+		Status:   domain.InvoiceIssued,
+		IssuedBy: &issuedBy,
+		IssuedAt: time.Now().UTC(),
+	}
+	err = tx.GetContext(ctx, &invoice.ID, `INSERT INTO invoices (client_id, status, total_cents, issued_by, issued_at) VALUES ($1, $2, 0, $3, $4) RETURNING id`,
+		invoice.ClientID, invoice.Status, invoice.IssuedBy, invoice.IssuedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `UPDATE billable_items SET invoice_id = $1
+	WHERE invoice_id IS NULL AND consultation_id IN (
+		SELECT c.id FROM consultations c JOIN patients p ON p.id = c.patient_id WHERE p.owner_id = $2
+	)
+	RETURNING ` + billableItemColumns
+	err = tx.SelectContext(ctx, &invoice.Items, query, invoice.ID, clientID)
+	if err != nil {
+		return nil, err
+	}
+	if len(invoice.Items) == 0 {
+		return nil, ErrNothingToInvoice
+	}
+	for i := range invoice.Items {
+		invoice.TotalCents += invoice.Items[i].TotalCents()
+	}
+	_, err = tx.ExecContext(ctx, `UPDATE invoices SET total_cents = $1 WHERE id = $2`, invoice.TotalCents, invoice.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	invoice.Payments = []domain.Payment{}
+	return invoice, nil
+}
+
+// GetInvoiceByID returns the invoice with its items and payments.
+func (billingRepository *BillingRepository) GetInvoiceByID(ctx context.Context, id int64) (*domain.Invoice, error) {
+	ctx, cancel := withQueryTimeout(ctx, billingRepository.QueryTimeout)
+	defer cancel()
+
+	invoice := domain.Invoice{}
+	err := billingRepository.DB.GetContext(ctx, &invoice, `SELECT `+invoiceColumns+` FROM invoices i WHERE i.id = $1`, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrInvoiceNotFound
+		}
+		return nil, err
+	}
+	invoice.Items = []domain.BillableItem{}
+	err = billingRepository.DB.SelectContext(ctx, &invoice.Items, `SELECT `+billableItemColumns+` FROM billable_items WHERE invoice_id = $1 ORDER BY id`, id)
+	if err != nil {
+		return nil, err
+	}
+	invoice.Payments = []domain.Payment{}
+	err = billingRepository.DB.SelectContext(ctx, &invoice.Payments, `SELECT `+paymentColumns+` FROM payments WHERE invoice_id = $1 ORDER BY paid_at, id`, id)
+	if err != nil {
+		return nil, err
+	}
+	return &invoice, nil
+}
+
+func (billingRepository *BillingRepository) GetInvoicesByClientID(ctx context.Context, clientID int64, limit int, offset int) ([]domain.Invoice, error) {
+	ctx, cancel := withQueryTimeout(ctx, billingRepository.QueryTimeout)
+	defer cancel()
+
+	query := `SELECT ` + invoiceColumns + ` FROM invoices i WHERE i.client_id = $1 ORDER BY i.issued_at DESC, i.id DESC LIMIT $2 OFFSET $3`
+	var invoices []domain.Invoice
+	err := billingRepository.DB.SelectContext(ctx, &invoices, query, clientID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return invoices, nil
+}
+
+func (billingRepository *BillingRepository) GetInvoicesCountByClientID(ctx context.Context, clientID int64) (int64, error) {
+	ctx, cancel := withQueryTimeout(ctx, billingRepository.QueryTimeout)
+	defer cancel()
+
+	var count int64
+	err := billingRepository.DB.GetContext(ctx, &count, `SELECT COUNT(*) FROM invoices WHERE client_id = $1`, clientID)
+	return count, err
+}
+
+// VoidInvoice cancels an unpaid invoice and releases its items so they can
+// be invoiced again.
+func (billingRepository *BillingRepository) VoidInvoice(ctx context.Context, id int64) error {
+	ctx, cancel := withQueryTimeout(ctx, billingRepository.QueryTimeout)
+	defer cancel()
+
+	tx, err := billingRepository.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var status domain.InvoiceStatus
+	err = tx.GetContext(ctx, &status, `SELECT status FROM invoices WHERE id = $1 FOR UPDATE`, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrInvoiceNotFound
+		}
+		return err
+	}
+	if status == domain.InvoiceVoid {
+		return ErrInvoiceVoid
+	}
+	var hasPayments bool
+	err = tx.GetContext(ctx, &hasPayments, `SELECT EXISTS (SELECT 1 FROM payments WHERE invoice_id = $1)`, id)
+	if err != nil {
+		return err
+	}
+	if hasPayments {
+		return ErrInvoiceHasPayments
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE billable_items SET invoice_id = NULL WHERE invoice_id = $1`, id); err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `UPDATE invoices SET status = $1, voided_at = $2 WHERE id = $3`, domain.InvoiceVoid, time.Now().UTC(), id)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// CreatePayment records a payment against an invoice, marking it PAID once
+// it is settled. Payments larger than the outstanding balance are refused.
+func (billingRepository *BillingRepository) CreatePayment(ctx context.Context, payment *domain.Payment) error {
+	ctx, cancel := withQueryTimeout(ctx, billingRepository.QueryTimeout)
+	defer cancel()
+
+	tx, err := billingRepository.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// The payments are summed once the invoice is locked, in a statement of
+	// their own: a statement that waited for the lock still sees the
+	// payments as they were before it started waiting.
+	invoice := domain.Invoice{ID: payment.InvoiceID}
+	err = tx.GetContext(ctx, &invoice, `SELECT status, total_cents FROM invoices WHERE id = $1 FOR UPDATE`, payment.InvoiceID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrInvoiceNotFound
+		}
+		return err
+	}
+	if invoice.Status == domain.InvoiceVoid {
+		return ErrInvoiceVoid
+	}
+	err = tx.GetContext(ctx, &invoice.PaidCents, `SELECT COALESCE(SUM(amount_cents), 0) FROM payments WHERE invoice_id = $1`, payment.InvoiceID)
+	if err != nil {
+		return err
+	}
+	if payment.AmountCents > invoice.OutstandingCents() {
+		return ErrPaymentExceedsBalance
+	}
+
+	query := `INSERT INTO payments (invoice_id, amount_cents, method, reference, recorded_by, paid_at)
+	VALUES (:invoice_id, :amount_cents, :method, :reference, :recorded_by, :paid_at)
+	RETURNING id`
+	stmt, err := tx.PrepareNamedContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	if err := stmt.GetContext(ctx, &payment.ID, payment); err != nil {
+		return err
+	}
+	if payment.AmountCents == invoice.OutstandingCents() {
+		_, err = tx.ExecContext(ctx, `UPDATE invoices SET status = $1 WHERE id = $2`, domain.InvoicePaid, invoice.ID)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (billingRepository *BillingRepository) GetClientBalance(ctx context.Context, clientID int64) (*domain.ClientBalance, error) {
+	ctx, cancel := withQueryTimeout(ctx, billingRepository.QueryTimeout)
+	defer cancel()
+
+	query := `SELECT $1::bigint AS client_id,
+		COALESCE((SELECT SUM(total_cents) FROM invoices WHERE client_id = $1 AND status <> $2), 0) AS invoiced_cents,
+		COALESCE((SELECT SUM(pm.amount_cents) FROM payments pm JOIN invoices i ON i.id = pm.invoice_id WHERE i.client_id = $1 AND i.status <> $2), 0) AS paid_cents,
+		COALESCE((SELECT SUM(bi.quantity * bi.unit_price_cents) FROM billable_items bi
+			JOIN consultations c ON c.id = bi.consultation_id
+			JOIN patients p ON p.id = c.patient_id
+			WHERE p.owner_id = $1 AND bi.invoice_id IS NULL), 0) AS uninvoiced_cents`
+	balance := domain.ClientBalance{}
+	err := billingRepository.DB.GetContext(ctx, &balance, `SELECT *, invoiced_cents - paid_cents AS outstanding_cents FROM (`+query+`) totals`, clientID, domain.InvoiceVoid)
+	if err != nil {
+		return nil, err
+	}
+	return &balance, nil
+}
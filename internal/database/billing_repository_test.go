@@ -0,0 +1,225 @@
+package database
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+	"vetsys/internal/domain"
+)
+
+func createBillingFixtures(t *testing.T) (*domain.User, *domain.Client, *domain.Consultation) {
+	t.Helper()
+	cleanupTables(testDB)
+
+	vet := domain.NewUser("12345678A", "vet@example.com", "hashedpassword", "Dr. Vet", "vet.jpg")
+	vet.Role = domain.RoleVeterinarian
+	if err := testDB.UserRepo.CreateUser(context.Background(), vet); err != nil {
+		t.Fatalf("Failed to create vet: %v", err)
+	}
+
+	client := domain.NewClient("23456789B", "Jane Smith", "+34600222333")
+	testDB.ClientRepo.CreateClient(context.Background(), client)
+
+	dob := time.Date(2019, 3, 10, 0, 0, 0, 0, time.UTC)
+	patient := domain.NewPatient("Max", "Dog", "Labrador", dob, client.ID)
+	testDB.PatientRepo.CreatePatient(context.Background(), patient)
+
+	consultation := domain.NewConsultation(patient.ID, "Limping", "Sprain", "Rest", domain.SeverityLow)
+	testDB.ConsultationRepo.CreateConsultation(context.Background(), consultation)
+
+	return vet, client, consultation
+}
+
+func TestBillingRepository_CreateInvoice(t *testing.T) {
+	vet, client, consultation := createBillingFixtures(t)
+
+	_, err := testDB.BillingRepo.CreateInvoice(context.Background(), client.ID, vet.ID)
+	if err != ErrNothingToInvoice {
+		t.Errorf("Expected ErrNothingToInvoice, got %v", err)
+	}
+
+	testDB.BillingRepo.CreateBillableItem(context.Background(), domain.NewBillableItem(consultation.ID, domain.ItemService, "Examination", 1, 3500))
+	testDB.BillingRepo.CreateBillableItem(context.Background(), domain.NewBillableItem(consultation.ID, domain.ItemProduct, "Bandage", 2, 450))
+
+	invoice, err := testDB.BillingRepo.CreateInvoice(context.Background(), client.ID, vet.ID)
+	if err != nil {
+		t.Fatalf("Failed to create invoice: %v", err)
+	}
+	if invoice.TotalCents != 4400 {
+		t.Errorf("Expected total 4400, got %d", invoice.TotalCents)
+	}
+	if len(invoice.Items) != 2 {
+		t.Errorf("Expected 2 items, got %d", len(invoice.Items))
+	}
+
+	_, err = testDB.BillingRepo.CreateInvoice(context.Background(), client.ID, vet.ID)
+	if err != ErrNothingToInvoice {
+		t.Errorf("Expected items to be invoiced only once, got %v", err)
+	}
+}
+
+func TestBillingRepository_DeleteBillableItem(t *testing.T) {
+	vet, client, consultation := createBillingFixtures(t)
+
+	item := domain.NewBillableItem(consultation.ID, domain.ItemService, "Examination", 1, 3500)
+	testDB.BillingRepo.CreateBillableItem(context.Background(), item)
+	testDB.BillingRepo.CreateInvoice(context.Background(), client.ID, vet.ID)
+
+	err := testDB.BillingRepo.DeleteBillableItem(context.Background(), item.ID)
+	if err != ErrBillableItemInvoiced {
+		t.Errorf("Expected ErrBillableItemInvoiced, got %v", err)
+	}
+	err = testDB.BillingRepo.DeleteBillableItem(context.Background(), 999999)
+	if err != ErrBillableItemNotFound {
+		t.Errorf("Expected ErrBillableItemNotFound, got %v", err)
+	}
+}
+
+func TestBillingRepository_CreatePayment(t *testing.T) {
+	vet, client, consultation := createBillingFixtures(t)
+
+	testDB.BillingRepo.CreateBillableItem(context.Background(), domain.NewBillableItem(consultation.ID, domain.ItemService, "Examination", 1, 5000))
+	invoice, _ := testDB.BillingRepo.CreateInvoice(context.Background(), client.ID, vet.ID)
+
+	err := testDB.BillingRepo.CreatePayment(context.Background(), domain.NewPayment(invoice.ID, 2000, domain.PaymentCash, "", vet.ID, time.Now()))
+	if err != nil {
+		t.Fatalf("Failed to create payment: %v", err)
+	}
+	err = testDB.BillingRepo.CreatePayment(context.Background(), domain.NewPayment(invoice.ID, 3001, domain.PaymentCard, "", vet.ID, time.Now()))
+	if err != ErrPaymentExceedsBalance {
+		t.Errorf("Expected ErrPaymentExceedsBalance, got %v", err)
+	}
+
+	retrieved, _ := testDB.BillingRepo.GetInvoiceByID(context.Background(), invoice.ID)
+	if retrieved.Status != domain.InvoiceIssued || retrieved.PaidCents != 2000 {
+		t.Errorf("Expected a partially paid ISSUED invoice, got %s with %d paid", retrieved.Status, retrieved.PaidCents)
+	}
+
+	err = testDB.BillingRepo.CreatePayment(context.Background(), domain.NewPayment(invoice.ID, 3000, domain.PaymentCard, "TX-1", vet.ID, time.Now()))
+	if err != nil {
+		t.Fatalf("Failed to create payment: %v", err)
+	}
+	retrieved, _ = testDB.BillingRepo.GetInvoiceByID(context.Background(), invoice.ID)
+	if retrieved.Status != domain.InvoicePaid {
+		t.Errorf("Expected PAID, got %s", retrieved.Status)
+	}
+	if len(retrieved.Payments) != 2 {
+		t.Errorf("Expected 2 payments, got %d", len(retrieved.Payments))
+	}
+}
+
+func TestBillingRepository_CreatePaymentConcurrently(t *testing.T) {
+	vet, client, consultation := createBillingFixtures(t)
+
+	testDB.BillingRepo.CreateBillableItem(context.Background(), domain.NewBillableItem(consultation.ID, domain.ItemService, "Examination", 1, 5000))
+	invoice, _ := testDB.BillingRepo.CreateInvoice(context.Background(), client.ID, vet.ID)
+
+	// Hold the invoice so both payments start before either is recorded.
+	tx, err := testDB.BillingRepo.DB.BeginTxx(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("Failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(`SELECT id FROM invoices WHERE id = $1 FOR UPDATE`, invoice.ID); err != nil {
+		t.Fatalf("Failed to lock invoice: %v", err)
+	}
+
+	errs := make([]error, 2)
+	var wg sync.WaitGroup
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = testDB.BillingRepo.CreatePayment(context.Background(), domain.NewPayment(invoice.ID, 3000, domain.PaymentCard, "", vet.ID, time.Now()))
+		}(i)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		var waiting int
+		err := testDB.BillingRepo.DB.Get(&waiting, `SELECT COUNT(*) FROM pg_stat_activity WHERE datname = current_database() AND wait_event_type = 'Lock'`)
+		if err != nil {
+			t.Fatalf("Failed to count waiting payments: %v", err)
+		}
+		if waiting == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected both payments to wait for the invoice, %d did", waiting)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Failed to release invoice: %v", err)
+	}
+	wg.Wait()
+
+	accepted, refused := 0, 0
+	for _, err := range errs {
+		switch err {
+		case nil:
+			accepted++
+		case ErrPaymentExceedsBalance:
+			refused++
+		default:
+			t.Fatalf("Failed to create payment: %v", err)
+		}
+	}
+	if accepted != 1 || refused != 1 {
+		t.Errorf("Expected one payment accepted and one refused with ErrPaymentExceedsBalance, got %v", errs)
+	}
+	retrieved, _ := testDB.BillingRepo.GetInvoiceByID(context.Background(), invoice.ID)
+	if retrieved.PaidCents != 3000 {
+		t.Errorf("Expected 3000 paid, got %d", retrieved.PaidCents)
+	}
+}
+
+func TestBillingRepository_VoidInvoice(t *testing.T) {
+	vet, client, consultation := createBillingFixtures(t)
+
+	testDB.BillingRepo.CreateBillableItem(context.Background(), domain.NewBillableItem(consultation.ID, domain.ItemService, "Examination", 1, 5000))
+	invoice, _ := testDB.BillingRepo.CreateInvoice(context.Background(), client.ID, vet.ID)
+
+	if err := testDB.BillingRepo.VoidInvoice(context.Background(), invoice.ID); err != nil {
+		t.Fatalf("Failed to void invoice: %v", err)
+	}
+	err := testDB.BillingRepo.CreatePayment(context.Background(), domain.NewPayment(invoice.ID, 100, domain.PaymentCash, "", vet.ID, time.Now()))
+	if err != ErrInvoiceVoid {
+		t.Errorf("Expected ErrInvoiceVoid, got %v", err)
+	}
+
+	reissued, err := testDB.BillingRepo.CreateInvoice(context.Background(), client.ID, vet.ID)
+	if err != nil {
+		t.Fatalf("Expected voided items to be invoiceable again, got %v", err)
+	}
+	testDB.BillingRepo.CreatePayment(context.Background(), domain.NewPayment(reissued.ID, 100, domain.PaymentCash, "", vet.ID, time.Now()))
+	err = testDB.BillingRepo.VoidInvoice(context.Background(), reissued.ID)
+	if err != ErrInvoiceHasPayments {
+		t.Errorf("Expected ErrInvoiceHasPayments, got %v", err)
+	}
+}
+
+func TestBillingRepository_GetClientBalance(t *testing.T) {
+	vet, client, consultation := createBillingFixtures(t)
+
+	testDB.BillingRepo.CreateBillableItem(context.Background(), domain.NewBillableItem(consultation.ID, domain.ItemService, "Examination", 1, 5000))
+	invoice, _ := testDB.BillingRepo.CreateInvoice(context.Background(), client.ID, vet.ID)
+	testDB.BillingRepo.CreatePayment(context.Background(), domain.NewPayment(invoice.ID, 1500, domain.PaymentTransfer, "", vet.ID, time.Now()))
+	testDB.BillingRepo.CreateBillableItem(context.Background(), domain.NewBillableItem(consultation.ID, domain.ItemProduct, "Shampoo", 1, 1200))
+
+	balance, err := testDB.BillingRepo.GetClientBalance(context.Background(), client.ID)
+	if err != nil {
+		t.Fatalf("Failed to get balance: %v", err)
+	}
+	if balance.InvoicedCents != 5000 || balance.PaidCents != 1500 || balance.OutstandingCents != 3500 {
+		t.Errorf("Expected 5000 invoiced, 1500 paid, 3500 outstanding, got %+v", balance)
+	}
+	if balance.UninvoicedCents != 1200 {
+		t.Errorf("Expected 1200 uninvoiced, got %d", balance.UninvoicedCents)
+	}
+
+	err = testDB.ClientRepo.DeleteClientByID(context.Background(), client.ID)
+	if err != ErrRecordInUse {
+		t.Errorf("Expected ErrRecordInUse, got %v", err)
+	}
+}
@@ -1,33 +1,40 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"errors"
+	"time"
 	"vetsys/internal/domain"
-
-	"github.com/jmoiron/sqlx"
 )
 
 type ClientRepository struct {
-	DB *sqlx.DB
+	DB           DBTX
+	QueryTimeout time.Duration
 }
 
 var ErrClientNotFound = errors.New("Client not found")
 
-func (clientRepository *ClientRepository) CreateClient(client *domain.Client) error {
+func (clientRepository *ClientRepository) CreateClient(ctx context.Context, client *domain.Client) error {
+	ctx, cancel := withQueryTimeout(ctx, clientRepository.QueryTimeout)
+	defer cancel()
+
 	query := `INSERT INTO clients (dni, name, phone_number) VALUES (:dni, :name, :phone_number) RETURNING id`
-	stmt, err := clientRepository.DB.PrepareNamed(query)
+	stmt, err := clientRepository.DB.PrepareNamedContext(ctx, query)
 	if err != nil {
 		return err
 	}
 	defer stmt.Close()
 
-	return stmt.Get(&client.ID, client)
+	return stmt.GetContext(ctx, &client.ID, client)
 }
 
-func (clientRepository *ClientRepository) GetClientByID(id int64) (*domain.Client, error) {
+func (clientRepository *ClientRepository) GetClientByID(ctx context.Context, id int64) (*domain.Client, error) {
+	ctx, cancel := withQueryTimeout(ctx, clientRepository.QueryTimeout)
+	defer cancel()
+
 	var client domain.Client
-	err := clientRepository.DB.Get(&client, "SELECT id, dni, name, phone_number FROM clients WHERE id = $1", id)
+	err := clientRepository.DB.GetContext(ctx, &client, "SELECT id, dni, name, phone_number FROM clients WHERE id = $1", id)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, ErrClientNotFound
@@ -37,9 +44,12 @@ func (clientRepository *ClientRepository) GetClientByID(id int64) (*domain.Clien
 	return &client, nil
 }
 
-func (clientRepository *ClientRepository) GetClientByDNI(dni string) (*domain.Client, error) {
+func (clientRepository *ClientRepository) GetClientByDNI(ctx context.Context, dni string) (*domain.Client, error) {
+	ctx, cancel := withQueryTimeout(ctx, clientRepository.QueryTimeout)
+	defer cancel()
+
 	var client domain.Client
-	err := clientRepository.DB.Get(&client, "SELECT id, dni, name, phone_number FROM clients WHERE dni = $1", dni)
+	err := clientRepository.DB.GetContext(ctx, &client, "SELECT id, dni, name, phone_number FROM clients WHERE dni = $1", dni)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, ErrClientNotFound
@@ -49,9 +59,12 @@ func (clientRepository *ClientRepository) GetClientByDNI(dni string) (*domain.Cl
 	return &client, nil
 }
 
-func (clientRepository *ClientRepository) UpdateClient(client *domain.Client) error {
+func (clientRepository *ClientRepository) UpdateClient(ctx context.Context, client *domain.Client) error {
+	ctx, cancel := withQueryTimeout(ctx, clientRepository.QueryTimeout)
+	defer cancel()
+
 	query := `UPDATE clients SET dni = $1, name = $2, phone_number = $3 WHERE id = $4`
-	result, err := clientRepository.DB.Exec(query, client.DNI, client.Name, client.PhoneNumber, client.ID)
+	result, err := clientRepository.DB.ExecContext(ctx, query, client.DNI, client.Name, client.PhoneNumber, client.ID)
 	if err != nil {
 		return err
 	}
@@ -65,11 +78,14 @@ func (clientRepository *ClientRepository) UpdateClient(client *domain.Client) er
 	return nil
 }
 
-func (clientRepository *ClientRepository) DeleteClientByID(id int64) error {
-	result, err := clientRepository.DB.Exec("DELETE FROM clients WHERE id = $1", id)
+func (clientRepository *ClientRepository) DeleteClientByID(ctx context.Context, id int64) error {
+	ctx, cancel := withQueryTimeout(ctx, clientRepository.QueryTimeout)
+	defer cancel()
+
+	result, err := clientRepository.DB.ExecContext(ctx, "DELETE FROM clients WHERE id = $1", id)
 
 	if err != nil {
-		return err
+		return mapForeignKeyViolation(err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
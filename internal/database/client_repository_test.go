@@ -1,6 +1,7 @@
 package database
 
 import (
+	"context"
 	"testing"
 	"vetsys/internal/domain"
 )
@@ -10,7 +11,7 @@ func TestClientRepository_CreateClient(t *testing.T) {
 
 	client := domain.NewClient("12345678A", "John Doe", "+34600111222")
 
-	err := testDB.ClientRepo.CreateClient(client)
+	err := testDB.ClientRepo.CreateClient(context.Background(), client)
 	if err != nil {
 		t.Fatalf("Failed to create client: %v", err)
 	}
@@ -24,12 +25,12 @@ func TestClientRepository_GetClientByID(t *testing.T) {
 	cleanupTables(testDB)
 
 	client := domain.NewClient("23456789B", "Jane Smith", "+34600222333")
-	err := testDB.ClientRepo.CreateClient(client)
+	err := testDB.ClientRepo.CreateClient(context.Background(), client)
 	if err != nil {
 		t.Fatalf("Failed to create client: %v", err)
 	}
 
-	retrieved, err := testDB.ClientRepo.GetClientByID(client.ID)
+	retrieved, err := testDB.ClientRepo.GetClientByID(context.Background(), client.ID)
 	if err != nil {
 		t.Fatalf("Failed to get client by ID: %v", err)
 	}
@@ -45,7 +46,7 @@ func TestClientRepository_GetClientByID(t *testing.T) {
 func TestClientRepository_GetClientByID_NotFound(t *testing.T) {
 	cleanupTables(testDB)
 
-	_, err := testDB.ClientRepo.GetClientByID(99999)
+	_, err := testDB.ClientRepo.GetClientByID(context.Background(), 99999)
 	if err != ErrClientNotFound {
 		t.Errorf("Expected ErrClientNotFound, got %v", err)
 	}
@@ -55,12 +56,12 @@ func TestClientRepository_GetClientByDNI(t *testing.T) {
 	cleanupTables(testDB)
 
 	client := domain.NewClient("34567890C", "Bob Johnson", "+34600333444")
-	err := testDB.ClientRepo.CreateClient(client)
+	err := testDB.ClientRepo.CreateClient(context.Background(), client)
 	if err != nil {
 		t.Fatalf("Failed to create client: %v", err)
 	}
 
-	retrieved, err := testDB.ClientRepo.GetClientByDNI(client.DNI)
+	retrieved, err := testDB.ClientRepo.GetClientByDNI(context.Background(), client.DNI)
 	if err != nil {
 		t.Fatalf("Failed to get client by DNI: %v", err)
 	}
@@ -74,19 +75,19 @@ func TestClientRepository_UpdateClient(t *testing.T) {
 	cleanupTables(testDB)
 
 	client := domain.NewClient("45678901D", "Alice Brown", "+34600444555")
-	err := testDB.ClientRepo.CreateClient(client)
+	err := testDB.ClientRepo.CreateClient(context.Background(), client)
 	if err != nil {
 		t.Fatalf("Failed to create client: %v", err)
 	}
 
 	client.Name = "Alice Brown Updated"
 	client.PhoneNumber = "+34600555666"
-	err = testDB.ClientRepo.UpdateClient(client)
+	err = testDB.ClientRepo.UpdateClient(context.Background(), client)
 	if err != nil {
 		t.Fatalf("Failed to update client: %v", err)
 	}
 
-	retrieved, err := testDB.ClientRepo.GetClientByID(client.ID)
+	retrieved, err := testDB.ClientRepo.GetClientByID(context.Background(), client.ID)
 	if err != nil {
 		t.Fatalf("Failed to retrieve updated client: %v", err)
 	}
@@ -100,17 +101,17 @@ func TestClientRepository_DeleteClientByID(t *testing.T) {
 	cleanupTables(testDB)
 
 	client := domain.NewClient("56789012E", "Charlie Davis", "+34600666777")
-	err := testDB.ClientRepo.CreateClient(client)
+	err := testDB.ClientRepo.CreateClient(context.Background(), client)
 	if err != nil {
 		t.Fatalf("Failed to create client: %v", err)
 	}
 
-	err = testDB.ClientRepo.DeleteClientByID(client.ID)
+	err = testDB.ClientRepo.DeleteClientByID(context.Background(), client.ID)
 	if err != nil {
 		t.Fatalf("Failed to delete client: %v", err)
 	}
 
-	_, err = testDB.ClientRepo.GetClientByID(client.ID)
+	_, err = testDB.ClientRepo.GetClientByID(context.Background(), client.ID)
 	if err != ErrClientNotFound {
 		t.Errorf("Expected ErrClientNotFound after deletion, got %v", err)
 	}
@@ -120,13 +121,13 @@ func TestClientRepository_UniqueConstraints(t *testing.T) {
 	cleanupTables(testDB)
 
 	client1 := domain.NewClient("67890123F", "David Wilson", "+34600777888")
-	err := testDB.ClientRepo.CreateClient(client1)
+	err := testDB.ClientRepo.CreateClient(context.Background(), client1)
 	if err != nil {
 		t.Fatalf("Failed to create first client: %v", err)
 	}
 
 	client2 := domain.NewClient("67890123F", "Different Name", "+34600888999")
-	err = testDB.ClientRepo.CreateClient(client2)
+	err = testDB.ClientRepo.CreateClient(context.Background(), client2)
 	if err == nil {
 		t.Error("Expected error when creating client with duplicate DNI")
 	}
@@ -1,34 +1,41 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"errors"
+	"time"
 	"vetsys/internal/domain"
-
-	"github.com/jmoiron/sqlx"
 )
 
 type ConsultationRepository struct {
-	DB *sqlx.DB
+	DB           DBTX
+	QueryTimeout time.Duration
 }
 
 var ErrConsultationNotFound = errors.New("Consultation not found")
 
-func (consultationRepository *ConsultationRepository) CreateConsultation(consultation *domain.Consultation) error {
+func (consultationRepository *ConsultationRepository) CreateConsultation(ctx context.Context, consultation *domain.Consultation) error {
+	ctx, cancel := withQueryTimeout(ctx, consultationRepository.QueryTimeout)
+	defer cancel()
+
 	query := `INSERT INTO consultations (patient_id, reason, diagnosis, treatment, severity, is_completed, created_at, updated_at) 
 	VALUES (:patient_id, :reason, :diagnosis, :treatment, :severity, :is_completed, :created_at, :updated_at) 
 	RETURNING id`
-	stmt, err := consultationRepository.DB.PrepareNamed(query)
+	stmt, err := consultationRepository.DB.PrepareNamedContext(ctx, query)
 	if err != nil {
 		return err
 	}
 	defer stmt.Close()
-	return stmt.Get(&consultation.ID, consultation)
+	return stmt.GetContext(ctx, &consultation.ID, consultation)
 }
-func (consultationRepository *ConsultationRepository) GetConsultationByID(id int64) (*domain.Consultation, error) {
+func (consultationRepository *ConsultationRepository) GetConsultationByID(ctx context.Context, id int64) (*domain.Consultation, error) {
+	ctx, cancel := withQueryTimeout(ctx, consultationRepository.QueryTimeout)
+	defer cancel()
+
 	consultation := domain.Consultation{}
 	query := `SELECT id, patient_id, reason, diagnosis, treatment, severity, is_completed, created_at, updated_at FROM consultations WHERE id = $1`
-	err := consultationRepository.DB.Get(&consultation, query, id)
+	err := consultationRepository.DB.GetContext(ctx, &consultation, query, id)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, ErrConsultationNotFound
@@ -37,7 +44,10 @@ func (consultationRepository *ConsultationRepository) GetConsultationByID(id int
 	}
 	return &consultation, nil
 }
-func (consultationRepository *ConsultationRepository) GetConsultationsByClientID(clientID int64, limit int, offset int) ([]domain.Consultation, error) {
+func (consultationRepository *ConsultationRepository) GetConsultationsByClientID(ctx context.Context, clientID int64, limit int, offset int) ([]domain.Consultation, error) {
+	ctx, cancel := withQueryTimeout(ctx, consultationRepository.QueryTimeout)
+	defer cancel()
+
 	query := `
 	SELECT c.id, c.patient_id, c.reason, c.diagnosis, 
 	       c.treatment, c.severity, c.is_completed, 
@@ -48,42 +58,54 @@ func (consultationRepository *ConsultationRepository) GetConsultationsByClientID
 	LIMIT $2 OFFSET $3
 	`
 	var consultations []domain.Consultation
-	err := consultationRepository.DB.Select(&consultations, query, clientID, limit, offset)
+	err := consultationRepository.DB.SelectContext(ctx, &consultations, query, clientID, limit, offset)
 	if err != nil {
 		return nil, err
 	}
 	return consultations, nil
 }
-func (consultationRepository *ConsultationRepository) GetConsultationsByPatientID(patientID int64, limit int, offset int) ([]domain.Consultation, error) {
+func (consultationRepository *ConsultationRepository) GetConsultationsByPatientID(ctx context.Context, patientID int64, limit int, offset int) ([]domain.Consultation, error) {
+	ctx, cancel := withQueryTimeout(ctx, consultationRepository.QueryTimeout)
+	defer cancel()
+
 	query := `SELECT id, patient_id, reason, diagnosis, treatment, severity, is_completed, created_at, updated_at FROM consultations WHERE patient_id = $1 LIMIT $2 OFFSET $3`
 	var consultations []domain.Consultation
-	err := consultationRepository.DB.Select(&consultations, query, patientID, limit, offset)
+	err := consultationRepository.DB.SelectContext(ctx, &consultations, query, patientID, limit, offset)
 	if err != nil {
 		return nil, err
 	}
 	return consultations, nil
 }
-func (consultationRepository *ConsultationRepository) GetAllConsultations(limit int, offset int) ([]domain.Consultation, error) {
+func (consultationRepository *ConsultationRepository) GetAllConsultations(ctx context.Context, limit int, offset int) ([]domain.Consultation, error) {
+	ctx, cancel := withQueryTimeout(ctx, consultationRepository.QueryTimeout)
+	defer cancel()
+
 	query := `SELECT id, patient_id, reason, diagnosis, treatment, severity, is_completed, created_at, updated_at FROM consultations LIMIT $1 OFFSET $2`
 	var consultations []domain.Consultation
-	err := consultationRepository.DB.Select(&consultations, query, limit, offset)
+	err := consultationRepository.DB.SelectContext(ctx, &consultations, query, limit, offset)
 	if err != nil {
 		return nil, err
 	}
 	return consultations, nil
 }
-func (consultationRepository *ConsultationRepository) GetConsultationsByIsCompleted(isCompleted bool, limit int, offset int) ([]domain.Consultation, error) {
+func (consultationRepository *ConsultationRepository) GetConsultationsByIsCompleted(ctx context.Context, isCompleted bool, limit int, offset int) ([]domain.Consultation, error) {
+	ctx, cancel := withQueryTimeout(ctx, consultationRepository.QueryTimeout)
+	defer cancel()
+
 	query := `SELECT id, patient_id, reason, diagnosis, treatment, severity, is_completed, created_at, updated_at FROM consultations WHERE is_completed = $1 LIMIT $2 OFFSET $3`
 	var consultations []domain.Consultation
-	err := consultationRepository.DB.Select(&consultations, query, isCompleted, limit, offset)
+	err := consultationRepository.DB.SelectContext(ctx, &consultations, query, isCompleted, limit, offset)
 	if err != nil {
 		return nil, err
 	}
 	return consultations, nil
 }
-func (consultationRepository *ConsultationRepository) UpdateConsultation(consultation *domain.Consultation) error {
+func (consultationRepository *ConsultationRepository) UpdateConsultation(ctx context.Context, consultation *domain.Consultation) error {
+	ctx, cancel := withQueryTimeout(ctx, consultationRepository.QueryTimeout)
+	defer cancel()
+
 	query := "UPDATE consultations SET patient_id = $1, reason = $2, diagnosis = $3, treatment = $4, severity = $5, is_completed = $6, updated_at = $7 WHERE id = $8"
-	result, err := consultationRepository.DB.Exec(query, consultation.PatientID, consultation.Reason, consultation.Diagnosis, consultation.Treatment, consultation.Severity, consultation.IsCompleted, consultation.UpdatedAt, consultation.ID)
+	result, err := consultationRepository.DB.ExecContext(ctx, query, consultation.PatientID, consultation.Reason, consultation.Diagnosis, consultation.Treatment, consultation.Severity, consultation.IsCompleted, consultation.UpdatedAt, consultation.ID)
 	if err != nil {
 		return err
 	}
@@ -97,11 +119,14 @@ func (consultationRepository *ConsultationRepository) UpdateConsultation(consult
 	}
 	return nil
 }
-func (consultationRepository *ConsultationRepository) DeleteConsultation(id int64) error {
+func (consultationRepository *ConsultationRepository) DeleteConsultation(ctx context.Context, id int64) error {
+	ctx, cancel := withQueryTimeout(ctx, consultationRepository.QueryTimeout)
+	defer cancel()
+
 	query := `DELETE FROM consultations WHERE id = $1`
-	result, err := consultationRepository.DB.Exec(query, id)
+	result, err := consultationRepository.DB.ExecContext(ctx, query, id)
 	if err != nil {
-		return err
+		return mapForeignKeyViolation(err)
 	}
 	rows, err := result.RowsAffected()
 	if err != nil {
@@ -113,32 +138,44 @@ func (consultationRepository *ConsultationRepository) DeleteConsultation(id int6
 	return nil
 }
 
-func (r *ConsultationRepository) GetAllConsultationsCount() (int64, error) {
+func (r *ConsultationRepository) GetAllConsultationsCount(ctx context.Context) (int64, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
 	var count int64
 	query := `SELECT COUNT(*) FROM consultations`
-	err := r.DB.Get(&count, query)
+	err := r.DB.GetContext(ctx, &count, query)
 	return count, err
 }
 
-func (r *ConsultationRepository) GetConsultationsByPatientIDCount(patientID int64) (int64, error) {
+func (r *ConsultationRepository) GetConsultationsByPatientIDCount(ctx context.Context, patientID int64) (int64, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
 	var count int64
 	query := `SELECT COUNT(*) FROM consultations WHERE patient_id = $1`
-	err := r.DB.Get(&count, query, patientID)
+	err := r.DB.GetContext(ctx, &count, query, patientID)
 	return count, err
 }
 
-func (r *ConsultationRepository) GetConsultationsByClientIDCount(clientID int64) (int64, error) {
+func (r *ConsultationRepository) GetConsultationsByClientIDCount(ctx context.Context, clientID int64) (int64, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
 	var count int64
 	query := `SELECT COUNT(*) FROM consultations c
               JOIN patients p ON c.patient_id = p.id
               WHERE p.owner_id = $1`
-	err := r.DB.Get(&count, query, clientID)
+	err := r.DB.GetContext(ctx, &count, query, clientID)
 	return count, err
 }
 
-func (r *ConsultationRepository) GetConsultationsByIsCompletedCount(isCompleted bool) (int64, error) {
+func (r *ConsultationRepository) GetConsultationsByIsCompletedCount(ctx context.Context, isCompleted bool) (int64, error) {
+	ctx, cancel := withQueryTimeout(ctx, r.QueryTimeout)
+	defer cancel()
+
 	var count int64
 	query := `SELECT COUNT(*) FROM consultations WHERE is_completed = $1`
-	err := r.DB.Get(&count, query, isCompleted)
+	err := r.DB.GetContext(ctx, &count, query, isCompleted)
 	return count, err
 }
@@ -1,6 +1,7 @@
 package database
 
 import (
+	"context"
 	"testing"
 	"time"
 	"vetsys/internal/domain"
@@ -10,15 +11,15 @@ func TestConsultationRepository_CreateConsultation(t *testing.T) {
 	cleanupTables(testDB)
 
 	client := domain.NewClient("12345678A", "John Doe", "+34600111222")
-	testDB.ClientRepo.CreateClient(client)
+	testDB.ClientRepo.CreateClient(context.Background(), client)
 
 	dob := time.Date(2020, 5, 15, 0, 0, 0, 0, time.UTC)
 	patient := domain.NewPatient("Max", "Dog", "Golden Retriever", dob, client.ID)
-	testDB.PatientRepo.CreatePatient(patient)
+	testDB.PatientRepo.CreatePatient(context.Background(), patient)
 
 	consultation := domain.NewConsultation(patient.ID, "Coughing", "Kennel cough", "Antibiotics", domain.SeverityMedium)
 
-	err := testDB.ConsultationRepo.CreateConsultation(consultation)
+	err := testDB.ConsultationRepo.CreateConsultation(context.Background(), consultation)
 	if err != nil {
 		t.Fatalf("Failed to create consultation: %v", err)
 	}
@@ -32,16 +33,16 @@ func TestConsultationRepository_GetConsultationByID(t *testing.T) {
 	cleanupTables(testDB)
 
 	client := domain.NewClient("23456789B", "Jane Smith", "+34600222333")
-	testDB.ClientRepo.CreateClient(client)
+	testDB.ClientRepo.CreateClient(context.Background(), client)
 
 	dob := time.Date(2019, 3, 10, 0, 0, 0, 0, time.UTC)
 	patient := domain.NewPatient("Luna", "Cat", "Persian", dob, client.ID)
-	testDB.PatientRepo.CreatePatient(patient)
+	testDB.PatientRepo.CreatePatient(context.Background(), patient)
 
 	consultation := domain.NewConsultation(patient.ID, "Limping", "Sprained paw", "Rest", domain.SeverityLow)
-	testDB.ConsultationRepo.CreateConsultation(consultation)
+	testDB.ConsultationRepo.CreateConsultation(context.Background(), consultation)
 
-	retrieved, err := testDB.ConsultationRepo.GetConsultationByID(consultation.ID)
+	retrieved, err := testDB.ConsultationRepo.GetConsultationByID(context.Background(), consultation.ID)
 	if err != nil {
 		t.Fatalf("Failed to get consultation by ID: %v", err)
 	}
@@ -55,18 +56,18 @@ func TestConsultationRepository_GetConsultationsByClientID(t *testing.T) {
 	cleanupTables(testDB)
 
 	client := domain.NewClient("34567890C", "Bob Johnson", "+34600333444")
-	testDB.ClientRepo.CreateClient(client)
+	testDB.ClientRepo.CreateClient(context.Background(), client)
 
 	dob := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
 	patient := domain.NewPatient("Buddy", "Dog", "Labrador", dob, client.ID)
-	testDB.PatientRepo.CreatePatient(patient)
+	testDB.PatientRepo.CreatePatient(context.Background(), patient)
 
 	cons1 := domain.NewConsultation(patient.ID, "Checkup", "Healthy", "None", domain.SeverityLow)
-	testDB.ConsultationRepo.CreateConsultation(cons1)
+	testDB.ConsultationRepo.CreateConsultation(context.Background(), cons1)
 
 	cons2 := domain.NewConsultation(patient.ID, "Vaccination", "Up to date", "Rabies vaccine", domain.SeverityLow)
-	testDB.ConsultationRepo.CreateConsultation(cons2)
-	consultations, err := testDB.ConsultationRepo.GetConsultationsByClientID(client.ID, 20, 0)
+	testDB.ConsultationRepo.CreateConsultation(context.Background(), cons2)
+	consultations, err := testDB.ConsultationRepo.GetConsultationsByClientID(context.Background(), client.ID, 20, 0)
 	if err != nil {
 		t.Fatalf("Failed to get consultations by client ID: %v", err)
 	}
@@ -80,16 +81,16 @@ func TestConsultationRepository_GetConsultationsByPatientID(t *testing.T) {
 	cleanupTables(testDB)
 
 	client := domain.NewClient("45678901D", "Alice Brown", "+34600444555")
-	testDB.ClientRepo.CreateClient(client)
+	testDB.ClientRepo.CreateClient(context.Background(), client)
 
 	dob := time.Date(2021, 6, 15, 0, 0, 0, 0, time.UTC)
 	patient := domain.NewPatient("Charlie", "Dog", "Beagle", dob, client.ID)
-	testDB.PatientRepo.CreatePatient(patient)
+	testDB.PatientRepo.CreatePatient(context.Background(), patient)
 
 	cons := domain.NewConsultation(patient.ID, "Emergency", "Toxic ingestion", "Activated charcoal", domain.SeverityCritical)
-	testDB.ConsultationRepo.CreateConsultation(cons)
+	testDB.ConsultationRepo.CreateConsultation(context.Background(), cons)
 
-	consultations, err := testDB.ConsultationRepo.GetConsultationsByPatientID(patient.ID, 20, 0)
+	consultations, err := testDB.ConsultationRepo.GetConsultationsByPatientID(context.Background(), patient.ID, 20, 0)
 	if err != nil {
 		t.Fatalf("Failed to get consultations by patient ID: %v", err)
 	}
@@ -103,26 +104,26 @@ func TestConsultationRepository_UpdateConsultation(t *testing.T) {
 	cleanupTables(testDB)
 
 	client := domain.NewClient("56789012E", "Charlie Davis", "+34600666777")
-	testDB.ClientRepo.CreateClient(client)
+	testDB.ClientRepo.CreateClient(context.Background(), client)
 
 	dob := time.Date(2020, 4, 10, 0, 0, 0, 0, time.UTC)
 	patient := domain.NewPatient("Milo", "Dog", "Poodle", dob, client.ID)
-	testDB.PatientRepo.CreatePatient(patient)
+	testDB.PatientRepo.CreatePatient(context.Background(), patient)
 
 	consultation := domain.NewConsultation(patient.ID, "Weight loss", "Under investigation", "Pending tests", domain.SeverityMedium)
-	testDB.ConsultationRepo.CreateConsultation(consultation)
+	testDB.ConsultationRepo.CreateConsultation(context.Background(), consultation)
 
 	consultation.Diagnosis = "Thyroid issue"
 	consultation.Severity = domain.SeverityHigh
 	consultation.IsCompleted = true
 	consultation.UpdatedAt = time.Now()
 
-	err := testDB.ConsultationRepo.UpdateConsultation(consultation)
+	err := testDB.ConsultationRepo.UpdateConsultation(context.Background(), consultation)
 	if err != nil {
 		t.Fatalf("Failed to update consultation: %v", err)
 	}
 
-	retrieved, err := testDB.ConsultationRepo.GetConsultationByID(consultation.ID)
+	retrieved, err := testDB.ConsultationRepo.GetConsultationByID(context.Background(), consultation.ID)
 	if err != nil {
 		t.Fatalf("Failed to retrieve updated consultation: %v", err)
 	}
@@ -136,21 +137,21 @@ func TestConsultationRepository_DeleteConsultation(t *testing.T) {
 	cleanupTables(testDB)
 
 	client := domain.NewClient("67890123F", "David Wilson", "+34600777888")
-	testDB.ClientRepo.CreateClient(client)
+	testDB.ClientRepo.CreateClient(context.Background(), client)
 
 	dob := time.Date(2021, 2, 28, 0, 0, 0, 0, time.UTC)
 	patient := domain.NewPatient("Daisy", "Cat", "Maine Coon", dob, client.ID)
-	testDB.PatientRepo.CreatePatient(patient)
+	testDB.PatientRepo.CreatePatient(context.Background(), patient)
 
 	consultation := domain.NewConsultation(patient.ID, "Annual checkup", "Healthy", "None", domain.SeverityLow)
-	testDB.ConsultationRepo.CreateConsultation(consultation)
+	testDB.ConsultationRepo.CreateConsultation(context.Background(), consultation)
 
-	err := testDB.ConsultationRepo.DeleteConsultation(consultation.ID)
+	err := testDB.ConsultationRepo.DeleteConsultation(context.Background(), consultation.ID)
 	if err != nil {
 		t.Fatalf("Failed to delete consultation: %v", err)
 	}
 
-	_, err = testDB.ConsultationRepo.GetConsultationByID(consultation.ID)
+	_, err = testDB.ConsultationRepo.GetConsultationByID(context.Background(), consultation.ID)
 	if err != ErrConsultationNotFound {
 		t.Errorf("Expected ErrConsultationNotFound after deletion, got %v", err)
 	}
@@ -1,6 +1,9 @@
 package database
 
 import (
+	"context"
+	"time"
+
 	"github.com/jmoiron/sqlx"
 )
 
@@ -12,119 +15,59 @@ type DataBase struct {
 	ConsultationRepo         *ConsultationRepository
 	SessionRepo              *SessionRepository
 	AllowedRegistrationsRepo *AllowedRegistrationRepository
+	AppointmentRepo          *AppointmentRepository
+	AuditRepo                *AuditRepository
+	SearchRepo               *SearchRepository
+	VaccinationRepo          *VaccinationRepository
+	PrescriptionRepo         *PrescriptionRepository
+	VitalsRepo               *VitalsRepository
+	BillingRepo              *BillingRepository
+	InventoryRepo            *InventoryRepository
+	UnitOfWork               *Transactor
+	JobRunRepo               *JobRunRepository
+	LoginLockoutRepo         *LoginLockoutRepository
+	PasswordResetRepo        *PasswordResetRepository
+	TwoFactorRepo            *TwoFactorRepository
+	LoginChallengeRepo       *LoginChallengeRepository
 }
 
-var createUserTable string = `
-CREATE TABLE IF NOT EXISTS users (
-    id BIGSERIAL PRIMARY KEY,
-    dni TEXT NOT NULL UNIQUE,
-    email TEXT NOT NULL UNIQUE,
-    password TEXT NOT NULL,
-    name TEXT NOT NULL,
-    profile_picture TEXT,
-    created_at TIMESTAMP DEFAULT NOW()
-);
-CREATE INDEX IF NOT EXISTS idx_users_dni ON users(dni);
-`
-
-var createClientsTable string = `
-CREATE TABLE IF NOT EXISTS clients (
-    id BIGSERIAL PRIMARY KEY,
-    dni TEXT NOT NULL UNIQUE,
-    name TEXT NOT NULL,
-    phone_number TEXT NOT NULL
-);
-CREATE INDEX IF NOT EXISTS idx_clients_dni ON clients(dni);
-`
-
-var createPatientsTable string = `
-CREATE TABLE IF NOT EXISTS patients (
-    id BIGSERIAL PRIMARY KEY,
-    name TEXT NOT NULL,
-    species TEXT NOT NULL,
-    breed TEXT NOT NULL,
-    aprox_date_of_birth TIMESTAMP NOT NULL,
-    owner_id BIGINT NOT NULL REFERENCES clients(id) ON DELETE CASCADE
-);
-CREATE INDEX IF NOT EXISTS idx_patients_owner_id ON patients(owner_id);
-`
-
-var createConsultationsTable string = `
-CREATE TABLE IF NOT EXISTS consultations (
-    id BIGSERIAL PRIMARY KEY,
-    patient_id BIGINT NOT NULL REFERENCES patients(id) ON DELETE CASCADE,
-    reason TEXT NOT NULL,
-    diagnosis TEXT NOT NULL,
-    treatment TEXT NOT NULL,
-    severity TEXT NOT NULL,
-    is_completed BOOLEAN NOT NULL DEFAULT false,
-    created_at TIMESTAMP DEFAULT NOW(),
-    updated_at TIMESTAMP DEFAULT NOW()
-);
-CREATE INDEX IF NOT EXISTS idx_consultations_patient_id ON consultations(patient_id);
-`
-
-var createSessionsTable string = `
-CREATE TABLE IF NOT EXISTS sessions (
-    id TEXT PRIMARY KEY,
-    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
-    expires_at TIMESTAMP NOT NULL,
-    created_at TIMESTAMP NOT NULL DEFAULT NOW()
-);
-CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions(user_id);
-CREATE INDEX IF NOT EXISTS idx_sessions_expires_at ON sessions(expires_at);
-`
-
-var createAllowedRegistrationsTable string = `
-CREATE TABLE IF NOT EXISTS allowed_registrations (
-    dni TEXT PRIMARY KEY,
-    used BOOLEAN NOT NULL DEFAULT FALSE
-);
-CREATE INDEX IF NOT EXISTS idx_allowed_registrations_used ON allowed_registrations(used);
-`
-
-func NewDataBase(db *sqlx.DB) *DataBase {
+// NewDataBase builds the repositories over db. Every repository call is
+// bounded by queryTimeout on top of the caller's context; zero disables it.
+func NewDataBase(db *sqlx.DB, queryTimeout time.Duration) *DataBase {
 	return &DataBase{
 		DB:                       db,
-		UserRepo:                 &UserRepository{DB: db},
-		PatientRepo:              &PatientRepository{DB: db},
-		ClientRepo:               &ClientRepository{DB: db},
-		ConsultationRepo:         &ConsultationRepository{DB: db},
-		SessionRepo:              &SessionRepository{DB: db},
-		AllowedRegistrationsRepo: &AllowedRegistrationRepository{DB: db},
+		UserRepo:                 &UserRepository{DB: db, QueryTimeout: queryTimeout},
+		PatientRepo:              &PatientRepository{DB: db, QueryTimeout: queryTimeout},
+		ClientRepo:               &ClientRepository{DB: db, QueryTimeout: queryTimeout},
+		ConsultationRepo:         &ConsultationRepository{DB: db, QueryTimeout: queryTimeout},
+		SessionRepo:              &SessionRepository{DB: db, QueryTimeout: queryTimeout},
+		AllowedRegistrationsRepo: &AllowedRegistrationRepository{DB: db, QueryTimeout: queryTimeout},
+		AppointmentRepo:          &AppointmentRepository{DB: db, QueryTimeout: queryTimeout},
+		AuditRepo:                &AuditRepository{DB: db, QueryTimeout: queryTimeout},
+		SearchRepo:               &SearchRepository{DB: db, QueryTimeout: queryTimeout},
+		VaccinationRepo:          &VaccinationRepository{DB: db, QueryTimeout: queryTimeout},
+		PrescriptionRepo:         &PrescriptionRepository{DB: db, QueryTimeout: queryTimeout},
+		VitalsRepo:               &VitalsRepository{DB: db, QueryTimeout: queryTimeout},
+		BillingRepo:              &BillingRepository{DB: db, QueryTimeout: queryTimeout},
+		InventoryRepo:            &InventoryRepository{DB: db, QueryTimeout: queryTimeout},
+		UnitOfWork:               &Transactor{DB: db, QueryTimeout: queryTimeout},
+		JobRunRepo:               &JobRunRepository{DB: db, QueryTimeout: queryTimeout},
+		LoginLockoutRepo:         &LoginLockoutRepository{DB: db, QueryTimeout: queryTimeout},
+		PasswordResetRepo:        &PasswordResetRepository{DB: db, QueryTimeout: queryTimeout},
+		TwoFactorRepo:            &TwoFactorRepository{DB: db, QueryTimeout: queryTimeout},
+		LoginChallengeRepo:       &LoginChallengeRepository{DB: db, QueryTimeout: queryTimeout},
 	}
 }
 
-func (d *DataBase) Init() error {
-	_, err := d.DB.Exec(createUserTable)
-	if err != nil {
-		return err
-	}
-
-	_, err = d.DB.Exec(createClientsTable)
-	if err != nil {
-		return err
+// withQueryTimeout derives the context a repository call runs under. The
+// timeout covers the whole call, including every statement of a transaction.
+// Calling the returned cancel also records the call's duration under the
+// name of the repository method, so it must be called from that method.
+func withQueryTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		ctx, cancel := context.WithCancel(ctx)
+		return ctx, observeQuery(cancel)
 	}
-
-	_, err = d.DB.Exec(createPatientsTable)
-	if err != nil {
-		return err
-	}
-
-	_, err = d.DB.Exec(createConsultationsTable)
-	if err != nil {
-		return err
-	}
-
-	_, err = d.DB.Exec(createSessionsTable)
-	if err != nil {
-		return err
-	}
-
-	_, err = d.DB.Exec(createAllowedRegistrationsTable)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	return ctx, observeQuery(cancel)
 }
@@ -1,9 +1,12 @@
 package database
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
@@ -42,10 +45,15 @@ func setupTestDB() (*DataBase, error) {
 	}
 
 	// Create database instance
-	database := NewDataBase(db)
+	database := NewDataBase(db, 5*time.Second)
 
-	// Initialize tables
-	if err := database.Init(); err != nil {
+	// Apply migrations
+	migrator, err := NewMigrator(db)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to load migrations: %w", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
 		db.Close()
 		return nil, fmt.Errorf("failed to initialize database: %w", err)
 	}
@@ -56,18 +64,63 @@ func setupTestDB() (*DataBase, error) {
 func cleanupTestDB(db *DataBase) {
 	if db != nil && db.DB != nil {
 		// Drop all tables in reverse order of dependencies
+		db.DB.Exec("DROP TABLE IF EXISTS login_challenges CASCADE")
+		db.DB.Exec("DROP TABLE IF EXISTS two_factor_roles CASCADE")
+		db.DB.Exec("DROP TABLE IF EXISTS user_recovery_codes CASCADE")
+		db.DB.Exec("DROP TABLE IF EXISTS user_two_factor CASCADE")
+		db.DB.Exec("DROP TABLE IF EXISTS password_reset_tokens CASCADE")
+		db.DB.Exec("DROP TABLE IF EXISTS login_lockouts CASCADE")
+		db.DB.Exec("DROP TABLE IF EXISTS job_runs CASCADE")
+		db.DB.Exec("DROP TABLE IF EXISTS stock_movements CASCADE")
+		db.DB.Exec("DROP TABLE IF EXISTS stock_batches CASCADE")
+		db.DB.Exec("DROP TABLE IF EXISTS payments CASCADE")
+		db.DB.Exec("DROP TABLE IF EXISTS billable_items CASCADE")
+		db.DB.Exec("DROP TABLE IF EXISTS invoices CASCADE")
+		db.DB.Exec("DROP TABLE IF EXISTS products CASCADE")
+		db.DB.Exec("DROP TABLE IF EXISTS vitals CASCADE")
+		db.DB.Exec("DROP TABLE IF EXISTS prescriptions CASCADE")
+		db.DB.Exec("DROP TABLE IF EXISTS medication_dose_ranges CASCADE")
+		db.DB.Exec("DROP TABLE IF EXISTS medications CASCADE")
+		db.DB.Exec("DROP TABLE IF EXISTS vaccinations CASCADE")
+		db.DB.Exec("DROP TABLE IF EXISTS vaccine_boosters CASCADE")
+		db.DB.Exec("DROP TABLE IF EXISTS vaccines CASCADE")
+		db.DB.Exec("DROP TABLE IF EXISTS audit_log CASCADE")
+		db.DB.Exec("DROP TABLE IF EXISTS appointments CASCADE")
 		db.DB.Exec("DROP TABLE IF EXISTS sessions CASCADE")
 		db.DB.Exec("DROP TABLE IF EXISTS consultations CASCADE")
 		db.DB.Exec("DROP TABLE IF EXISTS patients CASCADE")
 		db.DB.Exec("DROP TABLE IF EXISTS clients CASCADE")
 		db.DB.Exec("DROP TABLE IF EXISTS users CASCADE")
 		db.DB.Exec("DROP TABLE IF EXISTS allowed_registrations CASCADE")
+		db.DB.Exec("DROP TABLE IF EXISTS schema_migrations CASCADE")
 		db.DB.Close()
 	}
 }
 
 func cleanupTables(db *DataBase) {
 	// Clean tables but preserve schema
+	db.DB.Exec("TRUNCATE TABLE login_challenges")
+	db.DB.Exec("TRUNCATE TABLE two_factor_roles")
+	db.DB.Exec("TRUNCATE TABLE user_recovery_codes")
+	db.DB.Exec("TRUNCATE TABLE user_two_factor")
+	db.DB.Exec("TRUNCATE TABLE password_reset_tokens")
+	db.DB.Exec("TRUNCATE TABLE login_lockouts")
+	db.DB.Exec("TRUNCATE TABLE job_runs")
+	db.DB.Exec("TRUNCATE TABLE stock_movements CASCADE")
+	db.DB.Exec("TRUNCATE TABLE stock_batches CASCADE")
+	db.DB.Exec("TRUNCATE TABLE payments CASCADE")
+	db.DB.Exec("TRUNCATE TABLE billable_items CASCADE")
+	db.DB.Exec("TRUNCATE TABLE invoices CASCADE")
+	db.DB.Exec("TRUNCATE TABLE products CASCADE")
+	db.DB.Exec("TRUNCATE TABLE vitals CASCADE")
+	db.DB.Exec("TRUNCATE TABLE prescriptions CASCADE")
+	db.DB.Exec("TRUNCATE TABLE medication_dose_ranges CASCADE")
+	db.DB.Exec("TRUNCATE TABLE medications CASCADE")
+	db.DB.Exec("TRUNCATE TABLE vaccinations CASCADE")
+	db.DB.Exec("TRUNCATE TABLE vaccine_boosters CASCADE")
+	db.DB.Exec("TRUNCATE TABLE vaccines CASCADE")
+	db.DB.Exec("TRUNCATE TABLE audit_log")
+	db.DB.Exec("TRUNCATE TABLE appointments CASCADE")
 	db.DB.Exec("TRUNCATE TABLE sessions CASCADE")
 	db.DB.Exec("TRUNCATE TABLE consultations CASCADE")
 	db.DB.Exec("TRUNCATE TABLE patients CASCADE")
@@ -108,9 +161,39 @@ func TestNewDataBase(t *testing.T) {
 	if testDB.AllowedRegistrationsRepo == nil {
 		t.Error("AllowedRegistrationsRepo is nil")
 	}
+
+	if testDB.AppointmentRepo == nil {
+		t.Error("AppointmentRepo is nil")
+	}
+
+	if testDB.AuditRepo == nil {
+		t.Error("AuditRepo is nil")
+	}
+
+	if testDB.SearchRepo == nil {
+		t.Error("SearchRepo is nil")
+	}
+
+	if testDB.VaccinationRepo == nil {
+		t.Error("VaccinationRepo is nil")
+	}
+
+	if testDB.PrescriptionRepo == nil {
+		t.Error("PrescriptionRepo is nil")
+	}
+
+	if testDB.VitalsRepo == nil {
+		t.Error("VitalsRepo is nil")
+	}
+	if testDB.BillingRepo == nil {
+		t.Error("BillingRepo is nil")
+	}
+	if testDB.InventoryRepo == nil {
+		t.Error("InventoryRepo is nil")
+	}
 }
 
-func TestDataBaseInit(t *testing.T) {
+func TestDataBaseMigrate(t *testing.T) {
 	// Test that tables exist
 	var tableNames []string
 	expectedTables := []string{"users", "clients", "patients", "consultations", "sessions", "allowed_registrations"}
@@ -143,3 +226,30 @@ func TestDataBaseInit(t *testing.T) {
 		}
 	}
 }
+
+func TestRepositoryCallsHonourContext(t *testing.T) {
+	cleanupTables(testDB)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := testDB.ClientRepo.GetClientByID(ctx, 1)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+
+	repo := &ClientRepository{DB: testDB.DB, QueryTimeout: time.Nanosecond}
+	_, err = repo.GetClientByID(context.Background(), 1)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestRepositoryCallsRecordDuration(t *testing.T) {
+	cleanupTables(testDB)
+
+	before := queryDuration.Count("ClientRepository.GetClientByID")
+	testDB.ClientRepo.GetClientByID(context.Background(), 1)
+	if got := queryDuration.Count("ClientRepository.GetClientByID"); got != before+1 {
+		t.Errorf("Expected one more observation for ClientRepository.GetClientByID, got %d after %d", got, before)
+	}
+}
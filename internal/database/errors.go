@@ -0,0 +1,22 @@
+package database
+
+import (
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+// ErrRecordInUse is returned when a delete is blocked because other records,
+// such as invoices, still reference the row.
+var ErrRecordInUse = errors.New("record is referenced by other records and cannot be deleted")
+
+// foreignKeyViolation is the Postgres SQLSTATE for foreign_key_violation.
+const foreignKeyViolation = "23503"
+
+func mapForeignKeyViolation(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == foreignKeyViolation {
+		return ErrRecordInUse
+	}
+	return err
+}
@@ -0,0 +1,292 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+	"vetsys/internal/domain"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type InventoryRepository struct {
+	DB           *sqlx.DB
+	QueryTimeout time.Duration
+}
+
+var (
+	ErrProductNotFound   = errors.New("Product not found")
+	ErrProductExists     = errors.New("a product with this SKU already exists")
+	ErrBatchNotFound     = errors.New("Batch not found")
+	ErrInsufficientStock = errors.New("insufficient stock")
+)
+
+const productColumns = `p.id, p.sku, p.name, p.unit, p.medication_id, p.reorder_level, p.created_at,
+	COALESCE((SELECT SUM(quantity_on_hand) FROM stock_batches WHERE product_id = p.id), 0) AS quantity_on_hand`
+
+const batchColumns = `id, product_id, lot_number, expiry_date, quantity_on_hand`
+
+func (inventoryRepository *InventoryRepository) CreateProduct(ctx context.Context, product *domain.Product) error {
+	ctx, cancel := withQueryTimeout(ctx, inventoryRepository.QueryTimeout)
+	defer cancel()
+
+	query := `INSERT INTO products (sku, name, unit, medication_id, reorder_level, created_at)
+	VALUES (:sku, :name, :unit, :medication_id, :reorder_level, :created_at)
+	ON CONFLICT (sku) DO NOTHING
+	RETURNING id`
+	stmt, err := inventoryRepository.DB.PrepareNamedContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	err = stmt.GetContext(ctx, &product.ID, product)
+	if err == sql.ErrNoRows {
+		return ErrProductExists
+	}
+	return err
+}
+
+// GetProductByID returns the product with its batches, soonest expiry first.
+func (inventoryRepository *InventoryRepository) GetProductByID(ctx context.Context, id int64) (*domain.Product, error) {
+	ctx, cancel := withQueryTimeout(ctx, inventoryRepository.QueryTimeout)
+	defer cancel()
+
+	product := domain.Product{}
+	err := inventoryRepository.DB.GetContext(ctx, &product, `SELECT `+productColumns+` FROM products p WHERE p.id = $1`, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrProductNotFound
+		}
+		return nil, err
+	}
+	product.Batches = []domain.StockBatch{}
+	err = inventoryRepository.DB.SelectContext(ctx, &product.Batches, `SELECT `+batchColumns+` FROM stock_batches WHERE product_id = $1 ORDER BY expiry_date NULLS LAST, id`, id)
+	if err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+func (inventoryRepository *InventoryRepository) GetProducts(ctx context.Context, limit int, offset int) ([]domain.Product, error) {
+	ctx, cancel := withQueryTimeout(ctx, inventoryRepository.QueryTimeout)
+	defer cancel()
+
+	var products []domain.Product
+	err := inventoryRepository.DB.SelectContext(ctx, &products, `SELECT `+productColumns+` FROM products p ORDER BY p.name, p.id LIMIT $1 OFFSET $2`, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+func (inventoryRepository *InventoryRepository) GetProductsCount(ctx context.Context) (int64, error) {
+	ctx, cancel := withQueryTimeout(ctx, inventoryRepository.QueryTimeout)
+	defer cancel()
+
+	var count int64
+	err := inventoryRepository.DB.GetContext(ctx, &count, `SELECT COUNT(*) FROM products`)
+	return count, err
+}
+
+func (inventoryRepository *InventoryRepository) UpdateProduct(ctx context.Context, product *domain.Product) error {
+	ctx, cancel := withQueryTimeout(ctx, inventoryRepository.QueryTimeout)
+	defer cancel()
+
+	query := `UPDATE products SET name = :name, unit = :unit, medication_id = :medication_id, reorder_level = :reorder_level WHERE id = :id`
+	result, err := inventoryRepository.DB.NamedExecContext(ctx, query, product)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrProductNotFound
+	}
+	return nil
+}
+
+// RecordMovement applies a manual movement to a lot of the product.
+// Purchases create the lot if needed; adjustments and write-offs must not take
+// the lot below zero.
+func (inventoryRepository *InventoryRepository) RecordMovement(ctx context.Context, productID int64, lotNumber string, expiryDate *time.Time, movement *domain.StockMovement) error {
+	ctx, cancel := withQueryTimeout(ctx, inventoryRepository.QueryTimeout)
+	defer cancel()
+
+	tx, err := inventoryRepository.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if movement.Kind == domain.MovementPurchase {
+		query := `INSERT INTO stock_batches (product_id, lot_number, expiry_date, quantity_on_hand) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (product_id, lot_number) DO UPDATE
+		SET quantity_on_hand = stock_batches.quantity_on_hand + EXCLUDED.quantity_on_hand,
+			expiry_date = COALESCE(EXCLUDED.expiry_date, stock_batches.expiry_date)
+		RETURNING id`
+		err = tx.GetContext(ctx, &movement.BatchID, query, productID, lotNumber, expiryDate, movement.Quantity)
+		if err != nil {
+			if mapForeignKeyViolation(err) == ErrRecordInUse {
+				return ErrProductNotFound
+			}
+			return err
+		}
+	} else {
+		query := `UPDATE stock_batches SET quantity_on_hand = quantity_on_hand + $3
+		WHERE product_id = $1 AND lot_number = $2 AND quantity_on_hand + $3 >= 0
+		RETURNING id`
+		err = tx.GetContext(ctx, &movement.BatchID, query, productID, lotNumber, movement.Quantity)
+		if err == sql.ErrNoRows {
+			var exists bool
+			err = tx.GetContext(ctx, &exists, `SELECT EXISTS (SELECT 1 FROM stock_batches WHERE product_id = $1 AND lot_number = $2)`, productID, lotNumber)
+			if err != nil {
+				return err
+			}
+			if !exists {
+				return ErrBatchNotFound
+			}
+			return ErrInsufficientStock
+		}
+		if err != nil {
+			return err
+		}
+	}
+	movement.LotNumber = lotNumber
+	if err := insertStockMovement(ctx, tx, movement); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (inventoryRepository *InventoryRepository) GetMovementsByProductID(ctx context.Context, productID int64, limit int, offset int) ([]domain.StockMovement, error) {
+	ctx, cancel := withQueryTimeout(ctx, inventoryRepository.QueryTimeout)
+	defer cancel()
+
+	query := `SELECT m.id, m.batch_id, b.lot_number, m.kind, m.quantity, m.consultation_id, m.billable_item_id, m.reason, m.recorded_by, m.created_at
+	FROM stock_movements m JOIN stock_batches b ON b.id = m.batch_id
+	WHERE b.product_id = $1
+	ORDER BY m.created_at DESC, m.id DESC
+	LIMIT $2 OFFSET $3`
+	var movements []domain.StockMovement
+	err := inventoryRepository.DB.SelectContext(ctx, &movements, query, productID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return movements, nil
+}
+
+func (inventoryRepository *InventoryRepository) GetMovementsCountByProductID(ctx context.Context, productID int64) (int64, error) {
+	ctx, cancel := withQueryTimeout(ctx, inventoryRepository.QueryTimeout)
+	defer cancel()
+
+	var count int64
+	err := inventoryRepository.DB.GetContext(ctx, &count, `SELECT COUNT(*) FROM stock_movements m JOIN stock_batches b ON b.id = m.batch_id WHERE b.product_id = $1`, productID)
+	return count, err
+}
+
+// GetInventoryReport lists products at or below their reorder level and
+// batches with stock left that expire by until, including expired ones.
+func (inventoryRepository *InventoryRepository) GetInventoryReport(ctx context.Context, until time.Time) (*domain.InventoryReport, error) {
+	ctx, cancel := withQueryTimeout(ctx, inventoryRepository.QueryTimeout)
+	defer cancel()
+
+	report := &domain.InventoryReport{LowStock: []domain.Product{}, Expiring: []domain.ExpiringBatch{}}
+	query := `SELECT * FROM (SELECT ` + productColumns + ` FROM products p) stock
+	WHERE quantity_on_hand <= reorder_level
+	ORDER BY quantity_on_hand - reorder_level, name`
+	err := inventoryRepository.DB.SelectContext(ctx, &report.LowStock, query)
+	if err != nil {
+		return nil, err
+	}
+	query = `SELECT b.id, b.product_id, b.lot_number, b.expiry_date, b.quantity_on_hand, p.sku, p.name
+	FROM stock_batches b JOIN products p ON p.id = b.product_id
+	WHERE b.quantity_on_hand > 0 AND b.expiry_date <= $1::timestamp
+	ORDER BY b.expiry_date, b.id`
+	err = inventoryRepository.DB.SelectContext(ctx, &report.Expiring, query, until)
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func insertStockMovement(ctx context.Context, tx *sqlx.Tx, movement *domain.StockMovement) error {
+	query := `INSERT INTO stock_movements (batch_id, kind, quantity, consultation_id, billable_item_id, reason, recorded_by, created_at)
+	VALUES (:batch_id, :kind, :quantity, :consultation_id, :billable_item_id, :reason, :recorded_by, :created_at)
+	RETURNING id`
+	stmt, err := tx.PrepareNamedContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	return stmt.GetContext(ctx, &movement.ID, movement)
+}
+
+// dispenseStock takes the item's quantity of its product from unexpired
+// batches, soonest expiry first, recording a DISPENSE movement per batch.
+func dispenseStock(ctx context.Context, tx *sqlx.Tx, item *domain.BillableItem) error {
+	var batches []domain.StockBatch
+	query := `SELECT ` + batchColumns + ` FROM stock_batches
+	WHERE product_id = $1 AND quantity_on_hand > 0 AND (expiry_date IS NULL OR expiry_date >= CURRENT_DATE)
+	ORDER BY expiry_date NULLS LAST, id
+	FOR UPDATE`
+	if err := tx.SelectContext(ctx, &batches, query, *item.ProductID); err != nil {
+		return err
+	}
+	remaining := item.Quantity
+	for _, batch := range batches {
+		if remaining == 0 {
+			break
+		}
+		taken := min(remaining, batch.QuantityOnHand)
+		if _, err := tx.ExecContext(ctx, `UPDATE stock_batches SET quantity_on_hand = quantity_on_hand - $1 WHERE id = $2`, taken, batch.ID); err != nil {
+			return err
+		}
+		movement := &domain.StockMovement{
+			BatchID:        batch.ID,
+			Kind:           domain.MovementDispense,
+			Quantity:       -taken,
+			ConsultationID: &item.ConsultationID,
+			BillableItemID: &item.ID,
+			CreatedAt:      time.Now().UTC(),
+		}
+		if err := insertStockMovement(ctx, tx, movement); err != nil {
+			return err
+		}
+		remaining -= taken
+	}
+	if remaining > 0 {
+		return ErrInsufficientStock
+	}
+	return nil
+}
+
+// restockItem returns what was dispensed for a billable item to the batches
+// it came from.
+func restockItem(ctx context.Context, tx *sqlx.Tx, itemID int64) error {
+	var dispensed []domain.StockMovement
+	err := tx.SelectContext(ctx, &dispensed, `SELECT batch_id, quantity, consultation_id FROM stock_movements WHERE billable_item_id = $1 AND kind = $2`, itemID, domain.MovementDispense)
+	if err != nil {
+		return err
+	}
+	for _, movement := range dispensed {
+		if _, err := tx.ExecContext(ctx, `UPDATE stock_batches SET quantity_on_hand = quantity_on_hand - $1 WHERE id = $2`, movement.Quantity, movement.BatchID); err != nil {
+			return err
+		}
+		restock := &domain.StockMovement{
+			BatchID:        movement.BatchID,
+			Kind:           domain.MovementAdjustment,
+			Quantity:       -movement.Quantity,
+			ConsultationID: movement.ConsultationID,
+			BillableItemID: &itemID,
+			Reason:         "Billable item removed",
+			CreatedAt:      time.Now().UTC(),
+		}
+		if err := insertStockMovement(ctx, tx, restock); err != nil {
+			return err
+		}
+	}
+	return nil
+}
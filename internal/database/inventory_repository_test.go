@@ -0,0 +1,147 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+	"vetsys/internal/domain"
+)
+
+func createInventoryFixtures(t *testing.T) (*domain.User, *domain.Consultation, *domain.Product) {
+	t.Helper()
+	cleanupTables(testDB)
+
+	vet := domain.NewUser("12345678A", "vet@example.com", "hashedpassword", "Dr. Vet", "vet.jpg")
+	vet.Role = domain.RoleVeterinarian
+	if err := testDB.UserRepo.CreateUser(context.Background(), vet); err != nil {
+		t.Fatalf("Failed to create vet: %v", err)
+	}
+
+	client := domain.NewClient("23456789B", "Jane Smith", "+34600222333")
+	testDB.ClientRepo.CreateClient(context.Background(), client)
+
+	dob := time.Date(2019, 3, 10, 0, 0, 0, 0, time.UTC)
+	patient := domain.NewPatient("Max", "Dog", "Labrador", dob, client.ID)
+	testDB.PatientRepo.CreatePatient(context.Background(), patient)
+
+	consultation := domain.NewConsultation(patient.ID, "Limping", "Sprain", "Rest", domain.SeverityLow)
+	testDB.ConsultationRepo.CreateConsultation(context.Background(), consultation)
+
+	product := domain.NewProduct("MEL-15", "Meloxicam 1.5mg tablets", "tablet", nil, 10)
+	if err := testDB.InventoryRepo.CreateProduct(context.Background(), product); err != nil {
+		t.Fatalf("Failed to create product: %v", err)
+	}
+
+	return vet, consultation, product
+}
+
+func purchase(t *testing.T, vet *domain.User, productID int64, lot string, expiry time.Time, quantity int) {
+	t.Helper()
+	movement := domain.NewStockMovement(domain.MovementPurchase, quantity, "", vet.ID)
+	if err := testDB.InventoryRepo.RecordMovement(context.Background(), productID, lot, &expiry, movement); err != nil {
+		t.Fatalf("Failed to record purchase: %v", err)
+	}
+}
+
+func TestInventoryRepository_CreateProduct(t *testing.T) {
+	createInventoryFixtures(t)
+
+	err := testDB.InventoryRepo.CreateProduct(context.Background(), domain.NewProduct("MEL-15", "Duplicate", "tablet", nil, 0))
+	if err != ErrProductExists {
+		t.Errorf("Expected ErrProductExists, got %v", err)
+	}
+}
+
+func TestInventoryRepository_RecordMovement(t *testing.T) {
+	vet, _, product := createInventoryFixtures(t)
+	expiry := time.Now().UTC().AddDate(1, 0, 0)
+
+	purchase(t, vet, product.ID, "LOT-A", expiry, 20)
+	purchase(t, vet, product.ID, "LOT-A", expiry, 5)
+
+	writeOff := domain.NewStockMovement(domain.MovementWriteOff, -30, "Damaged", vet.ID)
+	err := testDB.InventoryRepo.RecordMovement(context.Background(), product.ID, "LOT-A", nil, writeOff)
+	if err != ErrInsufficientStock {
+		t.Errorf("Expected ErrInsufficientStock, got %v", err)
+	}
+	writeOff = domain.NewStockMovement(domain.MovementWriteOff, -3, "Damaged", vet.ID)
+	if err := testDB.InventoryRepo.RecordMovement(context.Background(), product.ID, "LOT-A", nil, writeOff); err != nil {
+		t.Fatalf("Failed to write off: %v", err)
+	}
+	err = testDB.InventoryRepo.RecordMovement(context.Background(), product.ID, "LOT-Z", nil, domain.NewStockMovement(domain.MovementAdjustment, 1, "Count", vet.ID))
+	if err != ErrBatchNotFound {
+		t.Errorf("Expected ErrBatchNotFound, got %v", err)
+	}
+
+	retrieved, _ := testDB.InventoryRepo.GetProductByID(context.Background(), product.ID)
+	if retrieved.QuantityOnHand != 22 || len(retrieved.Batches) != 1 {
+		t.Errorf("Expected 22 on hand in one batch, got %d in %d", retrieved.QuantityOnHand, len(retrieved.Batches))
+	}
+	count, _ := testDB.InventoryRepo.GetMovementsCountByProductID(context.Background(), product.ID)
+	if count != 3 {
+		t.Errorf("Expected 3 movements, got %d", count)
+	}
+}
+
+func TestInventoryRepository_DispenseThroughBillableItem(t *testing.T) {
+	vet, consultation, product := createInventoryFixtures(t)
+	now := time.Now().UTC()
+
+	purchase(t, vet, product.ID, "EXPIRED", now.AddDate(0, 0, -1), 50)
+	purchase(t, vet, product.ID, "LATE", now.AddDate(1, 0, 0), 10)
+	purchase(t, vet, product.ID, "SOON", now.AddDate(0, 1, 0), 4)
+
+	item := domain.NewBillableItem(consultation.ID, domain.ItemProduct, "Meloxicam", 6, 80)
+	item.ProductID = &product.ID
+	if err := testDB.BillingRepo.CreateBillableItem(context.Background(), item); err != nil {
+		t.Fatalf("Failed to create billable item: %v", err)
+	}
+
+	retrieved, _ := testDB.InventoryRepo.GetProductByID(context.Background(), product.ID)
+	left := map[string]int{}
+	for _, batch := range retrieved.Batches {
+		left[batch.LotNumber] = batch.QuantityOnHand
+	}
+	if left["SOON"] != 0 || left["LATE"] != 8 || left["EXPIRED"] != 50 {
+		t.Errorf("Expected the soonest unexpired lot to be used first, got %v", left)
+	}
+
+	tooMany := domain.NewBillableItem(consultation.ID, domain.ItemProduct, "Meloxicam", 9, 80)
+	tooMany.ProductID = &product.ID
+	if err := testDB.BillingRepo.CreateBillableItem(context.Background(), tooMany); err != ErrInsufficientStock {
+		t.Errorf("Expected ErrInsufficientStock, got %v", err)
+	}
+	items, _ := testDB.BillingRepo.GetBillableItemsByConsultationID(context.Background(), consultation.ID)
+	if len(items) != 1 {
+		t.Errorf("Expected the failed item to be rolled back, got %d items", len(items))
+	}
+
+	if err := testDB.BillingRepo.DeleteBillableItem(context.Background(), item.ID); err != nil {
+		t.Fatalf("Failed to delete billable item: %v", err)
+	}
+	retrieved, _ = testDB.InventoryRepo.GetProductByID(context.Background(), product.ID)
+	if retrieved.QuantityOnHand != 64 {
+		t.Errorf("Expected stock to be returned, got %d on hand", retrieved.QuantityOnHand)
+	}
+}
+
+func TestInventoryRepository_GetInventoryReport(t *testing.T) {
+	vet, _, product := createInventoryFixtures(t)
+	now := time.Now().UTC()
+
+	stocked := domain.NewProduct("GAUZE", "Gauze swabs", "pack", nil, 2)
+	testDB.InventoryRepo.CreateProduct(context.Background(), stocked)
+	purchase(t, vet, stocked.ID, "G-1", now.AddDate(2, 0, 0), 40)
+	purchase(t, vet, product.ID, "LOT-A", now.AddDate(0, 0, 10), 5)
+
+	report, err := testDB.InventoryRepo.GetInventoryReport(context.Background(), now.AddDate(0, 0, 30))
+	if err != nil {
+		t.Fatalf("Failed to get report: %v", err)
+	}
+	if len(report.LowStock) != 1 || report.LowStock[0].ID != product.ID {
+		t.Errorf("Expected only %s to be low on stock, got %v", product.SKU, report.LowStock)
+	}
+	if len(report.Expiring) != 1 || report.Expiring[0].LotNumber != "LOT-A" {
+		t.Errorf("Expected LOT-A to be expiring, got %v", report.Expiring)
+	}
+}
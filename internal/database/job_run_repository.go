@@ -0,0 +1,77 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// jobLockClass is the first key of the two-key advisory locks held while a
+// scheduled job runs; the second is a hash of the job name. Two-key locks
+// never collide with the single-key migration lock.
+const jobLockClass int32 = 0x76657473
+
+type JobRunRepository struct {
+	DB           *sqlx.DB
+	QueryTimeout time.Duration
+}
+
+// ClaimRun takes the job's advisory lock and records due as its latest
+// occurrence. ok is false when another instance holds the lock or has already
+// claimed this or a later occurrence. The lock lives on a connection of its
+// own until release is called, which must happen once the run ends.
+func (jobRunRepo *JobRunRepository) ClaimRun(ctx context.Context, name string, due time.Time) (release func(), ok bool, err error) {
+	ctx, cancel := withQueryTimeout(ctx, jobRunRepo.QueryTimeout)
+	defer cancel()
+
+	// Advisory locks belong to a database session, so the run keeps this connection.
+	conn, err := jobRunRepo.DB.Connx(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	key := jobLockKey(name)
+	if err := conn.GetContext(ctx, &ok, `SELECT pg_try_advisory_lock($1, $2)`, jobLockClass, key); err != nil {
+		conn.Close()
+		return nil, false, fmt.Errorf("acquire job lock: %w", err)
+	}
+	if !ok {
+		conn.Close()
+		return nil, false, nil
+	}
+	release = func() {
+		conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1, $2)`, jobLockClass, key)
+		conn.Close()
+	}
+
+	query := `
+	INSERT INTO job_runs (name, last_due_at, last_started_at)
+	VALUES ($1, $2, (now() AT TIME ZONE 'UTC'))
+	ON CONFLICT (name) DO UPDATE
+	SET last_due_at = EXCLUDED.last_due_at, last_started_at = EXCLUDED.last_started_at
+	WHERE job_runs.last_due_at < EXCLUDED.last_due_at
+	`
+	result, err := conn.ExecContext(ctx, query, name, due.UTC())
+	if err != nil {
+		release()
+		return nil, false, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		release()
+		return nil, false, err
+	}
+	if rows == 0 {
+		release()
+		return nil, false, nil
+	}
+	return release, true, nil
+}
+
+func jobLockKey(name string) int32 {
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	return int32(h.Sum32())
+}
@@ -0,0 +1,52 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestJobRunRepository_ClaimRun(t *testing.T) {
+	cleanupTables(testDB)
+
+	due := time.Date(2026, 3, 1, 3, 0, 0, 0, time.UTC)
+	release, ok, err := testDB.JobRunRepo.ClaimRun(context.Background(), "purge_sessions", due)
+	if err != nil {
+		t.Fatalf("Failed to claim run: %v", err)
+	}
+	if !ok {
+		t.Fatal("Expected the first claim to succeed")
+	}
+
+	// Held by the first claim.
+	_, ok, err = testDB.JobRunRepo.ClaimRun(context.Background(), "purge_sessions", due.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Failed to claim run: %v", err)
+	}
+	if ok {
+		t.Error("Expected a claim to fail while the job is running")
+	}
+
+	// Other jobs have locks of their own.
+	releaseOther, ok, err := testDB.JobRunRepo.ClaimRun(context.Background(), "send_reminders", due)
+	if err != nil || !ok {
+		t.Fatalf("Expected another job to be claimable, got ok=%v err=%v", ok, err)
+	}
+	releaseOther()
+	release()
+
+	// Already run.
+	_, ok, err = testDB.JobRunRepo.ClaimRun(context.Background(), "purge_sessions", due)
+	if err != nil {
+		t.Fatalf("Failed to claim run: %v", err)
+	}
+	if ok {
+		t.Error("Expected a claim for an occurrence that already ran to fail")
+	}
+
+	release, ok, err = testDB.JobRunRepo.ClaimRun(context.Background(), "purge_sessions", due.Add(time.Hour))
+	if err != nil || !ok {
+		t.Fatalf("Expected the next occurrence to be claimable, got ok=%v err=%v", ok, err)
+	}
+	release()
+}
@@ -0,0 +1,102 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+	"vetsys/internal/domain"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type LoginChallengeRepository struct {
+	DB           *sqlx.DB
+	QueryTimeout time.Duration
+}
+
+var ErrChallengeInvalid = errors.New("Invalid or expired login token")
+
+func (challengeRepo *LoginChallengeRepository) CreateChallenge(ctx context.Context, challenge *domain.LoginChallenge) error {
+	ctx, cancel := withQueryTimeout(ctx, challengeRepo.QueryTimeout)
+	defer cancel()
+
+	query := `
+	INSERT INTO login_challenges (token_hash, user_id, attempts, created_at, expires_at)
+	VALUES (:token_hash, :user_id, :attempts, :created_at, :expires_at)
+	`
+	_, err := challengeRepo.DB.NamedExecContext(ctx, query, challenge)
+	return err
+}
+
+func (challengeRepo *LoginChallengeRepository) GetChallenge(ctx context.Context, tokenHash string) (*domain.LoginChallenge, error) {
+	ctx, cancel := withQueryTimeout(ctx, challengeRepo.QueryTimeout)
+	defer cancel()
+
+	query := `
+	SELECT token_hash, user_id, attempts, created_at, expires_at
+	FROM login_challenges
+	WHERE token_hash = $1 AND expires_at > (now() AT TIME ZONE 'UTC')
+	`
+	challenge := domain.LoginChallenge{}
+	err := challengeRepo.DB.GetContext(ctx, &challenge, query, tokenHash)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrChallengeInvalid
+		}
+		return nil, err
+	}
+	return &challenge, nil
+}
+
+// FailChallenge counts a wrong code against a challenge, and deletes it once
+// maxAttempts have failed so its token cannot be used to guess on.
+func (challengeRepo *LoginChallengeRepository) FailChallenge(ctx context.Context, tokenHash string, maxAttempts int) error {
+	ctx, cancel := withQueryTimeout(ctx, challengeRepo.QueryTimeout)
+	defer cancel()
+
+	tx, err := challengeRepo.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE login_challenges SET attempts = attempts + 1 WHERE token_hash = $1`
+	if _, err := tx.ExecContext(ctx, query, tokenHash); err != nil {
+		return err
+	}
+	query = `DELETE FROM login_challenges WHERE token_hash = $1 AND attempts >= $2`
+	if _, err := tx.ExecContext(ctx, query, tokenHash, maxAttempts); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteChallenge uses up a challenge. It fails with ErrChallengeInvalid if
+// the challenge is gone, so of two concurrent uses only one succeeds.
+func (challengeRepo *LoginChallengeRepository) DeleteChallenge(ctx context.Context, tokenHash string) error {
+	ctx, cancel := withQueryTimeout(ctx, challengeRepo.QueryTimeout)
+	defer cancel()
+
+	result, err := challengeRepo.DB.ExecContext(ctx, `DELETE FROM login_challenges WHERE token_hash = $1`, tokenHash)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrChallengeInvalid
+	}
+	return nil
+}
+
+func (challengeRepo *LoginChallengeRepository) DeleteExpiredChallenges(ctx context.Context) error {
+	ctx, cancel := withQueryTimeout(ctx, challengeRepo.QueryTimeout)
+	defer cancel()
+
+	query := `DELETE FROM login_challenges WHERE expires_at < (now() AT TIME ZONE 'UTC')`
+	_, err := challengeRepo.DB.ExecContext(ctx, query)
+	return err
+}
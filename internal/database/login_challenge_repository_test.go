@@ -0,0 +1,72 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+	"vetsys/internal/domain"
+)
+
+func TestLoginChallengeRepository_Attempts(t *testing.T) {
+	cleanupTables(testDB)
+
+	user := domain.NewUser("12345678A", "test@example.com", "hashedpassword", "Test User", "profile.jpg")
+	testDB.UserRepo.CreateUser(context.Background(), user)
+	token, challenge, err := domain.NewLoginChallenge(user.ID, time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to create challenge: %v", err)
+	}
+	if err := testDB.LoginChallengeRepo.CreateChallenge(context.Background(), challenge); err != nil {
+		t.Fatalf("Failed to store challenge: %v", err)
+	}
+
+	hash := domain.HashToken(token)
+	if err := testDB.LoginChallengeRepo.FailChallenge(context.Background(), hash, 2); err != nil {
+		t.Fatalf("Failed to fail challenge: %v", err)
+	}
+	got, err := testDB.LoginChallengeRepo.GetChallenge(context.Background(), hash)
+	if err != nil {
+		t.Fatalf("Failed to get challenge: %v", err)
+	}
+	if got.UserID != user.ID || got.Attempts != 1 {
+		t.Errorf("Unexpected challenge %+v", got)
+	}
+	if err := testDB.LoginChallengeRepo.FailChallenge(context.Background(), hash, 2); err != nil {
+		t.Fatalf("Failed to fail challenge: %v", err)
+	}
+	if _, err := testDB.LoginChallengeRepo.GetChallenge(context.Background(), hash); err != ErrChallengeInvalid {
+		t.Errorf("Expected ErrChallengeInvalid after the last attempt, got %v", err)
+	}
+}
+
+func TestLoginChallengeRepository_DeleteAndExpiry(t *testing.T) {
+	cleanupTables(testDB)
+
+	user := domain.NewUser("12345678A", "test@example.com", "hashedpassword", "Test User", "profile.jpg")
+	testDB.UserRepo.CreateUser(context.Background(), user)
+	token, challenge, _ := domain.NewLoginChallenge(user.ID, time.Minute)
+	testDB.LoginChallengeRepo.CreateChallenge(context.Background(), challenge)
+	expired, challenge, _ := domain.NewLoginChallenge(user.ID, time.Minute)
+	challenge.ExpiresAt = time.Now().UTC().Add(-time.Minute)
+	testDB.LoginChallengeRepo.CreateChallenge(context.Background(), challenge)
+
+	if err := testDB.LoginChallengeRepo.DeleteChallenge(context.Background(), domain.HashToken(token)); err != nil {
+		t.Fatalf("Failed to delete challenge: %v", err)
+	}
+	if err := testDB.LoginChallengeRepo.DeleteChallenge(context.Background(), domain.HashToken(token)); err != ErrChallengeInvalid {
+		t.Errorf("Expected ErrChallengeInvalid deleting twice, got %v", err)
+	}
+	if _, err := testDB.LoginChallengeRepo.GetChallenge(context.Background(), domain.HashToken(expired)); err != ErrChallengeInvalid {
+		t.Errorf("Expected ErrChallengeInvalid for an expired challenge, got %v", err)
+	}
+	if err := testDB.LoginChallengeRepo.DeleteExpiredChallenges(context.Background()); err != nil {
+		t.Fatalf("Failed to delete expired challenges: %v", err)
+	}
+	var count int
+	if err := testDB.DB.Get(&count, `SELECT COUNT(*) FROM login_challenges`); err != nil {
+		t.Fatalf("Failed to count challenges: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("Expected the expired challenge to be deleted, got %d challenges", count)
+	}
+}
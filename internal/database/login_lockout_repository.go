@@ -0,0 +1,139 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+	"vetsys/internal/domain"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type LoginLockoutRepository struct {
+	DB           *sqlx.DB
+	QueryTimeout time.Duration
+}
+
+var ErrLockoutNotFound = errors.New("Lockout not found")
+
+func (lockoutRepo *LoginLockoutRepository) GetLockout(ctx context.Context, scope domain.LockoutScope, key string) (*domain.LoginLockout, error) {
+	ctx, cancel := withQueryTimeout(ctx, lockoutRepo.QueryTimeout)
+	defer cancel()
+
+	query := `
+	SELECT scope, key, failures, lockouts, last_failure_at, locked_until
+	FROM login_lockouts
+	WHERE scope = $1 AND key = $2
+	`
+	lockout := domain.LoginLockout{}
+	err := lockoutRepo.DB.GetContext(ctx, &lockout, query, scope, key)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrLockoutNotFound
+		}
+		return nil, err
+	}
+	return &lockout, nil
+}
+
+// GetLockouts returns every DNI and IP with failed logins on record, locked
+// ones first.
+func (lockoutRepo *LoginLockoutRepository) GetLockouts(ctx context.Context) ([]domain.LoginLockout, error) {
+	ctx, cancel := withQueryTimeout(ctx, lockoutRepo.QueryTimeout)
+	defer cancel()
+
+	query := `
+	SELECT scope, key, failures, lockouts, last_failure_at, locked_until
+	FROM login_lockouts
+	ORDER BY locked_until DESC NULLS LAST, last_failure_at DESC
+	`
+	lockouts := []domain.LoginLockout{}
+	err := lockoutRepo.DB.SelectContext(ctx, &lockouts, query)
+	if err != nil {
+		return nil, err
+	}
+	return lockouts, nil
+}
+
+// RecordFailure counts a failed login at now under policy and returns the
+// updated record. The row is locked while it is updated, so concurrent
+// failures are all counted and lock at most once.
+func (lockoutRepo *LoginLockoutRepository) RecordFailure(ctx context.Context, scope domain.LockoutScope, key string, policy domain.LockoutPolicy, now time.Time) (*domain.LoginLockout, error) {
+	ctx, cancel := withQueryTimeout(ctx, lockoutRepo.QueryTimeout)
+	defer cancel()
+
+	tx, err := lockoutRepo.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	insert := `
+	INSERT INTO login_lockouts (scope, key, last_failure_at)
+	VALUES ($1, $2, $3)
+	ON CONFLICT (scope, key) DO NOTHING
+	`
+	if _, err := tx.ExecContext(ctx, insert, scope, key, now); err != nil {
+		return nil, err
+	}
+	query := `
+	SELECT scope, key, failures, lockouts, last_failure_at, locked_until
+	FROM login_lockouts
+	WHERE scope = $1 AND key = $2
+	FOR UPDATE
+	`
+	lockout := domain.LoginLockout{}
+	if err := tx.GetContext(ctx, &lockout, query, scope, key); err != nil {
+		return nil, err
+	}
+	policy.RecordFailure(&lockout, now)
+
+	update := `
+	UPDATE login_lockouts
+	SET failures = :failures, lockouts = :lockouts, last_failure_at = :last_failure_at, locked_until = :locked_until
+	WHERE scope = :scope AND key = :key
+	`
+	if _, err := tx.NamedExecContext(ctx, update, &lockout); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &lockout, nil
+}
+
+// DeleteLockout forgets the failed logins of a DNI or IP, unlocking it.
+func (lockoutRepo *LoginLockoutRepository) DeleteLockout(ctx context.Context, scope domain.LockoutScope, key string) error {
+	ctx, cancel := withQueryTimeout(ctx, lockoutRepo.QueryTimeout)
+	defer cancel()
+
+	query := `DELETE FROM login_lockouts WHERE scope = $1 AND key = $2`
+	result, err := lockoutRepo.DB.ExecContext(ctx, query, scope, key)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrLockoutNotFound
+	}
+	return nil
+}
+
+// DeleteStaleLockouts forgets DNIs and IPs that are not locked and have had
+// no failed login since before.
+func (lockoutRepo *LoginLockoutRepository) DeleteStaleLockouts(ctx context.Context, before time.Time) error {
+	ctx, cancel := withQueryTimeout(ctx, lockoutRepo.QueryTimeout)
+	defer cancel()
+
+	query := `
+	DELETE FROM login_lockouts
+	WHERE last_failure_at < $1
+	AND (locked_until IS NULL OR locked_until < (now() AT TIME ZONE 'UTC'))
+	`
+	_, err := lockoutRepo.DB.ExecContext(ctx, query, before)
+	return err
+}
@@ -0,0 +1,90 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+	"vetsys/internal/domain"
+)
+
+func TestLoginLockoutRepository_RecordFailure(t *testing.T) {
+	cleanupTables(testDB)
+	repo := testDB.LoginLockoutRepo
+	policy := domain.LockoutPolicy{Threshold: 2, Duration: time.Minute, MaxDuration: 3 * time.Minute}
+	now := time.Now().UTC().Truncate(time.Second)
+
+	fail := func(at time.Time) *domain.LoginLockout {
+		t.Helper()
+		lockout, err := repo.RecordFailure(context.Background(), domain.LockoutScopeDNI, "12345678A", policy, at)
+		if err != nil {
+			t.Fatalf("Failed to record failure: %v", err)
+		}
+		return lockout
+	}
+
+	if lockout := fail(now); lockout.Failures != 1 || lockout.IsLocked(now) {
+		t.Fatalf("Expected one failure and no lockout, got %+v", lockout)
+	}
+	// Each lockout doubles, up to the maximum.
+	for i, want := range []time.Duration{time.Minute, 2 * time.Minute, 3 * time.Minute} {
+		if i > 0 {
+			now = now.Add(time.Hour)
+			fail(now)
+		}
+		lockout := fail(now)
+		if lockout.LockedUntil == nil || !lockout.LockedUntil.Equal(now.Add(want)) || lockout.Failures != 0 {
+			t.Fatalf("Lockout %d: expected to be locked for %s, got %+v", i+1, want, lockout)
+		}
+	}
+
+	stored, err := repo.GetLockout(context.Background(), domain.LockoutScopeDNI, "12345678A")
+	if err != nil {
+		t.Fatalf("Failed to get lockout: %v", err)
+	}
+	if stored.Lockouts != 3 {
+		t.Errorf("Expected 3 lockouts stored, got %+v", stored)
+	}
+
+	// A quiet day starts over.
+	if lockout := fail(now.Add(domain.LockoutResetAfter)); lockout.Failures != 1 || lockout.Lockouts != 0 {
+		t.Errorf("Expected the count to start over, got %+v", lockout)
+	}
+}
+
+func TestLoginLockoutRepository_DeleteAndList(t *testing.T) {
+	cleanupTables(testDB)
+	repo := testDB.LoginLockoutRepo
+	policy := domain.LockoutPolicy{Threshold: 1, Duration: time.Hour, MaxDuration: time.Hour}
+	now := time.Now().UTC()
+
+	if _, err := repo.RecordFailure(context.Background(), domain.LockoutScopeIP, "203.0.113.1", policy, now); err != nil {
+		t.Fatalf("Failed to record failure: %v", err)
+	}
+	old := domain.LockoutPolicy{Threshold: 5, Duration: time.Minute, MaxDuration: time.Minute}
+	if _, err := repo.RecordFailure(context.Background(), domain.LockoutScopeDNI, "12345678A", old, now.Add(-48*time.Hour)); err != nil {
+		t.Fatalf("Failed to record failure: %v", err)
+	}
+
+	lockouts, err := repo.GetLockouts(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to get lockouts: %v", err)
+	}
+	if len(lockouts) != 2 || lockouts[0].Scope != domain.LockoutScopeIP {
+		t.Fatalf("Expected the locked IP first, got %+v", lockouts)
+	}
+
+	// Stale records go, locked ones stay.
+	if err := repo.DeleteStaleLockouts(context.Background(), now.Add(-domain.LockoutResetAfter)); err != nil {
+		t.Fatalf("Failed to delete stale lockouts: %v", err)
+	}
+	if _, err := repo.GetLockout(context.Background(), domain.LockoutScopeDNI, "12345678A"); err != ErrLockoutNotFound {
+		t.Errorf("Expected the stale DNI to be gone, got %v", err)
+	}
+
+	if err := repo.DeleteLockout(context.Background(), domain.LockoutScopeIP, "203.0.113.1"); err != nil {
+		t.Fatalf("Failed to delete lockout: %v", err)
+	}
+	if err := repo.DeleteLockout(context.Background(), domain.LockoutScopeIP, "203.0.113.1"); err != ErrLockoutNotFound {
+		t.Errorf("Expected ErrLockoutNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,173 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+	"vetsys/internal/database"
+	"vetsys/internal/domain"
+)
+
+type AllowedRegistrationRepository struct {
+	store *store
+}
+
+func (r *AllowedRegistrationRepository) InsertDNI(ctx context.Context, dni string) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.registrations[dni]; ok {
+		return database.ErrDNIAlreadyExists
+	}
+	s.registrations[dni] = domain.AllowedRegistration{
+		DNI:       dni,
+		Role:      domain.RoleVeterinarian,
+		CreatedAt: time.Now().UTC(),
+	}
+	return nil
+}
+
+func (r *AllowedRegistrationRepository) UseDNI(ctx context.Context, dni string) (domain.Role, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC()
+	registration, ok := s.registrations[dni]
+	if !ok || registration.Used || registration.RevokedAt != nil ||
+		(registration.ExpiresAt != nil && !registration.ExpiresAt.After(now)) {
+		return "", database.ErrDNIInvalidOrUsed
+	}
+	registration.Used = true
+	registration.UsedAt = &now
+	s.registrations[dni] = registration
+	return registration.Role, nil
+}
+
+func (r *AllowedRegistrationRepository) DeleteDNI(ctx context.Context, dni string) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.registrations[dni]; !ok {
+		return database.ErrDNINotFound
+	}
+	delete(s.registrations, dni)
+	return nil
+}
+
+func (r *AllowedRegistrationRepository) InsertRegistrations(ctx context.Context, registrations []domain.AllowedRegistration) ([]string, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	created := []string{}
+	for _, registration := range registrations {
+		if _, ok := s.registrations[registration.DNI]; ok {
+			continue
+		}
+		registration.Used = false
+		registration.UsedAt = nil
+		registration.RevokedAt = nil
+		s.registrations[registration.DNI] = registration
+		created = append(created, registration.DNI)
+	}
+	return created, nil
+}
+
+func (r *AllowedRegistrationRepository) GetRegistration(ctx context.Context, dni string) (*domain.AllowedRegistration, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	registration, ok := s.registrations[dni]
+	if !ok {
+		return nil, database.ErrDNINotFound
+	}
+	registration.UserID = s.userIDByDNI(dni)
+	return &registration, nil
+}
+
+func (r *AllowedRegistrationRepository) GetRegistrations(ctx context.Context, used *bool, limit int, offset int) ([]domain.AllowedRegistration, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	registrations := s.filterRegistrations(used)
+	sort.Slice(registrations, func(i, j int) bool {
+		if !registrations[i].CreatedAt.Equal(registrations[j].CreatedAt) {
+			return registrations[i].CreatedAt.After(registrations[j].CreatedAt)
+		}
+		return registrations[i].DNI < registrations[j].DNI
+	})
+	registrations = page(registrations, limit, offset)
+	for i := range registrations {
+		registrations[i].UserID = s.userIDByDNI(registrations[i].DNI)
+	}
+	return registrations, nil
+}
+
+func (r *AllowedRegistrationRepository) GetRegistrationsCount(ctx context.Context, used *bool) (int64, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return int64(len(s.filterRegistrations(used))), nil
+}
+
+func (r *AllowedRegistrationRepository) RevokeDNI(ctx context.Context, dni string) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	registration, ok := s.registrations[dni]
+	if !ok {
+		return database.ErrDNINotFound
+	}
+	if registration.Used || registration.RevokedAt != nil {
+		return database.ErrDNIInvalidOrUsed
+	}
+	now := time.Now().UTC()
+	registration.RevokedAt = &now
+	s.registrations[dni] = registration
+	return nil
+}
+
+func (r *AllowedRegistrationRepository) ReopenDNI(ctx context.Context, dni string, invitedBy int64, expiresAt *time.Time) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	registration, ok := s.registrations[dni]
+	if !ok {
+		return database.ErrDNINotFound
+	}
+	registration.Used = false
+	registration.UsedAt = nil
+	registration.RevokedAt = nil
+	registration.InvitedBy = &invitedBy
+	registration.ExpiresAt = expiresAt
+	s.registrations[dni] = registration
+	return nil
+}
+
+func (s *store) filterRegistrations(used *bool) []domain.AllowedRegistration {
+	var registrations []domain.AllowedRegistration
+	for _, registration := range s.registrations {
+		if used == nil || registration.Used == *used {
+			registrations = append(registrations, registration)
+		}
+	}
+	return registrations
+}
+
+func (s *store) userIDByDNI(dni string) *int64 {
+	for _, user := range s.users {
+		if user.DNI == dni {
+			id := user.ID
+			return &id
+		}
+	}
+	return nil
+}
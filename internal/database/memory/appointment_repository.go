@@ -0,0 +1,155 @@
+package memory
+
+import (
+	"context"
+	"time"
+	"vetsys/internal/database"
+	"vetsys/internal/domain"
+)
+
+type AppointmentRepository struct {
+	store *store
+}
+
+func (appointmentRepository *AppointmentRepository) CreateAppointment(ctx context.Context, appointment *domain.Appointment) error {
+	s := appointmentRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkVetCalendar(appointment); err != nil {
+		return err
+	}
+	if _, ok := s.patients[appointment.PatientID]; !ok {
+		return errForeignKey
+	}
+	appointment.ID = s.nextID()
+	s.appointments[appointment.ID] = *appointment
+	return nil
+}
+
+func (appointmentRepository *AppointmentRepository) GetAppointmentByID(ctx context.Context, id int64) (*domain.Appointment, error) {
+	s := appointmentRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	appointment, ok := s.appointments[id]
+	if !ok {
+		return nil, database.ErrAppointmentNotFound
+	}
+	return &appointment, nil
+}
+
+func (appointmentRepository *AppointmentRepository) GetAppointments(ctx context.Context, filter database.AppointmentFilter, limit int, offset int) ([]domain.Appointment, error) {
+	s := appointmentRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return page(rows(s.appointments, appointmentMatches(filter), byStartsAt), limit, offset), nil
+}
+
+func (appointmentRepository *AppointmentRepository) GetAppointmentsCount(ctx context.Context, filter database.AppointmentFilter) (int64, error) {
+	s := appointmentRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return int64(len(rows(s.appointments, appointmentMatches(filter), byStartsAt))), nil
+}
+
+func (appointmentRepository *AppointmentRepository) UpdateAppointment(ctx context.Context, appointment *domain.Appointment) error {
+	s := appointmentRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkVetCalendar(appointment); err != nil {
+		return err
+	}
+	stored, ok := s.appointments[appointment.ID]
+	if !ok || stored.Status != domain.AppointmentBooked {
+		return database.ErrAppointmentStatusChanged
+	}
+	if _, ok := s.patients[appointment.PatientID]; !ok {
+		return errForeignKey
+	}
+	stored.PatientID = appointment.PatientID
+	stored.VetID = appointment.VetID
+	stored.StartsAt = appointment.StartsAt
+	stored.EndsAt = appointment.EndsAt
+	stored.Reason = appointment.Reason
+	stored.UpdatedAt = appointment.UpdatedAt
+	s.appointments[appointment.ID] = stored
+	return nil
+}
+
+func (appointmentRepository *AppointmentRepository) UpdateAppointmentStatus(ctx context.Context, id int64, from domain.AppointmentStatus, to domain.AppointmentStatus) error {
+	s := appointmentRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.appointments[id]
+	if !ok || stored.Status != from {
+		return database.ErrAppointmentStatusChanged
+	}
+	stored.Status = to
+	stored.UpdatedAt = time.Now().UTC()
+	s.appointments[id] = stored
+	return nil
+}
+
+func (appointmentRepository *AppointmentRepository) ConvertToConsultation(ctx context.Context, appointment *domain.Appointment, consultation *domain.Consultation) error {
+	s := appointmentRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.appointments[appointment.ID]
+	if !ok || stored.Status != appointment.Status {
+		return database.ErrAppointmentStatusChanged
+	}
+	if err := s.insertConsultation(consultation); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	stored.Status = domain.AppointmentConverted
+	stored.ConsultationID = &consultation.ID
+	stored.UpdatedAt = now
+	s.appointments[appointment.ID] = stored
+
+	appointment.Status = domain.AppointmentConverted
+	appointment.ConsultationID = &consultation.ID
+	appointment.UpdatedAt = now
+	return nil
+}
+
+// checkVetCalendar rejects the appointment if its vet cannot attend patients
+// or the slot overlaps one of the vet's active appointments.
+func (s *store) checkVetCalendar(appointment *domain.Appointment) error {
+	vet, ok := s.users[appointment.VetID]
+	if !ok || !vet.Role.Can(domain.PermClinicalNotesWrite) {
+		return database.ErrVetNotFound
+	}
+	for _, other := range s.appointments {
+		if other.VetID == appointment.VetID && other.ID != appointment.ID &&
+			other.Status.BlocksCalendar() &&
+			other.StartsAt.Before(appointment.EndsAt) && other.EndsAt.After(appointment.StartsAt) {
+			return database.ErrAppointmentConflict
+		}
+	}
+	return nil
+}
+
+func appointmentMatches(filter database.AppointmentFilter) func(domain.Appointment) bool {
+	return func(appointment domain.Appointment) bool {
+		return (filter.VetID == nil || appointment.VetID == *filter.VetID) &&
+			(filter.PatientID == nil || appointment.PatientID == *filter.PatientID) &&
+			(filter.Status == nil || appointment.Status == *filter.Status) &&
+			(filter.From == nil || appointment.EndsAt.After(*filter.From)) &&
+			(filter.To == nil || appointment.StartsAt.Before(*filter.To))
+	}
+}
+
+func byStartsAt(a, b domain.Appointment) bool {
+	if !a.StartsAt.Equal(b.StartsAt) {
+		return a.StartsAt.Before(b.StartsAt)
+	}
+	return a.ID < b.ID
+}
@@ -0,0 +1,54 @@
+package memory
+
+import (
+	"context"
+	"vetsys/internal/database"
+	"vetsys/internal/domain"
+)
+
+// AuditRepository only appends and reads, like the append-only audit_log.
+type AuditRepository struct {
+	store *store
+}
+
+func (auditRepository *AuditRepository) CreateEntry(ctx context.Context, entry *domain.AuditEntry) error {
+	s := auditRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry.ID = s.nextID()
+	s.audit = append(s.audit, *entry)
+	return nil
+}
+
+func (auditRepository *AuditRepository) GetEntries(ctx context.Context, filter database.AuditFilter, limit int, offset int) ([]domain.AuditEntry, error) {
+	s := auditRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return page(s.filterEntries(filter), limit, offset), nil
+}
+
+func (auditRepository *AuditRepository) GetEntriesCount(ctx context.Context, filter database.AuditFilter) (int64, error) {
+	s := auditRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return int64(len(s.filterEntries(filter))), nil
+}
+
+// filterEntries returns the matching entries newest first.
+func (s *store) filterEntries(filter database.AuditFilter) []domain.AuditEntry {
+	var entries []domain.AuditEntry
+	for i := len(s.audit) - 1; i >= 0; i-- {
+		entry := s.audit[i]
+		if (filter.Entity == nil || entry.Entity == *filter.Entity) &&
+			(filter.EntityID == nil || entry.EntityID == *filter.EntityID) &&
+			(filter.ActorID == nil || (entry.ActorID != nil && *entry.ActorID == *filter.ActorID)) &&
+			(filter.From == nil || !entry.CreatedAt.Before(*filter.From)) &&
+			(filter.To == nil || entry.CreatedAt.Before(*filter.To)) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
@@ -0,0 +1,280 @@
+package memory
+
+import (
+	"context"
+	"time"
+	"vetsys/internal/database"
+	"vetsys/internal/domain"
+)
+
+// BillingRepository stores invoices without their paid amount, which is
+// summed from the payments whenever an invoice is read.
+type BillingRepository struct {
+	store *store
+}
+
+func (billingRepository *BillingRepository) CreateBillableItem(ctx context.Context, item *domain.BillableItem) error {
+	s := billingRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.consultations[item.ConsultationID]; !ok {
+		return errForeignKey
+	}
+	if item.PrescriptionID != nil {
+		if _, ok := s.prescriptions[*item.PrescriptionID]; !ok {
+			return errForeignKey
+		}
+	}
+	if item.ProductID != nil {
+		if _, ok := s.products[*item.ProductID]; !ok {
+			return errForeignKey
+		}
+		if s.dispensableStock(*item.ProductID) < item.Quantity {
+			return database.ErrInsufficientStock
+		}
+	}
+	item.ID = s.nextID()
+	s.items[item.ID] = *item
+	if item.ProductID != nil {
+		s.dispenseStock(item)
+	}
+	return nil
+}
+
+func (billingRepository *BillingRepository) GetBillableItemByID(ctx context.Context, id int64) (*domain.BillableItem, error) {
+	s := billingRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, ok := s.items[id]
+	if !ok {
+		return nil, database.ErrBillableItemNotFound
+	}
+	return &item, nil
+}
+
+func (billingRepository *BillingRepository) GetBillableItemsByConsultationID(ctx context.Context, consultationID int64) ([]domain.BillableItem, error) {
+	s := billingRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	items := rows(s.items,
+		func(item domain.BillableItem) bool { return item.ConsultationID == consultationID },
+		byItemID)
+	if items == nil {
+		return []domain.BillableItem{}, nil
+	}
+	return items, nil
+}
+
+func (billingRepository *BillingRepository) DeleteBillableItem(ctx context.Context, id int64) error {
+	s := billingRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, ok := s.items[id]
+	if !ok {
+		return database.ErrBillableItemNotFound
+	}
+	if item.InvoiceID != nil {
+		return database.ErrBillableItemInvoiced
+	}
+	s.restockItem(id)
+	for movementID, movement := range s.movements {
+		if movement.BillableItemID != nil && *movement.BillableItemID == id {
+			movement.BillableItemID = nil
+			s.movements[movementID] = movement
+		}
+	}
+	delete(s.items, id)
+	return nil
+}
+
+func (billingRepository *BillingRepository) CreateInvoice(ctx context.Context, clientID int64, issuedBy int64) (*domain.Invoice, error) {
+	s := billingRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.clients[clientID]; !ok {
+		return nil, errForeignKey
+	}
+	items := rows(s.items,
+		func(item domain.BillableItem) bool {
+			return item.InvoiceID == nil && s.patients[s.consultations[item.ConsultationID].PatientID].OwnerID == clientID
+		},
+		byItemID)
+	if len(items) == 0 {
+		return nil, database.ErrNothingToInvoice
+	}
+
+	invoice := &domain.Invoice{
+		ID:       s.nextID(),
+		ClientID: clientID,
+		Status:   domain.InvoiceIssued,
+		IssuedBy: &issuedBy,
+		IssuedAt: time.Now().UTC(),
+	}
+	for i := range items {
+		items[i].InvoiceID = &invoice.ID
+		s.items[items[i].ID] = items[i]
+		invoice.TotalCents += items[i].TotalCents()
+	}
+	s.invoices[invoice.ID] = *invoice
+	invoice.Items = items
+	invoice.Payments = []domain.Payment{}
+	return invoice, nil
+}
+
+func (billingRepository *BillingRepository) GetInvoiceByID(ctx context.Context, id int64) (*domain.Invoice, error) {
+	s := billingRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	invoice, ok := s.invoices[id]
+	if !ok {
+		return nil, database.ErrInvoiceNotFound
+	}
+	invoice.PaidCents = s.paidCents(id)
+	invoice.Items = rows(s.items,
+		func(item domain.BillableItem) bool { return item.InvoiceID != nil && *item.InvoiceID == id },
+		byItemID)
+	if invoice.Items == nil {
+		invoice.Items = []domain.BillableItem{}
+	}
+	invoice.Payments = rows(s.payments,
+		func(payment domain.Payment) bool { return payment.InvoiceID == id },
+		func(a, b domain.Payment) bool {
+			if !a.PaidAt.Equal(b.PaidAt) {
+				return a.PaidAt.Before(b.PaidAt)
+			}
+			return a.ID < b.ID
+		})
+	if invoice.Payments == nil {
+		invoice.Payments = []domain.Payment{}
+	}
+	return &invoice, nil
+}
+
+func (billingRepository *BillingRepository) GetInvoicesByClientID(ctx context.Context, clientID int64, limit int, offset int) ([]domain.Invoice, error) {
+	s := billingRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	invoices := page(rows(s.invoices, invoiceOf(clientID), newestInvoiceFirst), limit, offset)
+	for i := range invoices {
+		invoices[i].PaidCents = s.paidCents(invoices[i].ID)
+	}
+	return invoices, nil
+}
+
+func (billingRepository *BillingRepository) GetInvoicesCountByClientID(ctx context.Context, clientID int64) (int64, error) {
+	s := billingRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return int64(len(rows(s.invoices, invoiceOf(clientID), newestInvoiceFirst))), nil
+}
+
+func (billingRepository *BillingRepository) VoidInvoice(ctx context.Context, id int64) error {
+	s := billingRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	invoice, ok := s.invoices[id]
+	if !ok {
+		return database.ErrInvoiceNotFound
+	}
+	if invoice.Status == domain.InvoiceVoid {
+		return database.ErrInvoiceVoid
+	}
+	for _, payment := range s.payments {
+		if payment.InvoiceID == id {
+			return database.ErrInvoiceHasPayments
+		}
+	}
+
+	for itemID, item := range s.items {
+		if item.InvoiceID != nil && *item.InvoiceID == id {
+			item.InvoiceID = nil
+			s.items[itemID] = item
+		}
+	}
+	voidedAt := time.Now().UTC()
+	invoice.Status = domain.InvoiceVoid
+	invoice.VoidedAt = &voidedAt
+	s.invoices[id] = invoice
+	return nil
+}
+
+func (billingRepository *BillingRepository) CreatePayment(ctx context.Context, payment *domain.Payment) error {
+	s := billingRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	invoice, ok := s.invoices[payment.InvoiceID]
+	if !ok {
+		return database.ErrInvoiceNotFound
+	}
+	if invoice.Status == domain.InvoiceVoid {
+		return database.ErrInvoiceVoid
+	}
+	outstanding := invoice.TotalCents - s.paidCents(invoice.ID)
+	if payment.AmountCents > outstanding {
+		return database.ErrPaymentExceedsBalance
+	}
+
+	payment.ID = s.nextID()
+	s.payments[payment.ID] = *payment
+	if payment.AmountCents == outstanding {
+		invoice.Status = domain.InvoicePaid
+		s.invoices[invoice.ID] = invoice
+	}
+	return nil
+}
+
+func (billingRepository *BillingRepository) GetClientBalance(ctx context.Context, clientID int64) (*domain.ClientBalance, error) {
+	s := billingRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	balance := domain.ClientBalance{ClientID: clientID}
+	for _, invoice := range s.invoices {
+		if invoice.ClientID == clientID && invoice.Status != domain.InvoiceVoid {
+			balance.InvoicedCents += invoice.TotalCents
+			balance.PaidCents += s.paidCents(invoice.ID)
+		}
+	}
+	for _, item := range s.items {
+		if item.InvoiceID == nil && s.patients[s.consultations[item.ConsultationID].PatientID].OwnerID == clientID {
+			balance.UninvoicedCents += item.TotalCents()
+		}
+	}
+	balance.OutstandingCents = balance.InvoicedCents - balance.PaidCents
+	return &balance, nil
+}
+
+func (s *store) paidCents(invoiceID int64) int64 {
+	var paid int64
+	for _, payment := range s.payments {
+		if payment.InvoiceID == invoiceID {
+			paid += payment.AmountCents
+		}
+	}
+	return paid
+}
+
+func invoiceOf(clientID int64) func(domain.Invoice) bool {
+	return func(invoice domain.Invoice) bool { return invoice.ClientID == clientID }
+}
+
+func newestInvoiceFirst(a, b domain.Invoice) bool {
+	if !a.IssuedAt.Equal(b.IssuedAt) {
+		return a.IssuedAt.After(b.IssuedAt)
+	}
+	return a.ID > b.ID
+}
+
+func byItemID(a, b domain.BillableItem) bool {
+	return a.ID < b.ID
+}
@@ -0,0 +1,102 @@
+package memory
+
+import (
+	"context"
+	"vetsys/internal/database"
+	"vetsys/internal/domain"
+)
+
+type ClientRepository struct {
+	store *store
+}
+
+func (clientRepository *ClientRepository) CreateClient(ctx context.Context, client *domain.Client) error {
+	s := clientRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.clientDNITaken(0, client.DNI) {
+		return errDuplicateKey
+	}
+	client.ID = s.nextID()
+	s.clients[client.ID] = *client
+	return nil
+}
+
+func (clientRepository *ClientRepository) GetClientByID(ctx context.Context, id int64) (*domain.Client, error) {
+	s := clientRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	client, ok := s.clients[id]
+	if !ok {
+		return nil, database.ErrClientNotFound
+	}
+	return &client, nil
+}
+
+func (clientRepository *ClientRepository) GetClientByDNI(ctx context.Context, dni string) (*domain.Client, error) {
+	s := clientRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, client := range s.clients {
+		if client.DNI == dni {
+			return &client, nil
+		}
+	}
+	return nil, database.ErrClientNotFound
+}
+
+func (clientRepository *ClientRepository) UpdateClient(ctx context.Context, client *domain.Client) error {
+	s := clientRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.clients[client.ID]; !ok {
+		return database.ErrClientNotFound
+	}
+	if s.clientDNITaken(client.ID, client.DNI) {
+		return errDuplicateKey
+	}
+	s.clients[client.ID] = *client
+	return nil
+}
+
+// DeleteClientByID cascades to the client's patients, unless the client or
+// any of their consultations has been billed.
+func (clientRepository *ClientRepository) DeleteClientByID(ctx context.Context, id int64) error {
+	s := clientRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.clients[id]; !ok {
+		return database.ErrClientNotFound
+	}
+	for _, invoice := range s.invoices {
+		if invoice.ClientID == id {
+			return database.ErrRecordInUse
+		}
+	}
+	for _, patient := range s.patients {
+		if patient.OwnerID == id && s.patientInUse(patient.ID) {
+			return database.ErrRecordInUse
+		}
+	}
+	for patientID, patient := range s.patients {
+		if patient.OwnerID == id {
+			s.deletePatient(patientID)
+		}
+	}
+	delete(s.clients, id)
+	return nil
+}
+
+func (s *store) clientDNITaken(id int64, dni string) bool {
+	for _, client := range s.clients {
+		if client.ID != id && client.DNI == dni {
+			return true
+		}
+	}
+	return false
+}
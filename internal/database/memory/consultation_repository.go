@@ -0,0 +1,181 @@
+package memory
+
+import (
+	"context"
+	"vetsys/internal/database"
+	"vetsys/internal/domain"
+)
+
+type ConsultationRepository struct {
+	store *store
+}
+
+func (consultationRepository *ConsultationRepository) CreateConsultation(ctx context.Context, consultation *domain.Consultation) error {
+	s := consultationRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.insertConsultation(consultation)
+}
+
+func (consultationRepository *ConsultationRepository) GetConsultationByID(ctx context.Context, id int64) (*domain.Consultation, error) {
+	s := consultationRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	consultation, ok := s.consultations[id]
+	if !ok {
+		return nil, database.ErrConsultationNotFound
+	}
+	return &consultation, nil
+}
+
+func (consultationRepository *ConsultationRepository) GetConsultationsByClientID(ctx context.Context, clientID int64, limit int, offset int) ([]domain.Consultation, error) {
+	return consultationRepository.list(consultationRepository.ofClient(clientID), limit, offset), nil
+}
+
+func (consultationRepository *ConsultationRepository) GetConsultationsByPatientID(ctx context.Context, patientID int64, limit int, offset int) ([]domain.Consultation, error) {
+	return consultationRepository.list(ofPatient(patientID), limit, offset), nil
+}
+
+func (consultationRepository *ConsultationRepository) GetAllConsultations(ctx context.Context, limit int, offset int) ([]domain.Consultation, error) {
+	return consultationRepository.list(nil, limit, offset), nil
+}
+
+func (consultationRepository *ConsultationRepository) GetConsultationsByIsCompleted(ctx context.Context, isCompleted bool, limit int, offset int) ([]domain.Consultation, error) {
+	return consultationRepository.list(completed(isCompleted), limit, offset), nil
+}
+
+func (consultationRepository *ConsultationRepository) UpdateConsultation(ctx context.Context, consultation *domain.Consultation) error {
+	s := consultationRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.consultations[consultation.ID]
+	if !ok {
+		return database.ErrConsultationNotFound
+	}
+	if _, ok := s.patients[consultation.PatientID]; !ok {
+		return errForeignKey
+	}
+	updated := *consultation
+	updated.CreatedAt = stored.CreatedAt
+	s.consultations[consultation.ID] = updated
+	return nil
+}
+
+func (consultationRepository *ConsultationRepository) DeleteConsultation(ctx context.Context, id int64) error {
+	s := consultationRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.consultations[id]; !ok {
+		return database.ErrConsultationNotFound
+	}
+	if s.consultationInUse(id) {
+		return database.ErrRecordInUse
+	}
+	s.deleteConsultation(id)
+	return nil
+}
+
+func (consultationRepository *ConsultationRepository) GetAllConsultationsCount(ctx context.Context) (int64, error) {
+	return consultationRepository.count(nil), nil
+}
+
+func (consultationRepository *ConsultationRepository) GetConsultationsByPatientIDCount(ctx context.Context, patientID int64) (int64, error) {
+	return consultationRepository.count(ofPatient(patientID)), nil
+}
+
+func (consultationRepository *ConsultationRepository) GetConsultationsByClientIDCount(ctx context.Context, clientID int64) (int64, error) {
+	return consultationRepository.count(consultationRepository.ofClient(clientID)), nil
+}
+
+func (consultationRepository *ConsultationRepository) GetConsultationsByIsCompletedCount(ctx context.Context, isCompleted bool) (int64, error) {
+	return consultationRepository.count(completed(isCompleted)), nil
+}
+
+func (consultationRepository *ConsultationRepository) list(keep func(domain.Consultation) bool, limit int, offset int) []domain.Consultation {
+	s := consultationRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return page(rows(s.consultations, keep, byConsultationID), limit, offset)
+}
+
+func (consultationRepository *ConsultationRepository) count(keep func(domain.Consultation) bool) int64 {
+	s := consultationRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return int64(len(rows(s.consultations, keep, byConsultationID)))
+}
+
+// ofClient must only be called with the store locked, as list and count do.
+func (consultationRepository *ConsultationRepository) ofClient(clientID int64) func(domain.Consultation) bool {
+	s := consultationRepository.store
+	return func(consultation domain.Consultation) bool {
+		return s.patients[consultation.PatientID].OwnerID == clientID
+	}
+}
+
+func ofPatient(patientID int64) func(domain.Consultation) bool {
+	return func(consultation domain.Consultation) bool { return consultation.PatientID == patientID }
+}
+
+func completed(isCompleted bool) func(domain.Consultation) bool {
+	return func(consultation domain.Consultation) bool { return consultation.IsCompleted == isCompleted }
+}
+
+func byConsultationID(a, b domain.Consultation) bool {
+	return a.ID < b.ID
+}
+
+func (s *store) insertConsultation(consultation *domain.Consultation) error {
+	if _, ok := s.patients[consultation.PatientID]; !ok {
+		return errForeignKey
+	}
+	consultation.ID = s.nextID()
+	s.consultations[consultation.ID] = *consultation
+	return nil
+}
+
+// consultationInUse reports whether the consultation has billable items,
+// which reference it with ON DELETE RESTRICT.
+func (s *store) consultationInUse(id int64) bool {
+	for _, item := range s.items {
+		if item.ConsultationID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// deleteConsultation cascades to the consultation's prescriptions and clears
+// the links appointments, vitals and stock movements keep to it.
+func (s *store) deleteConsultation(id int64) {
+	for prescriptionID, prescription := range s.prescriptions {
+		if prescription.ConsultationID == id {
+			s.deletePrescription(prescriptionID)
+		}
+	}
+	for appointmentID, appointment := range s.appointments {
+		if appointment.ConsultationID != nil && *appointment.ConsultationID == id {
+			appointment.ConsultationID = nil
+			s.appointments[appointmentID] = appointment
+		}
+	}
+	for vitalsID, vitals := range s.vitals {
+		if vitals.ConsultationID != nil && *vitals.ConsultationID == id {
+			vitals.ConsultationID = nil
+			s.vitals[vitalsID] = vitals
+		}
+	}
+	for movementID, movement := range s.movements {
+		if movement.ConsultationID != nil && *movement.ConsultationID == id {
+			movement.ConsultationID = nil
+			s.movements[movementID] = movement
+		}
+	}
+	delete(s.consultations, id)
+}
@@ -0,0 +1,317 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+	"vetsys/internal/database"
+	"vetsys/internal/domain"
+)
+
+// InventoryRepository stores products without their quantity on hand, which
+// is summed from the batches whenever a product is read.
+type InventoryRepository struct {
+	store *store
+}
+
+func (inventoryRepository *InventoryRepository) CreateProduct(ctx context.Context, product *domain.Product) error {
+	s := inventoryRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, other := range s.products {
+		if other.SKU == product.SKU {
+			return database.ErrProductExists
+		}
+	}
+	if err := s.checkProductLinks(product); err != nil {
+		return err
+	}
+	product.ID = s.nextID()
+	stored := *product
+	stored.QuantityOnHand = 0
+	stored.Batches = nil
+	s.products[product.ID] = stored
+	return nil
+}
+
+func (inventoryRepository *InventoryRepository) GetProductByID(ctx context.Context, id int64) (*domain.Product, error) {
+	s := inventoryRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	product, ok := s.products[id]
+	if !ok {
+		return nil, database.ErrProductNotFound
+	}
+	product.QuantityOnHand = s.quantityOnHand(id)
+	product.Batches = s.batchesOf(id, nil)
+	if product.Batches == nil {
+		product.Batches = []domain.StockBatch{}
+	}
+	return &product, nil
+}
+
+func (inventoryRepository *InventoryRepository) GetProducts(ctx context.Context, limit int, offset int) ([]domain.Product, error) {
+	s := inventoryRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	products := page(rows(s.products, nil, byProductName), limit, offset)
+	for i := range products {
+		products[i].QuantityOnHand = s.quantityOnHand(products[i].ID)
+	}
+	return products, nil
+}
+
+func (inventoryRepository *InventoryRepository) GetProductsCount(ctx context.Context) (int64, error) {
+	s := inventoryRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return int64(len(s.products)), nil
+}
+
+func (inventoryRepository *InventoryRepository) UpdateProduct(ctx context.Context, product *domain.Product) error {
+	s := inventoryRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.products[product.ID]
+	if !ok {
+		return database.ErrProductNotFound
+	}
+	if err := s.checkProductLinks(product); err != nil {
+		return err
+	}
+	stored.Name = product.Name
+	stored.Unit = product.Unit
+	stored.MedicationID = product.MedicationID
+	stored.ReorderLevel = product.ReorderLevel
+	s.products[product.ID] = stored
+	return nil
+}
+
+func (inventoryRepository *InventoryRepository) RecordMovement(ctx context.Context, productID int64, lotNumber string, expiryDate *time.Time, movement *domain.StockMovement) error {
+	s := inventoryRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var batch *domain.StockBatch
+	for _, candidate := range s.batches {
+		if candidate.ProductID == productID && candidate.LotNumber == lotNumber {
+			batch = &candidate
+			break
+		}
+	}
+
+	if movement.Kind == domain.MovementPurchase {
+		if _, ok := s.products[productID]; !ok {
+			return database.ErrProductNotFound
+		}
+		if batch == nil {
+			batch = &domain.StockBatch{ID: s.nextID(), ProductID: productID, LotNumber: lotNumber}
+		}
+		if expiryDate != nil {
+			expiry := dateOf(*expiryDate)
+			batch.ExpiryDate = &expiry
+		}
+	} else {
+		if batch == nil {
+			return database.ErrBatchNotFound
+		}
+		if batch.QuantityOnHand+movement.Quantity < 0 {
+			return database.ErrInsufficientStock
+		}
+	}
+	batch.QuantityOnHand += movement.Quantity
+	s.batches[batch.ID] = *batch
+
+	movement.BatchID = batch.ID
+	movement.LotNumber = lotNumber
+	s.insertStockMovement(movement)
+	return nil
+}
+
+func (inventoryRepository *InventoryRepository) GetMovementsByProductID(ctx context.Context, productID int64, limit int, offset int) ([]domain.StockMovement, error) {
+	s := inventoryRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return page(rows(s.movements, s.movementOf(productID), newestMovementFirst), limit, offset), nil
+}
+
+func (inventoryRepository *InventoryRepository) GetMovementsCountByProductID(ctx context.Context, productID int64) (int64, error) {
+	s := inventoryRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return int64(len(rows(s.movements, s.movementOf(productID), newestMovementFirst))), nil
+}
+
+func (inventoryRepository *InventoryRepository) GetInventoryReport(ctx context.Context, until time.Time) (*domain.InventoryReport, error) {
+	s := inventoryRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	report := &domain.InventoryReport{LowStock: []domain.Product{}, Expiring: []domain.ExpiringBatch{}}
+	for _, product := range s.products {
+		product.QuantityOnHand = s.quantityOnHand(product.ID)
+		if product.QuantityOnHand <= product.ReorderLevel {
+			report.LowStock = append(report.LowStock, product)
+		}
+	}
+	sort.Slice(report.LowStock, func(i, j int) bool {
+		a, b := report.LowStock[i], report.LowStock[j]
+		if a.QuantityOnHand-a.ReorderLevel != b.QuantityOnHand-b.ReorderLevel {
+			return a.QuantityOnHand-a.ReorderLevel < b.QuantityOnHand-b.ReorderLevel
+		}
+		return a.Name < b.Name
+	})
+
+	expiring := rows(s.batches,
+		func(batch domain.StockBatch) bool {
+			return batch.QuantityOnHand > 0 && batch.ExpiryDate != nil && !batch.ExpiryDate.After(until)
+		},
+		bySoonestExpiry)
+	for _, batch := range expiring {
+		product := s.products[batch.ProductID]
+		report.Expiring = append(report.Expiring, domain.ExpiringBatch{StockBatch: batch, SKU: product.SKU, Name: product.Name})
+	}
+	return report, nil
+}
+
+func (s *store) checkProductLinks(product *domain.Product) error {
+	if product.MedicationID != nil {
+		if _, ok := s.medications[*product.MedicationID]; !ok {
+			return errForeignKey
+		}
+	}
+	return nil
+}
+
+func (s *store) quantityOnHand(productID int64) int {
+	quantity := 0
+	for _, batch := range s.batches {
+		if batch.ProductID == productID {
+			quantity += batch.QuantityOnHand
+		}
+	}
+	return quantity
+}
+
+// batchesOf returns the product's batches matching keep, soonest expiry first.
+func (s *store) batchesOf(productID int64, keep func(domain.StockBatch) bool) []domain.StockBatch {
+	return rows(s.batches,
+		func(batch domain.StockBatch) bool {
+			return batch.ProductID == productID && (keep == nil || keep(batch))
+		},
+		bySoonestExpiry)
+}
+
+// dispensable selects batches with stock that has not expired.
+func dispensable(batch domain.StockBatch) bool {
+	return batch.QuantityOnHand > 0 && (batch.ExpiryDate == nil || !batch.ExpiryDate.Before(dateOf(time.Now())))
+}
+
+func (s *store) dispensableStock(productID int64) int {
+	quantity := 0
+	for _, batch := range s.batchesOf(productID, dispensable) {
+		quantity += batch.QuantityOnHand
+	}
+	return quantity
+}
+
+// dispenseStock takes the item's quantity of its product from unexpired
+// batches, soonest expiry first. Callers check dispensableStock beforehand,
+// since there is no transaction to roll back.
+func (s *store) dispenseStock(item *domain.BillableItem) {
+	remaining := item.Quantity
+	for _, batch := range s.batchesOf(*item.ProductID, dispensable) {
+		if remaining == 0 {
+			break
+		}
+		taken := min(remaining, batch.QuantityOnHand)
+		batch.QuantityOnHand -= taken
+		s.batches[batch.ID] = batch
+		s.insertStockMovement(&domain.StockMovement{
+			BatchID:        batch.ID,
+			LotNumber:      batch.LotNumber,
+			Kind:           domain.MovementDispense,
+			Quantity:       -taken,
+			ConsultationID: &item.ConsultationID,
+			BillableItemID: &item.ID,
+			CreatedAt:      time.Now().UTC(),
+		})
+		remaining -= taken
+	}
+}
+
+// restockItem returns what was dispensed for a billable item to the batches
+// it came from.
+func (s *store) restockItem(itemID int64) {
+	for _, movement := range s.movements {
+		if movement.Kind != domain.MovementDispense || movement.BillableItemID == nil || *movement.BillableItemID != itemID {
+			continue
+		}
+		batch := s.batches[movement.BatchID]
+		batch.QuantityOnHand -= movement.Quantity
+		s.batches[batch.ID] = batch
+		s.insertStockMovement(&domain.StockMovement{
+			BatchID:        movement.BatchID,
+			LotNumber:      movement.LotNumber,
+			Kind:           domain.MovementAdjustment,
+			Quantity:       -movement.Quantity,
+			ConsultationID: movement.ConsultationID,
+			BillableItemID: &itemID,
+			Reason:         "Billable item removed",
+			CreatedAt:      time.Now().UTC(),
+		})
+	}
+}
+
+func (s *store) insertStockMovement(movement *domain.StockMovement) {
+	movement.ID = s.nextID()
+	s.movements[movement.ID] = *movement
+}
+
+// movementOf must only be called with the store locked.
+func (s *store) movementOf(productID int64) func(domain.StockMovement) bool {
+	return func(movement domain.StockMovement) bool {
+		return s.batches[movement.BatchID].ProductID == productID
+	}
+}
+
+// dateOf truncates t to the UTC day, as Postgres stores expiry_date as DATE.
+func dateOf(t time.Time) time.Time {
+	year, month, day := t.UTC().Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func byProductName(a, b domain.Product) bool {
+	if a.Name != b.Name {
+		return a.Name < b.Name
+	}
+	return a.ID < b.ID
+}
+
+func bySoonestExpiry(a, b domain.StockBatch) bool {
+	switch {
+	case a.ExpiryDate == nil && b.ExpiryDate == nil:
+		return a.ID < b.ID
+	case a.ExpiryDate == nil:
+		return false
+	case b.ExpiryDate == nil:
+		return true
+	case !a.ExpiryDate.Equal(*b.ExpiryDate):
+		return a.ExpiryDate.Before(*b.ExpiryDate)
+	}
+	return a.ID < b.ID
+}
+
+func newestMovementFirst(a, b domain.StockMovement) bool {
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.After(b.CreatedAt)
+	}
+	return a.ID > b.ID
+}
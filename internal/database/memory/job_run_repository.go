@@ -0,0 +1,35 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// JobRunRepository keeps its own state: no unit of work touches job runs, and
+// a held job lock must not block the other stores.
+type JobRunRepository struct {
+	mu      sync.Mutex
+	lastDue map[string]time.Time
+	running map[string]bool
+}
+
+func (jobRunRepo *JobRunRepository) ClaimRun(ctx context.Context, name string, due time.Time) (func(), bool, error) {
+	jobRunRepo.mu.Lock()
+	defer jobRunRepo.mu.Unlock()
+
+	if jobRunRepo.running[name] {
+		return nil, false, nil
+	}
+	if last, ok := jobRunRepo.lastDue[name]; ok && !last.Before(due) {
+		return nil, false, nil
+	}
+	jobRunRepo.lastDue[name] = due
+	jobRunRepo.running[name] = true
+	release := func() {
+		jobRunRepo.mu.Lock()
+		defer jobRunRepo.mu.Unlock()
+		delete(jobRunRepo.running, name)
+	}
+	return release, true, nil
+}
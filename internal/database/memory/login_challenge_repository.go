@@ -0,0 +1,91 @@
+package memory
+
+import (
+	"context"
+	"time"
+	"vetsys/internal/database"
+	"vetsys/internal/domain"
+)
+
+type LoginChallengeRepository struct {
+	store *store
+}
+
+func (challengeRepo *LoginChallengeRepository) CreateChallenge(ctx context.Context, challenge *domain.LoginChallenge) error {
+	s := challengeRepo.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[challenge.UserID]; !ok {
+		return errForeignKey
+	}
+	if _, ok := s.challenges[challenge.TokenHash]; ok {
+		return errDuplicateKey
+	}
+	s.challenges[challenge.TokenHash] = *challenge
+	return nil
+}
+
+func (challengeRepo *LoginChallengeRepository) GetChallenge(ctx context.Context, tokenHash string) (*domain.LoginChallenge, error) {
+	s := challengeRepo.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	challenge, ok := s.challenges[tokenHash]
+	if !ok || !challenge.ExpiresAt.After(time.Now().UTC()) {
+		return nil, database.ErrChallengeInvalid
+	}
+	return &challenge, nil
+}
+
+func (challengeRepo *LoginChallengeRepository) FailChallenge(ctx context.Context, tokenHash string, maxAttempts int) error {
+	s := challengeRepo.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	challenge, ok := s.challenges[tokenHash]
+	if !ok {
+		return nil
+	}
+	challenge.Attempts++
+	if challenge.Attempts >= maxAttempts {
+		delete(s.challenges, tokenHash)
+		return nil
+	}
+	s.challenges[tokenHash] = challenge
+	return nil
+}
+
+func (challengeRepo *LoginChallengeRepository) DeleteChallenge(ctx context.Context, tokenHash string) error {
+	s := challengeRepo.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.challenges[tokenHash]; !ok {
+		return database.ErrChallengeInvalid
+	}
+	delete(s.challenges, tokenHash)
+	return nil
+}
+
+func (challengeRepo *LoginChallengeRepository) DeleteExpiredChallenges(ctx context.Context) error {
+	s := challengeRepo.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC()
+	for hash, challenge := range s.challenges {
+		if challenge.ExpiresAt.Before(now) {
+			delete(s.challenges, hash)
+		}
+	}
+	return nil
+}
+
+func (s *store) deleteChallenges(userID int64) {
+	for hash, challenge := range s.challenges {
+		if challenge.UserID == userID {
+			delete(s.challenges, hash)
+		}
+	}
+}
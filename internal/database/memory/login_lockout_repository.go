@@ -0,0 +1,92 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+	"vetsys/internal/database"
+	"vetsys/internal/domain"
+)
+
+type lockoutKey struct {
+	scope domain.LockoutScope
+	key   string
+}
+
+type LoginLockoutRepository struct {
+	store *store
+}
+
+func (lockoutRepo *LoginLockoutRepository) GetLockout(ctx context.Context, scope domain.LockoutScope, key string) (*domain.LoginLockout, error) {
+	s := lockoutRepo.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	lockout, ok := s.lockouts[lockoutKey{scope, key}]
+	if !ok {
+		return nil, database.ErrLockoutNotFound
+	}
+	return &lockout, nil
+}
+
+func (lockoutRepo *LoginLockoutRepository) GetLockouts(ctx context.Context) ([]domain.LoginLockout, error) {
+	s := lockoutRepo.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	lockouts := []domain.LoginLockout{}
+	for _, lockout := range s.lockouts {
+		lockouts = append(lockouts, lockout)
+	}
+	sort.Slice(lockouts, func(i, j int) bool {
+		a, b := lockouts[i].LockedUntil, lockouts[j].LockedUntil
+		if (a == nil) != (b == nil) {
+			return a != nil
+		}
+		if a != nil && !a.Equal(*b) {
+			return a.After(*b)
+		}
+		return lockouts[i].LastFailureAt.After(lockouts[j].LastFailureAt)
+	})
+	return lockouts, nil
+}
+
+func (lockoutRepo *LoginLockoutRepository) RecordFailure(ctx context.Context, scope domain.LockoutScope, key string, policy domain.LockoutPolicy, now time.Time) (*domain.LoginLockout, error) {
+	s := lockoutRepo.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	lockout, ok := s.lockouts[lockoutKey{scope, key}]
+	if !ok {
+		lockout = domain.LoginLockout{Scope: scope, Key: key, LastFailureAt: now}
+	}
+	policy.RecordFailure(&lockout, now)
+	s.lockouts[lockoutKey{scope, key}] = lockout
+	return &lockout, nil
+}
+
+func (lockoutRepo *LoginLockoutRepository) DeleteLockout(ctx context.Context, scope domain.LockoutScope, key string) error {
+	s := lockoutRepo.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.lockouts[lockoutKey{scope, key}]; !ok {
+		return database.ErrLockoutNotFound
+	}
+	delete(s.lockouts, lockoutKey{scope, key})
+	return nil
+}
+
+func (lockoutRepo *LoginLockoutRepository) DeleteStaleLockouts(ctx context.Context, before time.Time) error {
+	s := lockoutRepo.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC()
+	for k, lockout := range s.lockouts {
+		if lockout.LastFailureAt.Before(before) && !lockout.IsLocked(now) {
+			delete(s.lockouts, k)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+// Package memory implements the database stores in process memory. It is a
+// stand-in for Postgres in handler tests and local experiments: the stores
+// return the same sentinel errors and mirror the foreign-key cascades of the
+// schema, but nothing is persisted.
+package memory
+
+import (
+	"sort"
+	"sync"
+	"time"
+	"vetsys/internal/database"
+	"vetsys/internal/domain"
+
+	"github.com/lib/pq"
+)
+
+// errDuplicateKey and errForeignKey are the constraint errors Postgres returns
+// where the repositories do not map them to a sentinel, with the same SQLSTATE
+// so callers that inspect the code see no difference.
+var (
+	errDuplicateKey = &pq.Error{Code: "23505", Message: "duplicate key value violates unique constraint"}
+	errForeignKey   = &pq.Error{Code: "23503", Message: "insert or update violates foreign key constraint"}
+)
+
+// store holds every table behind one lock, so stores that join across tables
+// see a consistent snapshot.
+type store struct {
+	mu     sync.Mutex
+	lastID int64
+
+	users          map[int64]domain.User
+	sessions       map[string]domain.Session
+	registrations  map[string]domain.AllowedRegistration
+	clients        map[int64]domain.Client
+	patients       map[int64]domain.Patient
+	consultations  map[int64]domain.Consultation
+	appointments   map[int64]domain.Appointment
+	audit          []domain.AuditEntry
+	vaccines       map[int64]domain.Vaccine
+	vaccinations   map[int64]domain.Vaccination
+	medications    map[int64]domain.Medication
+	prescriptions  map[int64]domain.Prescription
+	vitals         map[int64]domain.Vitals
+	items          map[int64]domain.BillableItem
+	invoices       map[int64]domain.Invoice
+	payments       map[int64]domain.Payment
+	products       map[int64]domain.Product
+	batches        map[int64]domain.StockBatch
+	movements      map[int64]domain.StockMovement
+	lockouts       map[lockoutKey]domain.LoginLockout
+	resetTokens    map[string]domain.PasswordResetToken
+	twoFactors     map[int64]domain.TwoFactor
+	recoveryCodes  map[int64]map[string]bool
+	twoFactorRoles map[domain.Role]bool
+	challenges     map[string]domain.LoginChallenge
+}
+
+type DataBase struct {
+	UserRepo                 *UserRepository
+	PatientRepo              *PatientRepository
+	ClientRepo               *ClientRepository
+	ConsultationRepo         *ConsultationRepository
+	SessionRepo              *SessionRepository
+	AllowedRegistrationsRepo *AllowedRegistrationRepository
+	AppointmentRepo          *AppointmentRepository
+	AuditRepo                *AuditRepository
+	SearchRepo               *SearchRepository
+	VaccinationRepo          *VaccinationRepository
+	PrescriptionRepo         *PrescriptionRepository
+	VitalsRepo               *VitalsRepository
+	BillingRepo              *BillingRepository
+	InventoryRepo            *InventoryRepository
+	UnitOfWork               *Transactor
+	JobRunRepo               *JobRunRepository
+	LoginLockoutRepo         *LoginLockoutRepository
+	PasswordResetRepo        *PasswordResetRepository
+	TwoFactorRepo            *TwoFactorRepository
+	LoginChallengeRepo       *LoginChallengeRepository
+}
+
+// NewDataBase returns empty stores that share one in-memory database.
+func NewDataBase() *DataBase {
+	s := &store{
+		users:          map[int64]domain.User{},
+		sessions:       map[string]domain.Session{},
+		registrations:  map[string]domain.AllowedRegistration{},
+		clients:        map[int64]domain.Client{},
+		patients:       map[int64]domain.Patient{},
+		consultations:  map[int64]domain.Consultation{},
+		appointments:   map[int64]domain.Appointment{},
+		vaccines:       map[int64]domain.Vaccine{},
+		vaccinations:   map[int64]domain.Vaccination{},
+		medications:    map[int64]domain.Medication{},
+		prescriptions:  map[int64]domain.Prescription{},
+		vitals:         map[int64]domain.Vitals{},
+		items:          map[int64]domain.BillableItem{},
+		invoices:       map[int64]domain.Invoice{},
+		payments:       map[int64]domain.Payment{},
+		products:       map[int64]domain.Product{},
+		batches:        map[int64]domain.StockBatch{},
+		movements:      map[int64]domain.StockMovement{},
+		lockouts:       map[lockoutKey]domain.LoginLockout{},
+		resetTokens:    map[string]domain.PasswordResetToken{},
+		twoFactors:     map[int64]domain.TwoFactor{},
+		recoveryCodes:  map[int64]map[string]bool{},
+		twoFactorRoles: map[domain.Role]bool{},
+		challenges:     map[string]domain.LoginChallenge{},
+	}
+	return &DataBase{
+		UserRepo:                 &UserRepository{store: s},
+		PatientRepo:              &PatientRepository{store: s},
+		ClientRepo:               &ClientRepository{store: s},
+		ConsultationRepo:         &ConsultationRepository{store: s},
+		SessionRepo:              &SessionRepository{store: s},
+		AllowedRegistrationsRepo: &AllowedRegistrationRepository{store: s},
+		AppointmentRepo:          &AppointmentRepository{store: s},
+		AuditRepo:                &AuditRepository{store: s},
+		SearchRepo:               &SearchRepository{store: s},
+		VaccinationRepo:          &VaccinationRepository{store: s},
+		PrescriptionRepo:         &PrescriptionRepository{store: s},
+		VitalsRepo:               &VitalsRepository{store: s},
+		BillingRepo:              &BillingRepository{store: s},
+		InventoryRepo:            &InventoryRepository{store: s},
+		UnitOfWork:               &Transactor{store: s},
+		JobRunRepo:               &JobRunRepository{lastDue: map[string]time.Time{}, running: map[string]bool{}},
+		LoginLockoutRepo:         &LoginLockoutRepository{store: s},
+		PasswordResetRepo:        &PasswordResetRepository{store: s},
+		TwoFactorRepo:            &TwoFactorRepository{store: s},
+		LoginChallengeRepo:       &LoginChallengeRepository{store: s},
+	}
+}
+
+var (
+	_ database.UserStore                = (*UserRepository)(nil)
+	_ database.SessionStore             = (*SessionRepository)(nil)
+	_ database.AllowedRegistrationStore = (*AllowedRegistrationRepository)(nil)
+	_ database.ClientStore              = (*ClientRepository)(nil)
+	_ database.PatientStore             = (*PatientRepository)(nil)
+	_ database.ConsultationStore        = (*ConsultationRepository)(nil)
+	_ database.AppointmentStore         = (*AppointmentRepository)(nil)
+	_ database.AuditStore               = (*AuditRepository)(nil)
+	_ database.SearchStore              = (*SearchRepository)(nil)
+	_ database.VaccinationStore         = (*VaccinationRepository)(nil)
+	_ database.PrescriptionStore        = (*PrescriptionRepository)(nil)
+	_ database.VitalsStore              = (*VitalsRepository)(nil)
+	_ database.BillingStore             = (*BillingRepository)(nil)
+	_ database.InventoryStore           = (*InventoryRepository)(nil)
+	_ database.UnitOfWork               = (*Transactor)(nil)
+	_ database.JobRunStore              = (*JobRunRepository)(nil)
+	_ database.LoginLockoutStore        = (*LoginLockoutRepository)(nil)
+	_ database.PasswordResetStore       = (*PasswordResetRepository)(nil)
+	_ database.TwoFactorStore           = (*TwoFactorRepository)(nil)
+	_ database.LoginChallengeStore      = (*LoginChallengeRepository)(nil)
+)
+
+// nextID plays the role of the BIGSERIAL sequences. IDs are unique across
+// tables, which no caller relies on either way.
+func (s *store) nextID() int64 {
+	s.lastID++
+	return s.lastID
+}
+
+// rows returns a table's rows matching keep, ordered by less.
+func rows[T any](table map[int64]T, keep func(T) bool, less func(a, b T) bool) []T {
+	var result []T
+	for _, row := range table {
+		if keep == nil || keep(row) {
+			result = append(result, row)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return less(result[i], result[j]) })
+	return result
+}
+
+// page applies LIMIT and OFFSET. Like sqlx's Select it returns nil rather
+// than an empty slice when nothing matches.
+func page[T any](rows []T, limit int, offset int) []T {
+	if offset >= len(rows) {
+		return nil
+	}
+	rows = rows[offset:]
+	if limit < len(rows) {
+		rows = rows[:limit]
+	}
+	return rows
+}
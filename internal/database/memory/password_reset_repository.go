@@ -0,0 +1,60 @@
+package memory
+
+import (
+	"context"
+	"time"
+	"vetsys/internal/database"
+	"vetsys/internal/domain"
+)
+
+type PasswordResetRepository struct {
+	store *store
+}
+
+func (resetRepo *PasswordResetRepository) CreateResetToken(ctx context.Context, token *domain.PasswordResetToken) error {
+	s := resetRepo.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[token.UserID]; !ok {
+		return errForeignKey
+	}
+	s.deleteResetTokens(token.UserID)
+	s.resetTokens[token.TokenHash] = *token
+	return nil
+}
+
+func (resetRepo *PasswordResetRepository) ConsumeResetToken(ctx context.Context, tokenHash string) (int64, error) {
+	s := resetRepo.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	token, ok := s.resetTokens[tokenHash]
+	if !ok || !token.ExpiresAt.After(time.Now().UTC()) {
+		return 0, database.ErrResetTokenInvalid
+	}
+	s.deleteResetTokens(token.UserID)
+	return token.UserID, nil
+}
+
+func (resetRepo *PasswordResetRepository) DeleteExpiredResetTokens(ctx context.Context) error {
+	s := resetRepo.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC()
+	for hash, token := range s.resetTokens {
+		if token.ExpiresAt.Before(now) {
+			delete(s.resetTokens, hash)
+		}
+	}
+	return nil
+}
+
+func (s *store) deleteResetTokens(userID int64) {
+	for hash, token := range s.resetTokens {
+		if token.UserID == userID {
+			delete(s.resetTokens, hash)
+		}
+	}
+}
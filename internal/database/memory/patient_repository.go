@@ -0,0 +1,113 @@
+package memory
+
+import (
+	"context"
+	"vetsys/internal/database"
+	"vetsys/internal/domain"
+)
+
+type PatientRepository struct {
+	store *store
+}
+
+func (patientRepository *PatientRepository) CreatePatient(ctx context.Context, patient *domain.Patient) error {
+	s := patientRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.clients[patient.OwnerID]; !ok {
+		return errForeignKey
+	}
+	patient.ID = s.nextID()
+	s.patients[patient.ID] = *patient
+	return nil
+}
+
+func (patientRepository *PatientRepository) GetPatientByID(ctx context.Context, id int64) (*domain.Patient, error) {
+	s := patientRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	patient, ok := s.patients[id]
+	if !ok {
+		return nil, database.ErrPatientNotFound
+	}
+	return &patient, nil
+}
+
+func (patientRepository *PatientRepository) GetPatientsByOwner(ctx context.Context, ownerID int64) ([]domain.Patient, error) {
+	s := patientRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return rows(s.patients,
+		func(patient domain.Patient) bool { return patient.OwnerID == ownerID },
+		func(a, b domain.Patient) bool { return a.ID < b.ID }), nil
+}
+
+func (patientRepository *PatientRepository) UpdatePatient(ctx context.Context, patient *domain.Patient) error {
+	s := patientRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.patients[patient.ID]; !ok {
+		return database.ErrPatientNotFound
+	}
+	if _, ok := s.clients[patient.OwnerID]; !ok {
+		return errForeignKey
+	}
+	s.patients[patient.ID] = *patient
+	return nil
+}
+
+func (patientRepository *PatientRepository) DeletePatientByID(ctx context.Context, id int64) error {
+	s := patientRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.patients[id]; !ok {
+		return database.ErrPatientNotFound
+	}
+	if s.patientInUse(id) {
+		return database.ErrRecordInUse
+	}
+	s.deletePatient(id)
+	return nil
+}
+
+// patientInUse reports whether any of the patient's consultations has been
+// billed, which blocks the cascade.
+func (s *store) patientInUse(id int64) bool {
+	for _, consultation := range s.consultations {
+		if consultation.PatientID == id && s.consultationInUse(consultation.ID) {
+			return true
+		}
+	}
+	return false
+}
+
+// deletePatient cascades to the patient's consultations, appointments,
+// vaccinations and vitals.
+func (s *store) deletePatient(id int64) {
+	for consultationID, consultation := range s.consultations {
+		if consultation.PatientID == id {
+			s.deleteConsultation(consultationID)
+		}
+	}
+	for appointmentID, appointment := range s.appointments {
+		if appointment.PatientID == id {
+			delete(s.appointments, appointmentID)
+		}
+	}
+	for vaccinationID, vaccination := range s.vaccinations {
+		if vaccination.PatientID == id {
+			delete(s.vaccinations, vaccinationID)
+		}
+	}
+	for vitalsID, vitals := range s.vitals {
+		if vitals.PatientID == id {
+			delete(s.vitals, vitalsID)
+		}
+	}
+	delete(s.patients, id)
+}
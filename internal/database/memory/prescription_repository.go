@@ -0,0 +1,203 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"vetsys/internal/database"
+	"vetsys/internal/domain"
+)
+
+// PrescriptionRepository keeps each medication's dose ranges inside the
+// stored medication rather than in a table of their own.
+type PrescriptionRepository struct {
+	store *store
+}
+
+func (prescriptionRepository *PrescriptionRepository) CreateMedication(ctx context.Context, medication *domain.Medication) error {
+	s := prescriptionRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, other := range s.medications {
+		if other.Name == medication.Name {
+			return database.ErrMedicationExists
+		}
+	}
+	doseRanges := make([]domain.MedicationDoseRange, 0, len(medication.DoseRanges))
+	seen := map[string]bool{}
+	for _, doseRange := range medication.DoseRanges {
+		if seen[doseRange.Species] {
+			return errDuplicateKey
+		}
+		seen[doseRange.Species] = true
+		doseRanges = append(doseRanges, doseRange)
+	}
+
+	medication.ID = s.nextID()
+	for i := range medication.DoseRanges {
+		medication.DoseRanges[i].MedicationID = medication.ID
+		doseRanges[i].MedicationID = medication.ID
+	}
+	stored := *medication
+	stored.DoseRanges = doseRanges
+	s.medications[medication.ID] = stored
+	return nil
+}
+
+func (prescriptionRepository *PrescriptionRepository) GetMedicationByID(ctx context.Context, id int64) (*domain.Medication, error) {
+	s := prescriptionRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	medication, ok := s.medications[id]
+	if !ok {
+		return nil, database.ErrMedicationNotFound
+	}
+	medication = copyMedication(medication)
+	return &medication, nil
+}
+
+func (prescriptionRepository *PrescriptionRepository) GetMedications(ctx context.Context, limit int, offset int) ([]domain.Medication, error) {
+	s := prescriptionRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	medications := page(rows(s.medications, nil, byMedicationName), limit, offset)
+	for i := range medications {
+		medications[i] = copyMedication(medications[i])
+	}
+	return medications, nil
+}
+
+func (prescriptionRepository *PrescriptionRepository) GetMedicationsCount(ctx context.Context) (int64, error) {
+	s := prescriptionRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return int64(len(s.medications)), nil
+}
+
+func (prescriptionRepository *PrescriptionRepository) SetDoseRange(ctx context.Context, doseRange *domain.MedicationDoseRange) error {
+	s := prescriptionRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	medication, ok := s.medications[doseRange.MedicationID]
+	if !ok {
+		return database.ErrMedicationNotFound
+	}
+	doseRanges := []domain.MedicationDoseRange{*doseRange}
+	for _, other := range medication.DoseRanges {
+		if other.Species != doseRange.Species {
+			doseRanges = append(doseRanges, other)
+		}
+	}
+	medication.DoseRanges = doseRanges
+	s.medications[medication.ID] = medication
+	return nil
+}
+
+func (prescriptionRepository *PrescriptionRepository) DeleteDoseRange(ctx context.Context, medicationID int64, species string) error {
+	s := prescriptionRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	medication, ok := s.medications[medicationID]
+	if !ok {
+		return database.ErrDoseRangeNotFound
+	}
+	doseRanges := []domain.MedicationDoseRange{}
+	for _, doseRange := range medication.DoseRanges {
+		if doseRange.Species != species {
+			doseRanges = append(doseRanges, doseRange)
+		}
+	}
+	if len(doseRanges) == len(medication.DoseRanges) {
+		return database.ErrDoseRangeNotFound
+	}
+	medication.DoseRanges = doseRanges
+	s.medications[medicationID] = medication
+	return nil
+}
+
+func (prescriptionRepository *PrescriptionRepository) CreatePrescription(ctx context.Context, prescription *domain.Prescription) error {
+	s := prescriptionRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.consultations[prescription.ConsultationID]; !ok {
+		return errForeignKey
+	}
+	if _, ok := s.medications[prescription.MedicationID]; !ok {
+		return errForeignKey
+	}
+	prescription.ID = s.nextID()
+	stored := *prescription
+	stored.Warnings = append([]string{}, prescription.Warnings...)
+	s.prescriptions[prescription.ID] = stored
+	return nil
+}
+
+func (prescriptionRepository *PrescriptionRepository) GetPrescriptionByID(ctx context.Context, id int64) (*domain.Prescription, error) {
+	s := prescriptionRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prescription, ok := s.prescriptions[id]
+	if !ok {
+		return nil, database.ErrPrescriptionNotFound
+	}
+	prescription.Warnings = append([]string{}, prescription.Warnings...)
+	return &prescription, nil
+}
+
+func (prescriptionRepository *PrescriptionRepository) GetPrescriptionsByConsultationID(ctx context.Context, consultationID int64) ([]domain.Prescription, error) {
+	s := prescriptionRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prescriptions := rows(s.prescriptions,
+		func(prescription domain.Prescription) bool { return prescription.ConsultationID == consultationID },
+		func(a, b domain.Prescription) bool { return a.ID < b.ID })
+	if prescriptions == nil {
+		return []domain.Prescription{}, nil
+	}
+	for i := range prescriptions {
+		prescriptions[i].Warnings = append([]string{}, prescriptions[i].Warnings...)
+	}
+	return prescriptions, nil
+}
+
+func (prescriptionRepository *PrescriptionRepository) DeletePrescriptionByID(ctx context.Context, id int64) error {
+	s := prescriptionRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.prescriptions[id]; !ok {
+		return database.ErrPrescriptionNotFound
+	}
+	s.deletePrescription(id)
+	return nil
+}
+
+// deletePrescription clears the link billable items keep to the prescription.
+func (s *store) deletePrescription(id int64) {
+	for itemID, item := range s.items {
+		if item.PrescriptionID != nil && *item.PrescriptionID == id {
+			item.PrescriptionID = nil
+			s.items[itemID] = item
+		}
+	}
+	delete(s.prescriptions, id)
+}
+
+func copyMedication(medication domain.Medication) domain.Medication {
+	doseRanges := append([]domain.MedicationDoseRange{}, medication.DoseRanges...)
+	sort.Slice(doseRanges, func(i, j int) bool { return doseRanges[i].Species < doseRanges[j].Species })
+	medication.DoseRanges = doseRanges
+	return medication
+}
+
+func byMedicationName(a, b domain.Medication) bool {
+	return a.Name < b.Name
+}
@@ -0,0 +1,168 @@
+package memory
+
+import (
+	"context"
+	"strings"
+	"unicode"
+	"vetsys/internal/database"
+	"vetsys/internal/domain"
+)
+
+// SearchRepository matches the same fields as the search_vector columns, but
+// does not rank: results come back in the order the tie-breakers of the
+// Postgres queries would give them.
+type SearchRepository struct {
+	store *store
+}
+
+// maxSearchTerms matches the bound the Postgres repository applies.
+const maxSearchTerms = 8
+
+func (searchRepository *SearchRepository) SearchClients(ctx context.Context, q string, limit int, offset int) ([]domain.Client, error) {
+	clients, err := searchRepository.clients(q)
+	if err != nil {
+		return nil, err
+	}
+	return page(clients, limit, offset), nil
+}
+
+func (searchRepository *SearchRepository) SearchClientsCount(ctx context.Context, q string) (int64, error) {
+	clients, err := searchRepository.clients(q)
+	return int64(len(clients)), err
+}
+
+func (searchRepository *SearchRepository) SearchPatients(ctx context.Context, q string, limit int, offset int) ([]domain.Patient, error) {
+	patients, err := searchRepository.patients(q)
+	if err != nil {
+		return nil, err
+	}
+	return page(patients, limit, offset), nil
+}
+
+func (searchRepository *SearchRepository) SearchPatientsCount(ctx context.Context, q string) (int64, error) {
+	patients, err := searchRepository.patients(q)
+	return int64(len(patients)), err
+}
+
+func (searchRepository *SearchRepository) SearchConsultations(ctx context.Context, q string, limit int, offset int) ([]domain.Consultation, error) {
+	consultations, err := searchRepository.consultations(q)
+	if err != nil {
+		return nil, err
+	}
+	return page(consultations, limit, offset), nil
+}
+
+func (searchRepository *SearchRepository) SearchConsultationsCount(ctx context.Context, q string) (int64, error) {
+	consultations, err := searchRepository.consultations(q)
+	return int64(len(consultations)), err
+}
+
+func (searchRepository *SearchRepository) clients(q string) ([]domain.Client, error) {
+	terms, err := searchTerms(q)
+	if err != nil {
+		return nil, err
+	}
+	s := searchRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return rows(s.clients,
+		func(client domain.Client) bool {
+			return matchesTerms(terms, client.Name, digitsOnly(client.PhoneNumber))
+		},
+		func(a, b domain.Client) bool { return a.ID < b.ID }), nil
+}
+
+func (searchRepository *SearchRepository) patients(q string) ([]domain.Patient, error) {
+	terms, err := searchTerms(q)
+	if err != nil {
+		return nil, err
+	}
+	s := searchRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return rows(s.patients,
+		func(patient domain.Patient) bool {
+			return matchesTerms(terms, patient.Name, patient.Species, patient.Breed)
+		},
+		func(a, b domain.Patient) bool { return a.ID < b.ID }), nil
+}
+
+func (searchRepository *SearchRepository) consultations(q string) ([]domain.Consultation, error) {
+	terms, err := searchTerms(q)
+	if err != nil {
+		return nil, err
+	}
+	s := searchRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return rows(s.consultations,
+		func(consultation domain.Consultation) bool {
+			return matchesTerms(terms, consultation.Reason, consultation.Diagnosis)
+		},
+		func(a, b domain.Consultation) bool {
+			if !a.CreatedAt.Equal(b.CreatedAt) {
+				return a.CreatedAt.After(b.CreatedAt)
+			}
+			return a.ID < b.ID
+		}), nil
+}
+
+// searchTerms splits a query the way the Postgres repository builds its
+// prefix tsquery: a query without letters is a phone number reduced to its
+// digits, anything else is lower-cased words.
+func searchTerms(q string) ([]string, error) {
+	var terms []string
+	if strings.IndexFunc(q, unicode.IsLetter) < 0 {
+		if digits := digitsOnly(q); digits != "" {
+			terms = append(terms, digits)
+		}
+	} else {
+		terms = words(q)
+	}
+	if len(terms) == 0 {
+		return nil, database.ErrEmptySearchQuery
+	}
+	if len(terms) > maxSearchTerms {
+		terms = terms[:maxSearchTerms]
+	}
+	return terms, nil
+}
+
+// matchesTerms reports whether every term is a prefix of some word of fields.
+func matchesTerms(terms []string, fields ...string) bool {
+	var document []string
+	for _, field := range fields {
+		document = append(document, words(field)...)
+	}
+	for _, term := range terms {
+		found := false
+		for _, word := range document {
+			if strings.HasPrefix(word, term) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func digitsOnly(text string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, text)
+}
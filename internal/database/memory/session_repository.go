@@ -0,0 +1,129 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+	"vetsys/internal/database"
+	"vetsys/internal/domain"
+)
+
+type SessionRepository struct {
+	store *store
+}
+
+func (sessionRepo *SessionRepository) CreateSession(ctx context.Context, session *domain.Session) error {
+	s := sessionRepo.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.sessions[session.ID]; ok {
+		return errDuplicateKey
+	}
+	s.sessions[session.ID] = *session
+	return nil
+}
+
+func (sessionRepo *SessionRepository) GetSession(ctx context.Context, id string) (*domain.Session, error) {
+	s := sessionRepo.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[id]
+	if !ok || !session.ExpiresAt.After(time.Now().UTC()) {
+		return nil, database.ErrSessionNotFound
+	}
+	return &session, nil
+}
+
+func (sessionRepo *SessionRepository) GetSessionsByUserID(ctx context.Context, userID int64) ([]domain.Session, error) {
+	s := sessionRepo.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC()
+	sessions := []domain.Session{}
+	for _, session := range s.sessions {
+		if session.UserID == userID && session.ExpiresAt.After(now) {
+			sessions = append(sessions, session)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		if !sessions[i].LastSeenAt.Equal(sessions[j].LastSeenAt) {
+			return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt)
+		}
+		return sessions[i].CreatedAt.After(sessions[j].CreatedAt)
+	})
+	return sessions, nil
+}
+
+func (sessionRepo *SessionRepository) TouchSession(ctx context.Context, id string, lastSeenAt time.Time, expiresAt time.Time) error {
+	s := sessionRepo.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[id]
+	if !ok {
+		return database.ErrSessionNotFound
+	}
+	session.LastSeenAt = lastSeenAt
+	session.ExpiresAt = expiresAt
+	s.sessions[id] = session
+	return nil
+}
+
+func (sessionRepo *SessionRepository) DeleteSessionsByUserID(ctx context.Context, userID int64, exceptID string) (int64, error) {
+	s := sessionRepo.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var deleted int64
+	for id, session := range s.sessions {
+		if session.UserID == userID && id != exceptID {
+			delete(s.sessions, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func (sessionRepo *SessionRepository) DeleteSessionByID(ctx context.Context, id string) error {
+	s := sessionRepo.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.sessions[id]; !ok {
+		return database.ErrSessionNotFound
+	}
+	delete(s.sessions, id)
+	return nil
+}
+
+func (sessionRepo *SessionRepository) DeleteOldSessions(ctx context.Context) error {
+	s := sessionRepo.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC()
+	for id, session := range s.sessions {
+		if session.ExpiresAt.Before(now) {
+			delete(s.sessions, id)
+		}
+	}
+	return nil
+}
+
+func (sessionRepo *SessionRepository) CountActiveSessions(ctx context.Context) (int64, error) {
+	s := sessionRepo.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC()
+	var count int64
+	for _, session := range s.sessions {
+		if session.ExpiresAt.After(now) {
+			count++
+		}
+	}
+	return count, nil
+}
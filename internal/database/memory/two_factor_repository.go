@@ -0,0 +1,153 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+	"vetsys/internal/database"
+	"vetsys/internal/domain"
+)
+
+type TwoFactorRepository struct {
+	store *store
+}
+
+func (twoFactorRepo *TwoFactorRepository) GetTwoFactor(ctx context.Context, userID int64) (*domain.TwoFactor, error) {
+	s := twoFactorRepo.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	twoFactor, ok := s.twoFactors[userID]
+	if !ok {
+		return nil, database.ErrTwoFactorNotFound
+	}
+	return &twoFactor, nil
+}
+
+func (twoFactorRepo *TwoFactorRepository) SaveTwoFactorSecret(ctx context.Context, userID int64, secret string) error {
+	s := twoFactorRepo.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[userID]; !ok {
+		return errForeignKey
+	}
+	if twoFactor, ok := s.twoFactors[userID]; ok && twoFactor.IsEnabled() {
+		return database.ErrTwoFactorEnabled
+	}
+	s.twoFactors[userID] = domain.TwoFactor{UserID: userID, Secret: secret, CreatedAt: time.Now().UTC()}
+	return nil
+}
+
+func (twoFactorRepo *TwoFactorRepository) EnableTwoFactor(ctx context.Context, userID int64, step int64, codeHashes []string) error {
+	s := twoFactorRepo.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	twoFactor, ok := s.twoFactors[userID]
+	if !ok || twoFactor.IsEnabled() {
+		return database.ErrTwoFactorNotFound
+	}
+	now := time.Now().UTC()
+	twoFactor.EnabledAt = &now
+	twoFactor.LastUsedStep = step
+	s.twoFactors[userID] = twoFactor
+	s.replaceRecoveryCodes(userID, codeHashes)
+	return nil
+}
+
+func (twoFactorRepo *TwoFactorRepository) UseTOTPStep(ctx context.Context, userID int64, step int64) error {
+	s := twoFactorRepo.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	twoFactor, ok := s.twoFactors[userID]
+	if !ok || !twoFactor.IsEnabled() || twoFactor.LastUsedStep >= step {
+		return database.ErrTOTPCodeUsed
+	}
+	twoFactor.LastUsedStep = step
+	s.twoFactors[userID] = twoFactor
+	return nil
+}
+
+func (twoFactorRepo *TwoFactorRepository) UseRecoveryCode(ctx context.Context, userID int64, codeHash string) error {
+	s := twoFactorRepo.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.recoveryCodes[userID][codeHash] {
+		return database.ErrRecoveryCodeInvalid
+	}
+	delete(s.recoveryCodes[userID], codeHash)
+	return nil
+}
+
+func (twoFactorRepo *TwoFactorRepository) ReplaceRecoveryCodes(ctx context.Context, userID int64, codeHashes []string) error {
+	s := twoFactorRepo.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[userID]; !ok {
+		return errForeignKey
+	}
+	s.replaceRecoveryCodes(userID, codeHashes)
+	return nil
+}
+
+func (twoFactorRepo *TwoFactorRepository) CountRecoveryCodes(ctx context.Context, userID int64) (int64, error) {
+	s := twoFactorRepo.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return int64(len(s.recoveryCodes[userID])), nil
+}
+
+func (twoFactorRepo *TwoFactorRepository) DeleteTwoFactor(ctx context.Context, userID int64) error {
+	s := twoFactorRepo.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.twoFactors[userID]; !ok {
+		return database.ErrTwoFactorNotFound
+	}
+	s.deleteTwoFactor(userID)
+	return nil
+}
+
+func (twoFactorRepo *TwoFactorRepository) GetRequiredRoles(ctx context.Context) ([]domain.Role, error) {
+	s := twoFactorRepo.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	roles := []domain.Role{}
+	for role := range s.twoFactorRoles {
+		roles = append(roles, role)
+	}
+	sort.Slice(roles, func(i, j int) bool { return roles[i] < roles[j] })
+	return roles, nil
+}
+
+func (twoFactorRepo *TwoFactorRepository) SetRequiredRoles(ctx context.Context, roles []domain.Role) error {
+	s := twoFactorRepo.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.twoFactorRoles = map[domain.Role]bool{}
+	for _, role := range roles {
+		s.twoFactorRoles[role] = true
+	}
+	return nil
+}
+
+func (s *store) replaceRecoveryCodes(userID int64, codeHashes []string) {
+	codes := map[string]bool{}
+	for _, codeHash := range codeHashes {
+		codes[codeHash] = true
+	}
+	s.recoveryCodes[userID] = codes
+}
+
+func (s *store) deleteTwoFactor(userID int64) {
+	delete(s.twoFactors, userID)
+	delete(s.recoveryCodes, userID)
+}
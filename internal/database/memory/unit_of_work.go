@@ -0,0 +1,115 @@
+package memory
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync"
+	"vetsys/internal/database"
+	"vetsys/internal/domain"
+)
+
+// Transactor is the in-memory UnitOfWork. It hands fn the ordinary stores and,
+// if fn fails, puts every table back as it was when the unit began. Units of
+// work run one at a time, and a rollback also discards writes made outside
+// the unit meanwhile; tests do not run those concurrently.
+type Transactor struct {
+	store *store
+	mu    sync.Mutex
+}
+
+func (transactor *Transactor) Do(ctx context.Context, fn func(stores database.TxStores) error) error {
+	transactor.mu.Lock()
+	defer transactor.mu.Unlock()
+
+	s := transactor.store
+	snapshot := s.snapshot()
+	err := fn(database.TxStores{
+		Clients:       &ClientRepository{store: s},
+		Patients:      &PatientRepository{store: s},
+		Consultations: &ConsultationRepository{store: s},
+	})
+	if err != nil {
+		s.restore(snapshot)
+	}
+	return err
+}
+
+// tables is a copy of the store's rows. IDs handed out are not part of it,
+// just as Postgres sequences are not rolled back.
+type tables struct {
+	users         map[int64]domain.User
+	sessions      map[string]domain.Session
+	registrations map[string]domain.AllowedRegistration
+	clients       map[int64]domain.Client
+	patients      map[int64]domain.Patient
+	consultations map[int64]domain.Consultation
+	appointments  map[int64]domain.Appointment
+	audit         []domain.AuditEntry
+	vaccines      map[int64]domain.Vaccine
+	vaccinations  map[int64]domain.Vaccination
+	medications   map[int64]domain.Medication
+	prescriptions map[int64]domain.Prescription
+	vitals        map[int64]domain.Vitals
+	items         map[int64]domain.BillableItem
+	invoices      map[int64]domain.Invoice
+	payments      map[int64]domain.Payment
+	products      map[int64]domain.Product
+	batches       map[int64]domain.StockBatch
+	movements     map[int64]domain.StockMovement
+}
+
+// snapshot copies the tables. Rows are copied by value; the slices some rows
+// hold are safe to share because the stores replace them rather than write
+// into them.
+func (s *store) snapshot() tables {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return tables{
+		users:         maps.Clone(s.users),
+		sessions:      maps.Clone(s.sessions),
+		registrations: maps.Clone(s.registrations),
+		clients:       maps.Clone(s.clients),
+		patients:      maps.Clone(s.patients),
+		consultations: maps.Clone(s.consultations),
+		appointments:  maps.Clone(s.appointments),
+		audit:         slices.Clone(s.audit),
+		vaccines:      maps.Clone(s.vaccines),
+		vaccinations:  maps.Clone(s.vaccinations),
+		medications:   maps.Clone(s.medications),
+		prescriptions: maps.Clone(s.prescriptions),
+		vitals:        maps.Clone(s.vitals),
+		items:         maps.Clone(s.items),
+		invoices:      maps.Clone(s.invoices),
+		payments:      maps.Clone(s.payments),
+		products:      maps.Clone(s.products),
+		batches:       maps.Clone(s.batches),
+		movements:     maps.Clone(s.movements),
+	}
+}
+
+func (s *store) restore(snapshot tables) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.users = snapshot.users
+	s.sessions = snapshot.sessions
+	s.registrations = snapshot.registrations
+	s.clients = snapshot.clients
+	s.patients = snapshot.patients
+	s.consultations = snapshot.consultations
+	s.appointments = snapshot.appointments
+	s.audit = snapshot.audit
+	s.vaccines = snapshot.vaccines
+	s.vaccinations = snapshot.vaccinations
+	s.medications = snapshot.medications
+	s.prescriptions = snapshot.prescriptions
+	s.vitals = snapshot.vitals
+	s.items = snapshot.items
+	s.invoices = snapshot.invoices
+	s.payments = snapshot.payments
+	s.products = snapshot.products
+	s.batches = snapshot.batches
+	s.movements = snapshot.movements
+}
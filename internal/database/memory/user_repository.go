@@ -0,0 +1,182 @@
+package memory
+
+import (
+	"context"
+	"vetsys/internal/database"
+	"vetsys/internal/domain"
+)
+
+type UserRepository struct {
+	store *store
+}
+
+func (userRepository *UserRepository) CreateUser(ctx context.Context, user *domain.User) error {
+	s := userRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.userTaken(0, user.DNI, user.Email) {
+		return errDuplicateKey
+	}
+	user.ID = s.nextID()
+	s.users[user.ID] = *user
+	return nil
+}
+
+func (userRepository *UserRepository) GetUserByID(ctx context.Context, id int64) (*domain.User, error) {
+	s := userRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[id]
+	if !ok {
+		return nil, database.ErrUserNotFound
+	}
+	return &user, nil
+}
+
+func (userRepository *UserRepository) GetUserByDNI(ctx context.Context, dni string) (*domain.User, error) {
+	return userRepository.find(func(user domain.User) bool { return user.DNI == dni })
+}
+
+func (userRepository *UserRepository) GetUserByEmail(ctx context.Context, email string) (*domain.User, error) {
+	return userRepository.find(func(user domain.User) bool { return user.Email == email })
+}
+
+func (userRepository *UserRepository) DeleteUserByID(ctx context.Context, id int64) error {
+	s := userRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[id]; !ok {
+		return database.ErrUserNotFound
+	}
+	s.deleteUser(id)
+	return nil
+}
+
+func (userRepository *UserRepository) UpdateUser(ctx context.Context, user *domain.User) error {
+	s := userRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.users[user.ID]
+	if !ok {
+		return database.ErrUserNotFound
+	}
+	if s.userTaken(user.ID, user.DNI, user.Email) {
+		return errDuplicateKey
+	}
+	stored.DNI = user.DNI
+	stored.Email = user.Email
+	stored.Name = user.Name
+	stored.ProfilePicture = user.ProfilePicture
+	s.users[user.ID] = stored
+	return nil
+}
+
+func (userRepository *UserRepository) UpdatePassword(ctx context.Context, id int64, password string) error {
+	return userRepository.update(id, func(user *domain.User) { user.Password = password })
+}
+
+func (userRepository *UserRepository) UpdateUserRole(ctx context.Context, id int64, role domain.Role) error {
+	return userRepository.update(id, func(user *domain.User) { user.Role = role })
+}
+
+func (userRepository *UserRepository) find(match func(domain.User) bool) (*domain.User, error) {
+	s := userRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, user := range s.users {
+		if match(user) {
+			return &user, nil
+		}
+	}
+	return nil, database.ErrUserNotFound
+}
+
+func (userRepository *UserRepository) update(id int64, change func(*domain.User)) error {
+	s := userRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[id]
+	if !ok {
+		return database.ErrUserNotFound
+	}
+	change(&user)
+	s.users[id] = user
+	return nil
+}
+
+// userTaken reports whether another user already has the DNI or email.
+func (s *store) userTaken(id int64, dni string, email string) bool {
+	for _, user := range s.users {
+		if user.ID != id && (user.DNI == dni || user.Email == email) {
+			return true
+		}
+	}
+	return false
+}
+
+// deleteUser removes a user, cascading to their sessions, sign-in records and
+// appointments and clearing the references other records keep to them.
+func (s *store) deleteUser(id int64) {
+	delete(s.users, id)
+	for sessionID, session := range s.sessions {
+		if session.UserID == id {
+			delete(s.sessions, sessionID)
+		}
+	}
+	s.deleteResetTokens(id)
+	s.deleteTwoFactor(id)
+	s.deleteChallenges(id)
+	for appointmentID, appointment := range s.appointments {
+		if appointment.VetID == id {
+			delete(s.appointments, appointmentID)
+		}
+	}
+	for dni, registration := range s.registrations {
+		if registration.InvitedBy != nil && *registration.InvitedBy == id {
+			registration.InvitedBy = nil
+			s.registrations[dni] = registration
+		}
+	}
+	for vaccinationID, vaccination := range s.vaccinations {
+		if vaccination.VetID != nil && *vaccination.VetID == id {
+			vaccination.VetID = nil
+			s.vaccinations[vaccinationID] = vaccination
+		}
+	}
+	for prescriptionID, prescription := range s.prescriptions {
+		if prescription.PrescribedBy != nil && *prescription.PrescribedBy == id {
+			prescription.PrescribedBy = nil
+			s.prescriptions[prescriptionID] = prescription
+		}
+	}
+	for vitalsID, vitals := range s.vitals {
+		if vitals.RecordedBy != nil && *vitals.RecordedBy == id {
+			vitals.RecordedBy = nil
+			s.vitals[vitalsID] = vitals
+		}
+	}
+	for invoiceID, invoice := range s.invoices {
+		if invoice.IssuedBy != nil && *invoice.IssuedBy == id {
+			invoice.IssuedBy = nil
+			s.invoices[invoiceID] = invoice
+		}
+	}
+	for paymentID, payment := range s.payments {
+		if payment.RecordedBy != nil && *payment.RecordedBy == id {
+			payment.RecordedBy = nil
+			s.payments[paymentID] = payment
+		}
+	}
+	for movementID, movement := range s.movements {
+		if movement.RecordedBy != nil && *movement.RecordedBy == id {
+			movement.RecordedBy = nil
+			s.movements[movementID] = movement
+		}
+	}
+}
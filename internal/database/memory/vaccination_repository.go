@@ -0,0 +1,280 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+	"vetsys/internal/database"
+	"vetsys/internal/domain"
+)
+
+// VaccinationRepository keeps each vaccine's booster intervals inside the
+// stored vaccine rather than in a table of their own.
+type VaccinationRepository struct {
+	store *store
+}
+
+func (vaccinationRepository *VaccinationRepository) CreateVaccine(ctx context.Context, vaccine *domain.Vaccine) error {
+	s := vaccinationRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, other := range s.vaccines {
+		if other.Name == vaccine.Name {
+			return database.ErrVaccineExists
+		}
+	}
+	boosters := make([]domain.VaccineBooster, 0, len(vaccine.Boosters))
+	seen := map[string]bool{}
+	for _, booster := range vaccine.Boosters {
+		if seen[booster.Species] {
+			return errDuplicateKey
+		}
+		seen[booster.Species] = true
+		boosters = append(boosters, booster)
+	}
+
+	vaccine.ID = s.nextID()
+	for i := range vaccine.Boosters {
+		vaccine.Boosters[i].VaccineID = vaccine.ID
+		boosters[i].VaccineID = vaccine.ID
+	}
+	stored := *vaccine
+	stored.Boosters = boosters
+	s.vaccines[vaccine.ID] = stored
+	return nil
+}
+
+func (vaccinationRepository *VaccinationRepository) GetVaccineByID(ctx context.Context, id int64) (*domain.Vaccine, error) {
+	s := vaccinationRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	vaccine, ok := s.vaccines[id]
+	if !ok {
+		return nil, database.ErrVaccineNotFound
+	}
+	vaccine = copyVaccine(vaccine)
+	return &vaccine, nil
+}
+
+func (vaccinationRepository *VaccinationRepository) GetVaccines(ctx context.Context, limit int, offset int) ([]domain.Vaccine, error) {
+	s := vaccinationRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	vaccines := page(rows(s.vaccines, nil, byVaccineName), limit, offset)
+	for i := range vaccines {
+		vaccines[i] = copyVaccine(vaccines[i])
+	}
+	return vaccines, nil
+}
+
+func (vaccinationRepository *VaccinationRepository) GetVaccinesCount(ctx context.Context) (int64, error) {
+	s := vaccinationRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return int64(len(s.vaccines)), nil
+}
+
+func (vaccinationRepository *VaccinationRepository) SetVaccineBooster(ctx context.Context, booster *domain.VaccineBooster) error {
+	s := vaccinationRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	vaccine, ok := s.vaccines[booster.VaccineID]
+	if !ok {
+		return database.ErrVaccineNotFound
+	}
+	boosters := []domain.VaccineBooster{*booster}
+	for _, other := range vaccine.Boosters {
+		if other.Species != booster.Species {
+			boosters = append(boosters, other)
+		}
+	}
+	vaccine.Boosters = boosters
+	s.vaccines[vaccine.ID] = vaccine
+	return nil
+}
+
+func (vaccinationRepository *VaccinationRepository) DeleteVaccineBooster(ctx context.Context, vaccineID int64, species string) error {
+	s := vaccinationRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	vaccine, ok := s.vaccines[vaccineID]
+	if !ok {
+		return database.ErrVaccineBoosterNotFound
+	}
+	boosters := []domain.VaccineBooster{}
+	for _, booster := range vaccine.Boosters {
+		if booster.Species != species {
+			boosters = append(boosters, booster)
+		}
+	}
+	if len(boosters) == len(vaccine.Boosters) {
+		return database.ErrVaccineBoosterNotFound
+	}
+	vaccine.Boosters = boosters
+	s.vaccines[vaccineID] = vaccine
+	return nil
+}
+
+func (vaccinationRepository *VaccinationRepository) CreateVaccination(ctx context.Context, vaccination *domain.Vaccination) error {
+	s := vaccinationRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	patient, ok := s.patients[vaccination.PatientID]
+	if !ok {
+		return database.ErrPatientNotFound
+	}
+	vaccine, ok := s.vaccines[vaccination.VaccineID]
+	if !ok {
+		return database.ErrVaccineNotFound
+	}
+	if vaccination.VetID != nil {
+		vet, ok := s.users[*vaccination.VetID]
+		if !ok || !vet.Role.Can(domain.PermClinicalNotesWrite) {
+			return database.ErrVetNotFound
+		}
+	}
+
+	if vaccination.NextDueAt == nil {
+		species := domain.NormalizeSpecies(patient.Species)
+		for _, booster := range vaccine.Boosters {
+			if booster.Species == species {
+				nextDueAt := vaccination.AdministeredAt.AddDate(0, 0, booster.IntervalDays)
+				vaccination.NextDueAt = &nextDueAt
+			}
+		}
+	}
+
+	vaccination.ID = s.nextID()
+	s.vaccinations[vaccination.ID] = *vaccination
+	return nil
+}
+
+func (vaccinationRepository *VaccinationRepository) GetVaccinationByID(ctx context.Context, id int64) (*domain.Vaccination, error) {
+	s := vaccinationRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	vaccination, ok := s.vaccinations[id]
+	if !ok {
+		return nil, database.ErrVaccinationNotFound
+	}
+	return &vaccination, nil
+}
+
+func (vaccinationRepository *VaccinationRepository) GetVaccinationsByPatientID(ctx context.Context, patientID int64, limit int, offset int) ([]domain.Vaccination, error) {
+	s := vaccinationRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return page(rows(s.vaccinations, vaccinationOf(patientID), newestDoseFirst), limit, offset), nil
+}
+
+func (vaccinationRepository *VaccinationRepository) GetVaccinationsCountByPatientID(ctx context.Context, patientID int64) (int64, error) {
+	s := vaccinationRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return int64(len(rows(s.vaccinations, vaccinationOf(patientID), newestDoseFirst))), nil
+}
+
+func (vaccinationRepository *VaccinationRepository) DeleteVaccinationByID(ctx context.Context, id int64) error {
+	s := vaccinationRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.vaccinations[id]; !ok {
+		return database.ErrVaccinationNotFound
+	}
+	delete(s.vaccinations, id)
+	return nil
+}
+
+func (vaccinationRepository *VaccinationRepository) GetDueVaccinations(ctx context.Context, until time.Time, now time.Time, limit int, offset int) ([]domain.DueVaccination, error) {
+	s := vaccinationRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var due []domain.DueVaccination
+	for _, vaccination := range page(s.dueVaccinations(until), limit, offset) {
+		patient := s.patients[vaccination.PatientID]
+		client := s.clients[patient.OwnerID]
+		due = append(due, domain.DueVaccination{
+			VaccinationID:  vaccination.ID,
+			PatientID:      patient.ID,
+			PatientName:    patient.Name,
+			Species:        patient.Species,
+			VaccineID:      vaccination.VaccineID,
+			VaccineName:    s.vaccines[vaccination.VaccineID].Name,
+			AdministeredAt: vaccination.AdministeredAt,
+			NextDueAt:      *vaccination.NextDueAt,
+			Overdue:        vaccination.NextDueAt.Before(now),
+			ClientID:       client.ID,
+			ClientName:     client.Name,
+			PhoneNumber:    client.PhoneNumber,
+		})
+	}
+	return due, nil
+}
+
+func (vaccinationRepository *VaccinationRepository) GetDueVaccinationsCount(ctx context.Context, until time.Time) (int64, error) {
+	s := vaccinationRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return int64(len(s.dueVaccinations(until))), nil
+}
+
+// dueVaccinations returns the latest dose of each patient's vaccines whose
+// booster is due before until, soonest first.
+func (s *store) dueVaccinations(until time.Time) []domain.Vaccination {
+	type dose struct{ patientID, vaccineID int64 }
+	latest := map[dose]domain.Vaccination{}
+	for _, vaccination := range s.vaccinations {
+		key := dose{vaccination.PatientID, vaccination.VaccineID}
+		if current, ok := latest[key]; !ok || newestDoseFirst(vaccination, current) {
+			latest[key] = vaccination
+		}
+	}
+	var due []domain.Vaccination
+	for _, vaccination := range latest {
+		if vaccination.NextDueAt != nil && vaccination.NextDueAt.Before(until) {
+			due = append(due, vaccination)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		if !due[i].NextDueAt.Equal(*due[j].NextDueAt) {
+			return due[i].NextDueAt.Before(*due[j].NextDueAt)
+		}
+		return due[i].ID < due[j].ID
+	})
+	return due
+}
+
+func copyVaccine(vaccine domain.Vaccine) domain.Vaccine {
+	boosters := append([]domain.VaccineBooster{}, vaccine.Boosters...)
+	sort.Slice(boosters, func(i, j int) bool { return boosters[i].Species < boosters[j].Species })
+	vaccine.Boosters = boosters
+	return vaccine
+}
+
+func vaccinationOf(patientID int64) func(domain.Vaccination) bool {
+	return func(vaccination domain.Vaccination) bool { return vaccination.PatientID == patientID }
+}
+
+func newestDoseFirst(a, b domain.Vaccination) bool {
+	if !a.AdministeredAt.Equal(b.AdministeredAt) {
+		return a.AdministeredAt.After(b.AdministeredAt)
+	}
+	return a.ID > b.ID
+}
+
+func byVaccineName(a, b domain.Vaccine) bool {
+	return a.Name < b.Name
+}
@@ -0,0 +1,131 @@
+package memory
+
+import (
+	"context"
+	"time"
+	"vetsys/internal/database"
+	"vetsys/internal/domain"
+)
+
+type VitalsRepository struct {
+	store *store
+}
+
+// maxTrendPoints matches the cap the Postgres repository applies to trends.
+const maxTrendPoints = 1000
+
+func (vitalsRepository *VitalsRepository) CreateVitals(ctx context.Context, vitals *domain.Vitals) error {
+	s := vitalsRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkVitalsLinks(vitals); err != nil {
+		return err
+	}
+	vitals.ID = s.nextID()
+	s.vitals[vitals.ID] = *vitals
+	return nil
+}
+
+func (vitalsRepository *VitalsRepository) GetVitalsByID(ctx context.Context, id int64) (*domain.Vitals, error) {
+	s := vitalsRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	vitals, ok := s.vitals[id]
+	if !ok {
+		return nil, database.ErrVitalsNotFound
+	}
+	return &vitals, nil
+}
+
+func (vitalsRepository *VitalsRepository) GetVitalsByPatientID(ctx context.Context, patientID int64, from *time.Time, to *time.Time, limit int, offset int) ([]domain.Vitals, error) {
+	s := vitalsRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return page(rows(s.vitals, vitalsMatches(patientID, from, to), newestVitalsFirst), limit, offset), nil
+}
+
+func (vitalsRepository *VitalsRepository) GetVitalsCountByPatientID(ctx context.Context, patientID int64, from *time.Time, to *time.Time) (int64, error) {
+	s := vitalsRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return int64(len(rows(s.vitals, vitalsMatches(patientID, from, to), newestVitalsFirst))), nil
+}
+
+func (vitalsRepository *VitalsRepository) GetVitalsTrend(ctx context.Context, patientID int64, from *time.Time, to *time.Time) ([]domain.Vitals, error) {
+	s := vitalsRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	latest := page(rows(s.vitals, vitalsMatches(patientID, from, to), newestVitalsFirst), maxTrendPoints, 0)
+	for i, j := 0, len(latest)-1; i < j; i, j = i+1, j-1 {
+		latest[i], latest[j] = latest[j], latest[i]
+	}
+	return latest, nil
+}
+
+func (vitalsRepository *VitalsRepository) UpdateVitals(ctx context.Context, vitals *domain.Vitals) error {
+	s := vitalsRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.vitals[vitals.ID]
+	if !ok {
+		return database.ErrVitalsNotFound
+	}
+	stored.ConsultationID = vitals.ConsultationID
+	stored.RecordedAt = vitals.RecordedAt
+	stored.WeightKg = vitals.WeightKg
+	stored.TemperatureC = vitals.TemperatureC
+	stored.HeartRateBPM = vitals.HeartRateBPM
+	stored.RespiratoryRateBPM = vitals.RespiratoryRateBPM
+	stored.Notes = vitals.Notes
+	if err := s.checkVitalsLinks(&stored); err != nil {
+		return err
+	}
+	s.vitals[vitals.ID] = stored
+	return nil
+}
+
+func (vitalsRepository *VitalsRepository) DeleteVitalsByID(ctx context.Context, id int64) error {
+	s := vitalsRepository.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.vitals[id]; !ok {
+		return database.ErrVitalsNotFound
+	}
+	delete(s.vitals, id)
+	return nil
+}
+
+func (s *store) checkVitalsLinks(vitals *domain.Vitals) error {
+	if _, ok := s.patients[vitals.PatientID]; !ok {
+		return errForeignKey
+	}
+	if vitals.ConsultationID != nil {
+		if _, ok := s.consultations[*vitals.ConsultationID]; !ok {
+			return errForeignKey
+		}
+	}
+	return nil
+}
+
+// vitalsMatches selects a patient's vitals recorded in [from, to).
+func vitalsMatches(patientID int64, from *time.Time, to *time.Time) func(domain.Vitals) bool {
+	return func(vitals domain.Vitals) bool {
+		return vitals.PatientID == patientID &&
+			(from == nil || !vitals.RecordedAt.Before(*from)) &&
+			(to == nil || vitals.RecordedAt.Before(*to))
+	}
+}
+
+func newestVitalsFirst(a, b domain.Vitals) bool {
+	if !a.RecordedAt.Equal(b.RecordedAt) {
+		return a.RecordedAt.After(b.RecordedAt)
+	}
+	return a.ID > b.ID
+}
@@ -0,0 +1,99 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+	"vetsys/internal/metrics"
+)
+
+var queryDuration = metrics.NewHistogramVec(
+	"vetsys_db_query_duration_seconds",
+	"Duration of repository calls, by repository method.",
+	metrics.DefBuckets,
+	"method",
+)
+
+func init() {
+	metrics.Default.MustRegister(queryDuration)
+}
+
+// methodNames caches the metric label of each calling function by its
+// program counter.
+var methodNames sync.Map
+
+// callerMethod names the repository method that called withQueryTimeout,
+// e.g. "ClientRepository.GetClientByID".
+func callerMethod() string {
+	pc, _, _, ok := runtime.Caller(3)
+	if !ok {
+		return "unknown"
+	}
+	if name, ok := methodNames.Load(pc); ok {
+		return name.(string)
+	}
+	name := "unknown"
+	if fn := runtime.FuncForPC(pc); fn != nil {
+		name = fn.Name()
+		name = name[strings.LastIndex(name, "/")+1:]
+		name = strings.TrimPrefix(name, "database.")
+		name = strings.NewReplacer("(*", "", ")", "").Replace(name)
+	}
+	methodNames.Store(pc, name)
+	return name
+}
+
+// observeQuery wraps cancel so that it also records how long the repository
+// call took.
+func observeQuery(cancel context.CancelFunc) context.CancelFunc {
+	method := callerMethod()
+	start := time.Now()
+	return func() {
+		cancel()
+		queryDuration.Observe(time.Since(start).Seconds(), method)
+	}
+}
+
+// RegisterMetrics exposes the connection pool statistics and the number of
+// active sessions on registry.
+func (db *DataBase) RegisterMetrics(registry *metrics.Registry) {
+	stat := func(fn func(s sql.DBStats) float64) func() float64 {
+		return func() float64 { return fn(db.DB.Stats()) }
+	}
+	registry.MustRegister(
+		metrics.NewGaugeFunc("vetsys_db_open_connections", "Open connections, in use or idle.",
+			stat(func(s sql.DBStats) float64 { return float64(s.OpenConnections) })),
+		metrics.NewGaugeFunc("vetsys_db_in_use_connections", "Connections currently in use.",
+			stat(func(s sql.DBStats) float64 { return float64(s.InUse) })),
+		metrics.NewGaugeFunc("vetsys_db_idle_connections", "Idle connections.",
+			stat(func(s sql.DBStats) float64 { return float64(s.Idle) })),
+		metrics.NewGaugeFunc("vetsys_db_max_open_connections", "Maximum number of open connections, 0 for unlimited.",
+			stat(func(s sql.DBStats) float64 { return float64(s.MaxOpenConnections) })),
+		metrics.NewCounterFunc("vetsys_db_wait_count_total", "Connections waited for.",
+			stat(func(s sql.DBStats) float64 { return float64(s.WaitCount) })),
+		metrics.NewCounterFunc("vetsys_db_wait_duration_seconds_total", "Time spent waiting for a connection.",
+			stat(func(s sql.DBStats) float64 { return s.WaitDuration.Seconds() })),
+		metrics.NewCounterFunc("vetsys_db_max_idle_closed_total", "Connections closed because the idle pool was full.",
+			stat(func(s sql.DBStats) float64 { return float64(s.MaxIdleClosed) })),
+		metrics.NewCounterFunc("vetsys_db_max_lifetime_closed_total", "Connections closed for exceeding their maximum lifetime.",
+			stat(func(s sql.DBStats) float64 { return float64(s.MaxLifetimeClosed) })),
+		NewActiveSessionsGauge(db.SessionRepo),
+	)
+}
+
+// NewActiveSessionsGauge counts unexpired sessions at scrape time.
+func NewActiveSessionsGauge(sessions SessionStore) *metrics.ValueFunc {
+	return metrics.NewGaugeFunc("vetsys_active_sessions", "Sessions that have not expired.", func() float64 {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		count, err := sessions.CountActiveSessions(ctx)
+		if err != nil {
+			return math.NaN()
+		}
+		return float64(count)
+	})
+}
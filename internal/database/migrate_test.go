@@ -0,0 +1,127 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"testing/fstest"
+)
+
+func newTestMigrator(t *testing.T) *Migrator {
+	t.Helper()
+	migrator, err := NewMigrator(testDB.DB)
+	if err != nil {
+		t.Fatalf("Failed to load migrations: %v", err)
+	}
+	return migrator
+}
+
+func TestLoadMigrations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"0002_second.up.sql":   {Data: []byte("CREATE TABLE b (id INT);")},
+		"0002_second.down.sql": {Data: []byte("DROP TABLE b;")},
+		"0001_first.up.sql":    {Data: []byte("CREATE TABLE a (id INT);")},
+	}
+
+	migrations, err := LoadMigrations(fsys)
+	if err != nil {
+		t.Fatalf("Failed to load migrations: %v", err)
+	}
+
+	if len(migrations) != 2 {
+		t.Fatalf("Expected 2 migrations, got %d", len(migrations))
+	}
+	if migrations[0].Version != 1 || migrations[1].Version != 2 {
+		t.Errorf("Expected migrations sorted by version, got %d and %d", migrations[0].Version, migrations[1].Version)
+	}
+	if migrations[1].Down != "DROP TABLE b;" {
+		t.Errorf("Expected down script to be loaded, got %q", migrations[1].Down)
+	}
+	if migrations[0].Checksum == "" || migrations[0].Checksum == migrations[1].Checksum {
+		t.Error("Expected distinct checksums for each migration")
+	}
+}
+
+func TestLoadMigrations_InvalidName(t *testing.T) {
+	fsys := fstest.MapFS{
+		"create_users.sql": {Data: []byte("CREATE TABLE users (id INT);")},
+	}
+
+	if _, err := LoadMigrations(fsys); err == nil {
+		t.Error("Expected error for invalid migration file name")
+	}
+}
+
+func TestMigrator_Status(t *testing.T) {
+	migrator := newTestMigrator(t)
+
+	statuses, err := migrator.Status(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to get migration status: %v", err)
+	}
+
+	if len(statuses) != len(migrator.Migrations) {
+		t.Fatalf("Expected %d statuses, got %d", len(migrator.Migrations), len(statuses))
+	}
+	for _, status := range statuses {
+		if !status.Applied {
+			t.Errorf("Expected migration %d to be applied", status.Version)
+		}
+	}
+}
+
+func TestMigrator_UpIsIdempotent(t *testing.T) {
+	migrator := newTestMigrator(t)
+
+	applied, err := migrator.Up(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	if len(applied) != 0 {
+		t.Errorf("Expected no pending migrations, got %d", len(applied))
+	}
+}
+
+func TestMigrator_DownAndUp(t *testing.T) {
+	migrator := newTestMigrator(t)
+	ctx := context.Background()
+
+	latest := migrator.Migrations[len(migrator.Migrations)-1]
+	rolledBack, err := migrator.Down(ctx)
+	if err != nil {
+		t.Fatalf("Failed to roll back migration: %v", err)
+	}
+	if rolledBack.Version != latest.Version {
+		t.Errorf("Expected to roll back migration %d, got %d", latest.Version, rolledBack.Version)
+	}
+	pending, err := migrator.Pending(ctx)
+	if err != nil {
+		t.Fatalf("Failed to list pending migrations: %v", err)
+	}
+	if len(pending) != 1 || pending[0].Version != latest.Version {
+		t.Errorf("Expected migration %d to be pending, got %v", latest.Version, pending)
+	}
+
+	applied, err := migrator.Up(ctx)
+	if err != nil {
+		t.Fatalf("Failed to re-apply migration: %v", err)
+	}
+	if len(applied) != 1 || applied[0].Version != latest.Version {
+		t.Errorf("Expected migration %d to be re-applied, got %v", latest.Version, applied)
+	}
+}
+
+func TestMigrator_ChecksumMismatch(t *testing.T) {
+	migrator := newTestMigrator(t)
+
+	tampered := make([]Migration, len(migrator.Migrations))
+	copy(tampered, migrator.Migrations)
+	tampered[0].Checksum = "tampered"
+	migrator.Migrations = tampered
+
+	_, err := migrator.Up(context.Background())
+	if !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("Expected ErrChecksumMismatch, got %v", err)
+	}
+}
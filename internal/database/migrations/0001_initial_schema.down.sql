@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS allowed_registrations;
+DROP TABLE IF EXISTS sessions;
+DROP TABLE IF EXISTS consultations;
+DROP TABLE IF EXISTS patients;
+DROP TABLE IF EXISTS clients;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS appointments (
+    id BIGSERIAL PRIMARY KEY,
+    patient_id BIGINT NOT NULL REFERENCES patients(id) ON DELETE CASCADE,
+    vet_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    starts_at TIMESTAMP NOT NULL,
+    ends_at TIMESTAMP NOT NULL,
+    reason TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'BOOKED',
+    consultation_id BIGINT REFERENCES consultations(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CHECK (ends_at > starts_at)
+);
+CREATE INDEX IF NOT EXISTS idx_appointments_vet_id_starts_at ON appointments(vet_id, starts_at);
+CREATE INDEX IF NOT EXISTS idx_appointments_patient_id ON appointments(patient_id);
@@ -0,0 +1,2 @@
+ALTER TABLE allowed_registrations DROP COLUMN IF EXISTS role;
+ALTER TABLE users DROP COLUMN IF EXISTS role;
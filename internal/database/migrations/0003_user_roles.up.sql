@@ -0,0 +1,5 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role TEXT NOT NULL DEFAULT 'READ_ONLY';
+-- Accounts created before roles existed could do everything, so they keep full access.
+UPDATE users SET role = 'ADMIN';
+
+ALTER TABLE allowed_registrations ADD COLUMN IF NOT EXISTS role TEXT NOT NULL DEFAULT 'VETERINARIAN';
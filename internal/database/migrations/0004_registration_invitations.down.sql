@@ -0,0 +1,6 @@
+ALTER TABLE allowed_registrations
+    DROP COLUMN IF EXISTS revoked_at,
+    DROP COLUMN IF EXISTS used_at,
+    DROP COLUMN IF EXISTS expires_at,
+    DROP COLUMN IF EXISTS created_at,
+    DROP COLUMN IF EXISTS invited_by;
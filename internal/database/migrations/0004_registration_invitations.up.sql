@@ -0,0 +1,6 @@
+ALTER TABLE allowed_registrations
+    ADD COLUMN IF NOT EXISTS invited_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS created_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    ADD COLUMN IF NOT EXISTS expires_at TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS used_at TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS revoked_at TIMESTAMP;
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_consultations_search_vector;
+ALTER TABLE consultations DROP COLUMN IF EXISTS search_vector;
+
+DROP INDEX IF EXISTS idx_patients_search_vector;
+ALTER TABLE patients DROP COLUMN IF EXISTS search_vector;
+
+DROP INDEX IF EXISTS idx_clients_search_vector;
+ALTER TABLE clients DROP COLUMN IF EXISTS search_vector;
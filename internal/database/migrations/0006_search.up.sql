@@ -0,0 +1,23 @@
+-- The 'simple' configuration does no stemming, so names, breeds and phone
+-- numbers match as typed; searches use prefix queries for partial input.
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS search_vector tsvector
+    GENERATED ALWAYS AS (
+        setweight(to_tsvector('simple', coalesce(name, '')), 'A') ||
+        setweight(to_tsvector('simple', regexp_replace(coalesce(phone_number, ''), '[^0-9]', '', 'g')), 'B')
+    ) STORED;
+CREATE INDEX IF NOT EXISTS idx_clients_search_vector ON clients USING GIN (search_vector);
+
+ALTER TABLE patients ADD COLUMN IF NOT EXISTS search_vector tsvector
+    GENERATED ALWAYS AS (
+        setweight(to_tsvector('simple', coalesce(name, '')), 'A') ||
+        setweight(to_tsvector('simple', coalesce(species, '')), 'B') ||
+        setweight(to_tsvector('simple', coalesce(breed, '')), 'B')
+    ) STORED;
+CREATE INDEX IF NOT EXISTS idx_patients_search_vector ON patients USING GIN (search_vector);
+
+ALTER TABLE consultations ADD COLUMN IF NOT EXISTS search_vector tsvector
+    GENERATED ALWAYS AS (
+        setweight(to_tsvector('simple', coalesce(reason, '')), 'A') ||
+        setweight(to_tsvector('simple', coalesce(diagnosis, '')), 'B')
+    ) STORED;
+CREATE INDEX IF NOT EXISTS idx_consultations_search_vector ON consultations USING GIN (search_vector);
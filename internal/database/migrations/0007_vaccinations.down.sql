@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS vaccinations;
+DROP TABLE IF EXISTS vaccine_boosters;
+DROP TABLE IF EXISTS vaccines;
@@ -0,0 +1,28 @@
+CREATE TABLE IF NOT EXISTS vaccines (
+    id BIGSERIAL PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    description TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC')
+);
+
+-- Species are stored lower-cased and matched against lower(patients.species).
+CREATE TABLE IF NOT EXISTS vaccine_boosters (
+    vaccine_id BIGINT NOT NULL REFERENCES vaccines(id) ON DELETE CASCADE,
+    species TEXT NOT NULL,
+    interval_days INTEGER NOT NULL CHECK (interval_days > 0),
+    PRIMARY KEY (vaccine_id, species)
+);
+
+CREATE TABLE IF NOT EXISTS vaccinations (
+    id BIGSERIAL PRIMARY KEY,
+    patient_id BIGINT NOT NULL REFERENCES patients(id) ON DELETE CASCADE,
+    vaccine_id BIGINT NOT NULL REFERENCES vaccines(id) ON DELETE RESTRICT,
+    vet_id BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    lot_number TEXT NOT NULL,
+    administered_at TIMESTAMP NOT NULL,
+    next_due_at TIMESTAMP,
+    notes TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC')
+);
+CREATE INDEX IF NOT EXISTS idx_vaccinations_patient_vaccine ON vaccinations(patient_id, vaccine_id, administered_at DESC);
+CREATE INDEX IF NOT EXISTS idx_vaccinations_next_due_at ON vaccinations(next_due_at);
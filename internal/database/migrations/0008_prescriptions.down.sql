@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS prescriptions;
+DROP TABLE IF EXISTS medication_dose_ranges;
+DROP TABLE IF EXISTS medications;
@@ -0,0 +1,35 @@
+CREATE TABLE IF NOT EXISTS medications (
+    id BIGSERIAL PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    description TEXT NOT NULL DEFAULT '',
+    dose_unit TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC')
+);
+
+-- Doses are per kg of body weight, in the medication's dose_unit. Species are
+-- stored lower-cased like vaccine_boosters.
+CREATE TABLE IF NOT EXISTS medication_dose_ranges (
+    medication_id BIGINT NOT NULL REFERENCES medications(id) ON DELETE CASCADE,
+    species TEXT NOT NULL,
+    min_dose_per_kg DOUBLE PRECISION NOT NULL CHECK (min_dose_per_kg >= 0),
+    max_dose_per_kg DOUBLE PRECISION NOT NULL,
+    PRIMARY KEY (medication_id, species),
+    CHECK (max_dose_per_kg >= min_dose_per_kg)
+);
+
+CREATE TABLE IF NOT EXISTS prescriptions (
+    id BIGSERIAL PRIMARY KEY,
+    consultation_id BIGINT NOT NULL REFERENCES consultations(id) ON DELETE CASCADE,
+    medication_id BIGINT NOT NULL REFERENCES medications(id) ON DELETE RESTRICT,
+    prescribed_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    dose DOUBLE PRECISION NOT NULL CHECK (dose > 0),
+    dose_unit TEXT NOT NULL,
+    frequency_hours INTEGER NOT NULL CHECK (frequency_hours > 0),
+    duration_days INTEGER NOT NULL CHECK (duration_days > 0),
+    route TEXT NOT NULL,
+    patient_weight_kg DOUBLE PRECISION CHECK (patient_weight_kg > 0),
+    warnings TEXT[] NOT NULL DEFAULT '{}',
+    notes TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC')
+);
+CREATE INDEX IF NOT EXISTS idx_prescriptions_consultation_id ON prescriptions(consultation_id);
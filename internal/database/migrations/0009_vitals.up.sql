@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS vitals (
+    id BIGSERIAL PRIMARY KEY,
+    patient_id BIGINT NOT NULL REFERENCES patients(id) ON DELETE CASCADE,
+    consultation_id BIGINT REFERENCES consultations(id) ON DELETE SET NULL,
+    recorded_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    recorded_at TIMESTAMP NOT NULL,
+    weight_kg DOUBLE PRECISION CHECK (weight_kg > 0),
+    temperature_c DOUBLE PRECISION CHECK (temperature_c > 0),
+    heart_rate_bpm INTEGER CHECK (heart_rate_bpm > 0),
+    respiratory_rate_bpm INTEGER CHECK (respiratory_rate_bpm > 0),
+    notes TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    CHECK (weight_kg IS NOT NULL OR temperature_c IS NOT NULL OR heart_rate_bpm IS NOT NULL OR respiratory_rate_bpm IS NOT NULL)
+);
+CREATE INDEX IF NOT EXISTS idx_vitals_patient_recorded_at ON vitals(patient_id, recorded_at);
+CREATE INDEX IF NOT EXISTS idx_vitals_consultation_id ON vitals(consultation_id);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS payments;
+DROP TABLE IF EXISTS billable_items;
+DROP TABLE IF EXISTS invoices;
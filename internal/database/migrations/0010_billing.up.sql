@@ -0,0 +1,36 @@
+-- Amounts are integer cents to avoid rounding in sums.
+CREATE TABLE IF NOT EXISTS invoices (
+    id BIGSERIAL PRIMARY KEY,
+    client_id BIGINT NOT NULL REFERENCES clients(id) ON DELETE RESTRICT,
+    status TEXT NOT NULL,
+    total_cents BIGINT NOT NULL CHECK (total_cents >= 0),
+    issued_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    issued_at TIMESTAMP NOT NULL,
+    voided_at TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_invoices_client_id ON invoices(client_id);
+
+CREATE TABLE IF NOT EXISTS billable_items (
+    id BIGSERIAL PRIMARY KEY,
+    consultation_id BIGINT NOT NULL REFERENCES consultations(id) ON DELETE RESTRICT,
+    invoice_id BIGINT REFERENCES invoices(id) ON DELETE RESTRICT,
+    prescription_id BIGINT REFERENCES prescriptions(id) ON DELETE SET NULL,
+    kind TEXT NOT NULL,
+    description TEXT NOT NULL,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    unit_price_cents BIGINT NOT NULL CHECK (unit_price_cents >= 0),
+    created_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC')
+);
+CREATE INDEX IF NOT EXISTS idx_billable_items_consultation_id ON billable_items(consultation_id);
+CREATE INDEX IF NOT EXISTS idx_billable_items_invoice_id ON billable_items(invoice_id);
+
+CREATE TABLE IF NOT EXISTS payments (
+    id BIGSERIAL PRIMARY KEY,
+    invoice_id BIGINT NOT NULL REFERENCES invoices(id) ON DELETE RESTRICT,
+    amount_cents BIGINT NOT NULL CHECK (amount_cents > 0),
+    method TEXT NOT NULL,
+    reference TEXT NOT NULL DEFAULT '',
+    recorded_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    paid_at TIMESTAMP NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_payments_invoice_id ON payments(invoice_id);
@@ -0,0 +1,4 @@
+ALTER TABLE billable_items DROP COLUMN IF EXISTS product_id;
+DROP TABLE IF EXISTS stock_movements;
+DROP TABLE IF EXISTS stock_batches;
+DROP TABLE IF EXISTS products;
@@ -0,0 +1,37 @@
+CREATE TABLE IF NOT EXISTS products (
+    id BIGSERIAL PRIMARY KEY,
+    sku TEXT NOT NULL UNIQUE,
+    name TEXT NOT NULL,
+    unit TEXT NOT NULL,
+    medication_id BIGINT REFERENCES medications(id) ON DELETE SET NULL,
+    reorder_level INTEGER NOT NULL DEFAULT 0 CHECK (reorder_level >= 0),
+    created_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC')
+);
+
+-- One row per lot received; quantity_on_hand never goes negative.
+CREATE TABLE IF NOT EXISTS stock_batches (
+    id BIGSERIAL PRIMARY KEY,
+    product_id BIGINT NOT NULL REFERENCES products(id) ON DELETE RESTRICT,
+    lot_number TEXT NOT NULL,
+    expiry_date DATE,
+    quantity_on_hand INTEGER NOT NULL DEFAULT 0 CHECK (quantity_on_hand >= 0),
+    UNIQUE (product_id, lot_number)
+);
+CREATE INDEX IF NOT EXISTS idx_stock_batches_expiry_date ON stock_batches(expiry_date) WHERE quantity_on_hand > 0;
+
+-- quantity is the signed change applied to the batch.
+CREATE TABLE IF NOT EXISTS stock_movements (
+    id BIGSERIAL PRIMARY KEY,
+    batch_id BIGINT NOT NULL REFERENCES stock_batches(id) ON DELETE RESTRICT,
+    kind TEXT NOT NULL,
+    quantity INTEGER NOT NULL CHECK (quantity <> 0),
+    consultation_id BIGINT REFERENCES consultations(id) ON DELETE SET NULL,
+    billable_item_id BIGINT REFERENCES billable_items(id) ON DELETE SET NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    recorded_by BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC')
+);
+CREATE INDEX IF NOT EXISTS idx_stock_movements_batch_id ON stock_movements(batch_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_stock_movements_billable_item_id ON stock_movements(billable_item_id);
+
+ALTER TABLE billable_items ADD COLUMN IF NOT EXISTS product_id BIGINT REFERENCES products(id) ON DELETE RESTRICT;
@@ -0,0 +1,2 @@
+-- request_id ties an audit entry to the request log line of the change.
+ALTER TABLE audit_log ADD COLUMN IF NOT EXISTS request_id TEXT NOT NULL DEFAULT '';
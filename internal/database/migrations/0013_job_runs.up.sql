@@ -0,0 +1,7 @@
+-- job_runs records the latest occurrence of each scheduled job that an
+-- instance claimed, so replicas never run the same occurrence twice.
+CREATE TABLE IF NOT EXISTS job_runs (
+    name TEXT PRIMARY KEY,
+    last_due_at TIMESTAMP NOT NULL,
+    last_started_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
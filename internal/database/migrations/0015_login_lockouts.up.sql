@@ -0,0 +1,11 @@
+-- login_lockouts counts failed logins per DNI and per client IP, so repeated
+-- failures lock logins temporarily whichever IPs or DNIs they come from.
+CREATE TABLE IF NOT EXISTS login_lockouts (
+    scope TEXT NOT NULL CHECK (scope IN ('dni', 'ip')),
+    key TEXT NOT NULL,
+    failures INTEGER NOT NULL DEFAULT 0,
+    lockouts INTEGER NOT NULL DEFAULT 0,
+    last_failure_at TIMESTAMP NOT NULL,
+    locked_until TIMESTAMP,
+    PRIMARY KEY (scope, key)
+);
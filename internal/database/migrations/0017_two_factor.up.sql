@@ -0,0 +1,33 @@
+-- user_two_factor holds each user's TOTP enrolment; enabled_at stays NULL
+-- until the user confirms it with a first code.
+CREATE TABLE IF NOT EXISTS user_two_factor (
+    user_id BIGINT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    secret TEXT NOT NULL,
+    enabled_at TIMESTAMP,
+    last_used_step BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- user_recovery_codes holds the hashes of each user's unused recovery codes.
+CREATE TABLE IF NOT EXISTS user_recovery_codes (
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code_hash TEXT NOT NULL,
+    PRIMARY KEY (user_id, code_hash)
+);
+
+-- two_factor_roles lists the roles whose users must sign in with a second
+-- factor.
+CREATE TABLE IF NOT EXISTS two_factor_roles (
+    role TEXT PRIMARY KEY
+);
+
+-- login_challenges holds logins waiting for their second factor, by the hash
+-- of their pre-authentication token.
+CREATE TABLE IF NOT EXISTS login_challenges (
+    token_hash TEXT PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMP NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_login_challenges_user_id ON login_challenges(user_id);
@@ -0,0 +1,3 @@
+-- Each session gets a random CSRF token, which its state-changing requests
+-- must echo in a header. Existing sessions are left without one.
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS csrf_token TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,85 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+	"vetsys/internal/domain"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type PasswordResetRepository struct {
+	DB           *sqlx.DB
+	QueryTimeout time.Duration
+}
+
+var ErrResetTokenInvalid = errors.New("Invalid or expired reset token")
+
+func (resetRepo *PasswordResetRepository) CreateResetToken(ctx context.Context, token *domain.PasswordResetToken) error {
+	ctx, cancel := withQueryTimeout(ctx, resetRepo.QueryTimeout)
+	defer cancel()
+
+	tx, err := resetRepo.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM password_reset_tokens WHERE user_id = $1`, token.UserID); err != nil {
+		return err
+	}
+	query := `
+	INSERT INTO password_reset_tokens (token_hash, user_id, created_at, expires_at)
+	VALUES (:token_hash, :user_id, :created_at, :expires_at)
+	`
+	if _, err := tx.NamedExecContext(ctx, query, token); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (resetRepo *PasswordResetRepository) ConsumeResetToken(ctx context.Context, tokenHash string) (int64, error) {
+	ctx, cancel := withQueryTimeout(ctx, resetRepo.QueryTimeout)
+	defer cancel()
+
+	tx, err := resetRepo.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// The row lock makes a concurrent use of the same token wait, and then
+	// find it gone.
+	query := `
+	SELECT user_id
+	FROM password_reset_tokens
+	WHERE token_hash = $1 AND expires_at > (now() AT TIME ZONE 'UTC')
+	FOR UPDATE
+	`
+	var userID int64
+	err = tx.GetContext(ctx, &userID, query, tokenHash)
+	if err == sql.ErrNoRows {
+		return 0, ErrResetTokenInvalid
+	}
+	if err != nil {
+		return 0, err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM password_reset_tokens WHERE user_id = $1`, userID); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return userID, nil
+}
+
+func (resetRepo *PasswordResetRepository) DeleteExpiredResetTokens(ctx context.Context) error {
+	ctx, cancel := withQueryTimeout(ctx, resetRepo.QueryTimeout)
+	defer cancel()
+
+	query := `DELETE FROM password_reset_tokens WHERE expires_at < (now() AT TIME ZONE 'UTC')`
+	_, err := resetRepo.DB.ExecContext(ctx, query)
+	return err
+}
@@ -0,0 +1,75 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+	"vetsys/internal/domain"
+)
+
+func TestPasswordResetRepository_ConsumeResetToken(t *testing.T) {
+	cleanupTables(testDB)
+
+	user := domain.NewUser("12345678A", "test@example.com", "hashedpassword", "Test User", "profile.jpg")
+	testDB.UserRepo.CreateUser(context.Background(), user)
+
+	first, record, err := domain.NewPasswordResetToken(user.ID, time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to create token: %v", err)
+	}
+	if err := testDB.PasswordResetRepo.CreateResetToken(context.Background(), record); err != nil {
+		t.Fatalf("Failed to store token: %v", err)
+	}
+	second, record, err := domain.NewPasswordResetToken(user.ID, time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to create token: %v", err)
+	}
+	if err := testDB.PasswordResetRepo.CreateResetToken(context.Background(), record); err != nil {
+		t.Fatalf("Failed to store token: %v", err)
+	}
+
+	// Replaced by the second.
+	if _, err := testDB.PasswordResetRepo.ConsumeResetToken(context.Background(), domain.HashToken(first)); err != ErrResetTokenInvalid {
+		t.Errorf("Expected ErrResetTokenInvalid for a replaced token, got %v", err)
+	}
+	userID, err := testDB.PasswordResetRepo.ConsumeResetToken(context.Background(), domain.HashToken(second))
+	if err != nil {
+		t.Fatalf("Failed to consume token: %v", err)
+	}
+	if userID != user.ID {
+		t.Errorf("Expected user ID %d, got %d", user.ID, userID)
+	}
+	if _, err := testDB.PasswordResetRepo.ConsumeResetToken(context.Background(), domain.HashToken(second)); err != ErrResetTokenInvalid {
+		t.Errorf("Expected ErrResetTokenInvalid for a used token, got %v", err)
+	}
+}
+
+func TestPasswordResetRepository_Expiry(t *testing.T) {
+	cleanupTables(testDB)
+
+	user := domain.NewUser("12345678A", "test@example.com", "hashedpassword", "Test User", "profile.jpg")
+	testDB.UserRepo.CreateUser(context.Background(), user)
+
+	token, record, err := domain.NewPasswordResetToken(user.ID, time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to create token: %v", err)
+	}
+	record.ExpiresAt = time.Now().UTC().Add(-time.Minute)
+	if err := testDB.PasswordResetRepo.CreateResetToken(context.Background(), record); err != nil {
+		t.Fatalf("Failed to store token: %v", err)
+	}
+
+	if _, err := testDB.PasswordResetRepo.ConsumeResetToken(context.Background(), domain.HashToken(token)); err != ErrResetTokenInvalid {
+		t.Errorf("Expected ErrResetTokenInvalid for an expired token, got %v", err)
+	}
+	if err := testDB.PasswordResetRepo.DeleteExpiredResetTokens(context.Background()); err != nil {
+		t.Fatalf("Failed to delete expired tokens: %v", err)
+	}
+	var count int
+	if err := testDB.DB.Get(&count, `SELECT COUNT(*) FROM password_reset_tokens`); err != nil {
+		t.Fatalf("Failed to count tokens: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("Expected the expired token to be deleted, got %d tokens", count)
+	}
+}
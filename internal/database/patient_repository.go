@@ -1,38 +1,45 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"errors"
+	"time"
 	"vetsys/internal/domain"
-
-	"github.com/jmoiron/sqlx"
 )
 
 type PatientRepository struct {
-	DB *sqlx.DB
+	DB           DBTX
+	QueryTimeout time.Duration
 }
 
 var ErrPatientNotFound = errors.New("Patient not found")
 
-func (patientRepository *PatientRepository) CreatePatient(patient *domain.Patient) error {
+func (patientRepository *PatientRepository) CreatePatient(ctx context.Context, patient *domain.Patient) error {
+	ctx, cancel := withQueryTimeout(ctx, patientRepository.QueryTimeout)
+	defer cancel()
+
 	query := `
 	INSERT INTO patients (name, species, breed, aprox_date_of_birth, owner_id)
 	VALUES (:name, :species, :breed, :aprox_date_of_birth, :owner_id)
 	RETURNING id
 	`
-	stmt, err := patientRepository.DB.PrepareNamed(query)
+	stmt, err := patientRepository.DB.PrepareNamedContext(ctx, query)
 	if err != nil {
 		return err
 	}
 	defer stmt.Close()
 
-	return stmt.Get(&patient.ID, patient)
+	return stmt.GetContext(ctx, &patient.ID, patient)
 }
 
-func (patientRepository *PatientRepository) GetPatientByID(id int64) (*domain.Patient, error) {
+func (patientRepository *PatientRepository) GetPatientByID(ctx context.Context, id int64) (*domain.Patient, error) {
+	ctx, cancel := withQueryTimeout(ctx, patientRepository.QueryTimeout)
+	defer cancel()
+
 	query := `SELECT id, name, species, breed, aprox_date_of_birth, owner_id FROM patients WHERE id = $1`
 	patient := domain.Patient{}
-	err := patientRepository.DB.Get(&patient, query, id)
+	err := patientRepository.DB.GetContext(ctx, &patient, query, id)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, ErrPatientNotFound
@@ -42,19 +49,25 @@ func (patientRepository *PatientRepository) GetPatientByID(id int64) (*domain.Pa
 	return &patient, nil
 }
 
-func (patientRepository *PatientRepository) GetPatientsByOwner(ownerID int64) ([]domain.Patient, error) {
+func (patientRepository *PatientRepository) GetPatientsByOwner(ctx context.Context, ownerID int64) ([]domain.Patient, error) {
+	ctx, cancel := withQueryTimeout(ctx, patientRepository.QueryTimeout)
+	defer cancel()
+
 	query := `SELECT id, name, species, breed, aprox_date_of_birth, owner_id FROM patients WHERE owner_id = $1`
 	var patients []domain.Patient
-	err := patientRepository.DB.Select(&patients, query, ownerID)
+	err := patientRepository.DB.SelectContext(ctx, &patients, query, ownerID)
 	if err != nil {
 		return nil, err
 	}
 	return patients, nil
 }
 
-func (patientRepository *PatientRepository) UpdatePatient(patient *domain.Patient) error {
+func (patientRepository *PatientRepository) UpdatePatient(ctx context.Context, patient *domain.Patient) error {
+	ctx, cancel := withQueryTimeout(ctx, patientRepository.QueryTimeout)
+	defer cancel()
+
 	query := "UPDATE patients SET name = $1, species = $2, breed = $3, aprox_date_of_birth = $4, owner_id = $5 WHERE id = $6"
-	result, err := patientRepository.DB.Exec(query, patient.Name, patient.Species, patient.Breed, patient.AproxDateOfBirth, patient.OwnerID, patient.ID)
+	result, err := patientRepository.DB.ExecContext(ctx, query, patient.Name, patient.Species, patient.Breed, patient.AproxDateOfBirth, patient.OwnerID, patient.ID)
 	if err != nil {
 		return err
 	}
@@ -69,10 +82,13 @@ func (patientRepository *PatientRepository) UpdatePatient(patient *domain.Patien
 	return nil
 }
 
-func (patientRepository *PatientRepository) DeletePatientByID(id int64) error {
-	result, err := patientRepository.DB.Exec("DELETE FROM patients WHERE id = $1", id)
+func (patientRepository *PatientRepository) DeletePatientByID(ctx context.Context, id int64) error {
+	ctx, cancel := withQueryTimeout(ctx, patientRepository.QueryTimeout)
+	defer cancel()
+
+	result, err := patientRepository.DB.ExecContext(ctx, "DELETE FROM patients WHERE id = $1", id)
 	if err != nil {
-		return err
+		return mapForeignKeyViolation(err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
@@ -0,0 +1,245 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+	"vetsys/internal/domain"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type PrescriptionRepository struct {
+	DB           *sqlx.DB
+	QueryTimeout time.Duration
+}
+
+var (
+	ErrMedicationNotFound   = errors.New("Medication not found")
+	ErrMedicationExists     = errors.New("a medication with that name already exists")
+	ErrDoseRangeNotFound    = errors.New("Dose range not found")
+	ErrPrescriptionNotFound = errors.New("Prescription not found")
+)
+
+const prescriptionColumns = `id, consultation_id, medication_id, prescribed_by, dose, dose_unit, frequency_hours, duration_days, route, patient_weight_kg, warnings, notes, created_at`
+
+// prescriptionRow scans the warnings array, which domain.Prescription keeps
+// as a plain slice.
+type prescriptionRow struct {
+	domain.Prescription
+	Warnings pq.StringArray `db:"warnings"`
+}
+
+func (row prescriptionRow) prescription() domain.Prescription {
+	prescription := row.Prescription
+	prescription.Warnings = []string(row.Warnings)
+	if prescription.Warnings == nil {
+		prescription.Warnings = []string{}
+	}
+	return prescription
+}
+
+// CreateMedication adds a medication to the catalogue together with its dose
+// ranges.
+func (prescriptionRepository *PrescriptionRepository) CreateMedication(ctx context.Context, medication *domain.Medication) error {
+	ctx, cancel := withQueryTimeout(ctx, prescriptionRepository.QueryTimeout)
+	defer cancel()
+
+	tx, err := prescriptionRepository.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO medications (name, description, dose_unit, created_at) VALUES ($1, $2, $3, $4)
+	ON CONFLICT (name) DO NOTHING
+	RETURNING id`
+	err = tx.GetContext(ctx, &medication.ID, query, medication.Name, medication.Description, medication.DoseUnit, medication.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrMedicationExists
+		}
+		return err
+	}
+	for i := range medication.DoseRanges {
+		medication.DoseRanges[i].MedicationID = medication.ID
+		_, err := tx.NamedExecContext(ctx, `INSERT INTO medication_dose_ranges (medication_id, species, min_dose_per_kg, max_dose_per_kg)
+		VALUES (:medication_id, :species, :min_dose_per_kg, :max_dose_per_kg)`, medication.DoseRanges[i])
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (prescriptionRepository *PrescriptionRepository) GetMedicationByID(ctx context.Context, id int64) (*domain.Medication, error) {
+	ctx, cancel := withQueryTimeout(ctx, prescriptionRepository.QueryTimeout)
+	defer cancel()
+
+	medication := domain.Medication{}
+	err := prescriptionRepository.DB.GetContext(ctx, &medication, `SELECT id, name, description, dose_unit, created_at FROM medications WHERE id = $1`, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrMedicationNotFound
+		}
+		return nil, err
+	}
+	medications := []domain.Medication{medication}
+	if err := prescriptionRepository.loadDoseRanges(ctx, medications); err != nil {
+		return nil, err
+	}
+	return &medications[0], nil
+}
+
+func (prescriptionRepository *PrescriptionRepository) GetMedications(ctx context.Context, limit int, offset int) ([]domain.Medication, error) {
+	ctx, cancel := withQueryTimeout(ctx, prescriptionRepository.QueryTimeout)
+	defer cancel()
+
+	var medications []domain.Medication
+	err := prescriptionRepository.DB.SelectContext(ctx, &medications, `SELECT id, name, description, dose_unit, created_at FROM medications ORDER BY name LIMIT $1 OFFSET $2`, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	if err := prescriptionRepository.loadDoseRanges(ctx, medications); err != nil {
+		return nil, err
+	}
+	return medications, nil
+}
+
+func (prescriptionRepository *PrescriptionRepository) GetMedicationsCount(ctx context.Context) (int64, error) {
+	ctx, cancel := withQueryTimeout(ctx, prescriptionRepository.QueryTimeout)
+	defer cancel()
+
+	var count int64
+	err := prescriptionRepository.DB.GetContext(ctx, &count, `SELECT COUNT(*) FROM medications`)
+	return count, err
+}
+
+// SetDoseRange creates or replaces the dose range of a medication for one
+// species. Existing prescriptions keep the warnings they were given.
+func (prescriptionRepository *PrescriptionRepository) SetDoseRange(ctx context.Context, doseRange *domain.MedicationDoseRange) error {
+	ctx, cancel := withQueryTimeout(ctx, prescriptionRepository.QueryTimeout)
+	defer cancel()
+
+	query := `INSERT INTO medication_dose_ranges (medication_id, species, min_dose_per_kg, max_dose_per_kg)
+	SELECT $1, $2, $3, $4 WHERE EXISTS (SELECT 1 FROM medications WHERE id = $1)
+	ON CONFLICT (medication_id, species) DO UPDATE SET min_dose_per_kg = EXCLUDED.min_dose_per_kg, max_dose_per_kg = EXCLUDED.max_dose_per_kg`
+	result, err := prescriptionRepository.DB.ExecContext(ctx, query, doseRange.MedicationID, doseRange.Species, doseRange.MinDosePerKg, doseRange.MaxDosePerKg)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrMedicationNotFound
+	}
+	return nil
+}
+
+func (prescriptionRepository *PrescriptionRepository) DeleteDoseRange(ctx context.Context, medicationID int64, species string) error {
+	ctx, cancel := withQueryTimeout(ctx, prescriptionRepository.QueryTimeout)
+	defer cancel()
+
+	result, err := prescriptionRepository.DB.ExecContext(ctx, `DELETE FROM medication_dose_ranges WHERE medication_id = $1 AND species = $2`, medicationID, species)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrDoseRangeNotFound
+	}
+	return nil
+}
+
+func (prescriptionRepository *PrescriptionRepository) CreatePrescription(ctx context.Context, prescription *domain.Prescription) error {
+	ctx, cancel := withQueryTimeout(ctx, prescriptionRepository.QueryTimeout)
+	defer cancel()
+
+	query := `INSERT INTO prescriptions (consultation_id, medication_id, prescribed_by, dose, dose_unit, frequency_hours, duration_days, route, patient_weight_kg, warnings, notes, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	RETURNING id`
+	return prescriptionRepository.DB.GetContext(ctx, &prescription.ID, query,
+		prescription.ConsultationID, prescription.MedicationID, prescription.PrescribedBy,
+		prescription.Dose, prescription.DoseUnit, prescription.FrequencyHours, prescription.DurationDays,
+		prescription.Route, prescription.PatientWeightKg, pq.Array(prescription.Warnings), prescription.Notes, prescription.CreatedAt)
+}
+
+func (prescriptionRepository *PrescriptionRepository) GetPrescriptionByID(ctx context.Context, id int64) (*domain.Prescription, error) {
+	ctx, cancel := withQueryTimeout(ctx, prescriptionRepository.QueryTimeout)
+	defer cancel()
+
+	row := prescriptionRow{}
+	err := prescriptionRepository.DB.GetContext(ctx, &row, `SELECT `+prescriptionColumns+` FROM prescriptions WHERE id = $1`, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrPrescriptionNotFound
+		}
+		return nil, err
+	}
+	prescription := row.prescription()
+	return &prescription, nil
+}
+
+func (prescriptionRepository *PrescriptionRepository) GetPrescriptionsByConsultationID(ctx context.Context, consultationID int64) ([]domain.Prescription, error) {
+	ctx, cancel := withQueryTimeout(ctx, prescriptionRepository.QueryTimeout)
+	defer cancel()
+
+	var rows []prescriptionRow
+	err := prescriptionRepository.DB.SelectContext(ctx, &rows, `SELECT `+prescriptionColumns+` FROM prescriptions WHERE consultation_id = $1 ORDER BY id`, consultationID)
+	if err != nil {
+		return nil, err
+	}
+	prescriptions := make([]domain.Prescription, len(rows))
+	for i, row := range rows {
+		prescriptions[i] = row.prescription()
+	}
+	return prescriptions, nil
+}
+
+func (prescriptionRepository *PrescriptionRepository) DeletePrescriptionByID(ctx context.Context, id int64) error {
+	ctx, cancel := withQueryTimeout(ctx, prescriptionRepository.QueryTimeout)
+	defer cancel()
+
+	result, err := prescriptionRepository.DB.ExecContext(ctx, `DELETE FROM prescriptions WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrPrescriptionNotFound
+	}
+	return nil
+}
+
+func (prescriptionRepository *PrescriptionRepository) loadDoseRanges(ctx context.Context, medications []domain.Medication) error {
+	if len(medications) == 0 {
+		return nil
+	}
+	ids := make([]int64, len(medications))
+	index := make(map[int64]int, len(medications))
+	for i := range medications {
+		ids[i] = medications[i].ID
+		index[medications[i].ID] = i
+		medications[i].DoseRanges = []domain.MedicationDoseRange{}
+	}
+	var doseRanges []domain.MedicationDoseRange
+	err := prescriptionRepository.DB.SelectContext(ctx, &doseRanges, `SELECT medication_id, species, min_dose_per_kg, max_dose_per_kg FROM medication_dose_ranges WHERE medication_id = ANY($1) ORDER BY species`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	for _, doseRange := range doseRanges {
+		i := index[doseRange.MedicationID]
+		medications[i].DoseRanges = append(medications[i].DoseRanges, doseRange)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+	"vetsys/internal/domain"
+)
+
+func createPrescriptionFixtures(t *testing.T) (*domain.User, *domain.Consultation, *domain.Medication) {
+	t.Helper()
+	cleanupTables(testDB)
+
+	vet := domain.NewUser("12345678A", "vet@example.com", "hashedpassword", "Dr. Vet", "vet.jpg")
+	vet.Role = domain.RoleVeterinarian
+	if err := testDB.UserRepo.CreateUser(context.Background(), vet); err != nil {
+		t.Fatalf("Failed to create vet: %v", err)
+	}
+
+	client := domain.NewClient("23456789B", "Jane Smith", "+34600222333")
+	testDB.ClientRepo.CreateClient(context.Background(), client)
+
+	dob := time.Date(2019, 3, 10, 0, 0, 0, 0, time.UTC)
+	patient := domain.NewPatient("Max", "Dog", "Labrador", dob, client.ID)
+	testDB.PatientRepo.CreatePatient(context.Background(), patient)
+
+	consultation := domain.NewConsultation(patient.ID, "Limping", "Sprain", "Rest", domain.SeverityLow)
+	testDB.ConsultationRepo.CreateConsultation(context.Background(), consultation)
+
+	medication := domain.NewMedication("Meloxicam", "NSAID", "mg")
+	medication.DoseRanges = append(medication.DoseRanges, *domain.NewMedicationDoseRange(0, "dog", 0.1, 0.2))
+	if err := testDB.PrescriptionRepo.CreateMedication(context.Background(), medication); err != nil {
+		t.Fatalf("Failed to create medication: %v", err)
+	}
+
+	return vet, consultation, medication
+}
+
+func TestPrescriptionRepository_CreateMedication(t *testing.T) {
+	_, _, medication := createPrescriptionFixtures(t)
+
+	retrieved, err := testDB.PrescriptionRepo.GetMedicationByID(context.Background(), medication.ID)
+	if err != nil {
+		t.Fatalf("Failed to get medication: %v", err)
+	}
+	if len(retrieved.DoseRanges) != 1 || retrieved.DoseRanges[0].Species != "dog" {
+		t.Errorf("Expected one dog dose range, got %v", retrieved.DoseRanges)
+	}
+
+	err = testDB.PrescriptionRepo.CreateMedication(context.Background(), domain.NewMedication("Meloxicam", "", "mg"))
+	if err != ErrMedicationExists {
+		t.Errorf("Expected ErrMedicationExists, got %v", err)
+	}
+}
+
+func TestPrescriptionRepository_SetDoseRange(t *testing.T) {
+	_, _, medication := createPrescriptionFixtures(t)
+
+	err := testDB.PrescriptionRepo.SetDoseRange(context.Background(), domain.NewMedicationDoseRange(medication.ID, "Cat", 0.05, 0.1))
+	if err != nil {
+		t.Fatalf("Failed to set dose range: %v", err)
+	}
+	retrieved, _ := testDB.PrescriptionRepo.GetMedicationByID(context.Background(), medication.ID)
+	if _, ok := retrieved.DoseRangeFor("cat"); !ok {
+		t.Error("Expected a cat dose range")
+	}
+
+	err = testDB.PrescriptionRepo.DeleteDoseRange(context.Background(), medication.ID, "rabbit")
+	if err != ErrDoseRangeNotFound {
+		t.Errorf("Expected ErrDoseRangeNotFound, got %v", err)
+	}
+}
+
+func TestPrescriptionRepository_CreatePrescription(t *testing.T) {
+	vet, consultation, medication := createPrescriptionFixtures(t)
+
+	weight := 30.0
+	prescription := domain.NewPrescription(consultation.ID, medication.ID, vet.ID, 9, "mg", 24, 5, domain.RouteOral, &weight, "")
+	prescription.Warnings = prescription.CheckDose(medication, "Dog")
+	if len(prescription.Warnings) != 1 {
+		t.Fatalf("Expected an overdose warning for 0.3 mg/kg, got %v", prescription.Warnings)
+	}
+
+	err := testDB.PrescriptionRepo.CreatePrescription(context.Background(), prescription)
+	if err != nil {
+		t.Fatalf("Failed to create prescription: %v", err)
+	}
+
+	prescriptions, err := testDB.PrescriptionRepo.GetPrescriptionsByConsultationID(context.Background(), consultation.ID)
+	if err != nil {
+		t.Fatalf("Failed to get prescriptions: %v", err)
+	}
+	if len(prescriptions) != 1 {
+		t.Fatalf("Expected 1 prescription, got %d", len(prescriptions))
+	}
+	if len(prescriptions[0].Warnings) != 1 {
+		t.Errorf("Expected warning to be stored, got %v", prescriptions[0].Warnings)
+	}
+	if prescriptions[0].PatientWeightKg == nil || *prescriptions[0].PatientWeightKg != weight {
+		t.Errorf("Expected patient weight %v, got %v", weight, prescriptions[0].PatientWeightKg)
+	}
+}
+
+func TestPrescriptionRepository_DeletePrescriptionByID(t *testing.T) {
+	vet, consultation, medication := createPrescriptionFixtures(t)
+
+	weight := 30.0
+	prescription := domain.NewPrescription(consultation.ID, medication.ID, vet.ID, 4.5, "mg", 24, 5, domain.RouteOral, &weight, "")
+	testDB.PrescriptionRepo.CreatePrescription(context.Background(), prescription)
+
+	err := testDB.PrescriptionRepo.DeletePrescriptionByID(context.Background(), prescription.ID)
+	if err != nil {
+		t.Fatalf("Failed to delete prescription: %v", err)
+	}
+	_, err = testDB.PrescriptionRepo.GetPrescriptionByID(context.Background(), prescription.ID)
+	if err != ErrPrescriptionNotFound {
+		t.Errorf("Expected ErrPrescriptionNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,153 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+	"unicode"
+	"vetsys/internal/domain"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// SearchRepository runs ranked full-text searches over the search_vector
+// columns of clients, patients and consultations.
+type SearchRepository struct {
+	DB           *sqlx.DB
+	QueryTimeout time.Duration
+}
+
+var ErrEmptySearchQuery = errors.New("search query has no searchable terms")
+
+// maxSearchTerms bounds how many words of a query are used.
+const maxSearchTerms = 8
+
+const searchTSQuery = `to_tsquery('simple', $1)`
+
+func (searchRepository *SearchRepository) SearchClients(ctx context.Context, q string, limit int, offset int) ([]domain.Client, error) {
+	ctx, cancel := withQueryTimeout(ctx, searchRepository.QueryTimeout)
+	defer cancel()
+
+	tsquery, err := prefixTSQuery(q)
+	if err != nil {
+		return nil, err
+	}
+	query := `SELECT id, dni, name, phone_number FROM clients
+	WHERE search_vector @@ ` + searchTSQuery + `
+	ORDER BY ts_rank(search_vector, ` + searchTSQuery + `) DESC, id
+	LIMIT $2 OFFSET $3`
+	var clients []domain.Client
+	err = searchRepository.DB.SelectContext(ctx, &clients, query, tsquery, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return clients, nil
+}
+
+func (searchRepository *SearchRepository) SearchClientsCount(ctx context.Context, q string) (int64, error) {
+	ctx, cancel := withQueryTimeout(ctx, searchRepository.QueryTimeout)
+	defer cancel()
+
+	return searchRepository.count(ctx, "clients", q)
+}
+
+func (searchRepository *SearchRepository) SearchPatients(ctx context.Context, q string, limit int, offset int) ([]domain.Patient, error) {
+	ctx, cancel := withQueryTimeout(ctx, searchRepository.QueryTimeout)
+	defer cancel()
+
+	tsquery, err := prefixTSQuery(q)
+	if err != nil {
+		return nil, err
+	}
+	query := `SELECT id, name, species, breed, aprox_date_of_birth, owner_id FROM patients
+	WHERE search_vector @@ ` + searchTSQuery + `
+	ORDER BY ts_rank(search_vector, ` + searchTSQuery + `) DESC, id
+	LIMIT $2 OFFSET $3`
+	var patients []domain.Patient
+	err = searchRepository.DB.SelectContext(ctx, &patients, query, tsquery, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return patients, nil
+}
+
+func (searchRepository *SearchRepository) SearchPatientsCount(ctx context.Context, q string) (int64, error) {
+	ctx, cancel := withQueryTimeout(ctx, searchRepository.QueryTimeout)
+	defer cancel()
+
+	return searchRepository.count(ctx, "patients", q)
+}
+
+func (searchRepository *SearchRepository) SearchConsultations(ctx context.Context, q string, limit int, offset int) ([]domain.Consultation, error) {
+	ctx, cancel := withQueryTimeout(ctx, searchRepository.QueryTimeout)
+	defer cancel()
+
+	tsquery, err := prefixTSQuery(q)
+	if err != nil {
+		return nil, err
+	}
+	query := `SELECT id, patient_id, reason, diagnosis, treatment, severity, is_completed, created_at, updated_at FROM consultations
+	WHERE search_vector @@ ` + searchTSQuery + `
+	ORDER BY ts_rank(search_vector, ` + searchTSQuery + `) DESC, created_at DESC, id
+	LIMIT $2 OFFSET $3`
+	var consultations []domain.Consultation
+	err = searchRepository.DB.SelectContext(ctx, &consultations, query, tsquery, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return consultations, nil
+}
+
+func (searchRepository *SearchRepository) SearchConsultationsCount(ctx context.Context, q string) (int64, error) {
+	ctx, cancel := withQueryTimeout(ctx, searchRepository.QueryTimeout)
+	defer cancel()
+
+	return searchRepository.count(ctx, "consultations", q)
+}
+
+// count is shared by the Search*Count methods; table is never user input.
+func (searchRepository *SearchRepository) count(ctx context.Context, table string, q string) (int64, error) {
+	tsquery, err := prefixTSQuery(q)
+	if err != nil {
+		return 0, err
+	}
+	var count int64
+	query := `SELECT COUNT(*) FROM ` + table + ` WHERE search_vector @@ ` + searchTSQuery
+	err = searchRepository.DB.GetContext(ctx, &count, query, tsquery)
+	return count, err
+}
+
+// prefixTSQuery turns free text into a tsquery that matches rows containing
+// every word as a prefix, e.g. "lu pers" becomes "lu:* & pers:*". A query
+// without letters is treated as a phone number and reduced to its digits, so
+// "+34 600-222" matches 34600222333. Only letters and digits reach the query,
+// so user input cannot inject tsquery operators.
+func prefixTSQuery(q string) (string, error) {
+	var terms []string
+	if strings.IndexFunc(q, unicode.IsLetter) < 0 {
+		digits := strings.Map(func(r rune) rune {
+			if unicode.IsDigit(r) {
+				return r
+			}
+			return -1
+		}, q)
+		if digits != "" {
+			terms = append(terms, digits)
+		}
+	} else {
+		terms = strings.FieldsFunc(strings.ToLower(q), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+	}
+	if len(terms) == 0 {
+		return "", ErrEmptySearchQuery
+	}
+	if len(terms) > maxSearchTerms {
+		terms = terms[:maxSearchTerms]
+	}
+	for i, term := range terms {
+		terms[i] = term + ":*"
+	}
+	return strings.Join(terms, " & "), nil
+}
@@ -0,0 +1,116 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+	"vetsys/internal/domain"
+)
+
+func createSearchFixtures(t *testing.T) {
+	t.Helper()
+	cleanupTables(testDB)
+
+	jane := domain.NewClient("23456789B", "Jane Smith", "+34600222333")
+	testDB.ClientRepo.CreateClient(context.Background(), jane)
+	john := domain.NewClient("34567890C", "John Doe", "+34611444555")
+	testDB.ClientRepo.CreateClient(context.Background(), john)
+
+	dob := time.Date(2019, 3, 10, 0, 0, 0, 0, time.UTC)
+	luna := domain.NewPatient("Luna", "Cat", "Persian", dob, jane.ID)
+	testDB.PatientRepo.CreatePatient(context.Background(), luna)
+	testDB.PatientRepo.CreatePatient(context.Background(), domain.NewPatient("Max", "Dog", "Labrador", dob, john.ID))
+
+	testDB.ConsultationRepo.CreateConsultation(context.Background(), domain.NewConsultation(luna.ID, "Vomiting", "Hairball obstruction", "Laxative paste", domain.SeverityMedium))
+	testDB.ConsultationRepo.CreateConsultation(context.Background(), domain.NewConsultation(luna.ID, "Annual checkup", "Healthy", "None", domain.SeverityLow))
+}
+
+func TestSearchRepository_SearchClients(t *testing.T) {
+	createSearchFixtures(t)
+
+	clients, err := testDB.SearchRepo.SearchClients(context.Background(), "smi", 20, 0)
+	if err != nil {
+		t.Fatalf("Failed to search clients: %v", err)
+	}
+	if len(clients) != 1 || clients[0].Name != "Jane Smith" {
+		t.Errorf("Expected to find Jane Smith by partial name, got %v", clients)
+	}
+
+	clients, err = testDB.SearchRepo.SearchClients(context.Background(), "+34 611", 20, 0)
+	if err != nil {
+		t.Fatalf("Failed to search clients: %v", err)
+	}
+	if len(clients) != 1 || clients[0].Name != "John Doe" {
+		t.Errorf("Expected to find John Doe by partial phone, got %v", clients)
+	}
+
+	count, err := testDB.SearchRepo.SearchClientsCount(context.Background(), "j")
+	if err != nil {
+		t.Fatalf("Failed to count clients: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 clients matching 'j', got %d", count)
+	}
+}
+
+func TestSearchRepository_SearchPatients(t *testing.T) {
+	createSearchFixtures(t)
+
+	patients, err := testDB.SearchRepo.SearchPatients(context.Background(), "cat pers", 20, 0)
+	if err != nil {
+		t.Fatalf("Failed to search patients: %v", err)
+	}
+	if len(patients) != 1 || patients[0].Name != "Luna" {
+		t.Errorf("Expected to find Luna by species and breed, got %v", patients)
+	}
+}
+
+func TestSearchRepository_SearchConsultations(t *testing.T) {
+	createSearchFixtures(t)
+
+	consultations, err := testDB.SearchRepo.SearchConsultations(context.Background(), "hairball", 20, 0)
+	if err != nil {
+		t.Fatalf("Failed to search consultations: %v", err)
+	}
+	if len(consultations) != 1 || consultations[0].Reason != "Vomiting" {
+		t.Errorf("Expected to find consultation by diagnosis, got %v", consultations)
+	}
+
+	count, err := testDB.SearchRepo.SearchConsultationsCount(context.Background(), "laxative")
+	if err != nil {
+		t.Fatalf("Failed to count consultations: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("Expected treatment text not to be searched, got %d matches", count)
+	}
+}
+
+func TestSearchRepository_EmptyQuery(t *testing.T) {
+	_, err := testDB.SearchRepo.SearchClients(context.Background(), " +-& ", 20, 0)
+	if err != ErrEmptySearchQuery {
+		t.Errorf("Expected ErrEmptySearchQuery, got %v", err)
+	}
+}
+
+func TestPrefixTSQuery(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"Luna", "luna:*"},
+		{"jane  SMITH", "jane:* & smith:*"},
+		{"+34 600-222", "34600222:*"},
+		{"o'brien", "o:* & brien:*"},
+		{"luna & !max", "luna:* & max:*"},
+	}
+	for _, test := range tests {
+		got, err := prefixTSQuery(test.input)
+		if err != nil {
+			t.Errorf("prefixTSQuery(%q) returned error: %v", test.input, err)
+			continue
+		}
+		if got != test.expected {
+			t.Errorf("Expected prefixTSQuery(%q) = %q, got %q", test.input, test.expected, got)
+		}
+	}
+}
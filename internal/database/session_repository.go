@@ -1,32 +1,45 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"errors"
+	"time"
 	"vetsys/internal/domain"
 
 	"github.com/jmoiron/sqlx"
 )
 
 type SessionRepository struct {
-	DB *sqlx.DB
+	DB           *sqlx.DB
+	QueryTimeout time.Duration
 }
 
 var ErrSessionNotFound = errors.New("Session not found")
 
-func (sessionRepo *SessionRepository) CreateSession(session *domain.Session) error {
+func (sessionRepo *SessionRepository) CreateSession(ctx context.Context, session *domain.Session) error {
+	ctx, cancel := withQueryTimeout(ctx, sessionRepo.QueryTimeout)
+	defer cancel()
+
 	query := `
-	INSERT INTO sessions (id, expires_at, created_at, user_id)
-	VALUES (:id, :expires_at, :created_at, :user_id)
+	INSERT INTO sessions (id, expires_at, created_at, user_id, last_seen_at, ip, user_agent, csrf_token)
+	VALUES (:id, :expires_at, :created_at, :user_id, :last_seen_at, :ip, :user_agent, :csrf_token)
 	`
-	_, err := sessionRepo.DB.NamedExec(query, session)
+	_, err := sessionRepo.DB.NamedExecContext(ctx, query, session)
 	return err
 }
 
-func (sessionRepo *SessionRepository) GetSession(id string) (*domain.Session, error) {
-	query := `SELECT id, expires_at, created_at, user_id FROM sessions WHERE id = $1 AND expires_at > (now() AT TIME ZONE 'UTC')`
+func (sessionRepo *SessionRepository) GetSession(ctx context.Context, id string) (*domain.Session, error) {
+	ctx, cancel := withQueryTimeout(ctx, sessionRepo.QueryTimeout)
+	defer cancel()
+
+	query := `
+	SELECT id, expires_at, created_at, user_id, last_seen_at, ip, user_agent, csrf_token
+	FROM sessions
+	WHERE id = $1 AND expires_at > (now() AT TIME ZONE 'UTC')
+	`
 	session := domain.Session{}
-	err := sessionRepo.DB.Get(&session, query, id)
+	err := sessionRepo.DB.GetContext(ctx, &session, query, id)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, ErrSessionNotFound
@@ -35,9 +48,68 @@ func (sessionRepo *SessionRepository) GetSession(id string) (*domain.Session, er
 	}
 	return &session, nil
 }
-func (sessionRepo *SessionRepository) DeleteSessionByID(id string) error {
+
+// GetSessionsByUserID returns the user's unexpired sessions, most recently
+// used first.
+func (sessionRepo *SessionRepository) GetSessionsByUserID(ctx context.Context, userID int64) ([]domain.Session, error) {
+	ctx, cancel := withQueryTimeout(ctx, sessionRepo.QueryTimeout)
+	defer cancel()
+
+	query := `
+	SELECT id, expires_at, created_at, user_id, last_seen_at, ip, user_agent, csrf_token
+	FROM sessions
+	WHERE user_id = $1 AND expires_at > (now() AT TIME ZONE 'UTC')
+	ORDER BY last_seen_at DESC, created_at DESC
+	`
+	sessions := []domain.Session{}
+	err := sessionRepo.DB.SelectContext(ctx, &sessions, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// TouchSession records that the session was used at lastSeenAt and moves its
+// expiry to expiresAt.
+func (sessionRepo *SessionRepository) TouchSession(ctx context.Context, id string, lastSeenAt time.Time, expiresAt time.Time) error {
+	ctx, cancel := withQueryTimeout(ctx, sessionRepo.QueryTimeout)
+	defer cancel()
+
+	query := `UPDATE sessions SET last_seen_at = $2, expires_at = $3 WHERE id = $1`
+	result, err := sessionRepo.DB.ExecContext(ctx, query, id, lastSeenAt, expiresAt)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+// DeleteSessionsByUserID signs the user out everywhere except the session
+// exceptID, which may be empty, and returns how many sessions it deleted.
+func (sessionRepo *SessionRepository) DeleteSessionsByUserID(ctx context.Context, userID int64, exceptID string) (int64, error) {
+	ctx, cancel := withQueryTimeout(ctx, sessionRepo.QueryTimeout)
+	defer cancel()
+
+	query := `DELETE FROM sessions WHERE user_id = $1 AND id <> $2`
+	result, err := sessionRepo.DB.ExecContext(ctx, query, userID, exceptID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func (sessionRepo *SessionRepository) DeleteSessionByID(ctx context.Context, id string) error {
+	ctx, cancel := withQueryTimeout(ctx, sessionRepo.QueryTimeout)
+	defer cancel()
+
 	query := `DELETE FROM sessions WHERE id = $1`
-	result, err := sessionRepo.DB.Exec(query, id)
+	result, err := sessionRepo.DB.ExecContext(ctx, query, id)
 	if err != nil {
 		return err
 	}
@@ -51,9 +123,12 @@ func (sessionRepo *SessionRepository) DeleteSessionByID(id string) error {
 	return nil
 }
 
-func (sessionRepo *SessionRepository) DeleteOldSessions() error {
+func (sessionRepo *SessionRepository) DeleteOldSessions(ctx context.Context) error {
+	ctx, cancel := withQueryTimeout(ctx, sessionRepo.QueryTimeout)
+	defer cancel()
+
 	query := `DELETE FROM sessions WHERE expires_at < (now() AT TIME ZONE 'UTC')`
-	result, err := sessionRepo.DB.Exec(query)
+	result, err := sessionRepo.DB.ExecContext(ctx, query)
 	if err != nil {
 		return err
 	}
@@ -63,3 +138,13 @@ func (sessionRepo *SessionRepository) DeleteOldSessions() error {
 	}
 	return nil
 }
+
+func (sessionRepo *SessionRepository) CountActiveSessions(ctx context.Context) (int64, error) {
+	ctx, cancel := withQueryTimeout(ctx, sessionRepo.QueryTimeout)
+	defer cancel()
+
+	var count int64
+	query := `SELECT COUNT(*) FROM sessions WHERE expires_at > (now() AT TIME ZONE 'UTC')`
+	err := sessionRepo.DB.GetContext(ctx, &count, query)
+	return count, err
+}
@@ -1,6 +1,7 @@
 package database
 
 import (
+	"context"
 	"testing"
 	"time"
 	"vetsys/internal/domain"
@@ -10,14 +11,14 @@ func TestSessionRepository_CreateSession(t *testing.T) {
 	cleanupTables(testDB)
 
 	user := domain.NewUser("12345678A", "test@example.com", "hashedpassword", "Test User", "profile.jpg")
-	testDB.UserRepo.CreateUser(user)
+	testDB.UserRepo.CreateUser(context.Background(), user)
 
 	session, err := domain.NewSession(user.ID, 24*time.Hour)
 	if err != nil {
 		t.Fatalf("Failed to create session object: %v", err)
 	}
 
-	err = testDB.SessionRepo.CreateSession(session)
+	err = testDB.SessionRepo.CreateSession(context.Background(), session)
 	if err != nil {
 		t.Fatalf("Failed to create session: %v", err)
 	}
@@ -31,12 +32,12 @@ func TestSessionRepository_GetSession(t *testing.T) {
 	cleanupTables(testDB)
 
 	user := domain.NewUser("23456789B", "user1@example.com", "hashedpass1", "User One", "avatar1.jpg")
-	testDB.UserRepo.CreateUser(user)
+	testDB.UserRepo.CreateUser(context.Background(), user)
 
 	session, _ := domain.NewSession(user.ID, 24*time.Hour)
-	testDB.SessionRepo.CreateSession(session)
+	testDB.SessionRepo.CreateSession(context.Background(), session)
 
-	retrieved, err := testDB.SessionRepo.GetSession(session.ID)
+	retrieved, err := testDB.SessionRepo.GetSession(context.Background(), session.ID)
 	if err != nil {
 		t.Fatalf("Failed to get session: %v", err)
 	}
@@ -44,18 +45,21 @@ func TestSessionRepository_GetSession(t *testing.T) {
 	if retrieved.ID != session.ID {
 		t.Errorf("Expected session ID %s, got %s", session.ID, retrieved.ID)
 	}
+	if retrieved.CSRFToken == "" || retrieved.CSRFToken != session.CSRFToken {
+		t.Errorf("Expected CSRF token %s, got %s", session.CSRFToken, retrieved.CSRFToken)
+	}
 }
 
 func TestSessionRepository_GetSession_Expired(t *testing.T) {
 	cleanupTables(testDB)
 
 	user := domain.NewUser("34567890C", "user2@example.com", "hashedpass2", "User Two", "avatar2.jpg")
-	testDB.UserRepo.CreateUser(user)
+	testDB.UserRepo.CreateUser(context.Background(), user)
 
 	session, _ := domain.NewSession(user.ID, -1*time.Second)
-	testDB.SessionRepo.CreateSession(session)
+	testDB.SessionRepo.CreateSession(context.Background(), session)
 
-	_, err := testDB.SessionRepo.GetSession(session.ID)
+	_, err := testDB.SessionRepo.GetSession(context.Background(), session.ID)
 	if err != ErrSessionNotFound {
 		t.Errorf("Expected ErrSessionNotFound for expired session, got %v", err)
 	}
@@ -65,17 +69,17 @@ func TestSessionRepository_DeleteSessionByID(t *testing.T) {
 	cleanupTables(testDB)
 
 	user := domain.NewUser("45678901D", "user3@example.com", "hashedpass3", "User Three", "avatar3.jpg")
-	testDB.UserRepo.CreateUser(user)
+	testDB.UserRepo.CreateUser(context.Background(), user)
 
 	session, _ := domain.NewSession(user.ID, 24*time.Hour)
-	testDB.SessionRepo.CreateSession(session)
+	testDB.SessionRepo.CreateSession(context.Background(), session)
 
-	err := testDB.SessionRepo.DeleteSessionByID(session.ID)
+	err := testDB.SessionRepo.DeleteSessionByID(context.Background(), session.ID)
 	if err != nil {
 		t.Fatalf("Failed to delete session: %v", err)
 	}
 
-	_, err = testDB.SessionRepo.GetSession(session.ID)
+	_, err = testDB.SessionRepo.GetSession(context.Background(), session.ID)
 	if err != ErrSessionNotFound {
 		t.Errorf("Expected ErrSessionNotFound after deletion, got %v", err)
 	}
@@ -85,25 +89,25 @@ func TestSessionRepository_DeleteOldSessions(t *testing.T) {
 	cleanupTables(testDB)
 
 	user := domain.NewUser("56789012E", "user4@example.com", "hashedpass4", "User Four", "avatar4.jpg")
-	testDB.UserRepo.CreateUser(user)
+	testDB.UserRepo.CreateUser(context.Background(), user)
 
 	expiredSession, _ := domain.NewSession(user.ID, -1*time.Hour)
-	testDB.SessionRepo.CreateSession(expiredSession)
+	testDB.SessionRepo.CreateSession(context.Background(), expiredSession)
 
 	validSession, _ := domain.NewSession(user.ID, 24*time.Hour)
-	testDB.SessionRepo.CreateSession(validSession)
+	testDB.SessionRepo.CreateSession(context.Background(), validSession)
 
-	err := testDB.SessionRepo.DeleteOldSessions()
+	err := testDB.SessionRepo.DeleteOldSessions(context.Background())
 	if err != nil {
 		t.Fatalf("Failed to delete old sessions: %v", err)
 	}
 
-	_, err = testDB.SessionRepo.GetSession(expiredSession.ID)
+	_, err = testDB.SessionRepo.GetSession(context.Background(), expiredSession.ID)
 	if err != ErrSessionNotFound {
 		t.Errorf("Expected expired session to be deleted")
 	}
 
-	_, err = testDB.SessionRepo.GetSession(validSession.ID)
+	_, err = testDB.SessionRepo.GetSession(context.Background(), validSession.ID)
 	if err != nil {
 		t.Errorf("Expected valid session to still exist, got error: %v", err)
 	}
@@ -113,18 +117,116 @@ func TestSessionRepository_CascadeDeleteOnUserDelete(t *testing.T) {
 	cleanupTables(testDB)
 
 	user := domain.NewUser("67890123F", "user5@example.com", "hashedpass5", "User Five", "avatar5.jpg")
-	testDB.UserRepo.CreateUser(user)
+	testDB.UserRepo.CreateUser(context.Background(), user)
 
 	session, _ := domain.NewSession(user.ID, 24*time.Hour)
-	testDB.SessionRepo.CreateSession(session)
+	testDB.SessionRepo.CreateSession(context.Background(), session)
 
-	err := testDB.UserRepo.DeleteUserByID(user.ID)
+	err := testDB.UserRepo.DeleteUserByID(context.Background(), user.ID)
 	if err != nil {
 		t.Fatalf("Failed to delete user: %v", err)
 	}
 
-	_, err = testDB.SessionRepo.GetSession(session.ID)
+	_, err = testDB.SessionRepo.GetSession(context.Background(), session.ID)
 	if err != ErrSessionNotFound {
 		t.Errorf("Expected session to be cascade deleted, got error: %v", err)
 	}
 }
+
+func TestSessionRepository_GetSessionsByUserID(t *testing.T) {
+	cleanupTables(testDB)
+
+	user := domain.NewUser("45678901D", "user3@example.com", "hashedpass3", "User Three", "avatar3.jpg")
+	testDB.UserRepo.CreateUser(context.Background(), user)
+	other := domain.NewUser("56789012E", "user4@example.com", "hashedpass4", "User Four", "avatar4.jpg")
+	testDB.UserRepo.CreateUser(context.Background(), other)
+
+	older, _ := domain.NewSession(user.ID, time.Hour)
+	older.LastSeenAt = older.LastSeenAt.Add(-time.Hour)
+	older.IP = "203.0.113.7"
+	older.UserAgent = "Firefox"
+	testDB.SessionRepo.CreateSession(context.Background(), older)
+	newer, _ := domain.NewSession(user.ID, time.Hour)
+	testDB.SessionRepo.CreateSession(context.Background(), newer)
+	expired, _ := domain.NewSession(user.ID, -time.Second)
+	testDB.SessionRepo.CreateSession(context.Background(), expired)
+	foreign, _ := domain.NewSession(other.ID, time.Hour)
+	testDB.SessionRepo.CreateSession(context.Background(), foreign)
+
+	sessions, err := testDB.SessionRepo.GetSessionsByUserID(context.Background(), user.ID)
+	if err != nil {
+		t.Fatalf("Failed to get sessions: %v", err)
+	}
+	if len(sessions) != 2 {
+		t.Fatalf("Expected 2 active sessions, got %d", len(sessions))
+	}
+	if sessions[0].ID != newer.ID || sessions[1].ID != older.ID {
+		t.Error("Expected the most recently used session first")
+	}
+	if sessions[1].IP != "203.0.113.7" || sessions[1].UserAgent != "Firefox" {
+		t.Errorf("Expected IP and user agent to be stored, got %q and %q", sessions[1].IP, sessions[1].UserAgent)
+	}
+}
+
+func TestSessionRepository_TouchSession(t *testing.T) {
+	cleanupTables(testDB)
+
+	user := domain.NewUser("67890123F", "user5@example.com", "hashedpass5", "User Five", "avatar5.jpg")
+	testDB.UserRepo.CreateUser(context.Background(), user)
+	session, _ := domain.NewSession(user.ID, time.Minute)
+	testDB.SessionRepo.CreateSession(context.Background(), session)
+
+	lastSeenAt := time.Now().UTC().Truncate(time.Microsecond)
+	expiresAt := lastSeenAt.Add(time.Hour)
+	if err := testDB.SessionRepo.TouchSession(context.Background(), session.ID, lastSeenAt, expiresAt); err != nil {
+		t.Fatalf("Failed to touch session: %v", err)
+	}
+	touched, err := testDB.SessionRepo.GetSession(context.Background(), session.ID)
+	if err != nil {
+		t.Fatalf("Failed to get session: %v", err)
+	}
+	if !touched.LastSeenAt.Equal(lastSeenAt) || !touched.ExpiresAt.Equal(expiresAt) {
+		t.Errorf("Expected last seen %v and expiry %v, got %v and %v", lastSeenAt, expiresAt, touched.LastSeenAt, touched.ExpiresAt)
+	}
+
+	if err := testDB.SessionRepo.TouchSession(context.Background(), "missing", lastSeenAt, expiresAt); err != ErrSessionNotFound {
+		t.Errorf("Expected ErrSessionNotFound, got %v", err)
+	}
+}
+
+func TestSessionRepository_DeleteSessionsByUserID(t *testing.T) {
+	cleanupTables(testDB)
+
+	user := domain.NewUser("78901234G", "user6@example.com", "hashedpass6", "User Six", "avatar6.jpg")
+	testDB.UserRepo.CreateUser(context.Background(), user)
+	other := domain.NewUser("89012345H", "user7@example.com", "hashedpass7", "User Seven", "avatar7.jpg")
+	testDB.UserRepo.CreateUser(context.Background(), other)
+
+	kept, _ := domain.NewSession(user.ID, time.Hour)
+	testDB.SessionRepo.CreateSession(context.Background(), kept)
+	for range 2 {
+		session, _ := domain.NewSession(user.ID, time.Hour)
+		testDB.SessionRepo.CreateSession(context.Background(), session)
+	}
+	foreign, _ := domain.NewSession(other.ID, time.Hour)
+	testDB.SessionRepo.CreateSession(context.Background(), foreign)
+
+	deleted, err := testDB.SessionRepo.DeleteSessionsByUserID(context.Background(), user.ID, kept.ID)
+	if err != nil {
+		t.Fatalf("Failed to delete sessions: %v", err)
+	}
+	if deleted != 2 {
+		t.Errorf("Expected 2 sessions deleted, got %d", deleted)
+	}
+	if _, err := testDB.SessionRepo.GetSession(context.Background(), kept.ID); err != nil {
+		t.Errorf("Expected the excepted session to remain, got %v", err)
+	}
+	if _, err := testDB.SessionRepo.GetSession(context.Background(), foreign.ID); err != nil {
+		t.Errorf("Expected other users' sessions to remain, got %v", err)
+	}
+
+	deleted, err = testDB.SessionRepo.DeleteSessionsByUserID(context.Background(), user.ID, "")
+	if err != nil || deleted != 1 {
+		t.Errorf("Expected the last session deleted, got %d, %v", deleted, err)
+	}
+}
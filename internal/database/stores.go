@@ -0,0 +1,246 @@
+package database
+
+import (
+	"context"
+	"time"
+	"vetsys/internal/domain"
+)
+
+// The Store interfaces are what handlers and middleware depend on. The
+// Postgres repositories in this package implement them, and so does the
+// in-memory stand-in in the memory package. Every method takes the context of
+// the request it serves, so a cancelled request stops its queries.
+
+type UserStore interface {
+	CreateUser(ctx context.Context, user *domain.User) error
+	GetUserByID(ctx context.Context, id int64) (*domain.User, error)
+	GetUserByDNI(ctx context.Context, dni string) (*domain.User, error)
+	GetUserByEmail(ctx context.Context, email string) (*domain.User, error)
+	DeleteUserByID(ctx context.Context, id int64) error
+	UpdateUser(ctx context.Context, user *domain.User) error
+	UpdatePassword(ctx context.Context, id int64, password string) error
+	UpdateUserRole(ctx context.Context, id int64, role domain.Role) error
+}
+
+type SessionStore interface {
+	CreateSession(ctx context.Context, session *domain.Session) error
+	GetSession(ctx context.Context, id string) (*domain.Session, error)
+	GetSessionsByUserID(ctx context.Context, userID int64) ([]domain.Session, error)
+	TouchSession(ctx context.Context, id string, lastSeenAt time.Time, expiresAt time.Time) error
+	DeleteSessionByID(ctx context.Context, id string) error
+	DeleteSessionsByUserID(ctx context.Context, userID int64, exceptID string) (int64, error)
+	DeleteOldSessions(ctx context.Context) error
+	CountActiveSessions(ctx context.Context) (int64, error)
+}
+
+type AllowedRegistrationStore interface {
+	InsertDNI(ctx context.Context, dni string) error
+	UseDNI(ctx context.Context, dni string) (domain.Role, error)
+	DeleteDNI(ctx context.Context, dni string) error
+	InsertRegistrations(ctx context.Context, registrations []domain.AllowedRegistration) ([]string, error)
+	GetRegistration(ctx context.Context, dni string) (*domain.AllowedRegistration, error)
+	GetRegistrations(ctx context.Context, used *bool, limit int, offset int) ([]domain.AllowedRegistration, error)
+	GetRegistrationsCount(ctx context.Context, used *bool) (int64, error)
+	RevokeDNI(ctx context.Context, dni string) error
+	ReopenDNI(ctx context.Context, dni string, invitedBy int64, expiresAt *time.Time) error
+}
+
+type ClientStore interface {
+	CreateClient(ctx context.Context, client *domain.Client) error
+	GetClientByID(ctx context.Context, id int64) (*domain.Client, error)
+	GetClientByDNI(ctx context.Context, dni string) (*domain.Client, error)
+	UpdateClient(ctx context.Context, client *domain.Client) error
+	DeleteClientByID(ctx context.Context, id int64) error
+}
+
+type PatientStore interface {
+	CreatePatient(ctx context.Context, patient *domain.Patient) error
+	GetPatientByID(ctx context.Context, id int64) (*domain.Patient, error)
+	GetPatientsByOwner(ctx context.Context, ownerID int64) ([]domain.Patient, error)
+	UpdatePatient(ctx context.Context, patient *domain.Patient) error
+	DeletePatientByID(ctx context.Context, id int64) error
+}
+
+type ConsultationStore interface {
+	CreateConsultation(ctx context.Context, consultation *domain.Consultation) error
+	GetConsultationByID(ctx context.Context, id int64) (*domain.Consultation, error)
+	GetConsultationsByClientID(ctx context.Context, clientID int64, limit int, offset int) ([]domain.Consultation, error)
+	GetConsultationsByPatientID(ctx context.Context, patientID int64, limit int, offset int) ([]domain.Consultation, error)
+	GetAllConsultations(ctx context.Context, limit int, offset int) ([]domain.Consultation, error)
+	GetConsultationsByIsCompleted(ctx context.Context, isCompleted bool, limit int, offset int) ([]domain.Consultation, error)
+	UpdateConsultation(ctx context.Context, consultation *domain.Consultation) error
+	DeleteConsultation(ctx context.Context, id int64) error
+	GetAllConsultationsCount(ctx context.Context) (int64, error)
+	GetConsultationsByPatientIDCount(ctx context.Context, patientID int64) (int64, error)
+	GetConsultationsByClientIDCount(ctx context.Context, clientID int64) (int64, error)
+	GetConsultationsByIsCompletedCount(ctx context.Context, isCompleted bool) (int64, error)
+}
+
+type AppointmentStore interface {
+	CreateAppointment(ctx context.Context, appointment *domain.Appointment) error
+	GetAppointmentByID(ctx context.Context, id int64) (*domain.Appointment, error)
+	GetAppointments(ctx context.Context, filter AppointmentFilter, limit int, offset int) ([]domain.Appointment, error)
+	GetAppointmentsCount(ctx context.Context, filter AppointmentFilter) (int64, error)
+	UpdateAppointment(ctx context.Context, appointment *domain.Appointment) error
+	UpdateAppointmentStatus(ctx context.Context, id int64, from domain.AppointmentStatus, to domain.AppointmentStatus) error
+	ConvertToConsultation(ctx context.Context, appointment *domain.Appointment, consultation *domain.Consultation) error
+}
+
+type AuditStore interface {
+	CreateEntry(ctx context.Context, entry *domain.AuditEntry) error
+	GetEntries(ctx context.Context, filter AuditFilter, limit int, offset int) ([]domain.AuditEntry, error)
+	GetEntriesCount(ctx context.Context, filter AuditFilter) (int64, error)
+}
+
+type SearchStore interface {
+	SearchClients(ctx context.Context, q string, limit int, offset int) ([]domain.Client, error)
+	SearchClientsCount(ctx context.Context, q string) (int64, error)
+	SearchPatients(ctx context.Context, q string, limit int, offset int) ([]domain.Patient, error)
+	SearchPatientsCount(ctx context.Context, q string) (int64, error)
+	SearchConsultations(ctx context.Context, q string, limit int, offset int) ([]domain.Consultation, error)
+	SearchConsultationsCount(ctx context.Context, q string) (int64, error)
+}
+
+type VaccinationStore interface {
+	CreateVaccine(ctx context.Context, vaccine *domain.Vaccine) error
+	GetVaccineByID(ctx context.Context, id int64) (*domain.Vaccine, error)
+	GetVaccines(ctx context.Context, limit int, offset int) ([]domain.Vaccine, error)
+	GetVaccinesCount(ctx context.Context) (int64, error)
+	SetVaccineBooster(ctx context.Context, booster *domain.VaccineBooster) error
+	DeleteVaccineBooster(ctx context.Context, vaccineID int64, species string) error
+	CreateVaccination(ctx context.Context, vaccination *domain.Vaccination) error
+	GetVaccinationByID(ctx context.Context, id int64) (*domain.Vaccination, error)
+	GetVaccinationsByPatientID(ctx context.Context, patientID int64, limit int, offset int) ([]domain.Vaccination, error)
+	GetVaccinationsCountByPatientID(ctx context.Context, patientID int64) (int64, error)
+	DeleteVaccinationByID(ctx context.Context, id int64) error
+	GetDueVaccinations(ctx context.Context, until time.Time, now time.Time, limit int, offset int) ([]domain.DueVaccination, error)
+	GetDueVaccinationsCount(ctx context.Context, until time.Time) (int64, error)
+}
+
+type PrescriptionStore interface {
+	CreateMedication(ctx context.Context, medication *domain.Medication) error
+	GetMedicationByID(ctx context.Context, id int64) (*domain.Medication, error)
+	GetMedications(ctx context.Context, limit int, offset int) ([]domain.Medication, error)
+	GetMedicationsCount(ctx context.Context) (int64, error)
+	SetDoseRange(ctx context.Context, doseRange *domain.MedicationDoseRange) error
+	DeleteDoseRange(ctx context.Context, medicationID int64, species string) error
+	CreatePrescription(ctx context.Context, prescription *domain.Prescription) error
+	GetPrescriptionByID(ctx context.Context, id int64) (*domain.Prescription, error)
+	GetPrescriptionsByConsultationID(ctx context.Context, consultationID int64) ([]domain.Prescription, error)
+	DeletePrescriptionByID(ctx context.Context, id int64) error
+}
+
+type VitalsStore interface {
+	CreateVitals(ctx context.Context, vitals *domain.Vitals) error
+	GetVitalsByID(ctx context.Context, id int64) (*domain.Vitals, error)
+	GetVitalsByPatientID(ctx context.Context, patientID int64, from *time.Time, to *time.Time, limit int, offset int) ([]domain.Vitals, error)
+	GetVitalsCountByPatientID(ctx context.Context, patientID int64, from *time.Time, to *time.Time) (int64, error)
+	GetVitalsTrend(ctx context.Context, patientID int64, from *time.Time, to *time.Time) ([]domain.Vitals, error)
+	UpdateVitals(ctx context.Context, vitals *domain.Vitals) error
+	DeleteVitalsByID(ctx context.Context, id int64) error
+}
+
+type BillingStore interface {
+	CreateBillableItem(ctx context.Context, item *domain.BillableItem) error
+	GetBillableItemByID(ctx context.Context, id int64) (*domain.BillableItem, error)
+	GetBillableItemsByConsultationID(ctx context.Context, consultationID int64) ([]domain.BillableItem, error)
+	DeleteBillableItem(ctx context.Context, id int64) error
+	CreateInvoice(ctx context.Context, clientID int64, issuedBy int64) (*domain.Invoice, error)
+	GetInvoiceByID(ctx context.Context, id int64) (*domain.Invoice, error)
+	GetInvoicesByClientID(ctx context.Context, clientID int64, limit int, offset int) ([]domain.Invoice, error)
+	GetInvoicesCountByClientID(ctx context.Context, clientID int64) (int64, error)
+	VoidInvoice(ctx context.Context, id int64) error
+	CreatePayment(ctx context.Context, payment *domain.Payment) error
+	GetClientBalance(ctx context.Context, clientID int64) (*domain.ClientBalance, error)
+}
+
+type InventoryStore interface {
+	CreateProduct(ctx context.Context, product *domain.Product) error
+	GetProductByID(ctx context.Context, id int64) (*domain.Product, error)
+	GetProducts(ctx context.Context, limit int, offset int) ([]domain.Product, error)
+	GetProductsCount(ctx context.Context) (int64, error)
+	UpdateProduct(ctx context.Context, product *domain.Product) error
+	RecordMovement(ctx context.Context, productID int64, lotNumber string, expiryDate *time.Time, movement *domain.StockMovement) error
+	GetMovementsByProductID(ctx context.Context, productID int64, limit int, offset int) ([]domain.StockMovement, error)
+	GetMovementsCountByProductID(ctx context.Context, productID int64) (int64, error)
+	GetInventoryReport(ctx context.Context, until time.Time) (*domain.InventoryReport, error)
+}
+
+// JobRunStore decides which instance runs each occurrence of a scheduled job.
+// ClaimRun reports ok only to the first caller for an occurrence, and only
+// while no other run of the job is in progress; release ends the claim.
+type JobRunStore interface {
+	ClaimRun(ctx context.Context, name string, due time.Time) (release func(), ok bool, err error)
+}
+
+// LoginLockoutStore counts failed logins per DNI and per client IP.
+// RecordFailure must count concurrent failures for the same DNI or IP
+// atomically.
+type LoginLockoutStore interface {
+	GetLockout(ctx context.Context, scope domain.LockoutScope, key string) (*domain.LoginLockout, error)
+	GetLockouts(ctx context.Context) ([]domain.LoginLockout, error)
+	RecordFailure(ctx context.Context, scope domain.LockoutScope, key string, policy domain.LockoutPolicy, now time.Time) (*domain.LoginLockout, error)
+	DeleteLockout(ctx context.Context, scope domain.LockoutScope, key string) error
+	DeleteStaleLockouts(ctx context.Context, before time.Time) error
+}
+
+// PasswordResetStore keeps pending password resets by token hash.
+type PasswordResetStore interface {
+	// CreateResetToken stores token and drops the user's earlier ones, so only
+	// the latest reset link works.
+	CreateResetToken(ctx context.Context, token *domain.PasswordResetToken) error
+	// ConsumeResetToken uses up an unexpired token, and every other token of
+	// its user, returning the user's ID. It fails with ErrResetTokenInvalid if
+	// the token is unknown, expired or already used.
+	ConsumeResetToken(ctx context.Context, tokenHash string) (int64, error)
+	DeleteExpiredResetTokens(ctx context.Context) error
+}
+
+// TwoFactorStore keeps TOTP enrolments, recovery codes and the roles that
+// must use them. UseTOTPStep and UseRecoveryCode must each let a code through
+// at most once, even to concurrent callers.
+type TwoFactorStore interface {
+	GetTwoFactor(ctx context.Context, userID int64) (*domain.TwoFactor, error)
+	SaveTwoFactorSecret(ctx context.Context, userID int64, secret string) error
+	EnableTwoFactor(ctx context.Context, userID int64, step int64, codeHashes []string) error
+	UseTOTPStep(ctx context.Context, userID int64, step int64) error
+	UseRecoveryCode(ctx context.Context, userID int64, codeHash string) error
+	ReplaceRecoveryCodes(ctx context.Context, userID int64, codeHashes []string) error
+	CountRecoveryCodes(ctx context.Context, userID int64) (int64, error)
+	DeleteTwoFactor(ctx context.Context, userID int64) error
+	GetRequiredRoles(ctx context.Context) ([]domain.Role, error)
+	SetRequiredRoles(ctx context.Context, roles []domain.Role) error
+}
+
+// LoginChallengeStore keeps logins waiting for their second factor by token
+// hash. GetChallenge fails with ErrChallengeInvalid for unknown or expired
+// tokens.
+type LoginChallengeStore interface {
+	CreateChallenge(ctx context.Context, challenge *domain.LoginChallenge) error
+	GetChallenge(ctx context.Context, tokenHash string) (*domain.LoginChallenge, error)
+	FailChallenge(ctx context.Context, tokenHash string, maxAttempts int) error
+	DeleteChallenge(ctx context.Context, tokenHash string) error
+	DeleteExpiredChallenges(ctx context.Context) error
+}
+
+var (
+	_ UserStore                = (*UserRepository)(nil)
+	_ SessionStore             = (*SessionRepository)(nil)
+	_ AllowedRegistrationStore = (*AllowedRegistrationRepository)(nil)
+	_ ClientStore              = (*ClientRepository)(nil)
+	_ PatientStore             = (*PatientRepository)(nil)
+	_ ConsultationStore        = (*ConsultationRepository)(nil)
+	_ AppointmentStore         = (*AppointmentRepository)(nil)
+	_ AuditStore               = (*AuditRepository)(nil)
+	_ SearchStore              = (*SearchRepository)(nil)
+	_ VaccinationStore         = (*VaccinationRepository)(nil)
+	_ PrescriptionStore        = (*PrescriptionRepository)(nil)
+	_ VitalsStore              = (*VitalsRepository)(nil)
+	_ BillingStore             = (*BillingRepository)(nil)
+	_ InventoryStore           = (*InventoryRepository)(nil)
+	_ JobRunStore              = (*JobRunRepository)(nil)
+	_ LoginLockoutStore        = (*LoginLockoutRepository)(nil)
+	_ PasswordResetStore       = (*PasswordResetRepository)(nil)
+	_ TwoFactorStore           = (*TwoFactorRepository)(nil)
+	_ LoginChallengeStore      = (*LoginChallengeRepository)(nil)
+)
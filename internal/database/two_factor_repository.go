@@ -0,0 +1,257 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+	"vetsys/internal/domain"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type TwoFactorRepository struct {
+	DB           *sqlx.DB
+	QueryTimeout time.Duration
+}
+
+var (
+	ErrTwoFactorNotFound   = errors.New("Two-factor authentication not set up")
+	ErrTwoFactorEnabled    = errors.New("Two-factor authentication is already enabled")
+	ErrTOTPCodeUsed        = errors.New("Code already used")
+	ErrRecoveryCodeInvalid = errors.New("Invalid recovery code")
+)
+
+func (twoFactorRepo *TwoFactorRepository) GetTwoFactor(ctx context.Context, userID int64) (*domain.TwoFactor, error) {
+	ctx, cancel := withQueryTimeout(ctx, twoFactorRepo.QueryTimeout)
+	defer cancel()
+
+	query := `
+	SELECT user_id, secret, enabled_at, last_used_step, created_at
+	FROM user_two_factor
+	WHERE user_id = $1
+	`
+	twoFactor := domain.TwoFactor{}
+	err := twoFactorRepo.DB.GetContext(ctx, &twoFactor, query, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrTwoFactorNotFound
+		}
+		return nil, err
+	}
+	return &twoFactor, nil
+}
+
+// SaveTwoFactorSecret starts an enrolment with secret, replacing any pending
+// one. It fails with ErrTwoFactorEnabled if the user's enrolment is enabled.
+func (twoFactorRepo *TwoFactorRepository) SaveTwoFactorSecret(ctx context.Context, userID int64, secret string) error {
+	ctx, cancel := withQueryTimeout(ctx, twoFactorRepo.QueryTimeout)
+	defer cancel()
+
+	query := `
+	INSERT INTO user_two_factor (user_id, secret, created_at)
+	VALUES ($1, $2, (now() AT TIME ZONE 'UTC'))
+	ON CONFLICT (user_id) DO UPDATE
+	SET secret = EXCLUDED.secret, last_used_step = 0, created_at = EXCLUDED.created_at
+	WHERE user_two_factor.enabled_at IS NULL
+	`
+	result, err := twoFactorRepo.DB.ExecContext(ctx, query, userID, secret)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrTwoFactorEnabled
+	}
+	return nil
+}
+
+// EnableTwoFactor confirms the user's pending enrolment with the code of
+// step, and gives the user the recovery codes of codeHashes.
+func (twoFactorRepo *TwoFactorRepository) EnableTwoFactor(ctx context.Context, userID int64, step int64, codeHashes []string) error {
+	ctx, cancel := withQueryTimeout(ctx, twoFactorRepo.QueryTimeout)
+	defer cancel()
+
+	tx, err := twoFactorRepo.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+	UPDATE user_two_factor
+	SET enabled_at = (now() AT TIME ZONE 'UTC'), last_used_step = $2
+	WHERE user_id = $1 AND enabled_at IS NULL
+	`
+	result, err := tx.ExecContext(ctx, query, userID, step)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrTwoFactorNotFound
+	}
+	if err := replaceRecoveryCodes(ctx, tx, userID, codeHashes); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// UseTOTPStep records that the user signed in with the code of step. It fails
+// with ErrTOTPCodeUsed unless step is later than any used before.
+func (twoFactorRepo *TwoFactorRepository) UseTOTPStep(ctx context.Context, userID int64, step int64) error {
+	ctx, cancel := withQueryTimeout(ctx, twoFactorRepo.QueryTimeout)
+	defer cancel()
+
+	query := `
+	UPDATE user_two_factor
+	SET last_used_step = $2
+	WHERE user_id = $1 AND enabled_at IS NOT NULL AND last_used_step < $2
+	`
+	result, err := twoFactorRepo.DB.ExecContext(ctx, query, userID, step)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrTOTPCodeUsed
+	}
+	return nil
+}
+
+// UseRecoveryCode uses up one of the user's recovery codes. It fails with
+// ErrRecoveryCodeInvalid if the user has no unused code with that hash.
+func (twoFactorRepo *TwoFactorRepository) UseRecoveryCode(ctx context.Context, userID int64, codeHash string) error {
+	ctx, cancel := withQueryTimeout(ctx, twoFactorRepo.QueryTimeout)
+	defer cancel()
+
+	query := `DELETE FROM user_recovery_codes WHERE user_id = $1 AND code_hash = $2`
+	result, err := twoFactorRepo.DB.ExecContext(ctx, query, userID, codeHash)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrRecoveryCodeInvalid
+	}
+	return nil
+}
+
+// ReplaceRecoveryCodes gives the user the recovery codes of codeHashes, voiding
+// the ones they had.
+func (twoFactorRepo *TwoFactorRepository) ReplaceRecoveryCodes(ctx context.Context, userID int64, codeHashes []string) error {
+	ctx, cancel := withQueryTimeout(ctx, twoFactorRepo.QueryTimeout)
+	defer cancel()
+
+	tx, err := twoFactorRepo.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := replaceRecoveryCodes(ctx, tx, userID, codeHashes); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func replaceRecoveryCodes(ctx context.Context, tx *sqlx.Tx, userID int64, codeHashes []string) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	for _, codeHash := range codeHashes {
+		query := `INSERT INTO user_recovery_codes (user_id, code_hash) VALUES ($1, $2)`
+		if _, err := tx.ExecContext(ctx, query, userID, codeHash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (twoFactorRepo *TwoFactorRepository) CountRecoveryCodes(ctx context.Context, userID int64) (int64, error) {
+	ctx, cancel := withQueryTimeout(ctx, twoFactorRepo.QueryTimeout)
+	defer cancel()
+
+	var count int64
+	err := twoFactorRepo.DB.GetContext(ctx, &count, `SELECT COUNT(*) FROM user_recovery_codes WHERE user_id = $1`, userID)
+	return count, err
+}
+
+// DeleteTwoFactor removes the user's enrolment, pending or enabled, and their
+// recovery codes.
+func (twoFactorRepo *TwoFactorRepository) DeleteTwoFactor(ctx context.Context, userID int64) error {
+	ctx, cancel := withQueryTimeout(ctx, twoFactorRepo.QueryTimeout)
+	defer cancel()
+
+	tx, err := twoFactorRepo.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	result, err := tx.ExecContext(ctx, `DELETE FROM user_two_factor WHERE user_id = $1`, userID)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrTwoFactorNotFound
+	}
+	return tx.Commit()
+}
+
+// GetRequiredRoles returns the roles whose users must sign in with a second
+// factor.
+func (twoFactorRepo *TwoFactorRepository) GetRequiredRoles(ctx context.Context) ([]domain.Role, error) {
+	ctx, cancel := withQueryTimeout(ctx, twoFactorRepo.QueryTimeout)
+	defer cancel()
+
+	roles := []domain.Role{}
+	err := twoFactorRepo.DB.SelectContext(ctx, &roles, `SELECT role FROM two_factor_roles ORDER BY role`)
+	if err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+// SetRequiredRoles replaces the roles whose users must sign in with a second
+// factor.
+func (twoFactorRepo *TwoFactorRepository) SetRequiredRoles(ctx context.Context, roles []domain.Role) error {
+	ctx, cancel := withQueryTimeout(ctx, twoFactorRepo.QueryTimeout)
+	defer cancel()
+
+	tx, err := twoFactorRepo.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM two_factor_roles`); err != nil {
+		return err
+	}
+	for _, role := range roles {
+		query := `INSERT INTO two_factor_roles (role) VALUES ($1) ON CONFLICT (role) DO NOTHING`
+		if _, err := tx.ExecContext(ctx, query, role); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
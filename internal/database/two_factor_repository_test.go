@@ -0,0 +1,123 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"vetsys/internal/domain"
+)
+
+func TestTwoFactorRepository_Enrolment(t *testing.T) {
+	cleanupTables(testDB)
+
+	user := domain.NewUser("12345678A", "test@example.com", "hashedpassword", "Test User", "profile.jpg")
+	testDB.UserRepo.CreateUser(context.Background(), user)
+
+	if _, err := testDB.TwoFactorRepo.GetTwoFactor(context.Background(), user.ID); err != ErrTwoFactorNotFound {
+		t.Fatalf("Expected ErrTwoFactorNotFound, got %v", err)
+	}
+	if err := testDB.TwoFactorRepo.SaveTwoFactorSecret(context.Background(), user.ID, "FIRSTSECRET"); err != nil {
+		t.Fatalf("Failed to save secret: %v", err)
+	}
+	// A pending enrolment can be started over.
+	if err := testDB.TwoFactorRepo.SaveTwoFactorSecret(context.Background(), user.ID, "SECONDSECRET"); err != nil {
+		t.Fatalf("Failed to replace secret: %v", err)
+	}
+	_, hashes, err := domain.NewRecoveryCodes(3)
+	if err != nil {
+		t.Fatalf("Failed to create recovery codes: %v", err)
+	}
+	if err := testDB.TwoFactorRepo.EnableTwoFactor(context.Background(), user.ID, 100, hashes); err != nil {
+		t.Fatalf("Failed to enable: %v", err)
+	}
+	if err := testDB.TwoFactorRepo.EnableTwoFactor(context.Background(), user.ID, 101, hashes); err != ErrTwoFactorNotFound {
+		t.Errorf("Expected ErrTwoFactorNotFound enabling twice, got %v", err)
+	}
+	if err := testDB.TwoFactorRepo.SaveTwoFactorSecret(context.Background(), user.ID, "THIRDSECRET"); err != ErrTwoFactorEnabled {
+		t.Errorf("Expected ErrTwoFactorEnabled, got %v", err)
+	}
+
+	twoFactor, err := testDB.TwoFactorRepo.GetTwoFactor(context.Background(), user.ID)
+	if err != nil {
+		t.Fatalf("Failed to get two-factor: %v", err)
+	}
+	if twoFactor.Secret != "SECONDSECRET" || !twoFactor.IsEnabled() || twoFactor.LastUsedStep != 100 {
+		t.Errorf("Unexpected enrolment %+v", twoFactor)
+	}
+
+	if err := testDB.TwoFactorRepo.DeleteTwoFactor(context.Background(), user.ID); err != nil {
+		t.Fatalf("Failed to delete: %v", err)
+	}
+	if count, _ := testDB.TwoFactorRepo.CountRecoveryCodes(context.Background(), user.ID); count != 0 {
+		t.Errorf("Expected the recovery codes to be deleted, got %d", count)
+	}
+	if err := testDB.TwoFactorRepo.DeleteTwoFactor(context.Background(), user.ID); err != ErrTwoFactorNotFound {
+		t.Errorf("Expected ErrTwoFactorNotFound, got %v", err)
+	}
+}
+
+func TestTwoFactorRepository_SingleUse(t *testing.T) {
+	cleanupTables(testDB)
+
+	user := domain.NewUser("12345678A", "test@example.com", "hashedpassword", "Test User", "profile.jpg")
+	testDB.UserRepo.CreateUser(context.Background(), user)
+	codes, hashes, err := domain.NewRecoveryCodes(2)
+	if err != nil {
+		t.Fatalf("Failed to create recovery codes: %v", err)
+	}
+	testDB.TwoFactorRepo.SaveTwoFactorSecret(context.Background(), user.ID, "SECRET")
+	if err := testDB.TwoFactorRepo.EnableTwoFactor(context.Background(), user.ID, 100, hashes); err != nil {
+		t.Fatalf("Failed to enable: %v", err)
+	}
+
+	if err := testDB.TwoFactorRepo.UseTOTPStep(context.Background(), user.ID, 100); err != ErrTOTPCodeUsed {
+		t.Errorf("Expected ErrTOTPCodeUsed for the enrolment's step, got %v", err)
+	}
+	if err := testDB.TwoFactorRepo.UseTOTPStep(context.Background(), user.ID, 101); err != nil {
+		t.Errorf("Failed to use a later step: %v", err)
+	}
+	if err := testDB.TwoFactorRepo.UseTOTPStep(context.Background(), user.ID, 101); err != ErrTOTPCodeUsed {
+		t.Errorf("Expected ErrTOTPCodeUsed for a replayed step, got %v", err)
+	}
+
+	if err := testDB.TwoFactorRepo.UseRecoveryCode(context.Background(), user.ID, domain.HashRecoveryCode(codes[0])); err != nil {
+		t.Errorf("Failed to use recovery code: %v", err)
+	}
+	if err := testDB.TwoFactorRepo.UseRecoveryCode(context.Background(), user.ID, domain.HashRecoveryCode(codes[0])); err != ErrRecoveryCodeInvalid {
+		t.Errorf("Expected ErrRecoveryCodeInvalid for a used code, got %v", err)
+	}
+	if count, _ := testDB.TwoFactorRepo.CountRecoveryCodes(context.Background(), user.ID); count != 1 {
+		t.Errorf("Expected 1 recovery code left, got %d", count)
+	}
+	_, hashes, _ = domain.NewRecoveryCodes(5)
+	if err := testDB.TwoFactorRepo.ReplaceRecoveryCodes(context.Background(), user.ID, hashes); err != nil {
+		t.Fatalf("Failed to replace recovery codes: %v", err)
+	}
+	if err := testDB.TwoFactorRepo.UseRecoveryCode(context.Background(), user.ID, domain.HashRecoveryCode(codes[1])); err != ErrRecoveryCodeInvalid {
+		t.Errorf("Expected a replaced code to be void, got %v", err)
+	}
+	if count, _ := testDB.TwoFactorRepo.CountRecoveryCodes(context.Background(), user.ID); count != 5 {
+		t.Errorf("Expected 5 recovery codes, got %d", count)
+	}
+}
+
+func TestTwoFactorRepository_RequiredRoles(t *testing.T) {
+	cleanupTables(testDB)
+
+	roles, err := testDB.TwoFactorRepo.GetRequiredRoles(context.Background())
+	if err != nil || len(roles) != 0 {
+		t.Fatalf("Expected no required roles, got %v, %v", roles, err)
+	}
+	if err := testDB.TwoFactorRepo.SetRequiredRoles(context.Background(), []domain.Role{domain.RoleVeterinarian, domain.RoleAdmin}); err != nil {
+		t.Fatalf("Failed to set roles: %v", err)
+	}
+	if err := testDB.TwoFactorRepo.SetRequiredRoles(context.Background(), []domain.Role{domain.RoleAdmin}); err != nil {
+		t.Fatalf("Failed to replace roles: %v", err)
+	}
+	roles, err = testDB.TwoFactorRepo.GetRequiredRoles(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to get roles: %v", err)
+	}
+	if len(roles) != 1 || roles[0] != domain.RoleAdmin {
+		t.Errorf("Expected [ADMIN], got %v", roles)
+	}
+}
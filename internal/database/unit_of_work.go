@@ -0,0 +1,70 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// DBTX is the part of sqlx that *sqlx.DB and *sqlx.Tx share. Repositories
+// built on it run either on the pool or inside a unit of work.
+type DBTX interface {
+	GetContext(ctx context.Context, dest any, query string, args ...any) error
+	SelectContext(ctx context.Context, dest any, query string, args ...any) error
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	NamedExecContext(ctx context.Context, query string, arg any) (sql.Result, error)
+	PrepareNamedContext(ctx context.Context, query string) (*sqlx.NamedStmt, error)
+}
+
+var (
+	_ DBTX = (*sqlx.DB)(nil)
+	_ DBTX = (*sqlx.Tx)(nil)
+)
+
+// TxStores are the stores a unit of work hands to its function. Every call
+// on them runs in the unit's transaction.
+type TxStores struct {
+	Clients       ClientStore
+	Patients      PatientStore
+	Consultations ConsultationStore
+}
+
+// UnitOfWork groups writes to several entities so they commit or roll back
+// together.
+type UnitOfWork interface {
+	// Do runs fn in one transaction, committing if it returns nil and rolling
+	// back otherwise. fn's error is returned as is.
+	Do(ctx context.Context, fn func(stores TxStores) error) error
+}
+
+// Transactor is the Postgres UnitOfWork. QueryTimeout bounds the whole
+// transaction rather than each statement in it.
+type Transactor struct {
+	DB           *sqlx.DB
+	QueryTimeout time.Duration
+}
+
+var _ UnitOfWork = (*Transactor)(nil)
+
+func (transactor *Transactor) Do(ctx context.Context, fn func(stores TxStores) error) error {
+	ctx, cancel := withQueryTimeout(ctx, transactor.QueryTimeout)
+	defer cancel()
+
+	tx, err := transactor.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stores := TxStores{
+		Clients:       &ClientRepository{DB: tx},
+		Patients:      &PatientRepository{DB: tx},
+		Consultations: &ConsultationRepository{DB: tx},
+	}
+	if err := fn(stores); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
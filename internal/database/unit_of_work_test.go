@@ -0,0 +1,77 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"vetsys/internal/domain"
+)
+
+func TestTransactor_Commit(t *testing.T) {
+	cleanupTables(testDB)
+
+	var client *domain.Client
+	var patient *domain.Patient
+	err := testDB.UnitOfWork.Do(context.Background(), func(stores TxStores) error {
+		client = domain.NewClient("12345678A", "John Doe", "+34600111222")
+		if err := stores.Clients.CreateClient(context.Background(), client); err != nil {
+			return err
+		}
+		patient = domain.NewPatient("Max", "Dog", "Labrador", time.Now().AddDate(-3, 0, 0), client.ID)
+		return stores.Patients.CreatePatient(context.Background(), patient)
+	})
+	if err != nil {
+		t.Fatalf("Failed to run unit of work: %v", err)
+	}
+
+	if _, err := testDB.ClientRepo.GetClientByID(context.Background(), client.ID); err != nil {
+		t.Errorf("Expected committed client, got %v", err)
+	}
+	if _, err := testDB.PatientRepo.GetPatientByID(context.Background(), patient.ID); err != nil {
+		t.Errorf("Expected committed patient, got %v", err)
+	}
+}
+
+func TestTransactor_Rollback(t *testing.T) {
+	cleanupTables(testDB)
+
+	errAbort := errors.New("abort")
+	var client *domain.Client
+	err := testDB.UnitOfWork.Do(context.Background(), func(stores TxStores) error {
+		client = domain.NewClient("12345678A", "John Doe", "+34600111222")
+		if err := stores.Clients.CreateClient(context.Background(), client); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	if err != errAbort {
+		t.Fatalf("Expected fn's error back, got %v", err)
+	}
+
+	_, err = testDB.ClientRepo.GetClientByID(context.Background(), client.ID)
+	if err != ErrClientNotFound {
+		t.Errorf("Expected rolled back client to be gone, got %v", err)
+	}
+}
+
+func TestTransactor_RollbackOnFailedStatement(t *testing.T) {
+	cleanupTables(testDB)
+
+	err := testDB.UnitOfWork.Do(context.Background(), func(stores TxStores) error {
+		client := domain.NewClient("12345678A", "John Doe", "+34600111222")
+		if err := stores.Clients.CreateClient(context.Background(), client); err != nil {
+			return err
+		}
+		orphan := domain.NewPatient("Max", "Dog", "Labrador", time.Now().AddDate(-3, 0, 0), 99999)
+		return stores.Patients.CreatePatient(context.Background(), orphan)
+	})
+	if err == nil {
+		t.Fatal("Expected an error for a patient with an unknown owner")
+	}
+
+	_, err = testDB.ClientRepo.GetClientByDNI(context.Background(), "12345678A")
+	if err != ErrClientNotFound {
+		t.Errorf("Expected client to be rolled back, got %v", err)
+	}
+}
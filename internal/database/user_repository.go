@@ -1,65 +1,93 @@
 package database
 
 import (
+	"context"
+	"database/sql"
 	"errors"
+	"time"
 	"vetsys/internal/domain"
 
 	"github.com/jmoiron/sqlx"
 )
 
 type UserRepository struct {
-	DB *sqlx.DB
+	DB           *sqlx.DB
+	QueryTimeout time.Duration
 }
 
 var ErrUserNotFound = errors.New("user not found")
 
-func (userRepository *UserRepository) CreateUser(user *domain.User) error {
+func (userRepository *UserRepository) CreateUser(ctx context.Context, user *domain.User) error {
+	ctx, cancel := withQueryTimeout(ctx, userRepository.QueryTimeout)
+	defer cancel()
+
 	query := `
-	INSERT INTO users (dni, email, password, name, profile_picture)
-	VALUES (:dni, :email, :password, :name, :profile_picture)
+	INSERT INTO users (dni, email, password, name, profile_picture, role)
+	VALUES (:dni, :email, :password, :name, :profile_picture, :role)
 	RETURNING id
 	`
-	stmt, err := userRepository.DB.PrepareNamed(query)
+	stmt, err := userRepository.DB.PrepareNamedContext(ctx, query)
 	if err != nil {
 		return err
 	}
 	defer stmt.Close()
 
-	return stmt.Get(&user.ID, user)
+	return stmt.GetContext(ctx, &user.ID, user)
 }
 
-func (userRepository *UserRepository) GetUserByID(id int64) (*domain.User, error) {
-	query := `SELECT id, dni, email, password, name, profile_picture FROM users WHERE id = $1`
+func (userRepository *UserRepository) GetUserByID(ctx context.Context, id int64) (*domain.User, error) {
+	ctx, cancel := withQueryTimeout(ctx, userRepository.QueryTimeout)
+	defer cancel()
+
+	query := `SELECT id, dni, email, password, name, profile_picture, role FROM users WHERE id = $1`
 	user := domain.User{}
-	err := userRepository.DB.Get(&user, query, id)
+	err := userRepository.DB.GetContext(ctx, &user, query, id)
 	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrUserNotFound
+		}
 		return nil, err
 	}
 	return &user, nil
 }
 
-func (userRepository *UserRepository) GetUserByDNI(dni string) (*domain.User, error) {
-	query := `SELECT id, dni, email, password, name, profile_picture FROM users WHERE dni = $1`
+func (userRepository *UserRepository) GetUserByDNI(ctx context.Context, dni string) (*domain.User, error) {
+	ctx, cancel := withQueryTimeout(ctx, userRepository.QueryTimeout)
+	defer cancel()
+
+	query := `SELECT id, dni, email, password, name, profile_picture, role FROM users WHERE dni = $1`
 	user := domain.User{}
-	err := userRepository.DB.Get(&user, query, dni)
+	err := userRepository.DB.GetContext(ctx, &user, query, dni)
 	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrUserNotFound
+		}
 		return nil, err
 	}
 	return &user, nil
 }
 
-func (userRepository *UserRepository) GetUserByEmail(email string) (*domain.User, error) {
-	query := `SELECT id, dni, email, password, name, profile_picture FROM users WHERE email = $1`
+func (userRepository *UserRepository) GetUserByEmail(ctx context.Context, email string) (*domain.User, error) {
+	ctx, cancel := withQueryTimeout(ctx, userRepository.QueryTimeout)
+	defer cancel()
+
+	query := `SELECT id, dni, email, password, name, profile_picture, role FROM users WHERE email = $1`
 	user := domain.User{}
-	err := userRepository.DB.Get(&user, query, email)
+	err := userRepository.DB.GetContext(ctx, &user, query, email)
 	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrUserNotFound
+		}
 		return nil, err
 	}
 	return &user, nil
 }
 
-func (userRepository *UserRepository) DeleteUserByID(id int64) error {
-	result, err := userRepository.DB.Exec("DELETE FROM users WHERE id = $1", id)
+func (userRepository *UserRepository) DeleteUserByID(ctx context.Context, id int64) error {
+	ctx, cancel := withQueryTimeout(ctx, userRepository.QueryTimeout)
+	defer cancel()
+
+	result, err := userRepository.DB.ExecContext(ctx, "DELETE FROM users WHERE id = $1", id)
 	if err != nil {
 		return err
 	}
@@ -76,9 +104,12 @@ func (userRepository *UserRepository) DeleteUserByID(id int64) error {
 	return nil
 }
 
-func (userRepository *UserRepository) UpdateUser(user *domain.User) error {
+func (userRepository *UserRepository) UpdateUser(ctx context.Context, user *domain.User) error {
+	ctx, cancel := withQueryTimeout(ctx, userRepository.QueryTimeout)
+	defer cancel()
+
 	query := "UPDATE users SET dni = $1, email = $2, name = $3, profile_picture = $4 WHERE id = $5"
-	result, err := userRepository.DB.Exec(query, user.DNI, user.Email, user.Name, user.ProfilePicture, user.ID)
+	result, err := userRepository.DB.ExecContext(ctx, query, user.DNI, user.Email, user.Name, user.ProfilePicture, user.ID)
 	if err != nil {
 		return err
 	}
@@ -93,9 +124,32 @@ func (userRepository *UserRepository) UpdateUser(user *domain.User) error {
 	return nil
 }
 
-func (userRepository *UserRepository) UpdatePassword(id int64, password string) error {
+func (userRepository *UserRepository) UpdatePassword(ctx context.Context, id int64, password string) error {
+	ctx, cancel := withQueryTimeout(ctx, userRepository.QueryTimeout)
+	defer cancel()
+
 	query := "UPDATE users SET password = $1 WHERE id = $2"
-	result, err := userRepository.DB.Exec(query, password, id)
+	result, err := userRepository.DB.ExecContext(ctx, query, password, id)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+func (userRepository *UserRepository) UpdateUserRole(ctx context.Context, id int64, role domain.Role) error {
+	ctx, cancel := withQueryTimeout(ctx, userRepository.QueryTimeout)
+	defer cancel()
+
+	query := "UPDATE users SET role = $1 WHERE id = $2"
+	result, err := userRepository.DB.ExecContext(ctx, query, role, id)
 	if err != nil {
 		return err
 	}
@@ -1,6 +1,7 @@
 package database
 
 import (
+	"context"
 	"testing"
 	"vetsys/internal/domain"
 )
@@ -10,7 +11,7 @@ func TestUserRepository_CreateUser(t *testing.T) {
 
 	user := domain.NewUser("12345678A", "test@example.com", "hashedpassword", "Test User", "profile.jpg")
 
-	err := testDB.UserRepo.CreateUser(user)
+	err := testDB.UserRepo.CreateUser(context.Background(), user)
 	if err != nil {
 		t.Fatalf("Failed to create user: %v", err)
 	}
@@ -24,12 +25,12 @@ func TestUserRepository_GetUserByID(t *testing.T) {
 	cleanupTables(testDB)
 
 	user := domain.NewUser("23456789B", "user1@example.com", "hashedpass1", "User One", "avatar1.jpg")
-	err := testDB.UserRepo.CreateUser(user)
+	err := testDB.UserRepo.CreateUser(context.Background(), user)
 	if err != nil {
 		t.Fatalf("Failed to create user: %v", err)
 	}
 
-	retrieved, err := testDB.UserRepo.GetUserByID(user.ID)
+	retrieved, err := testDB.UserRepo.GetUserByID(context.Background(), user.ID)
 	if err != nil {
 		t.Fatalf("Failed to get user by ID: %v", err)
 	}
@@ -46,12 +47,12 @@ func TestUserRepository_GetUserByDNI(t *testing.T) {
 	cleanupTables(testDB)
 
 	user := domain.NewUser("34567890C", "user2@example.com", "hashedpass2", "User Two", "avatar2.jpg")
-	err := testDB.UserRepo.CreateUser(user)
+	err := testDB.UserRepo.CreateUser(context.Background(), user)
 	if err != nil {
 		t.Fatalf("Failed to create user: %v", err)
 	}
 
-	retrieved, err := testDB.UserRepo.GetUserByDNI(user.DNI)
+	retrieved, err := testDB.UserRepo.GetUserByDNI(context.Background(), user.DNI)
 	if err != nil {
 		t.Fatalf("Failed to get user by DNI: %v", err)
 	}
@@ -65,12 +66,12 @@ func TestUserRepository_GetUserByEmail(t *testing.T) {
 	cleanupTables(testDB)
 
 	user := domain.NewUser("45678901D", "user3@example.com", "hashedpass3", "User Three", "avatar3.jpg")
-	err := testDB.UserRepo.CreateUser(user)
+	err := testDB.UserRepo.CreateUser(context.Background(), user)
 	if err != nil {
 		t.Fatalf("Failed to create user: %v", err)
 	}
 
-	retrieved, err := testDB.UserRepo.GetUserByEmail(user.Email)
+	retrieved, err := testDB.UserRepo.GetUserByEmail(context.Background(), user.Email)
 	if err != nil {
 		t.Fatalf("Failed to get user by email: %v", err)
 	}
@@ -84,7 +85,7 @@ func TestUserRepository_UpdateUser(t *testing.T) {
 	cleanupTables(testDB)
 
 	user := domain.NewUser("56789012E", "user4@example.com", "hashedpass4", "User Four", "avatar4.jpg")
-	err := testDB.UserRepo.CreateUser(user)
+	err := testDB.UserRepo.CreateUser(context.Background(), user)
 	if err != nil {
 		t.Fatalf("Failed to create user: %v", err)
 	}
@@ -92,12 +93,12 @@ func TestUserRepository_UpdateUser(t *testing.T) {
 	user.Name = "User Four Updated"
 	user.Email = "updated4@example.com"
 
-	err = testDB.UserRepo.UpdateUser(user)
+	err = testDB.UserRepo.UpdateUser(context.Background(), user)
 	if err != nil {
 		t.Fatalf("Failed to update user: %v", err)
 	}
 
-	retrieved, err := testDB.UserRepo.GetUserByID(user.ID)
+	retrieved, err := testDB.UserRepo.GetUserByID(context.Background(), user.ID)
 	if err != nil {
 		t.Fatalf("Failed to retrieve updated user: %v", err)
 	}
@@ -111,18 +112,18 @@ func TestUserRepository_UpdatePassword(t *testing.T) {
 	cleanupTables(testDB)
 
 	user := domain.NewUser("67890123F", "user5@example.com", "oldpassword", "User Five", "avatar5.jpg")
-	err := testDB.UserRepo.CreateUser(user)
+	err := testDB.UserRepo.CreateUser(context.Background(), user)
 	if err != nil {
 		t.Fatalf("Failed to create user: %v", err)
 	}
 
 	newPassword := "newhashedpassword"
-	err = testDB.UserRepo.UpdatePassword(user.ID, newPassword)
+	err = testDB.UserRepo.UpdatePassword(context.Background(), user.ID, newPassword)
 	if err != nil {
 		t.Fatalf("Failed to update password: %v", err)
 	}
 
-	retrieved, err := testDB.UserRepo.GetUserByID(user.ID)
+	retrieved, err := testDB.UserRepo.GetUserByID(context.Background(), user.ID)
 	if err != nil {
 		t.Fatalf("Failed to retrieve user: %v", err)
 	}
@@ -136,17 +137,17 @@ func TestUserRepository_DeleteUserByID(t *testing.T) {
 	cleanupTables(testDB)
 
 	user := domain.NewUser("78901234G", "user6@example.com", "hashedpass6", "User Six", "avatar6.jpg")
-	err := testDB.UserRepo.CreateUser(user)
+	err := testDB.UserRepo.CreateUser(context.Background(), user)
 	if err != nil {
 		t.Fatalf("Failed to create user: %v", err)
 	}
 
-	err = testDB.UserRepo.DeleteUserByID(user.ID)
+	err = testDB.UserRepo.DeleteUserByID(context.Background(), user.ID)
 	if err != nil {
 		t.Fatalf("Failed to delete user: %v", err)
 	}
 
-	_, err = testDB.UserRepo.GetUserByID(user.ID)
+	_, err = testDB.UserRepo.GetUserByID(context.Background(), user.ID)
 	if err == nil {
 		t.Error("Expected error when getting deleted user")
 	}
@@ -156,22 +157,52 @@ func TestUserRepository_UniqueConstraints(t *testing.T) {
 	cleanupTables(testDB)
 
 	user1 := domain.NewUser("89012345H", "unique@example.com", "pass1", "User Seven", "avatar7.jpg")
-	err := testDB.UserRepo.CreateUser(user1)
+	err := testDB.UserRepo.CreateUser(context.Background(), user1)
 	if err != nil {
 		t.Fatalf("Failed to create first user: %v", err)
 	}
 
 	// Duplicate DNI
 	user2 := domain.NewUser("89012345H", "different@example.com", "pass2", "User Eight", "avatar8.jpg")
-	err = testDB.UserRepo.CreateUser(user2)
+	err = testDB.UserRepo.CreateUser(context.Background(), user2)
 	if err == nil {
 		t.Error("Expected error when creating user with duplicate DNI")
 	}
 
 	// Duplicate email
 	user3 := domain.NewUser("90123456I", "unique@example.com", "pass3", "User Nine", "avatar9.jpg")
-	err = testDB.UserRepo.CreateUser(user3)
+	err = testDB.UserRepo.CreateUser(context.Background(), user3)
 	if err == nil {
 		t.Error("Expected error when creating user with duplicate email")
 	}
 }
+
+func TestUserRepository_UpdateUserRole(t *testing.T) {
+	cleanupTables(testDB)
+
+	user := domain.NewUser("01234567J", "user10@example.com", "hashedpass10", "User Ten", "avatar10.jpg")
+	testDB.UserRepo.CreateUser(context.Background(), user)
+
+	if user.Role != domain.RoleReadOnly {
+		t.Errorf("Expected new user to default to %s, got %s", domain.RoleReadOnly, user.Role)
+	}
+
+	err := testDB.UserRepo.UpdateUserRole(context.Background(), user.ID, domain.RoleReceptionist)
+	if err != nil {
+		t.Fatalf("Failed to update user role: %v", err)
+	}
+
+	retrieved, _ := testDB.UserRepo.GetUserByID(context.Background(), user.ID)
+	if retrieved.Role != domain.RoleReceptionist {
+		t.Errorf("Expected role %s, got %s", domain.RoleReceptionist, retrieved.Role)
+	}
+}
+
+func TestUserRepository_UpdateUserRole_NotFound(t *testing.T) {
+	cleanupTables(testDB)
+
+	err := testDB.UserRepo.UpdateUserRole(context.Background(), 99999, domain.RoleAdmin)
+	if err != ErrUserNotFound {
+		t.Errorf("Expected ErrUserNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,331 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+	"vetsys/internal/domain"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type VaccinationRepository struct {
+	DB           *sqlx.DB
+	QueryTimeout time.Duration
+}
+
+var (
+	ErrVaccineNotFound        = errors.New("Vaccine not found")
+	ErrVaccineExists          = errors.New("a vaccine with that name already exists")
+	ErrVaccineBoosterNotFound = errors.New("Booster interval not found")
+	ErrVaccinationNotFound    = errors.New("Vaccination not found")
+)
+
+const vaccinationColumns = `id, patient_id, vaccine_id, vet_id, lot_number, administered_at, next_due_at, notes, created_at`
+
+// CreateVaccine adds a vaccine to the catalogue together with its booster
+// intervals.
+func (vaccinationRepository *VaccinationRepository) CreateVaccine(ctx context.Context, vaccine *domain.Vaccine) error {
+	ctx, cancel := withQueryTimeout(ctx, vaccinationRepository.QueryTimeout)
+	defer cancel()
+
+	tx, err := vaccinationRepository.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO vaccines (name, description, created_at) VALUES ($1, $2, $3)
+	ON CONFLICT (name) DO NOTHING
+	RETURNING id`
+	err = tx.GetContext(ctx, &vaccine.ID, query, vaccine.Name, vaccine.Description, vaccine.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrVaccineExists
+		}
+		return err
+	}
+	for i := range vaccine.Boosters {
+		vaccine.Boosters[i].VaccineID = vaccine.ID
+		_, err := tx.NamedExecContext(ctx, `INSERT INTO vaccine_boosters (vaccine_id, species, interval_days) VALUES (:vaccine_id, :species, :interval_days)`, vaccine.Boosters[i])
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (vaccinationRepository *VaccinationRepository) GetVaccineByID(ctx context.Context, id int64) (*domain.Vaccine, error) {
+	ctx, cancel := withQueryTimeout(ctx, vaccinationRepository.QueryTimeout)
+	defer cancel()
+
+	vaccine := domain.Vaccine{}
+	err := vaccinationRepository.DB.GetContext(ctx, &vaccine, `SELECT id, name, description, created_at FROM vaccines WHERE id = $1`, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrVaccineNotFound
+		}
+		return nil, err
+	}
+	vaccines := []domain.Vaccine{vaccine}
+	if err := vaccinationRepository.loadBoosters(ctx, vaccines); err != nil {
+		return nil, err
+	}
+	return &vaccines[0], nil
+}
+
+func (vaccinationRepository *VaccinationRepository) GetVaccines(ctx context.Context, limit int, offset int) ([]domain.Vaccine, error) {
+	ctx, cancel := withQueryTimeout(ctx, vaccinationRepository.QueryTimeout)
+	defer cancel()
+
+	var vaccines []domain.Vaccine
+	err := vaccinationRepository.DB.SelectContext(ctx, &vaccines, `SELECT id, name, description, created_at FROM vaccines ORDER BY name LIMIT $1 OFFSET $2`, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	if err := vaccinationRepository.loadBoosters(ctx, vaccines); err != nil {
+		return nil, err
+	}
+	return vaccines, nil
+}
+
+func (vaccinationRepository *VaccinationRepository) GetVaccinesCount(ctx context.Context) (int64, error) {
+	ctx, cancel := withQueryTimeout(ctx, vaccinationRepository.QueryTimeout)
+	defer cancel()
+
+	var count int64
+	err := vaccinationRepository.DB.GetContext(ctx, &count, `SELECT COUNT(*) FROM vaccines`)
+	return count, err
+}
+
+// SetVaccineBooster creates or replaces the booster interval of a vaccine for
+// one species. Doses already recorded keep the due date they were given.
+func (vaccinationRepository *VaccinationRepository) SetVaccineBooster(ctx context.Context, booster *domain.VaccineBooster) error {
+	ctx, cancel := withQueryTimeout(ctx, vaccinationRepository.QueryTimeout)
+	defer cancel()
+
+	query := `INSERT INTO vaccine_boosters (vaccine_id, species, interval_days)
+	SELECT $1, $2, $3 WHERE EXISTS (SELECT 1 FROM vaccines WHERE id = $1)
+	ON CONFLICT (vaccine_id, species) DO UPDATE SET interval_days = EXCLUDED.interval_days`
+	result, err := vaccinationRepository.DB.ExecContext(ctx, query, booster.VaccineID, booster.Species, booster.IntervalDays)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrVaccineNotFound
+	}
+	return nil
+}
+
+func (vaccinationRepository *VaccinationRepository) DeleteVaccineBooster(ctx context.Context, vaccineID int64, species string) error {
+	ctx, cancel := withQueryTimeout(ctx, vaccinationRepository.QueryTimeout)
+	defer cancel()
+
+	result, err := vaccinationRepository.DB.ExecContext(ctx, `DELETE FROM vaccine_boosters WHERE vaccine_id = $1 AND species = $2`, vaccineID, species)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrVaccineBoosterNotFound
+	}
+	return nil
+}
+
+// CreateVaccination records an administered dose. Unless NextDueAt is already
+// set, it is derived from the vaccine's booster interval for the patient's
+// species.
+func (vaccinationRepository *VaccinationRepository) CreateVaccination(ctx context.Context, vaccination *domain.Vaccination) error {
+	ctx, cancel := withQueryTimeout(ctx, vaccinationRepository.QueryTimeout)
+	defer cancel()
+
+	tx, err := vaccinationRepository.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var species string
+	err = tx.GetContext(ctx, &species, `SELECT species FROM patients WHERE id = $1`, vaccination.PatientID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrPatientNotFound
+		}
+		return err
+	}
+	var exists bool
+	err = tx.GetContext(ctx, &exists, `SELECT EXISTS (SELECT 1 FROM vaccines WHERE id = $1)`, vaccination.VaccineID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrVaccineNotFound
+	}
+	if vaccination.VetID != nil {
+		var role domain.Role
+		err = tx.GetContext(ctx, &role, `SELECT role FROM users WHERE id = $1`, *vaccination.VetID)
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+		if err == sql.ErrNoRows || !role.Can(domain.PermClinicalNotesWrite) {
+			return ErrVetNotFound
+		}
+	}
+
+	if vaccination.NextDueAt == nil {
+		var intervalDays int
+		err = tx.GetContext(ctx, &intervalDays, `SELECT interval_days FROM vaccine_boosters WHERE vaccine_id = $1 AND species = $2`,
+			vaccination.VaccineID, domain.NormalizeSpecies(species))
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+		if err == nil {
+			nextDueAt := vaccination.AdministeredAt.AddDate(0, 0, intervalDays)
+			vaccination.NextDueAt = &nextDueAt
+		}
+	}
+
+	query := `INSERT INTO vaccinations (patient_id, vaccine_id, vet_id, lot_number, administered_at, next_due_at, notes, created_at)
+	VALUES (:patient_id, :vaccine_id, :vet_id, :lot_number, :administered_at, :next_due_at, :notes, :created_at)
+	RETURNING id`
+	stmt, err := tx.PrepareNamedContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	if err := stmt.GetContext(ctx, &vaccination.ID, vaccination); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (vaccinationRepository *VaccinationRepository) GetVaccinationByID(ctx context.Context, id int64) (*domain.Vaccination, error) {
+	ctx, cancel := withQueryTimeout(ctx, vaccinationRepository.QueryTimeout)
+	defer cancel()
+
+	vaccination := domain.Vaccination{}
+	err := vaccinationRepository.DB.GetContext(ctx, &vaccination, `SELECT `+vaccinationColumns+` FROM vaccinations WHERE id = $1`, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrVaccinationNotFound
+		}
+		return nil, err
+	}
+	return &vaccination, nil
+}
+
+func (vaccinationRepository *VaccinationRepository) GetVaccinationsByPatientID(ctx context.Context, patientID int64, limit int, offset int) ([]domain.Vaccination, error) {
+	ctx, cancel := withQueryTimeout(ctx, vaccinationRepository.QueryTimeout)
+	defer cancel()
+
+	query := `SELECT ` + vaccinationColumns + ` FROM vaccinations WHERE patient_id = $1 ORDER BY administered_at DESC, id DESC LIMIT $2 OFFSET $3`
+	var vaccinations []domain.Vaccination
+	err := vaccinationRepository.DB.SelectContext(ctx, &vaccinations, query, patientID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return vaccinations, nil
+}
+
+func (vaccinationRepository *VaccinationRepository) GetVaccinationsCountByPatientID(ctx context.Context, patientID int64) (int64, error) {
+	ctx, cancel := withQueryTimeout(ctx, vaccinationRepository.QueryTimeout)
+	defer cancel()
+
+	var count int64
+	err := vaccinationRepository.DB.GetContext(ctx, &count, `SELECT COUNT(*) FROM vaccinations WHERE patient_id = $1`, patientID)
+	return count, err
+}
+
+func (vaccinationRepository *VaccinationRepository) DeleteVaccinationByID(ctx context.Context, id int64) error {
+	ctx, cancel := withQueryTimeout(ctx, vaccinationRepository.QueryTimeout)
+	defer cancel()
+
+	result, err := vaccinationRepository.DB.ExecContext(ctx, `DELETE FROM vaccinations WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrVaccinationNotFound
+	}
+	return nil
+}
+
+// dueVaccinationsFrom only considers each patient's latest dose of a vaccine,
+// so a booster that has been given clears the reminder for the previous one.
+const dueVaccinationsFrom = `
+	FROM vaccinations v
+	JOIN patients p ON p.id = v.patient_id
+	JOIN clients c ON c.id = p.owner_id
+	JOIN vaccines vc ON vc.id = v.vaccine_id
+	WHERE v.next_due_at IS NOT NULL AND v.next_due_at < $1
+	AND NOT EXISTS (
+		SELECT 1 FROM vaccinations newer
+		WHERE newer.patient_id = v.patient_id AND newer.vaccine_id = v.vaccine_id
+		AND (newer.administered_at, newer.id) > (v.administered_at, v.id)
+	)
+`
+
+// GetDueVaccinations lists boosters due before until, including overdue ones,
+// soonest first. Overdue is relative to now.
+func (vaccinationRepository *VaccinationRepository) GetDueVaccinations(ctx context.Context, until time.Time, now time.Time, limit int, offset int) ([]domain.DueVaccination, error) {
+	ctx, cancel := withQueryTimeout(ctx, vaccinationRepository.QueryTimeout)
+	defer cancel()
+
+	query := `SELECT v.id AS vaccination_id, p.id AS patient_id, p.name AS patient_name, p.species,
+		vc.id AS vaccine_id, vc.name AS vaccine_name, v.administered_at, v.next_due_at,
+		v.next_due_at < $2 AS overdue,
+		c.id AS client_id, c.name AS client_name, c.phone_number` + dueVaccinationsFrom + `
+	ORDER BY v.next_due_at, v.id
+	LIMIT $3 OFFSET $4`
+	var due []domain.DueVaccination
+	err := vaccinationRepository.DB.SelectContext(ctx, &due, query, until, now, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return due, nil
+}
+
+func (vaccinationRepository *VaccinationRepository) GetDueVaccinationsCount(ctx context.Context, until time.Time) (int64, error) {
+	ctx, cancel := withQueryTimeout(ctx, vaccinationRepository.QueryTimeout)
+	defer cancel()
+
+	var count int64
+	err := vaccinationRepository.DB.GetContext(ctx, &count, `SELECT COUNT(*)`+dueVaccinationsFrom, until)
+	return count, err
+}
+
+func (vaccinationRepository *VaccinationRepository) loadBoosters(ctx context.Context, vaccines []domain.Vaccine) error {
+	if len(vaccines) == 0 {
+		return nil
+	}
+	ids := make([]int64, len(vaccines))
+	index := make(map[int64]int, len(vaccines))
+	for i := range vaccines {
+		ids[i] = vaccines[i].ID
+		index[vaccines[i].ID] = i
+		vaccines[i].Boosters = []domain.VaccineBooster{}
+	}
+	var boosters []domain.VaccineBooster
+	err := vaccinationRepository.DB.SelectContext(ctx, &boosters, `SELECT vaccine_id, species, interval_days FROM vaccine_boosters WHERE vaccine_id = ANY($1) ORDER BY species`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	for _, booster := range boosters {
+		i := index[booster.VaccineID]
+		vaccines[i].Boosters = append(vaccines[i].Boosters, booster)
+	}
+	return nil
+}
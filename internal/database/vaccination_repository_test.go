@@ -0,0 +1,150 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+	"vetsys/internal/domain"
+)
+
+func createVaccinationFixtures(t *testing.T) (*domain.User, *domain.Patient, *domain.Vaccine) {
+	t.Helper()
+	cleanupTables(testDB)
+
+	vet := domain.NewUser("12345678A", "vet@example.com", "hashedpassword", "Dr. Vet", "vet.jpg")
+	vet.Role = domain.RoleVeterinarian
+	if err := testDB.UserRepo.CreateUser(context.Background(), vet); err != nil {
+		t.Fatalf("Failed to create vet: %v", err)
+	}
+
+	client := domain.NewClient("23456789B", "Jane Smith", "+34600222333")
+	testDB.ClientRepo.CreateClient(context.Background(), client)
+
+	dob := time.Date(2019, 3, 10, 0, 0, 0, 0, time.UTC)
+	patient := domain.NewPatient("Luna", "Cat", "Persian", dob, client.ID)
+	testDB.PatientRepo.CreatePatient(context.Background(), patient)
+
+	vaccine := domain.NewVaccine("Rabies", "Inactivated rabies vaccine")
+	vaccine.Boosters = append(vaccine.Boosters, *domain.NewVaccineBooster(0, "cat", 365))
+	if err := testDB.VaccinationRepo.CreateVaccine(context.Background(), vaccine); err != nil {
+		t.Fatalf("Failed to create vaccine: %v", err)
+	}
+
+	return vet, patient, vaccine
+}
+
+func TestVaccinationRepository_CreateVaccine(t *testing.T) {
+	_, _, vaccine := createVaccinationFixtures(t)
+
+	retrieved, err := testDB.VaccinationRepo.GetVaccineByID(context.Background(), vaccine.ID)
+	if err != nil {
+		t.Fatalf("Failed to get vaccine: %v", err)
+	}
+	if len(retrieved.Boosters) != 1 || retrieved.Boosters[0].IntervalDays != 365 {
+		t.Errorf("Expected one 365 day booster, got %v", retrieved.Boosters)
+	}
+
+	err = testDB.VaccinationRepo.CreateVaccine(context.Background(), domain.NewVaccine("Rabies", ""))
+	if err != ErrVaccineExists {
+		t.Errorf("Expected ErrVaccineExists, got %v", err)
+	}
+}
+
+func TestVaccinationRepository_SetVaccineBooster(t *testing.T) {
+	_, _, vaccine := createVaccinationFixtures(t)
+
+	err := testDB.VaccinationRepo.SetVaccineBooster(context.Background(), domain.NewVaccineBooster(vaccine.ID, "Cat", 730))
+	if err != nil {
+		t.Fatalf("Failed to set booster: %v", err)
+	}
+	retrieved, _ := testDB.VaccinationRepo.GetVaccineByID(context.Background(), vaccine.ID)
+	if len(retrieved.Boosters) != 1 || retrieved.Boosters[0].IntervalDays != 730 {
+		t.Errorf("Expected booster interval to be replaced, got %v", retrieved.Boosters)
+	}
+
+	err = testDB.VaccinationRepo.SetVaccineBooster(context.Background(), domain.NewVaccineBooster(99999, "cat", 365))
+	if err != ErrVaccineNotFound {
+		t.Errorf("Expected ErrVaccineNotFound, got %v", err)
+	}
+}
+
+func TestVaccinationRepository_CreateVaccination_DerivesNextDue(t *testing.T) {
+	vet, patient, vaccine := createVaccinationFixtures(t)
+
+	administeredAt := time.Date(2025, 5, 1, 10, 0, 0, 0, time.UTC)
+	vaccination := domain.NewVaccination(patient.ID, vaccine.ID, vet.ID, "LOT-1", administeredAt, "")
+	err := testDB.VaccinationRepo.CreateVaccination(context.Background(), vaccination)
+	if err != nil {
+		t.Fatalf("Failed to create vaccination: %v", err)
+	}
+	if vaccination.ID == 0 {
+		t.Error("Expected vaccination ID to be set after creation")
+	}
+	expected := administeredAt.AddDate(0, 0, 365)
+	if vaccination.NextDueAt == nil || !vaccination.NextDueAt.Equal(expected) {
+		t.Errorf("Expected next due %v, got %v", expected, vaccination.NextDueAt)
+	}
+}
+
+func TestVaccinationRepository_CreateVaccination_NonClinician(t *testing.T) {
+	_, patient, vaccine := createVaccinationFixtures(t)
+
+	receptionist := domain.NewUser("34567890C", "desk@example.com", "hashedpassword", "Front Desk", "desk.jpg")
+	receptionist.Role = domain.RoleReceptionist
+	testDB.UserRepo.CreateUser(context.Background(), receptionist)
+
+	vaccination := domain.NewVaccination(patient.ID, vaccine.ID, receptionist.ID, "LOT-1", time.Now(), "")
+	err := testDB.VaccinationRepo.CreateVaccination(context.Background(), vaccination)
+	if err != ErrVetNotFound {
+		t.Errorf("Expected ErrVetNotFound, got %v", err)
+	}
+}
+
+func TestVaccinationRepository_GetDueVaccinations(t *testing.T) {
+	vet, patient, vaccine := createVaccinationFixtures(t)
+	now := time.Now().UTC()
+
+	overdue := domain.NewVaccination(patient.ID, vaccine.ID, vet.ID, "LOT-1", now.AddDate(-1, 0, -10), "")
+	testDB.VaccinationRepo.CreateVaccination(context.Background(), overdue)
+
+	due, err := testDB.VaccinationRepo.GetDueVaccinations(context.Background(), now.AddDate(0, 0, 30), now, 20, 0)
+	if err != nil {
+		t.Fatalf("Failed to get due vaccinations: %v", err)
+	}
+	if len(due) != 1 {
+		t.Fatalf("Expected 1 due vaccination, got %d", len(due))
+	}
+	if !due[0].Overdue {
+		t.Error("Expected vaccination to be overdue")
+	}
+	if due[0].PhoneNumber != "+34600222333" {
+		t.Errorf("Expected owner phone number, got %s", due[0].PhoneNumber)
+	}
+
+	booster := domain.NewVaccination(patient.ID, vaccine.ID, vet.ID, "LOT-2", now.AddDate(0, 0, -1), "")
+	testDB.VaccinationRepo.CreateVaccination(context.Background(), booster)
+
+	count, err := testDB.VaccinationRepo.GetDueVaccinationsCount(context.Background(), now.AddDate(0, 0, 30))
+	if err != nil {
+		t.Fatalf("Failed to count due vaccinations: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("Expected booster to clear the reminder, got %d due", count)
+	}
+}
+
+func TestVaccinationRepository_DeleteVaccinationByID(t *testing.T) {
+	vet, patient, vaccine := createVaccinationFixtures(t)
+
+	vaccination := domain.NewVaccination(patient.ID, vaccine.ID, vet.ID, "LOT-1", time.Now(), "")
+	testDB.VaccinationRepo.CreateVaccination(context.Background(), vaccination)
+
+	err := testDB.VaccinationRepo.DeleteVaccinationByID(context.Background(), vaccination.ID)
+	if err != nil {
+		t.Fatalf("Failed to delete vaccination: %v", err)
+	}
+	_, err = testDB.VaccinationRepo.GetVaccinationByID(context.Background(), vaccination.ID)
+	if err != ErrVaccinationNotFound {
+		t.Errorf("Expected ErrVaccinationNotFound, got %v", err)
+	}
+}
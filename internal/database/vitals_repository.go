@@ -0,0 +1,137 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+	"vetsys/internal/domain"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type VitalsRepository struct {
+	DB           *sqlx.DB
+	QueryTimeout time.Duration
+}
+
+var ErrVitalsNotFound = errors.New("Vitals not found")
+
+// maxTrendPoints caps how many records a trend query reads.
+const maxTrendPoints = 1000
+
+const vitalsColumns = `id, patient_id, consultation_id, recorded_by, recorded_at, weight_kg, temperature_c, heart_rate_bpm, respiratory_rate_bpm, notes, created_at`
+
+const vitalsFilterWhere = `
+	WHERE patient_id = $1
+	AND ($2::timestamp IS NULL OR recorded_at >= $2)
+	AND ($3::timestamp IS NULL OR recorded_at < $3)
+`
+
+func (vitalsRepository *VitalsRepository) CreateVitals(ctx context.Context, vitals *domain.Vitals) error {
+	ctx, cancel := withQueryTimeout(ctx, vitalsRepository.QueryTimeout)
+	defer cancel()
+
+	query := `INSERT INTO vitals (patient_id, consultation_id, recorded_by, recorded_at, weight_kg, temperature_c, heart_rate_bpm, respiratory_rate_bpm, notes, created_at)
+	VALUES (:patient_id, :consultation_id, :recorded_by, :recorded_at, :weight_kg, :temperature_c, :heart_rate_bpm, :respiratory_rate_bpm, :notes, :created_at)
+	RETURNING id`
+	stmt, err := vitalsRepository.DB.PrepareNamedContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	return stmt.GetContext(ctx, &vitals.ID, vitals)
+}
+
+func (vitalsRepository *VitalsRepository) GetVitalsByID(ctx context.Context, id int64) (*domain.Vitals, error) {
+	ctx, cancel := withQueryTimeout(ctx, vitalsRepository.QueryTimeout)
+	defer cancel()
+
+	vitals := domain.Vitals{}
+	err := vitalsRepository.DB.GetContext(ctx, &vitals, `SELECT `+vitalsColumns+` FROM vitals WHERE id = $1`, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrVitalsNotFound
+		}
+		return nil, err
+	}
+	return &vitals, nil
+}
+
+// GetVitalsByPatientID lists a patient's vitals recorded in [from, to), most
+// recent first. Nil bounds are open.
+func (vitalsRepository *VitalsRepository) GetVitalsByPatientID(ctx context.Context, patientID int64, from *time.Time, to *time.Time, limit int, offset int) ([]domain.Vitals, error) {
+	ctx, cancel := withQueryTimeout(ctx, vitalsRepository.QueryTimeout)
+	defer cancel()
+
+	query := `SELECT ` + vitalsColumns + ` FROM vitals` + vitalsFilterWhere + `ORDER BY recorded_at DESC, id DESC LIMIT $4 OFFSET $5`
+	var vitals []domain.Vitals
+	err := vitalsRepository.DB.SelectContext(ctx, &vitals, query, patientID, from, to, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return vitals, nil
+}
+
+func (vitalsRepository *VitalsRepository) GetVitalsCountByPatientID(ctx context.Context, patientID int64, from *time.Time, to *time.Time) (int64, error) {
+	ctx, cancel := withQueryTimeout(ctx, vitalsRepository.QueryTimeout)
+	defer cancel()
+
+	var count int64
+	err := vitalsRepository.DB.GetContext(ctx, &count, `SELECT COUNT(*) FROM vitals`+vitalsFilterWhere, patientID, from, to)
+	return count, err
+}
+
+// GetVitalsTrend returns a patient's vitals recorded in [from, to) oldest
+// first, keeping the most recent maxTrendPoints.
+func (vitalsRepository *VitalsRepository) GetVitalsTrend(ctx context.Context, patientID int64, from *time.Time, to *time.Time) ([]domain.Vitals, error) {
+	ctx, cancel := withQueryTimeout(ctx, vitalsRepository.QueryTimeout)
+	defer cancel()
+
+	query := `SELECT * FROM (
+		SELECT ` + vitalsColumns + ` FROM vitals` + vitalsFilterWhere + `ORDER BY recorded_at DESC, id DESC LIMIT $4
+	) latest ORDER BY recorded_at, id`
+	var vitals []domain.Vitals
+	err := vitalsRepository.DB.SelectContext(ctx, &vitals, query, patientID, from, to, maxTrendPoints)
+	if err != nil {
+		return nil, err
+	}
+	return vitals, nil
+}
+
+func (vitalsRepository *VitalsRepository) UpdateVitals(ctx context.Context, vitals *domain.Vitals) error {
+	ctx, cancel := withQueryTimeout(ctx, vitalsRepository.QueryTimeout)
+	defer cancel()
+
+	query := `UPDATE vitals SET consultation_id = $1, recorded_at = $2, weight_kg = $3, temperature_c = $4, heart_rate_bpm = $5, respiratory_rate_bpm = $6, notes = $7 WHERE id = $8`
+	result, err := vitalsRepository.DB.ExecContext(ctx, query, vitals.ConsultationID, vitals.RecordedAt, vitals.WeightKg, vitals.TemperatureC, vitals.HeartRateBPM, vitals.RespiratoryRateBPM, vitals.Notes, vitals.ID)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrVitalsNotFound
+	}
+	return nil
+}
+
+func (vitalsRepository *VitalsRepository) DeleteVitalsByID(ctx context.Context, id int64) error {
+	ctx, cancel := withQueryTimeout(ctx, vitalsRepository.QueryTimeout)
+	defer cancel()
+
+	result, err := vitalsRepository.DB.ExecContext(ctx, `DELETE FROM vitals WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrVitalsNotFound
+	}
+	return nil
+}
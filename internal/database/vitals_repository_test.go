@@ -0,0 +1,148 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+	"vetsys/internal/domain"
+)
+
+func createVitalsFixtures(t *testing.T) (*domain.User, *domain.Patient) {
+	t.Helper()
+	cleanupTables(testDB)
+
+	nurse := domain.NewUser("12345678A", "desk@example.com", "hashedpassword", "Front Desk", "desk.jpg")
+	nurse.Role = domain.RoleReceptionist
+	if err := testDB.UserRepo.CreateUser(context.Background(), nurse); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	client := domain.NewClient("23456789B", "Jane Smith", "+34600222333")
+	testDB.ClientRepo.CreateClient(context.Background(), client)
+
+	dob := time.Date(2019, 3, 10, 0, 0, 0, 0, time.UTC)
+	patient := domain.NewPatient("Max", "Dog", "Labrador", dob, client.ID)
+	testDB.PatientRepo.CreatePatient(context.Background(), patient)
+
+	return nurse, patient
+}
+
+func newWeightVitals(patientID int64, recordedBy int64, recordedAt time.Time, weight float64) *domain.Vitals {
+	vitals := domain.NewVitals(patientID, nil, recordedBy, recordedAt, "")
+	vitals.WeightKg = &weight
+	return vitals
+}
+
+func TestVitalsRepository_CreateVitals(t *testing.T) {
+	nurse, patient := createVitalsFixtures(t)
+
+	vitals := newWeightVitals(patient.ID, nurse.ID, time.Date(2025, 5, 1, 10, 0, 0, 0, time.UTC), 30.5)
+	heartRate := 90
+	vitals.HeartRateBPM = &heartRate
+
+	err := testDB.VitalsRepo.CreateVitals(context.Background(), vitals)
+	if err != nil {
+		t.Fatalf("Failed to create vitals: %v", err)
+	}
+	if vitals.ID == 0 {
+		t.Error("Expected vitals ID to be set after creation")
+	}
+
+	retrieved, err := testDB.VitalsRepo.GetVitalsByID(context.Background(), vitals.ID)
+	if err != nil {
+		t.Fatalf("Failed to get vitals: %v", err)
+	}
+	if retrieved.WeightKg == nil || *retrieved.WeightKg != 30.5 {
+		t.Errorf("Expected weight 30.5, got %v", retrieved.WeightKg)
+	}
+	if retrieved.TemperatureC != nil {
+		t.Errorf("Expected temperature to be unset, got %v", *retrieved.TemperatureC)
+	}
+}
+
+func TestVitalsRepository_CreateVitals_RequiresMeasurement(t *testing.T) {
+	nurse, patient := createVitalsFixtures(t)
+
+	vitals := domain.NewVitals(patient.ID, nil, nurse.ID, time.Now(), "Nothing measured")
+	err := testDB.VitalsRepo.CreateVitals(context.Background(), vitals)
+	if err == nil {
+		t.Error("Expected vitals without measurements to be rejected")
+	}
+}
+
+func TestVitalsRepository_GetVitalsByPatientID(t *testing.T) {
+	nurse, patient := createVitalsFixtures(t)
+
+	start := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		testDB.VitalsRepo.CreateVitals(context.Background(), newWeightVitals(patient.ID, nurse.ID, start.AddDate(0, i, 0), 30+float64(i)))
+	}
+
+	from := start.AddDate(0, 1, 0)
+	vitals, err := testDB.VitalsRepo.GetVitalsByPatientID(context.Background(), patient.ID, &from, nil, 20, 0)
+	if err != nil {
+		t.Fatalf("Failed to get vitals: %v", err)
+	}
+	if len(vitals) != 2 {
+		t.Fatalf("Expected 2 vitals since %v, got %d", from, len(vitals))
+	}
+	if *vitals[0].WeightKg != 32 {
+		t.Errorf("Expected most recent vitals first, got weight %v", *vitals[0].WeightKg)
+	}
+
+	count, err := testDB.VitalsRepo.GetVitalsCountByPatientID(context.Background(), patient.ID, nil, nil)
+	if err != nil {
+		t.Fatalf("Failed to count vitals: %v", err)
+	}
+	if count != 3 {
+		t.Errorf("Expected 3 vitals, got %d", count)
+	}
+}
+
+func TestVitalsRepository_GetVitalsTrend(t *testing.T) {
+	nurse, patient := createVitalsFixtures(t)
+
+	start := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	testDB.VitalsRepo.CreateVitals(context.Background(), newWeightVitals(patient.ID, nurse.ID, start.AddDate(0, 1, 0), 31))
+	testDB.VitalsRepo.CreateVitals(context.Background(), newWeightVitals(patient.ID, nurse.ID, start, 30))
+
+	records, err := testDB.VitalsRepo.GetVitalsTrend(context.Background(), patient.ID, nil, nil)
+	if err != nil {
+		t.Fatalf("Failed to get vitals trend: %v", err)
+	}
+	trend := domain.VitalsTrend(records, domain.VitalsMetrics)
+	weights := trend[domain.MetricWeight]
+	if len(weights) != 2 || weights[0].Value != 30 || weights[1].Value != 31 {
+		t.Errorf("Expected weight series [30 31] oldest first, got %v", weights)
+	}
+	if len(trend[domain.MetricTemperature]) != 0 {
+		t.Errorf("Expected empty temperature series, got %v", trend[domain.MetricTemperature])
+	}
+}
+
+func TestVitalsRepository_UpdateAndDeleteVitals(t *testing.T) {
+	nurse, patient := createVitalsFixtures(t)
+
+	vitals := newWeightVitals(patient.ID, nurse.ID, time.Date(2025, 5, 1, 10, 0, 0, 0, time.UTC), 30)
+	testDB.VitalsRepo.CreateVitals(context.Background(), vitals)
+
+	temperature := 38.5
+	vitals.TemperatureC = &temperature
+	err := testDB.VitalsRepo.UpdateVitals(context.Background(), vitals)
+	if err != nil {
+		t.Fatalf("Failed to update vitals: %v", err)
+	}
+	retrieved, _ := testDB.VitalsRepo.GetVitalsByID(context.Background(), vitals.ID)
+	if retrieved.TemperatureC == nil || *retrieved.TemperatureC != temperature {
+		t.Errorf("Expected temperature %v, got %v", temperature, retrieved.TemperatureC)
+	}
+
+	err = testDB.VitalsRepo.DeleteVitalsByID(context.Background(), vitals.ID)
+	if err != nil {
+		t.Fatalf("Failed to delete vitals: %v", err)
+	}
+	err = testDB.VitalsRepo.DeleteVitalsByID(context.Background(), vitals.ID)
+	if err != ErrVitalsNotFound {
+		t.Errorf("Expected ErrVitalsNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,27 @@
+package domain
+
+import "time"
+
+// AllowedRegistration is an invitation for a staff member to create an account
+// with their DNI.
+type AllowedRegistration struct {
+	DNI       string     `db:"dni" json:"dni"`
+	Role      Role       `db:"role" json:"role"`
+	Used      bool       `db:"used" json:"used"`
+	InvitedBy *int64     `db:"invited_by" json:"invitedBy"`
+	CreatedAt time.Time  `db:"created_at" json:"createdAt"`
+	ExpiresAt *time.Time `db:"expires_at" json:"expiresAt"`
+	UsedAt    *time.Time `db:"used_at" json:"usedAt"`
+	RevokedAt *time.Time `db:"revoked_at" json:"revokedAt"`
+	UserID    *int64     `db:"user_id" json:"userId"`
+}
+
+func NewAllowedRegistration(dni string, role Role, invitedBy int64, expiresAt *time.Time) *AllowedRegistration {
+	return &AllowedRegistration{
+		DNI:       dni,
+		Role:      role,
+		InvitedBy: &invitedBy,
+		CreatedAt: time.Now().UTC(),
+		ExpiresAt: expiresAt,
+	}
+}
@@ -0,0 +1,70 @@
+package domain
+
+import "time"
+
+type Appointment struct {
+	ID             int64             `db:"id" json:"id"`
+	PatientID      int64             `db:"patient_id" json:"patient_id"`
+	VetID          int64             `db:"vet_id" json:"vet_id"`
+	StartsAt       time.Time         `db:"starts_at" json:"starts_at"`
+	EndsAt         time.Time         `db:"ends_at" json:"ends_at"`
+	Reason         string            `db:"reason" json:"reason"`
+	Status         AppointmentStatus `db:"status" json:"status"`
+	ConsultationID *int64            `db:"consultation_id" json:"consultation_id"`
+	CreatedAt      time.Time         `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time         `db:"updated_at" json:"updated_at"`
+}
+
+type AppointmentStatus string
+
+const (
+	AppointmentBooked    AppointmentStatus = "BOOKED"
+	AppointmentCheckedIn AppointmentStatus = "CHECKED_IN"
+	AppointmentNoShow    AppointmentStatus = "NO_SHOW"
+	AppointmentCancelled AppointmentStatus = "CANCELLED"
+	AppointmentConverted AppointmentStatus = "CONVERTED"
+)
+
+// appointmentTransitions lists the statuses each status may move to; statuses
+// missing from the map are final.
+var appointmentTransitions = map[AppointmentStatus][]AppointmentStatus{
+	AppointmentBooked:    {AppointmentCheckedIn, AppointmentNoShow, AppointmentCancelled},
+	AppointmentCheckedIn: {AppointmentConverted, AppointmentCancelled},
+}
+
+func NewAppointment(patientID int64, vetID int64, startsAt time.Time, endsAt time.Time, reason string) *Appointment {
+	now := time.Now().UTC()
+	return &Appointment{
+		PatientID: patientID,
+		VetID:     vetID,
+		StartsAt:  startsAt.UTC(),
+		EndsAt:    endsAt.UTC(),
+		Reason:    reason,
+		Status:    AppointmentBooked,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+func (status AppointmentStatus) IsValid() bool {
+	switch status {
+	case AppointmentBooked, AppointmentCheckedIn, AppointmentNoShow, AppointmentCancelled, AppointmentConverted:
+		return true
+	}
+	return false
+}
+
+func (status AppointmentStatus) CanTransitionTo(next AppointmentStatus) bool {
+	for _, allowed := range appointmentTransitions[status] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// BlocksCalendar reports whether an appointment in this status still occupies
+// the vet's time slot.
+func (status AppointmentStatus) BlocksCalendar() bool {
+	return status == AppointmentBooked || status == AppointmentCheckedIn
+}
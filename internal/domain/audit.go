@@ -0,0 +1,65 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type AuditAction string
+
+const (
+	AuditCreate AuditAction = "CREATE"
+	AuditUpdate AuditAction = "UPDATE"
+	AuditDelete AuditAction = "DELETE"
+	AuditLock   AuditAction = "LOCK"
+	AuditUnlock AuditAction = "UNLOCK"
+)
+
+const (
+	AuditEntityClient       = "client"
+	AuditEntityPatient      = "patient"
+	AuditEntityConsultation = "consultation"
+	AuditEntityUser         = "user"
+	AuditEntityVaccination  = "vaccination"
+	AuditEntityPrescription = "prescription"
+	AuditEntityVitals       = "vitals"
+	AuditEntityInvoice      = "invoice"
+	AuditEntityPayment      = "payment"
+	// AuditEntityLogin entries record login lockouts. Their entity ID is the
+	// user's for a lockout of a user's DNI, and 0 otherwise.
+	AuditEntityLogin = "login"
+	// AuditEntityTwoFactorPolicy entries record changes to the roles that
+	// must use two-factor authentication. Their entity ID is always 0.
+	AuditEntityTwoFactorPolicy = "two_factor_policy"
+)
+
+// AuditEntry is one immutable record of a change to a clinical or user record.
+// Changes maps each changed field to its before and after values.
+type AuditEntry struct {
+	ID        int64           `db:"id" json:"id"`
+	ActorID   *int64          `db:"actor_id" json:"actor_id"`
+	Entity    string          `db:"entity" json:"entity"`
+	EntityID  int64           `db:"entity_id" json:"entity_id"`
+	Action    AuditAction     `db:"action" json:"action"`
+	Changes   json.RawMessage `db:"changes" json:"changes"`
+	IP        string          `db:"ip" json:"ip"`
+	RequestID string          `db:"request_id" json:"request_id"`
+	CreatedAt time.Time       `db:"created_at" json:"created_at"`
+}
+
+type FieldChange struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+func NewAuditEntry(actorID *int64, entity string, entityID int64, action AuditAction, changes json.RawMessage, ip string) *AuditEntry {
+	return &AuditEntry{
+		ActorID:   actorID,
+		Entity:    entity,
+		EntityID:  entityID,
+		Action:    action,
+		Changes:   changes,
+		IP:        ip,
+		CreatedAt: time.Now().UTC(),
+	}
+}
@@ -0,0 +1,125 @@
+package domain
+
+import "time"
+
+type BillableItemKind string
+
+const (
+	ItemService      BillableItemKind = "SERVICE"
+	ItemProduct      BillableItemKind = "PRODUCT"
+	ItemPrescription BillableItemKind = "PRESCRIPTION"
+)
+
+// BillableItem is a charge raised during a consultation. It is billed once it
+// is attached to an invoice. Amounts are in cents. Items with a ProductID
+// dispense that quantity from stock.
+type BillableItem struct {
+	ID             int64            `db:"id" json:"id"`
+	ConsultationID int64            `db:"consultation_id" json:"consultation_id"`
+	InvoiceID      *int64           `db:"invoice_id" json:"invoice_id"`
+	PrescriptionID *int64           `db:"prescription_id" json:"prescription_id"`
+	ProductID      *int64           `db:"product_id" json:"product_id"`
+	Kind           BillableItemKind `db:"kind" json:"kind"`
+	Description    string           `db:"description" json:"description"`
+	Quantity       int              `db:"quantity" json:"quantity"`
+	UnitPriceCents int64            `db:"unit_price_cents" json:"unit_price_cents"`
+	CreatedAt      time.Time        `db:"created_at" json:"created_at"`
+}
+
+type InvoiceStatus string
+
+const (
+	InvoiceIssued InvoiceStatus = "ISSUED"
+	InvoicePaid   InvoiceStatus = "PAID"
+	InvoiceVoid   InvoiceStatus = "VOID"
+)
+
+// Invoice bills a client for the uninvoiced items of their patients'
+// consultations. Items and Payments are only loaded for single invoices.
+type Invoice struct {
+	ID         int64          `db:"id" json:"id"`
+	ClientID   int64          `db:"client_id" json:"client_id"`
+	Status     InvoiceStatus  `db:"status" json:"status"`
+	TotalCents int64          `db:"total_cents" json:"total_cents"`
+	PaidCents  int64          `db:"paid_cents" json:"paid_cents"`
+	IssuedBy   *int64         `db:"issued_by" json:"issued_by"`
+	IssuedAt   time.Time      `db:"issued_at" json:"issued_at"`
+	VoidedAt   *time.Time     `db:"voided_at" json:"voided_at"`
+	Items      []BillableItem `db:"-" json:"items,omitempty"`
+	Payments   []Payment      `db:"-" json:"payments,omitempty"`
+}
+
+type PaymentMethod string
+
+const (
+	PaymentCash     PaymentMethod = "CASH"
+	PaymentCard     PaymentMethod = "CARD"
+	PaymentTransfer PaymentMethod = "TRANSFER"
+	PaymentOther    PaymentMethod = "OTHER"
+)
+
+type Payment struct {
+	ID          int64         `db:"id" json:"id"`
+	InvoiceID   int64         `db:"invoice_id" json:"invoice_id"`
+	AmountCents int64         `db:"amount_cents" json:"amount_cents"`
+	Method      PaymentMethod `db:"method" json:"method"`
+	Reference   string        `db:"reference" json:"reference"`
+	RecordedBy  *int64        `db:"recorded_by" json:"recorded_by"`
+	PaidAt      time.Time     `db:"paid_at" json:"paid_at"`
+}
+
+// ClientBalance summarises what a client has been billed and paid. Void
+// invoices are excluded; UninvoicedCents is work not yet invoiced.
+type ClientBalance struct {
+	ClientID         int64 `db:"client_id" json:"client_id"`
+	InvoicedCents    int64 `db:"invoiced_cents" json:"invoiced_cents"`
+	PaidCents        int64 `db:"paid_cents" json:"paid_cents"`
+	OutstandingCents int64 `db:"outstanding_cents" json:"outstanding_cents"`
+	UninvoicedCents  int64 `db:"uninvoiced_cents" json:"uninvoiced_cents"`
+}
+
+func NewBillableItem(consultationID int64, kind BillableItemKind, description string, quantity int, unitPriceCents int64) *BillableItem {
+	return &BillableItem{
+		ConsultationID: consultationID,
+		Kind:           kind,
+		Description:    description,
+		Quantity:       quantity,
+		UnitPriceCents: unitPriceCents,
+		CreatedAt:      time.Now().UTC(),
+	}
+}
+
+func NewPayment(invoiceID int64, amountCents int64, method PaymentMethod, reference string, recordedBy int64, paidAt time.Time) *Payment {
+	return &Payment{
+		InvoiceID:   invoiceID,
+		AmountCents: amountCents,
+		Method:      method,
+		Reference:   reference,
+		RecordedBy:  &recordedBy,
+		PaidAt:      paidAt.UTC(),
+	}
+}
+
+func (kind BillableItemKind) IsValid() bool {
+	switch kind {
+	case ItemService, ItemProduct, ItemPrescription:
+		return true
+	}
+	return false
+}
+
+func (method PaymentMethod) IsValid() bool {
+	switch method {
+	case PaymentCash, PaymentCard, PaymentTransfer, PaymentOther:
+		return true
+	}
+	return false
+}
+
+func (item *BillableItem) TotalCents() int64 {
+	return int64(item.Quantity) * item.UnitPriceCents
+}
+
+func (invoice *Invoice) OutstandingCents() int64 {
+	return invoice.TotalCents - invoice.PaidCents
+}
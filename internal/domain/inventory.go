@@ -0,0 +1,97 @@
+package domain
+
+import (
+	"strings"
+	"time"
+)
+
+// Product is a stocked medication or supply. QuantityOnHand is the sum over
+// its batches; Batches is only loaded for single products.
+type Product struct {
+	ID             int64        `db:"id" json:"id"`
+	SKU            string       `db:"sku" json:"sku"`
+	Name           string       `db:"name" json:"name"`
+	Unit           string       `db:"unit" json:"unit"`
+	MedicationID   *int64       `db:"medication_id" json:"medication_id"`
+	ReorderLevel   int          `db:"reorder_level" json:"reorder_level"`
+	QuantityOnHand int          `db:"quantity_on_hand" json:"quantity_on_hand"`
+	CreatedAt      time.Time    `db:"created_at" json:"created_at"`
+	Batches        []StockBatch `db:"-" json:"batches,omitempty"`
+}
+
+// StockBatch is the stock of one lot of a product.
+type StockBatch struct {
+	ID             int64      `db:"id" json:"id"`
+	ProductID      int64      `db:"product_id" json:"product_id"`
+	LotNumber      string     `db:"lot_number" json:"lot_number"`
+	ExpiryDate     *time.Time `db:"expiry_date" json:"expiry_date"`
+	QuantityOnHand int        `db:"quantity_on_hand" json:"quantity_on_hand"`
+}
+
+type StockMovementKind string
+
+const (
+	MovementPurchase   StockMovementKind = "PURCHASE"
+	MovementDispense   StockMovementKind = "DISPENSE"
+	MovementAdjustment StockMovementKind = "ADJUSTMENT"
+	MovementWriteOff   StockMovementKind = "WRITE_OFF"
+)
+
+// StockMovement is one change to a batch. Quantity is signed: purchases are
+// positive, dispenses and write-offs negative.
+type StockMovement struct {
+	ID             int64             `db:"id" json:"id"`
+	BatchID        int64             `db:"batch_id" json:"batch_id"`
+	LotNumber      string            `db:"lot_number" json:"lot_number"`
+	Kind           StockMovementKind `db:"kind" json:"kind"`
+	Quantity       int               `db:"quantity" json:"quantity"`
+	ConsultationID *int64            `db:"consultation_id" json:"consultation_id"`
+	BillableItemID *int64            `db:"billable_item_id" json:"billable_item_id"`
+	Reason         string            `db:"reason" json:"reason"`
+	RecordedBy     *int64            `db:"recorded_by" json:"recorded_by"`
+	CreatedAt      time.Time         `db:"created_at" json:"created_at"`
+}
+
+// ExpiringBatch is a batch with stock left that expires within the report
+// window.
+type ExpiringBatch struct {
+	StockBatch
+	SKU  string `db:"sku" json:"sku"`
+	Name string `db:"name" json:"name"`
+}
+
+type InventoryReport struct {
+	LowStock []Product       `json:"low_stock"`
+	Expiring []ExpiringBatch `json:"expiring"`
+}
+
+func NewProduct(sku string, name string, unit string, medicationID *int64, reorderLevel int) *Product {
+	return &Product{
+		SKU:          strings.TrimSpace(sku),
+		Name:         name,
+		Unit:         unit,
+		MedicationID: medicationID,
+		ReorderLevel: reorderLevel,
+		CreatedAt:    time.Now().UTC(),
+	}
+}
+
+func NewStockMovement(kind StockMovementKind, quantity int, reason string, recordedBy int64) *StockMovement {
+	return &StockMovement{
+		Kind:       kind,
+		Quantity:   quantity,
+		Reason:     reason,
+		RecordedBy: &recordedBy,
+		CreatedAt:  time.Now().UTC(),
+	}
+}
+
+// IsManual reports whether the movement can be recorded directly. Dispenses
+// only happen through consultation billable items.
+func (kind StockMovementKind) IsManual() bool {
+	switch kind {
+	case MovementPurchase, MovementAdjustment, MovementWriteOff:
+		return true
+	}
+	return false
+}
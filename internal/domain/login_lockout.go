@@ -0,0 +1,71 @@
+package domain
+
+import "time"
+
+// LockoutScope is what failed logins are counted against.
+type LockoutScope string
+
+const (
+	LockoutScopeDNI LockoutScope = "dni"
+	LockoutScopeIP  LockoutScope = "ip"
+)
+
+func (scope LockoutScope) IsValid() bool {
+	return scope == LockoutScopeDNI || scope == LockoutScopeIP
+}
+
+// LockoutResetAfter is how long without a failed login it takes for a DNI or
+// IP to start over with no failures and no past lockouts.
+const LockoutResetAfter = 24 * time.Hour
+
+// LoginLockout counts the failed logins of one DNI or client IP. Failures
+// counts towards the next lockout, and Lockouts how many there have been,
+// which sets the length of the next one.
+type LoginLockout struct {
+	Scope         LockoutScope `json:"scope" db:"scope"`
+	Key           string       `json:"key" db:"key"`
+	Failures      int          `json:"failures" db:"failures"`
+	Lockouts      int          `json:"lockouts" db:"lockouts"`
+	LastFailureAt time.Time    `json:"lastFailureAt" db:"last_failure_at"`
+	LockedUntil   *time.Time   `json:"lockedUntil" db:"locked_until"`
+}
+
+func (lockout *LoginLockout) IsLocked(now time.Time) bool {
+	return lockout.LockedUntil != nil && lockout.LockedUntil.After(now)
+}
+
+// LockoutPolicy locks a DNI or IP after Threshold consecutive failed logins.
+// The first lockout lasts Duration and each one after it twice as long as
+// the last, up to MaxDuration.
+type LockoutPolicy struct {
+	Threshold   int
+	Duration    time.Duration
+	MaxDuration time.Duration
+}
+
+// Backoff is how long the nth lockout lasts.
+func (policy LockoutPolicy) Backoff(n int) time.Duration {
+	d := policy.Duration
+	for i := 1; i < n && d < policy.MaxDuration; i++ {
+		d *= 2
+	}
+	return min(d, policy.MaxDuration)
+}
+
+// RecordFailure counts a failed login at now and reports whether it locked
+// the DNI or IP.
+func (policy LockoutPolicy) RecordFailure(lockout *LoginLockout, now time.Time) bool {
+	if !lockout.IsLocked(now) && now.Sub(lockout.LastFailureAt) >= LockoutResetAfter {
+		lockout.Failures, lockout.Lockouts = 0, 0
+	}
+	lockout.Failures++
+	lockout.LastFailureAt = now
+	if lockout.Failures < policy.Threshold {
+		return false
+	}
+	lockout.Failures = 0
+	lockout.Lockouts++
+	lockedUntil := now.Add(policy.Backoff(lockout.Lockouts))
+	lockout.LockedUntil = &lockedUntil
+	return true
+}
@@ -0,0 +1,39 @@
+package domain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// PasswordResetToken is a pending password reset. Only the hash of the token
+// is kept; the token itself goes to the user and is a credential.
+type PasswordResetToken struct {
+	TokenHash string    `db:"token_hash"`
+	UserID    int64     `db:"user_id"`
+	CreatedAt time.Time `db:"created_at"`
+	ExpiresAt time.Time `db:"expires_at"`
+}
+
+// NewPasswordResetToken returns a token for the user, valid for ttl, and the
+// record to store for it.
+func NewPasswordResetToken(userID int64, ttl time.Duration) (string, *PasswordResetToken, error) {
+	token, err := generateToken()
+	if err != nil {
+		return "", nil, err
+	}
+	now := time.Now().UTC()
+	return token, &PasswordResetToken{
+		TokenHash: HashToken(token),
+		UserID:    userID,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}, nil
+}
+
+// HashToken is how tokens that are credentials, such as reset tokens, are
+// stored, so a leaked table does not let anyone use them.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,139 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+type Medication struct {
+	ID          int64                 `db:"id" json:"id"`
+	Name        string                `db:"name" json:"name"`
+	Description string                `db:"description" json:"description"`
+	DoseUnit    string                `db:"dose_unit" json:"dose_unit"`
+	CreatedAt   time.Time             `db:"created_at" json:"created_at"`
+	DoseRanges  []MedicationDoseRange `db:"-" json:"dose_ranges"`
+}
+
+// MedicationDoseRange is the accepted dose per kg of body weight of a
+// medication for one species, in the medication's dose unit.
+type MedicationDoseRange struct {
+	MedicationID int64   `db:"medication_id" json:"-"`
+	Species      string  `db:"species" json:"species"`
+	MinDosePerKg float64 `db:"min_dose_per_kg" json:"min_dose_per_kg"`
+	MaxDosePerKg float64 `db:"max_dose_per_kg" json:"max_dose_per_kg"`
+}
+
+type Route string
+
+const (
+	RouteOral          Route = "ORAL"
+	RouteSubcutaneous  Route = "SUBCUTANEOUS"
+	RouteIntramuscular Route = "INTRAMUSCULAR"
+	RouteIntravenous   Route = "INTRAVENOUS"
+	RouteTopical       Route = "TOPICAL"
+	RouteOphthalmic    Route = "OPHTHALMIC"
+	RouteOtic          Route = "OTIC"
+	RouteInhaled       Route = "INHALED"
+)
+
+// Prescription is one drug prescribed during a consultation. Warnings holds
+// the dose checks that failed when it was prescribed; they do not block it.
+type Prescription struct {
+	ID              int64     `db:"id" json:"id"`
+	ConsultationID  int64     `db:"consultation_id" json:"consultation_id"`
+	MedicationID    int64     `db:"medication_id" json:"medication_id"`
+	PrescribedBy    *int64    `db:"prescribed_by" json:"prescribed_by"`
+	Dose            float64   `db:"dose" json:"dose"`
+	DoseUnit        string    `db:"dose_unit" json:"dose_unit"`
+	FrequencyHours  int       `db:"frequency_hours" json:"frequency_hours"`
+	DurationDays    int       `db:"duration_days" json:"duration_days"`
+	Route           Route     `db:"route" json:"route"`
+	PatientWeightKg *float64  `db:"patient_weight_kg" json:"patient_weight_kg"`
+	Warnings        []string  `db:"-" json:"warnings"`
+	Notes           string    `db:"notes" json:"notes"`
+	CreatedAt       time.Time `db:"created_at" json:"created_at"`
+}
+
+func NewMedication(name string, description string, doseUnit string) *Medication {
+	return &Medication{
+		Name:        name,
+		Description: description,
+		DoseUnit:    doseUnit,
+		CreatedAt:   time.Now().UTC(),
+		DoseRanges:  []MedicationDoseRange{},
+	}
+}
+
+func NewMedicationDoseRange(medicationID int64, species string, minDosePerKg float64, maxDosePerKg float64) *MedicationDoseRange {
+	return &MedicationDoseRange{
+		MedicationID: medicationID,
+		Species:      NormalizeSpecies(species),
+		MinDosePerKg: minDosePerKg,
+		MaxDosePerKg: maxDosePerKg,
+	}
+}
+
+func NewPrescription(consultationID int64, medicationID int64, prescribedBy int64, dose float64, doseUnit string, frequencyHours int, durationDays int, route Route, patientWeightKg *float64, notes string) *Prescription {
+	return &Prescription{
+		ConsultationID:  consultationID,
+		MedicationID:    medicationID,
+		PrescribedBy:    &prescribedBy,
+		Dose:            dose,
+		DoseUnit:        doseUnit,
+		FrequencyHours:  frequencyHours,
+		DurationDays:    durationDays,
+		Route:           route,
+		PatientWeightKg: patientWeightKg,
+		Warnings:        []string{},
+		Notes:           notes,
+		CreatedAt:       time.Now().UTC(),
+	}
+}
+
+func (route Route) IsValid() bool {
+	switch route {
+	case RouteOral, RouteSubcutaneous, RouteIntramuscular, RouteIntravenous,
+		RouteTopical, RouteOphthalmic, RouteOtic, RouteInhaled:
+		return true
+	}
+	return false
+}
+
+// DoseRangeFor returns the medication's dose range for species, if any.
+func (medication *Medication) DoseRangeFor(species string) (MedicationDoseRange, bool) {
+	species = NormalizeSpecies(species)
+	for _, doseRange := range medication.DoseRanges {
+		if doseRange.Species == species {
+			return doseRange, true
+		}
+	}
+	return MedicationDoseRange{}, false
+}
+
+// CheckDose compares the prescription's dose per kg against the medication's
+// range for species and returns a warning for each check that fails or could
+// not be made.
+func (prescription *Prescription) CheckDose(medication *Medication, species string) []string {
+	doseRange, ok := medication.DoseRangeFor(species)
+	if !ok {
+		return []string{fmt.Sprintf("No dose range for %s in %s; dose not checked", medication.Name, NormalizeSpecies(species))}
+	}
+	if !strings.EqualFold(prescription.DoseUnit, medication.DoseUnit) {
+		return []string{fmt.Sprintf("Dose unit %s does not match the %s range for %s; dose not checked", prescription.DoseUnit, medication.DoseUnit, medication.Name)}
+	}
+	if prescription.PatientWeightKg == nil {
+		return []string{"Patient weight not given; dose per kg not checked"}
+	}
+
+	dosePerKg := prescription.Dose / *prescription.PatientWeightKg
+	if dosePerKg < doseRange.MinDosePerKg {
+		return []string{fmt.Sprintf("Dose of %.3g %s/kg is below the minimum of %.3g %s/kg for %s",
+			dosePerKg, medication.DoseUnit, doseRange.MinDosePerKg, medication.DoseUnit, doseRange.Species)}
+	}
+	if dosePerKg > doseRange.MaxDosePerKg {
+		return []string{fmt.Sprintf("Dose of %.3g %s/kg is above the maximum of %.3g %s/kg for %s",
+			dosePerKg, medication.DoseUnit, doseRange.MaxDosePerKg, medication.DoseUnit, doseRange.Species)}
+	}
+	return []string{}
+}
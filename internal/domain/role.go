@@ -0,0 +1,100 @@
+package domain
+
+type Role string
+
+const (
+	RoleAdmin        Role = "ADMIN"
+	RoleVeterinarian Role = "VETERINARIAN"
+	RoleReceptionist Role = "RECEPTIONIST"
+	RoleReadOnly     Role = "READ_ONLY"
+)
+
+type Permission string
+
+const (
+	PermClientsRead         Permission = "clients:read"
+	PermClientsWrite        Permission = "clients:write"
+	PermClientsDelete       Permission = "clients:delete"
+	PermPatientsRead        Permission = "patients:read"
+	PermPatientsWrite       Permission = "patients:write"
+	PermPatientsDelete      Permission = "patients:delete"
+	PermConsultationsRead   Permission = "consultations:read"
+	PermConsultationsWrite  Permission = "consultations:write"
+	PermConsultationsDelete Permission = "consultations:delete"
+	PermClinicalNotesWrite  Permission = "clinical_notes:write"
+	PermAppointmentsRead    Permission = "appointments:read"
+	PermAppointmentsWrite   Permission = "appointments:write"
+	PermUsersManage         Permission = "users:manage"
+	PermRegistrationsManage Permission = "registrations:manage"
+	PermAuditRead           Permission = "audit:read"
+	PermVaccinationsRead    Permission = "vaccinations:read"
+	PermVaccinationsWrite   Permission = "vaccinations:write"
+	PermVaccinesManage      Permission = "vaccines:manage"
+	PermPrescriptionsRead   Permission = "prescriptions:read"
+	PermPrescriptionsWrite  Permission = "prescriptions:write"
+	PermMedicationsManage   Permission = "medications:manage"
+	PermVitalsRead          Permission = "vitals:read"
+	PermVitalsWrite         Permission = "vitals:write"
+	PermBillingRead         Permission = "billing:read"
+	PermBillingWrite        Permission = "billing:write"
+	PermInventoryRead       Permission = "inventory:read"
+	PermInventoryWrite      Permission = "inventory:write"
+)
+
+var readPermissions = []Permission{
+	PermClientsRead,
+	PermPatientsRead,
+	PermConsultationsRead,
+	PermAppointmentsRead,
+	PermVaccinationsRead,
+	PermPrescriptionsRead,
+	PermVitalsRead,
+	PermBillingRead,
+	PermInventoryRead,
+}
+
+// rolePermissions is the per-role policy. Admins are allowed everything and
+// are not listed.
+var rolePermissions = map[Role][]Permission{
+	RoleVeterinarian: append([]Permission{
+		PermClientsWrite, PermClientsDelete,
+		PermPatientsWrite, PermPatientsDelete,
+		PermConsultationsWrite, PermConsultationsDelete, PermClinicalNotesWrite,
+		PermAppointmentsWrite,
+		PermVaccinationsWrite, PermVaccinesManage,
+		PermPrescriptionsWrite, PermMedicationsManage,
+		PermVitalsWrite,
+		PermBillingWrite,
+		PermInventoryWrite,
+	}, readPermissions...),
+	RoleReceptionist: append([]Permission{
+		PermClientsWrite,
+		PermPatientsWrite,
+		PermConsultationsWrite,
+		PermAppointmentsWrite,
+		PermVitalsWrite,
+		PermBillingWrite,
+		PermInventoryWrite,
+	}, readPermissions...),
+	RoleReadOnly: readPermissions,
+}
+
+func (role Role) IsValid() bool {
+	switch role {
+	case RoleAdmin, RoleVeterinarian, RoleReceptionist, RoleReadOnly:
+		return true
+	}
+	return false
+}
+
+func (role Role) Can(permission Permission) bool {
+	if role == RoleAdmin {
+		return true
+	}
+	for _, allowed := range rolePermissions[role] {
+		if allowed == permission {
+			return true
+		}
+	}
+	return false
+}
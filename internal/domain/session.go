@@ -2,19 +2,33 @@ package domain
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
 	"time"
 )
 
+// Session is a signed-in browser. Its ID is the cookie value and so a
+// credential; show PublicID instead wherever sessions are listed.
 type Session struct {
-	ID        string    `json:"id" db:"id"`
-	ExpiresAt time.Time `json:"expiresAt" db:"expires_at"`
-	CreatedAt time.Time `json:"createdAt" db:"created_at"`
-	UserID    int64     `json:"userId" db:"user_id"`
+	ID         string    `json:"id" db:"id"`
+	ExpiresAt  time.Time `json:"expiresAt" db:"expires_at"`
+	CreatedAt  time.Time `json:"createdAt" db:"created_at"`
+	UserID     int64     `json:"userId" db:"user_id"`
+	LastSeenAt time.Time `json:"lastSeenAt" db:"last_seen_at"`
+	IP         string    `json:"ip" db:"ip"`
+	UserAgent  string    `json:"userAgent" db:"user_agent"`
+	// CSRFToken is a random token, unrelated to the ID, that state-changing
+	// requests of the session must carry. Sessions opened before tokens were
+	// issued have none and cannot make such requests.
+	CSRFToken string `json:"-" db:"csrf_token"`
 }
 
 func NewSession(userID int64, duration time.Duration) (*Session, error) {
-	id, err := generateSessionID()
+	id, err := generateToken()
+	if err != nil {
+		return nil, err
+	}
+	csrfToken, err := generateToken()
 	if err != nil {
 		return nil, err
 	}
@@ -22,14 +36,33 @@ func NewSession(userID int64, duration time.Duration) (*Session, error) {
 	now := time.Now().UTC()
 
 	return &Session{
-		ID:        id,
-		UserID:    userID,
-		CreatedAt: now,
-		ExpiresAt: now.Add(duration),
+		ID:         id,
+		UserID:     userID,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(duration),
+		CSRFToken:  csrfToken,
 	}, nil
 }
 
-func generateSessionID() (string, error) {
+// PublicID identifies the session to its user without revealing its ID.
+func (session *Session) PublicID() string {
+	sum := sha256.Sum256([]byte(session.ID))
+	return base64.RawURLEncoding.EncodeToString(sum[:12])
+}
+
+// SlidingExpiry is when the session expires if used at now: idle after now,
+// but no later than maxLifetime after it was created.
+func (session *Session) SlidingExpiry(now time.Time, idle time.Duration, maxLifetime time.Duration) time.Time {
+	expiresAt := now.Add(idle)
+	if limit := session.CreatedAt.Add(maxLifetime); expiresAt.After(limit) {
+		return limit
+	}
+	return expiresAt
+}
+
+// generateToken returns a random URL-safe token for use as a credential.
+func generateToken() (string, error) {
 	b := make([]byte, 32) // 256 bits of entropy
 
 	if _, err := rand.Read(b); err != nil {
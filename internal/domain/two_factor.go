@@ -0,0 +1,82 @@
+package domain
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"strings"
+	"time"
+)
+
+// RecoveryCodeCount is how many recovery codes a user gets at a time.
+const RecoveryCodeCount = 10
+
+// TwoFactor is a user's TOTP enrolment. It is pending, and not asked for at
+// login, until the user confirms it with a first code.
+type TwoFactor struct {
+	UserID int64 `db:"user_id"`
+	// Secret is the shared TOTP secret. Codes cannot be checked without it,
+	// so unlike passwords it is stored as is.
+	Secret    string     `db:"secret"`
+	EnabledAt *time.Time `db:"enabled_at"`
+	// LastUsedStep is the time step of the last code accepted, so no code is
+	// accepted twice.
+	LastUsedStep int64     `db:"last_used_step"`
+	CreatedAt    time.Time `db:"created_at"`
+}
+
+func (twoFactor *TwoFactor) IsEnabled() bool {
+	return twoFactor.EnabledAt != nil
+}
+
+// NewRecoveryCodes returns n single-use recovery codes to show the user once,
+// and the hashes to store for them.
+func NewRecoveryCodes(n int) ([]string, []string, error) {
+	codes := make([]string, 0, n)
+	hashes := make([]string, 0, n)
+	encoding := base32.StdEncoding.WithPadding(base32.NoPadding)
+	for range n {
+		b := make([]byte, 8)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		raw := strings.ToLower(encoding.EncodeToString(b))[:12]
+		code := raw[:4] + "-" + raw[4:8] + "-" + raw[8:]
+		codes = append(codes, code)
+		hashes = append(hashes, HashRecoveryCode(code))
+	}
+	return codes, hashes, nil
+}
+
+// HashRecoveryCode hashes a recovery code as typed, ignoring case, dashes
+// and spaces.
+func HashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	return HashToken(code)
+}
+
+// LoginChallenge is a login that passed the password check and waits for the
+// second factor. Its token stands in for the session until then, and is only
+// stored hashed.
+type LoginChallenge struct {
+	TokenHash string    `db:"token_hash"`
+	UserID    int64     `db:"user_id"`
+	Attempts  int       `db:"attempts"`
+	CreatedAt time.Time `db:"created_at"`
+	ExpiresAt time.Time `db:"expires_at"`
+}
+
+// NewLoginChallenge returns a pre-authentication token for the user, valid
+// for ttl, and the challenge to store for it.
+func NewLoginChallenge(userID int64, ttl time.Duration) (string, *LoginChallenge, error) {
+	token, err := generateToken()
+	if err != nil {
+		return "", nil, err
+	}
+	now := time.Now().UTC()
+	return token, &LoginChallenge{
+		TokenHash: HashToken(token),
+		UserID:    userID,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}, nil
+}
@@ -7,6 +7,7 @@ type User struct {
 	Password       string `db:"password" json:"-"`
 	Name           string `db:"name" json:"name"`
 	ProfilePicture string `db:"profile_picture" json:"profilePicture"`
+	Role           Role   `db:"role" json:"role"`
 }
 
 func NewUser(dni string, email string, password string, name string, profilePicture string) *User {
@@ -16,5 +17,6 @@ func NewUser(dni string, email string, password string, name string, profilePict
 		Password:       password,
 		Name:           name,
 		ProfilePicture: profilePicture,
+		Role:           RoleReadOnly,
 	}
 }
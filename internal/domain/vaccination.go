@@ -0,0 +1,88 @@
+package domain
+
+import (
+	"strings"
+	"time"
+)
+
+type Vaccine struct {
+	ID          int64            `db:"id" json:"id"`
+	Name        string           `db:"name" json:"name"`
+	Description string           `db:"description" json:"description"`
+	CreatedAt   time.Time        `db:"created_at" json:"created_at"`
+	Boosters    []VaccineBooster `db:"-" json:"boosters"`
+}
+
+// VaccineBooster is how long a dose of a vaccine protects one species before
+// the next one is due.
+type VaccineBooster struct {
+	VaccineID    int64  `db:"vaccine_id" json:"-"`
+	Species      string `db:"species" json:"species"`
+	IntervalDays int    `db:"interval_days" json:"interval_days"`
+}
+
+// Vaccination is one administered dose. NextDueAt is nil when the vaccine has
+// no booster interval for the patient's species.
+type Vaccination struct {
+	ID             int64      `db:"id" json:"id"`
+	PatientID      int64      `db:"patient_id" json:"patient_id"`
+	VaccineID      int64      `db:"vaccine_id" json:"vaccine_id"`
+	VetID          *int64     `db:"vet_id" json:"vet_id"`
+	LotNumber      string     `db:"lot_number" json:"lot_number"`
+	AdministeredAt time.Time  `db:"administered_at" json:"administered_at"`
+	NextDueAt      *time.Time `db:"next_due_at" json:"next_due_at"`
+	Notes          string     `db:"notes" json:"notes"`
+	CreatedAt      time.Time  `db:"created_at" json:"created_at"`
+}
+
+// DueVaccination is a patient's most recent dose of a vaccine whose booster
+// is due, with the owner's contact details for reminder calls.
+type DueVaccination struct {
+	VaccinationID  int64     `db:"vaccination_id" json:"vaccination_id"`
+	PatientID      int64     `db:"patient_id" json:"patient_id"`
+	PatientName    string    `db:"patient_name" json:"patient_name"`
+	Species        string    `db:"species" json:"species"`
+	VaccineID      int64     `db:"vaccine_id" json:"vaccine_id"`
+	VaccineName    string    `db:"vaccine_name" json:"vaccine_name"`
+	AdministeredAt time.Time `db:"administered_at" json:"administered_at"`
+	NextDueAt      time.Time `db:"next_due_at" json:"next_due_at"`
+	Overdue        bool      `db:"overdue" json:"overdue"`
+	ClientID       int64     `db:"client_id" json:"client_id"`
+	ClientName     string    `db:"client_name" json:"client_name"`
+	PhoneNumber    string    `db:"phone_number" json:"phone_number"`
+}
+
+func NewVaccine(name string, description string) *Vaccine {
+	return &Vaccine{
+		Name:        name,
+		Description: description,
+		CreatedAt:   time.Now().UTC(),
+		Boosters:    []VaccineBooster{},
+	}
+}
+
+func NewVaccineBooster(vaccineID int64, species string, intervalDays int) *VaccineBooster {
+	return &VaccineBooster{
+		VaccineID:    vaccineID,
+		Species:      NormalizeSpecies(species),
+		IntervalDays: intervalDays,
+	}
+}
+
+func NewVaccination(patientID int64, vaccineID int64, vetID int64, lotNumber string, administeredAt time.Time, notes string) *Vaccination {
+	return &Vaccination{
+		PatientID:      patientID,
+		VaccineID:      vaccineID,
+		VetID:          &vetID,
+		LotNumber:      lotNumber,
+		AdministeredAt: administeredAt.UTC(),
+		Notes:          notes,
+		CreatedAt:      time.Now().UTC(),
+	}
+}
+
+// NormalizeSpecies is the form species are stored and compared in for
+// booster intervals, since patients.species is free text.
+func NormalizeSpecies(species string) string {
+	return strings.ToLower(strings.TrimSpace(species))
+}
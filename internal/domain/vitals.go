@@ -0,0 +1,101 @@
+package domain
+
+import "time"
+
+// Vitals is one set of measurements taken for a patient, optionally during a
+// consultation. Measurements that were not taken are nil.
+type Vitals struct {
+	ID                 int64     `db:"id" json:"id"`
+	PatientID          int64     `db:"patient_id" json:"patient_id"`
+	ConsultationID     *int64    `db:"consultation_id" json:"consultation_id"`
+	RecordedBy         *int64    `db:"recorded_by" json:"recorded_by"`
+	RecordedAt         time.Time `db:"recorded_at" json:"recorded_at"`
+	WeightKg           *float64  `db:"weight_kg" json:"weight_kg"`
+	TemperatureC       *float64  `db:"temperature_c" json:"temperature_c"`
+	HeartRateBPM       *int      `db:"heart_rate_bpm" json:"heart_rate_bpm"`
+	RespiratoryRateBPM *int      `db:"respiratory_rate_bpm" json:"respiratory_rate_bpm"`
+	Notes              string    `db:"notes" json:"notes"`
+	CreatedAt          time.Time `db:"created_at" json:"created_at"`
+}
+
+type VitalsMetric string
+
+const (
+	MetricWeight          VitalsMetric = "weight_kg"
+	MetricTemperature     VitalsMetric = "temperature_c"
+	MetricHeartRate       VitalsMetric = "heart_rate_bpm"
+	MetricRespiratoryRate VitalsMetric = "respiratory_rate_bpm"
+)
+
+var VitalsMetrics = []VitalsMetric{MetricWeight, MetricTemperature, MetricHeartRate, MetricRespiratoryRate}
+
+// TrendPoint is one measurement in a time series.
+type TrendPoint struct {
+	RecordedAt time.Time `json:"recorded_at"`
+	Value      float64   `json:"value"`
+}
+
+func NewVitals(patientID int64, consultationID *int64, recordedBy int64, recordedAt time.Time, notes string) *Vitals {
+	return &Vitals{
+		PatientID:      patientID,
+		ConsultationID: consultationID,
+		RecordedBy:     &recordedBy,
+		RecordedAt:     recordedAt.UTC(),
+		Notes:          notes,
+		CreatedAt:      time.Now().UTC(),
+	}
+}
+
+func (metric VitalsMetric) IsValid() bool {
+	for _, valid := range VitalsMetrics {
+		if metric == valid {
+			return true
+		}
+	}
+	return false
+}
+
+// HasMeasurement reports whether at least one measurement is set.
+func (vitals *Vitals) HasMeasurement() bool {
+	return vitals.WeightKg != nil || vitals.TemperatureC != nil || vitals.HeartRateBPM != nil || vitals.RespiratoryRateBPM != nil
+}
+
+// Value returns the measurement for metric, if it was taken.
+func (vitals *Vitals) Value(metric VitalsMetric) (float64, bool) {
+	switch metric {
+	case MetricWeight:
+		if vitals.WeightKg != nil {
+			return *vitals.WeightKg, true
+		}
+	case MetricTemperature:
+		if vitals.TemperatureC != nil {
+			return *vitals.TemperatureC, true
+		}
+	case MetricHeartRate:
+		if vitals.HeartRateBPM != nil {
+			return float64(*vitals.HeartRateBPM), true
+		}
+	case MetricRespiratoryRate:
+		if vitals.RespiratoryRateBPM != nil {
+			return float64(*vitals.RespiratoryRateBPM), true
+		}
+	}
+	return 0, false
+}
+
+// VitalsTrend groups measurements into one time series per metric, in the
+// order given. Records that did not measure a metric are skipped in its series.
+func VitalsTrend(records []Vitals, metrics []VitalsMetric) map[VitalsMetric][]TrendPoint {
+	trend := make(map[VitalsMetric][]TrendPoint, len(metrics))
+	for _, metric := range metrics {
+		trend[metric] = []TrendPoint{}
+	}
+	for i := range records {
+		for _, metric := range metrics {
+			if value, ok := records[i].Value(metric); ok {
+				trend[metric] = append(trend[metric], TrendPoint{RecordedAt: records[i].RecordedAt, Value: value})
+			}
+		}
+	}
+	return trend
+}
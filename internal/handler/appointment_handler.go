@@ -0,0 +1,357 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+	"vetsys/internal/apierror"
+	"vetsys/internal/database"
+	"vetsys/internal/domain"
+	"vetsys/internal/utils"
+)
+
+type AppointmentHandler struct {
+	appointmentRepo database.AppointmentStore
+	patientRepo     database.PatientStore
+	auditor         *Auditor
+}
+
+type AppointmentRequest struct {
+	PatientID int64     `json:"patient_id"`
+	VetID     int64     `json:"vet_id"`
+	StartsAt  time.Time `json:"starts_at"`
+	EndsAt    time.Time `json:"ends_at"`
+	Reason    string    `json:"reason"`
+}
+
+type AppointmentUpdate struct {
+	PatientID *int64     `json:"patient_id"`
+	VetID     *int64     `json:"vet_id"`
+	StartsAt  *time.Time `json:"starts_at"`
+	EndsAt    *time.Time `json:"ends_at"`
+	Reason    *string    `json:"reason"`
+}
+
+type AppointmentStatusUpdate struct {
+	Status domain.AppointmentStatus `json:"status"`
+}
+
+type AppointmentConversionRequest struct {
+	Diagnosis string          `json:"diagnosis"`
+	Treatment string          `json:"treatment"`
+	Severity  domain.Severity `json:"severity"`
+}
+
+func NewAppointmentHandler(appointmentRepo database.AppointmentStore, patientRepo database.PatientStore, auditor *Auditor) *AppointmentHandler {
+	return &AppointmentHandler{
+		appointmentRepo: appointmentRepo,
+		patientRepo:     patientRepo,
+		auditor:         auditor,
+	}
+}
+
+func (appointmentHandler *AppointmentHandler) CreateAppointmentHandler(w http.ResponseWriter, r *http.Request) {
+	var appointmentRequest AppointmentRequest
+	err := json.NewDecoder(r.Body).Decode(&appointmentRequest)
+	if err != nil {
+		apierror.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if appointmentRequest.Reason == "" {
+		apierror.Validation(w, "reason", "Reason is required")
+		return
+	}
+	if appointmentRequest.VetID <= 0 {
+		apierror.Validation(w, "vet_id", "Vet is required")
+		return
+	}
+	if !validateAppointmentSlot(w, appointmentRequest.StartsAt, appointmentRequest.EndsAt) {
+		return
+	}
+	if !appointmentHandler.validatePatient(w, r, appointmentRequest.PatientID) {
+		return
+	}
+
+	appointment := domain.NewAppointment(appointmentRequest.PatientID, appointmentRequest.VetID, appointmentRequest.StartsAt, appointmentRequest.EndsAt, appointmentRequest.Reason)
+	err = appointmentHandler.appointmentRepo.CreateAppointment(r.Context(), appointment)
+	if !writeAppointmentError(w, err) {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(appointment)
+}
+
+func (appointmentHandler *AppointmentHandler) GetAppointmentByIDHandler(w http.ResponseWriter, r *http.Request) {
+	appointment, ok := appointmentHandler.appointmentFromPath(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(appointment)
+}
+
+func (appointmentHandler *AppointmentHandler) GetAppointmentsHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var filter database.AppointmentFilter
+
+	if vetID := query.Get("vet_id"); vetID != "" {
+		value, err := strconv.ParseInt(vetID, 10, 64)
+		if err != nil {
+			apierror.Validation(w, "vet_id", "Invalid vet_id parameter")
+			return
+		}
+		filter.VetID = &value
+	}
+	if patientID := query.Get("patient_id"); patientID != "" {
+		value, err := strconv.ParseInt(patientID, 10, 64)
+		if err != nil {
+			apierror.Validation(w, "patient_id", "Invalid patient_id parameter")
+			return
+		}
+		filter.PatientID = &value
+	}
+	if status := query.Get("status"); status != "" {
+		value := domain.AppointmentStatus(status)
+		if !value.IsValid() {
+			apierror.Validation(w, "status", "Invalid status parameter")
+			return
+		}
+		filter.Status = &value
+	}
+	if from := query.Get("from"); from != "" {
+		value, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			apierror.Validation(w, "from", "Invalid from parameter. Use RFC 3339")
+			return
+		}
+		value = value.UTC()
+		filter.From = &value
+	}
+	if to := query.Get("to"); to != "" {
+		value, err := time.Parse(time.RFC3339, to)
+		if err != nil {
+			apierror.Validation(w, "to", "Invalid to parameter. Use RFC 3339")
+			return
+		}
+		value = value.UTC()
+		filter.To = &value
+	}
+
+	limit, offset := utils.Pagination(r)
+	total, err := appointmentHandler.appointmentRepo.GetAppointmentsCount(r.Context(), filter)
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	totalPages := int((total + int64(limit) - 1) / int64(limit))
+	page := (offset / limit) + 1
+
+	appointments, err := appointmentHandler.appointmentRepo.GetAppointments(r.Context(), filter, limit, offset)
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	response := PaginatedResponse{
+		Data:       appointments,
+		Page:       page,
+		Limit:      limit,
+		Total:      total,
+		TotalPages: totalPages,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (appointmentHandler *AppointmentHandler) UpdateAppointmentHandler(w http.ResponseWriter, r *http.Request) {
+	appointment, ok := appointmentHandler.appointmentFromPath(w, r)
+	if !ok {
+		return
+	}
+	var appointmentUpdate AppointmentUpdate
+	err := json.NewDecoder(r.Body).Decode(&appointmentUpdate)
+	if err != nil {
+		apierror.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if appointment.Status != domain.AppointmentBooked {
+		apierror.Error(w, "Only booked appointments can be rescheduled", http.StatusConflict)
+		return
+	}
+	if appointmentUpdate.PatientID != nil {
+		if !appointmentHandler.validatePatient(w, r, *appointmentUpdate.PatientID) {
+			return
+		}
+		appointment.PatientID = *appointmentUpdate.PatientID
+	}
+	if appointmentUpdate.VetID != nil {
+		if *appointmentUpdate.VetID <= 0 {
+			apierror.Validation(w, "vet_id", "VetID must be greater than 0")
+			return
+		}
+		appointment.VetID = *appointmentUpdate.VetID
+	}
+	if appointmentUpdate.Reason != nil {
+		if *appointmentUpdate.Reason == "" {
+			apierror.Validation(w, "reason", "Reason cannot be empty")
+			return
+		}
+		appointment.Reason = *appointmentUpdate.Reason
+	}
+	if appointmentUpdate.StartsAt != nil {
+		appointment.StartsAt = appointmentUpdate.StartsAt.UTC()
+	}
+	if appointmentUpdate.EndsAt != nil {
+		appointment.EndsAt = appointmentUpdate.EndsAt.UTC()
+	}
+	if !validateAppointmentSlot(w, appointment.StartsAt, appointment.EndsAt) {
+		return
+	}
+
+	appointment.UpdatedAt = time.Now().UTC()
+
+	err = appointmentHandler.appointmentRepo.UpdateAppointment(r.Context(), appointment)
+	if !writeAppointmentError(w, err) {
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (appointmentHandler *AppointmentHandler) UpdateAppointmentStatusHandler(w http.ResponseWriter, r *http.Request) {
+	appointment, ok := appointmentHandler.appointmentFromPath(w, r)
+	if !ok {
+		return
+	}
+	var statusUpdate AppointmentStatusUpdate
+	err := json.NewDecoder(r.Body).Decode(&statusUpdate)
+	if err != nil {
+		apierror.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if !statusUpdate.Status.IsValid() {
+		apierror.Validation(w, "status", "Invalid status. Must be BOOKED, CHECKED_IN, NO_SHOW, CANCELLED or CONVERTED")
+		return
+	}
+	if statusUpdate.Status == domain.AppointmentConverted {
+		apierror.Validation(w, "status", "Use POST /api/appointments/{appointment_id}/consultation to convert an appointment")
+		return
+	}
+	if !appointment.Status.CanTransitionTo(statusUpdate.Status) {
+		apierror.Error(w, "Cannot change appointment from "+string(appointment.Status)+" to "+string(statusUpdate.Status), http.StatusConflict)
+		return
+	}
+
+	err = appointmentHandler.appointmentRepo.UpdateAppointmentStatus(r.Context(), appointment.ID, appointment.Status, statusUpdate.Status)
+	if !writeAppointmentError(w, err) {
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (appointmentHandler *AppointmentHandler) ConvertAppointmentHandler(w http.ResponseWriter, r *http.Request) {
+	appointment, ok := appointmentHandler.appointmentFromPath(w, r)
+	if !ok {
+		return
+	}
+	var conversionRequest AppointmentConversionRequest
+	err := json.NewDecoder(r.Body).Decode(&conversionRequest)
+	if err != nil {
+		apierror.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if !isValidSeverity(conversionRequest.Severity) {
+		apierror.Validation(w, "severity", "Invalid severity. Must be LOW, MEDIUM, HIGH, or CRITICAL")
+		return
+	}
+	if (conversionRequest.Diagnosis != "" || conversionRequest.Treatment != "") && !canWriteClinicalNotes(r) {
+		apierror.Error(w, "Forbidden: Only veterinarians can set diagnosis or treatment", http.StatusForbidden)
+		return
+	}
+	if !appointment.Status.CanTransitionTo(domain.AppointmentConverted) {
+		apierror.Error(w, "Only checked-in appointments can be converted to a consultation", http.StatusConflict)
+		return
+	}
+
+	consultation := domain.NewConsultation(appointment.PatientID, appointment.Reason, conversionRequest.Diagnosis, conversionRequest.Treatment, conversionRequest.Severity)
+	err = appointmentHandler.appointmentRepo.ConvertToConsultation(r.Context(), appointment, consultation)
+	if !writeAppointmentError(w, err) {
+		return
+	}
+	appointmentHandler.auditor.Record(r, domain.AuditCreate, domain.AuditEntityConsultation, consultation.ID, nil, consultation)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(consultation)
+}
+
+func (appointmentHandler *AppointmentHandler) appointmentFromPath(w http.ResponseWriter, r *http.Request) (*domain.Appointment, bool) {
+	id := r.PathValue("appointment_id")
+	if id == "" {
+		apierror.Error(w, "No id passed", http.StatusBadRequest)
+		return nil, false
+	}
+	idValue, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		apierror.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+	appointment, err := appointmentHandler.appointmentRepo.GetAppointmentByID(r.Context(), idValue)
+	if !writeAppointmentError(w, err) {
+		return nil, false
+	}
+	return appointment, true
+}
+
+func (appointmentHandler *AppointmentHandler) validatePatient(w http.ResponseWriter, r *http.Request, patientID int64) bool {
+	if patientID <= 0 {
+		apierror.Validation(w, "patient_id", "PatientID must be greater than 0")
+		return false
+	}
+	_, err := appointmentHandler.patientRepo.GetPatientByID(r.Context(), patientID)
+	if err == database.ErrPatientNotFound {
+		apierror.Error(w, err.Error(), http.StatusBadRequest)
+		return false
+	}
+	if err != nil {
+		apierror.Respond(w, err)
+		return false
+	}
+	return true
+}
+
+func validateAppointmentSlot(w http.ResponseWriter, startsAt time.Time, endsAt time.Time) bool {
+	if startsAt.IsZero() || endsAt.IsZero() {
+		apierror.Validation(w, "starts_at", "Start and end times are required")
+		return false
+	}
+	if !endsAt.After(startsAt) {
+		apierror.Validation(w, "ends_at", "End time must be after start time")
+		return false
+	}
+	return true
+}
+
+// writeAppointmentError maps appointment repository errors to responses and
+// reports whether the caller may continue.
+func writeAppointmentError(w http.ResponseWriter, err error) bool {
+	switch err {
+	case nil:
+		return true
+	case database.ErrAppointmentNotFound:
+		apierror.Error(w, err.Error(), http.StatusNotFound)
+	case database.ErrVetNotFound:
+		apierror.Error(w, err.Error(), http.StatusBadRequest)
+	case database.ErrAppointmentConflict, database.ErrAppointmentStatusChanged:
+		apierror.Error(w, err.Error(), http.StatusConflict)
+	default:
+		apierror.Respond(w, err)
+	}
+	return false
+}
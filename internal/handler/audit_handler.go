@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+	"vetsys/internal/apierror"
+	"vetsys/internal/database"
+	"vetsys/internal/domain"
+	"vetsys/internal/utils"
+)
+
+type AuditHandler struct {
+	auditRepo database.AuditStore
+}
+
+func NewAuditHandler(auditRepo database.AuditStore) *AuditHandler {
+	return &AuditHandler{
+		auditRepo: auditRepo,
+	}
+}
+
+func (auditHandler *AuditHandler) GetAuditEntriesHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var filter database.AuditFilter
+
+	if entity := query.Get("entity"); entity != "" {
+		if !isAuditedEntity(entity) {
+			apierror.Validation(w, "entity", "Invalid entity. Must be client, patient, consultation, user, vaccination, prescription, vitals, invoice, payment, login or two_factor_policy")
+			return
+		}
+		filter.Entity = &entity
+	}
+	if entityID := query.Get("entity_id"); entityID != "" {
+		value, err := strconv.ParseInt(entityID, 10, 64)
+		if err != nil {
+			apierror.Validation(w, "entity_id", "Invalid entity_id parameter")
+			return
+		}
+		filter.EntityID = &value
+	}
+	if actorID := query.Get("actor_id"); actorID != "" {
+		value, err := strconv.ParseInt(actorID, 10, 64)
+		if err != nil {
+			apierror.Validation(w, "actor_id", "Invalid actor_id parameter")
+			return
+		}
+		filter.ActorID = &value
+	}
+	if from := query.Get("from"); from != "" {
+		value, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			apierror.Validation(w, "from", "Invalid from parameter. Use RFC 3339")
+			return
+		}
+		value = value.UTC()
+		filter.From = &value
+	}
+	if to := query.Get("to"); to != "" {
+		value, err := time.Parse(time.RFC3339, to)
+		if err != nil {
+			apierror.Validation(w, "to", "Invalid to parameter. Use RFC 3339")
+			return
+		}
+		value = value.UTC()
+		filter.To = &value
+	}
+
+	limit, offset := utils.Pagination(r)
+	total, err := auditHandler.auditRepo.GetEntriesCount(r.Context(), filter)
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	totalPages := int((total + int64(limit) - 1) / int64(limit))
+	page := (offset / limit) + 1
+
+	entries, err := auditHandler.auditRepo.GetEntries(r.Context(), filter, limit, offset)
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	response := PaginatedResponse{
+		Data:       entries,
+		Page:       page,
+		Limit:      limit,
+		Total:      total,
+		TotalPages: totalPages,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+func isAuditedEntity(entity string) bool {
+	return entity == domain.AuditEntityClient ||
+		entity == domain.AuditEntityPatient ||
+		entity == domain.AuditEntityConsultation ||
+		entity == domain.AuditEntityUser ||
+		entity == domain.AuditEntityVaccination ||
+		entity == domain.AuditEntityPrescription ||
+		entity == domain.AuditEntityVitals ||
+		entity == domain.AuditEntityInvoice ||
+		entity == domain.AuditEntityPayment ||
+		entity == domain.AuditEntityLogin ||
+		entity == domain.AuditEntityTwoFactorPolicy
+}
@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net"
+	"net/http"
+	"reflect"
+	"vetsys/internal/database"
+	"vetsys/internal/domain"
+	"vetsys/internal/middleware"
+)
+
+// Auditor appends an audit entry for every change handlers make to clients,
+// patients, consultations, vaccinations, prescriptions, vitals, invoices,
+// payments and users.
+type Auditor struct {
+	auditRepo database.AuditStore
+}
+
+func NewAuditor(auditRepo database.AuditStore) *Auditor {
+	return &Auditor{
+		auditRepo: auditRepo,
+	}
+}
+
+// Record stores the field-level difference between before and after. Pass a
+// nil before for creations and a nil after for deletions. The change has
+// already been committed, so failures are logged rather than returned.
+func (auditor *Auditor) Record(r *http.Request, action domain.AuditAction, entity string, entityID int64, before any, after any) {
+	changes, err := auditChanges(before, after)
+	if err != nil {
+		requestID, _ := middleware.GetRequestID(r.Context())
+		slog.ErrorContext(r.Context(), "audit: failed to diff change", "request_id", requestID, "entity", entity, "entity_id", entityID, "error", err)
+		return
+	}
+	var actorID *int64
+	if userID, ok := middleware.GetUserID(r.Context()); ok {
+		actorID = &userID
+	}
+	entry := domain.NewAuditEntry(actorID, entity, entityID, action, changes, clientIP(r))
+	entry.RequestID, _ = middleware.GetRequestID(r.Context())
+	if err := auditor.auditRepo.CreateEntry(r.Context(), entry); err != nil {
+		slog.ErrorContext(r.Context(), "audit: failed to record change", "request_id", entry.RequestID, "action", action, "entity", entity, "entity_id", entityID, "error", err)
+	}
+}
+
+// auditChanges compares the JSON representations of before and after and
+// returns the fields that differ.
+func auditChanges(before any, after any) (json.RawMessage, error) {
+	beforeFields, err := jsonFields(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := jsonFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]domain.FieldChange)
+	for field, value := range beforeFields {
+		if !reflect.DeepEqual(value, afterFields[field]) {
+			changes[field] = domain.FieldChange{Before: value, After: afterFields[field]}
+		}
+	}
+	for field, value := range afterFields {
+		if _, seen := beforeFields[field]; !seen {
+			changes[field] = domain.FieldChange{Before: nil, After: value}
+		}
+	}
+	return json.Marshal(changes)
+}
+
+func jsonFields(value any) (map[string]any, error) {
+	fields := make(map[string]any)
+	if value == nil {
+		return fields, nil
+	}
+	if v := reflect.ValueOf(value); v.Kind() == reflect.Pointer && v.IsNil() {
+		return fields, nil
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(encoded, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+func clientIP(r *http.Request) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return ip
+}
@@ -0,0 +1,379 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+	"vetsys/internal/apierror"
+	"vetsys/internal/database"
+	"vetsys/internal/domain"
+	"vetsys/internal/middleware"
+	"vetsys/internal/utils"
+)
+
+type BillingHandler struct {
+	billingRepo      database.BillingStore
+	clientRepo       database.ClientStore
+	consultRepo      database.ConsultationStore
+	prescriptionRepo database.PrescriptionStore
+	inventoryRepo    database.InventoryStore
+	auditor          *Auditor
+}
+
+type BillableItemRequest struct {
+	Kind           domain.BillableItemKind `json:"kind"`
+	Description    string                  `json:"description"`
+	Quantity       int                     `json:"quantity"`
+	UnitPriceCents int64                   `json:"unit_price_cents"`
+	PrescriptionID *int64                  `json:"prescription_id"`
+	ProductID      *int64                  `json:"product_id"`
+}
+
+type PaymentRequest struct {
+	AmountCents int64                `json:"amount_cents"`
+	Method      domain.PaymentMethod `json:"method"`
+	Reference   string               `json:"reference"`
+	PaidAt      *time.Time           `json:"paid_at"`
+}
+
+func NewBillingHandler(billingRepo database.BillingStore, clientRepo database.ClientStore, consultRepo database.ConsultationStore, prescriptionRepo database.PrescriptionStore, inventoryRepo database.InventoryStore, auditor *Auditor) *BillingHandler {
+	return &BillingHandler{
+		billingRepo:      billingRepo,
+		clientRepo:       clientRepo,
+		consultRepo:      consultRepo,
+		prescriptionRepo: prescriptionRepo,
+		inventoryRepo:    inventoryRepo,
+		auditor:          auditor,
+	}
+}
+
+// CreateBillableItemHandler adds a charge to a consultation. Prescription
+// items must reference a prescription written in the same consultation, and
+// items with a product_id dispense that product from stock.
+func (billingHandler *BillingHandler) CreateBillableItemHandler(w http.ResponseWriter, r *http.Request) {
+	consultationID, ok := pathID(w, r, "consultation_id")
+	if !ok {
+		return
+	}
+	var itemRequest BillableItemRequest
+	err := json.NewDecoder(r.Body).Decode(&itemRequest)
+	if err != nil {
+		apierror.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if !itemRequest.Kind.IsValid() {
+		apierror.Validation(w, "kind", "Invalid kind. Must be SERVICE, PRODUCT or PRESCRIPTION")
+		return
+	}
+	if itemRequest.Description == "" {
+		apierror.Validation(w, "description", "Description is required")
+		return
+	}
+	if itemRequest.Quantity <= 0 {
+		apierror.Validation(w, "quantity", "Quantity must be greater than 0")
+		return
+	}
+	if itemRequest.UnitPriceCents < 0 {
+		apierror.Validation(w, "unit_price_cents", "Unit price cannot be negative")
+		return
+	}
+	if itemRequest.Kind == domain.ItemPrescription && itemRequest.PrescriptionID == nil {
+		apierror.Validation(w, "prescription_id", "Prescription items require a prescription_id")
+		return
+	}
+	if itemRequest.Kind == domain.ItemService && itemRequest.ProductID != nil {
+		apierror.Validation(w, "product_id", "Service items cannot dispense a product")
+		return
+	}
+
+	_, err = billingHandler.consultRepo.GetConsultationByID(r.Context(), consultationID)
+	if err == database.ErrConsultationNotFound {
+		apierror.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	if itemRequest.PrescriptionID != nil {
+		prescription, err := billingHandler.prescriptionRepo.GetPrescriptionByID(r.Context(), *itemRequest.PrescriptionID)
+		if err == database.ErrPrescriptionNotFound {
+			apierror.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			apierror.Respond(w, err)
+			return
+		}
+		if prescription.ConsultationID != consultationID {
+			apierror.Validation(w, "prescription_id", "Prescription does not belong to this consultation")
+			return
+		}
+	}
+
+	if itemRequest.ProductID != nil {
+		_, err := billingHandler.inventoryRepo.GetProductByID(r.Context(), *itemRequest.ProductID)
+		if err == database.ErrProductNotFound {
+			apierror.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			apierror.Respond(w, err)
+			return
+		}
+	}
+
+	item := domain.NewBillableItem(consultationID, itemRequest.Kind, itemRequest.Description, itemRequest.Quantity, itemRequest.UnitPriceCents)
+	item.PrescriptionID = itemRequest.PrescriptionID
+	item.ProductID = itemRequest.ProductID
+	err = billingHandler.billingRepo.CreateBillableItem(r.Context(), item)
+	if err == database.ErrInsufficientStock {
+		apierror.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(item)
+}
+
+func (billingHandler *BillingHandler) GetConsultationItemsHandler(w http.ResponseWriter, r *http.Request) {
+	consultationID, ok := pathID(w, r, "consultation_id")
+	if !ok {
+		return
+	}
+	items, err := billingHandler.billingRepo.GetBillableItemsByConsultationID(r.Context(), consultationID)
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(items)
+}
+
+func (billingHandler *BillingHandler) DeleteBillableItemHandler(w http.ResponseWriter, r *http.Request) {
+	itemID, ok := pathID(w, r, "item_id")
+	if !ok {
+		return
+	}
+	err := billingHandler.billingRepo.DeleteBillableItem(r.Context(), itemID)
+	if err == database.ErrBillableItemNotFound {
+		apierror.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err == database.ErrBillableItemInvoiced {
+		apierror.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// CreateInvoiceHandler invoices every uninvoiced item across the client's
+// patients' consultations.
+func (billingHandler *BillingHandler) CreateInvoiceHandler(w http.ResponseWriter, r *http.Request) {
+	clientID, ok := pathID(w, r, "client_id")
+	if !ok {
+		return
+	}
+	issuedBy, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		apierror.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	_, err := billingHandler.clientRepo.GetClientByID(r.Context(), clientID)
+	if err == database.ErrClientNotFound {
+		apierror.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+
+	invoice, err := billingHandler.billingRepo.CreateInvoice(r.Context(), clientID, issuedBy)
+	if err == database.ErrNothingToInvoice {
+		apierror.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	billingHandler.auditor.Record(r, domain.AuditCreate, domain.AuditEntityInvoice, invoice.ID, nil, invoice)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(invoice)
+}
+
+func (billingHandler *BillingHandler) GetClientInvoicesHandler(w http.ResponseWriter, r *http.Request) {
+	clientID, ok := pathID(w, r, "client_id")
+	if !ok {
+		return
+	}
+	limit, offset := utils.Pagination(r)
+	total, err := billingHandler.billingRepo.GetInvoicesCountByClientID(r.Context(), clientID)
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	totalPages := int((total + int64(limit) - 1) / int64(limit))
+	page := (offset / limit) + 1
+
+	invoices, err := billingHandler.billingRepo.GetInvoicesByClientID(r.Context(), clientID, limit, offset)
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	response := PaginatedResponse{
+		Data:       invoices,
+		Page:       page,
+		Limit:      limit,
+		Total:      total,
+		TotalPages: totalPages,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (billingHandler *BillingHandler) GetInvoiceByIDHandler(w http.ResponseWriter, r *http.Request) {
+	invoiceID, ok := pathID(w, r, "invoice_id")
+	if !ok {
+		return
+	}
+	invoice, err := billingHandler.billingRepo.GetInvoiceByID(r.Context(), invoiceID)
+	if err == database.ErrInvoiceNotFound {
+		apierror.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(invoice)
+}
+
+func (billingHandler *BillingHandler) VoidInvoiceHandler(w http.ResponseWriter, r *http.Request) {
+	invoiceID, ok := pathID(w, r, "invoice_id")
+	if !ok {
+		return
+	}
+	before, err := billingHandler.billingRepo.GetInvoiceByID(r.Context(), invoiceID)
+	if err == database.ErrInvoiceNotFound {
+		apierror.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	err = billingHandler.billingRepo.VoidInvoice(r.Context(), invoiceID)
+	if err == database.ErrInvoiceNotFound {
+		apierror.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err == database.ErrInvoiceVoid || err == database.ErrInvoiceHasPayments {
+		apierror.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	after, err := billingHandler.billingRepo.GetInvoiceByID(r.Context(), invoiceID)
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	billingHandler.auditor.Record(r, domain.AuditUpdate, domain.AuditEntityInvoice, invoiceID, before, after)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(after)
+}
+
+// CreatePaymentHandler records a full or partial payment. The invoice is
+// marked PAID once its outstanding balance reaches zero.
+func (billingHandler *BillingHandler) CreatePaymentHandler(w http.ResponseWriter, r *http.Request) {
+	invoiceID, ok := pathID(w, r, "invoice_id")
+	if !ok {
+		return
+	}
+	var paymentRequest PaymentRequest
+	err := json.NewDecoder(r.Body).Decode(&paymentRequest)
+	if err != nil {
+		apierror.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if paymentRequest.AmountCents <= 0 {
+		apierror.Validation(w, "amount_cents", "Amount must be greater than 0")
+		return
+	}
+	if !paymentRequest.Method.IsValid() {
+		apierror.Validation(w, "method", "Invalid method. Must be CASH, CARD, TRANSFER or OTHER")
+		return
+	}
+	recordedBy, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		apierror.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	paidAt := time.Now()
+	if paymentRequest.PaidAt != nil {
+		paidAt = *paymentRequest.PaidAt
+	}
+
+	payment := domain.NewPayment(invoiceID, paymentRequest.AmountCents, paymentRequest.Method, paymentRequest.Reference, recordedBy, paidAt)
+	err = billingHandler.billingRepo.CreatePayment(r.Context(), payment)
+	if err == database.ErrInvoiceNotFound {
+		apierror.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err == database.ErrInvoiceVoid || err == database.ErrPaymentExceedsBalance {
+		apierror.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	billingHandler.auditor.Record(r, domain.AuditCreate, domain.AuditEntityPayment, payment.ID, nil, payment)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(payment)
+}
+
+func (billingHandler *BillingHandler) GetClientBalanceHandler(w http.ResponseWriter, r *http.Request) {
+	clientID, ok := pathID(w, r, "client_id")
+	if !ok {
+		return
+	}
+	_, err := billingHandler.clientRepo.GetClientByID(r.Context(), clientID)
+	if err == database.ErrClientNotFound {
+		apierror.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	balance, err := billingHandler.billingRepo.GetClientBalance(r.Context(), clientID)
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(balance)
+}
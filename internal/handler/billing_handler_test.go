@@ -0,0 +1,91 @@
+package handler_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+	"vetsys/internal/domain"
+)
+
+func TestBillingHandler_InvoiceAndPay(t *testing.T) {
+	app := newTestApp(t)
+	cookie := app.signIn(t, domain.RoleReceptionist)
+	client, consultation := seedConsultation(t, app)
+
+	rec := app.do(t, cookie, http.MethodPost, fmt.Sprintf("/api/consultations/%d/items", consultation.ID), map[string]any{
+		"kind": domain.ItemService, "description": "Checkup", "quantity": 2, "unit_price_cents": 2500,
+	})
+	expectStatus(t, rec, http.StatusCreated)
+
+	rec = app.do(t, cookie, http.MethodPost, fmt.Sprintf("/api/clients/%d/invoices", client.ID), nil)
+	expectStatus(t, rec, http.StatusCreated)
+	var invoice domain.Invoice
+	decodeBody(t, rec, &invoice)
+	if invoice.TotalCents != 5000 {
+		t.Fatalf("Expected total of 5000 cents, got %d", invoice.TotalCents)
+	}
+	expectStatus(t, app.do(t, cookie, http.MethodPost, fmt.Sprintf("/api/clients/%d/invoices", client.ID), nil), http.StatusConflict)
+
+	paymentsPath := fmt.Sprintf("/api/invoices/%d/payments", invoice.ID)
+	expectStatus(t, app.do(t, cookie, http.MethodPost, paymentsPath, map[string]any{"amount_cents": 6000, "method": domain.PaymentCash}), http.StatusConflict)
+	expectStatus(t, app.do(t, cookie, http.MethodPost, paymentsPath, map[string]any{"amount_cents": 5000, "method": domain.PaymentCard}), http.StatusCreated)
+
+	rec = app.do(t, cookie, http.MethodGet, fmt.Sprintf("/api/invoices/%d", invoice.ID), nil)
+	expectStatus(t, rec, http.StatusOK)
+	decodeBody(t, rec, &invoice)
+	if invoice.Status != domain.InvoicePaid || invoice.PaidCents != 5000 {
+		t.Errorf("Expected a PAID invoice with 5000 cents paid, got %s with %d", invoice.Status, invoice.PaidCents)
+	}
+
+	rec = app.do(t, cookie, http.MethodGet, fmt.Sprintf("/api/clients/%d/balance", client.ID), nil)
+	expectStatus(t, rec, http.StatusOK)
+	var balance domain.ClientBalance
+	decodeBody(t, rec, &balance)
+	if balance.OutstandingCents != 0 || balance.InvoicedCents != 5000 {
+		t.Errorf("Expected 5000 invoiced and nothing outstanding, got %+v", balance)
+	}
+}
+
+func TestBillingHandler_ProductItemDispensesStock(t *testing.T) {
+	app := newTestApp(t)
+	cookie := app.signIn(t, domain.RoleReceptionist)
+	_, consultation := seedConsultation(t, app)
+
+	rec := app.do(t, cookie, http.MethodPost, "/api/products", map[string]any{"sku": "AMX-250", "name": "Amoxicillin 250mg", "unit": "tablet"})
+	expectStatus(t, rec, http.StatusCreated)
+	var product domain.Product
+	decodeBody(t, rec, &product)
+
+	expiry := time.Now().AddDate(1, 0, 0).Format(time.DateOnly)
+	rec = app.do(t, cookie, http.MethodPost, fmt.Sprintf("/api/products/%d/movements", product.ID), map[string]any{
+		"kind": domain.MovementPurchase, "lot_number": "L1", "expiry_date": expiry, "quantity": 10,
+	})
+	expectStatus(t, rec, http.StatusCreated)
+
+	itemsPath := fmt.Sprintf("/api/consultations/%d/items", consultation.ID)
+	item := map[string]any{
+		"kind": domain.ItemProduct, "description": "Amoxicillin", "quantity": 12, "unit_price_cents": 50, "product_id": product.ID,
+	}
+	expectStatus(t, app.do(t, cookie, http.MethodPost, itemsPath, item), http.StatusConflict)
+
+	item["quantity"] = 4
+	rec = app.do(t, cookie, http.MethodPost, itemsPath, item)
+	expectStatus(t, rec, http.StatusCreated)
+	var created domain.BillableItem
+	decodeBody(t, rec, &created)
+
+	rec = app.do(t, cookie, http.MethodGet, fmt.Sprintf("/api/products/%d", product.ID), nil)
+	expectStatus(t, rec, http.StatusOK)
+	decodeBody(t, rec, &product)
+	if product.QuantityOnHand != 6 {
+		t.Fatalf("Expected 6 units left after dispensing, got %d", product.QuantityOnHand)
+	}
+
+	expectStatus(t, app.do(t, cookie, http.MethodDelete, fmt.Sprintf("/api/billable-items/%d", created.ID), nil), http.StatusNoContent)
+	rec = app.do(t, cookie, http.MethodGet, fmt.Sprintf("/api/products/%d", product.ID), nil)
+	decodeBody(t, rec, &product)
+	if product.QuantityOnHand != 10 {
+		t.Errorf("Expected stock restored to 10, got %d", product.QuantityOnHand)
+	}
+}
@@ -4,17 +4,20 @@ import (
 	"encoding/json"
 	"net/http"
 	"strconv"
+	"vetsys/internal/apierror"
 	"vetsys/internal/database"
 	"vetsys/internal/domain"
 )
 
 type ClientHandler struct {
-	clientRepo *database.ClientRepository
+	clientRepo database.ClientStore
+	auditor    *Auditor
 }
 
-func NewClientHandler(clientRepo *database.ClientRepository) *ClientHandler {
+func NewClientHandler(clientRepo database.ClientStore, auditor *Auditor) *ClientHandler {
 	return &ClientHandler{
 		clientRepo: clientRepo,
+		auditor:    auditor,
 	}
 }
 
@@ -28,27 +31,28 @@ func (clientHandler *ClientHandler) CreateClient(w http.ResponseWriter, r *http.
 	var client domain.Client
 	err := json.NewDecoder(r.Body).Decode(&client)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apierror.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
 	if client.DNI == "" {
-		http.Error(w, "DNI is required", http.StatusBadRequest)
+		apierror.Validation(w, "dni", "DNI is required")
 		return
 	}
 	if client.Name == "" {
-		http.Error(w, "Name is required", http.StatusBadRequest)
+		apierror.Validation(w, "name", "Name is required")
 		return
 	}
 	if client.PhoneNumber == "" {
-		http.Error(w, "Phone number is required", http.StatusBadRequest)
+		apierror.Validation(w, "phoneNumber", "Phone number is required")
 		return
 	}
-	err = clientHandler.clientRepo.CreateClient(&client)
+	err = clientHandler.clientRepo.CreateClient(r.Context(), &client)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.Respond(w, err)
 		return
 	}
+	clientHandler.auditor.Record(r, domain.AuditCreate, domain.AuditEntityClient, client.ID, nil, &client)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(client)
@@ -57,21 +61,21 @@ func (clientHandler *ClientHandler) CreateClient(w http.ResponseWriter, r *http.
 func (clientHandler *ClientHandler) GetClientByIDHandler(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("client_id")
 	if id == "" {
-		http.Error(w, "No id passed", http.StatusBadRequest)
+		apierror.Error(w, "No id passed", http.StatusBadRequest)
 		return
 	}
 	idValue, err := strconv.ParseInt(id, 10, 64)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apierror.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	client, err := clientHandler.clientRepo.GetClientByID(idValue)
+	client, err := clientHandler.clientRepo.GetClientByID(r.Context(), idValue)
 	if err == database.ErrClientNotFound {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		apierror.Error(w, err.Error(), http.StatusNotFound)
 		return
 	}
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.Respond(w, err)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
@@ -82,16 +86,16 @@ func (clientHandler *ClientHandler) GetClientByIDHandler(w http.ResponseWriter,
 func (clientHandler *ClientHandler) GetClientByDNIHandler(w http.ResponseWriter, r *http.Request) {
 	dni := r.PathValue("client_dni")
 	if dni == "" {
-		http.Error(w, "No dni passed", http.StatusBadRequest)
+		apierror.Error(w, "No dni passed", http.StatusBadRequest)
 		return
 	}
-	client, err := clientHandler.clientRepo.GetClientByDNI(dni)
+	client, err := clientHandler.clientRepo.GetClientByDNI(r.Context(), dni)
 	if err == database.ErrClientNotFound {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		apierror.Error(w, err.Error(), http.StatusNotFound)
 		return
 	}
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.Respond(w, err)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
@@ -102,81 +106,97 @@ func (clientHandler *ClientHandler) GetClientByDNIHandler(w http.ResponseWriter,
 func (clientHandler *ClientHandler) UpdateClientHandler(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("client_id")
 	if id == "" {
-		http.Error(w, "No id passed", http.StatusBadRequest)
+		apierror.Error(w, "No id passed", http.StatusBadRequest)
 		return
 	}
 	idValue, err := strconv.ParseInt(id, 10, 64)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apierror.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 	var clientUpdate ClientUpdate
 	err = json.NewDecoder(r.Body).Decode(&clientUpdate)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apierror.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	client, err := clientHandler.clientRepo.GetClientByID(idValue)
+	client, err := clientHandler.clientRepo.GetClientByID(r.Context(), idValue)
 	if err == database.ErrClientNotFound {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		apierror.Error(w, err.Error(), http.StatusNotFound)
 		return
 	}
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.Respond(w, err)
 		return
 	}
+	before := *client
 	if clientUpdate.DNI != nil {
 		if *clientUpdate.DNI == "" {
-			http.Error(w, "DNI cannot be empty", http.StatusBadRequest)
+			apierror.Validation(w, "dni", "DNI cannot be empty")
 			return
 		}
 		client.DNI = *clientUpdate.DNI
 	}
 	if clientUpdate.Name != nil {
 		if *clientUpdate.Name == "" {
-			http.Error(w, "Name cannot be empty", http.StatusBadRequest)
+			apierror.Validation(w, "name", "Name cannot be empty")
 			return
 		}
 		client.Name = *clientUpdate.Name
 	}
 	if clientUpdate.PhoneNumber != nil {
 		if *clientUpdate.PhoneNumber == "" {
-			http.Error(w, "Phone number cannot be empty", http.StatusBadRequest)
+			apierror.Validation(w, "phoneNumber", "Phone number cannot be empty")
 			return
 		}
 		client.PhoneNumber = *clientUpdate.PhoneNumber
 	}
-	err = clientHandler.clientRepo.UpdateClient(client)
+	err = clientHandler.clientRepo.UpdateClient(r.Context(), client)
 	if err == database.ErrClientNotFound {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		apierror.Error(w, err.Error(), http.StatusNotFound)
 		return
 	}
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.Respond(w, err)
 		return
 	}
+	clientHandler.auditor.Record(r, domain.AuditUpdate, domain.AuditEntityClient, client.ID, &before, client)
 	w.WriteHeader(http.StatusNoContent)
 }
 
 func (clientHandler *ClientHandler) DeleteClientHandler(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("client_id")
 	if id == "" {
-		http.Error(w, "No id passed", http.StatusBadRequest)
+		apierror.Error(w, "No id passed", http.StatusBadRequest)
 		return
 	}
 	idValue, err := strconv.ParseInt(id, 10, 64)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apierror.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	err = clientHandler.clientRepo.DeleteClientByID(idValue)
+	client, err := clientHandler.clientRepo.GetClientByID(r.Context(), idValue)
 	if err == database.ErrClientNotFound {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		apierror.Error(w, err.Error(), http.StatusNotFound)
 		return
 	}
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.Respond(w, err)
 		return
 	}
+	err = clientHandler.clientRepo.DeleteClientByID(r.Context(), idValue)
+	if err == database.ErrClientNotFound {
+		apierror.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err == database.ErrRecordInUse {
+		apierror.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	clientHandler.auditor.Record(r, domain.AuditDelete, domain.AuditEntityClient, client.ID, client, nil)
 	w.WriteHeader(http.StatusNoContent)
 }
@@ -0,0 +1,152 @@
+package handler_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"vetsys/internal/apierror"
+	"vetsys/internal/database"
+	"vetsys/internal/domain"
+)
+
+func TestClientHandler_CreateAndGet(t *testing.T) {
+	app := newTestApp(t)
+	cookie := app.signIn(t, domain.RoleReceptionist)
+
+	rec := app.do(t, cookie, http.MethodPost, "/api/clients", map[string]string{
+		"dni": "12345678A", "name": "John Doe", "phoneNumber": "+34600111222",
+	})
+	expectStatus(t, rec, http.StatusCreated)
+	var created domain.Client
+	decodeBody(t, rec, &created)
+	if created.ID == 0 {
+		t.Fatal("Expected client ID to be set after creation")
+	}
+
+	rec = app.do(t, cookie, http.MethodGet, fmt.Sprintf("/api/clients/%d", created.ID), nil)
+	expectStatus(t, rec, http.StatusOK)
+	var retrieved domain.Client
+	decodeBody(t, rec, &retrieved)
+	if retrieved != created {
+		t.Errorf("Expected %+v, got %+v", created, retrieved)
+	}
+
+	rec = app.do(t, cookie, http.MethodGet, "/api/clients/dni/12345678A", nil)
+	expectStatus(t, rec, http.StatusOK)
+	decodeBody(t, rec, &retrieved)
+	if retrieved.ID != created.ID {
+		t.Errorf("Expected client ID %d by DNI, got %d", created.ID, retrieved.ID)
+	}
+}
+
+func TestClientHandler_CreateValidation(t *testing.T) {
+	app := newTestApp(t)
+	cookie := app.signIn(t, domain.RoleReceptionist)
+
+	rec := app.do(t, cookie, http.MethodPost, "/api/clients", map[string]string{"dni": "12345678A", "name": "John Doe"})
+	expectStatus(t, rec, http.StatusBadRequest)
+}
+
+func TestClientHandler_RequiresSession(t *testing.T) {
+	app := newTestApp(t)
+
+	rec := app.do(t, nil, http.MethodGet, "/api/clients/1", nil)
+	expectStatus(t, rec, http.StatusUnauthorized)
+
+	rec = app.do(t, &http.Cookie{Name: "session_id", Value: "unknown"}, http.MethodGet, "/api/clients/1", nil)
+	expectStatus(t, rec, http.StatusUnauthorized)
+}
+
+func TestClientHandler_Permissions(t *testing.T) {
+	app := newTestApp(t)
+	client := domain.NewClient("12345678A", "John Doe", "+34600111222")
+	if err := app.db.ClientRepo.CreateClient(context.Background(), client); err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	path := fmt.Sprintf("/api/clients/%d", client.ID)
+
+	readOnly := app.signIn(t, domain.RoleReadOnly)
+	expectStatus(t, app.do(t, readOnly, http.MethodGet, path, nil), http.StatusOK)
+	expectStatus(t, app.do(t, readOnly, http.MethodPut, path, map[string]string{"name": "Jane"}), http.StatusForbidden)
+
+	receptionist := app.signIn(t, domain.RoleReceptionist)
+	expectStatus(t, app.do(t, receptionist, http.MethodDelete, path, nil), http.StatusForbidden)
+
+	vet := app.signIn(t, domain.RoleVeterinarian)
+	expectStatus(t, app.do(t, vet, http.MethodDelete, path, nil), http.StatusNoContent)
+	expectStatus(t, app.do(t, vet, http.MethodGet, path, nil), http.StatusNotFound)
+}
+
+func TestClientHandler_Update(t *testing.T) {
+	app := newTestApp(t)
+	cookie := app.signIn(t, domain.RoleReceptionist)
+	client := domain.NewClient("12345678A", "John Doe", "+34600111222")
+	if err := app.db.ClientRepo.CreateClient(context.Background(), client); err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	rec := app.do(t, cookie, http.MethodPut, fmt.Sprintf("/api/clients/%d", client.ID), map[string]string{"name": "John Smith"})
+	expectStatus(t, rec, http.StatusNoContent)
+
+	updated, err := app.db.ClientRepo.GetClientByID(context.Background(), client.ID)
+	if err != nil {
+		t.Fatalf("Failed to get client: %v", err)
+	}
+	if updated.Name != "John Smith" || updated.PhoneNumber != client.PhoneNumber {
+		t.Errorf("Expected only the name to change, got %+v", updated)
+	}
+
+	entries, err := app.db.AuditRepo.GetEntries(context.Background(), databaseAuditFilter(domain.AuditEntityClient, client.ID), 10, 0)
+	if err != nil {
+		t.Fatalf("Failed to get audit entries: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Action != domain.AuditUpdate {
+		t.Errorf("Expected one UPDATE audit entry, got %+v", entries)
+	}
+}
+
+func TestClientHandler_DeleteInvoicedClient(t *testing.T) {
+	app := newTestApp(t)
+	vet := app.signIn(t, domain.RoleVeterinarian)
+	client, consultation := seedConsultation(t, app)
+
+	item := domain.NewBillableItem(consultation.ID, domain.ItemService, "Checkup", 1, 3000)
+	if err := app.db.BillingRepo.CreateBillableItem(context.Background(), item); err != nil {
+		t.Fatalf("Failed to create billable item: %v", err)
+	}
+	expectStatus(t, app.do(t, vet, http.MethodDelete, fmt.Sprintf("/api/clients/%d", client.ID), nil), http.StatusConflict)
+}
+
+func TestClientHandler_ErrorEnvelope(t *testing.T) {
+	app := newTestApp(t)
+	cookie := app.signIn(t, domain.RoleReceptionist)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/clients/99999", nil)
+	req.Header.Set(apierror.RequestIDHeader, "trace-123")
+	req.AddCookie(cookie)
+	rec := httptest.NewRecorder()
+	app.handler.ServeHTTP(rec, req)
+
+	expectStatus(t, rec, http.StatusNotFound)
+	if id := rec.Header().Get(apierror.RequestIDHeader); id != "trace-123" {
+		t.Errorf("Expected the incoming request ID to be echoed, got %q", id)
+	}
+	var response apierror.Response
+	decodeBody(t, rec, &response)
+	want := apierror.Body{Code: apierror.CodeNotFound, Message: database.ErrClientNotFound.Error(), RequestID: "trace-123"}
+	if response.Error.Code != want.Code || response.Error.Message != want.Message || response.Error.RequestID != want.RequestID {
+		t.Errorf("Expected %+v, got %+v", want, response.Error)
+	}
+
+	rec = app.do(t, cookie, http.MethodPost, "/api/clients", map[string]string{"dni": "12345678A", "name": "John Doe"})
+	expectStatus(t, rec, http.StatusBadRequest)
+	decodeBody(t, rec, &response)
+	if response.Error.Code != apierror.CodeValidation || len(response.Error.Details) != 1 || response.Error.Details[0].Field != "phoneNumber" {
+		t.Errorf("Expected a validation error on phoneNumber, got %+v", response.Error)
+	}
+	if response.Error.RequestID == "" {
+		t.Error("Expected a generated request ID")
+	}
+}
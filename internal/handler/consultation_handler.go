@@ -5,13 +5,16 @@ import (
 	"net/http"
 	"strconv"
 	"time"
+	"vetsys/internal/apierror"
 	"vetsys/internal/database"
 	"vetsys/internal/domain"
+	"vetsys/internal/middleware"
 	"vetsys/internal/utils"
 )
 
 type ConsultationHandler struct {
-	consultRepo *database.ConsultationRepository
+	consultRepo database.ConsultationStore
+	auditor     *Auditor
 }
 
 type ConsultationRequest struct {
@@ -40,9 +43,10 @@ type PaginatedResponse struct {
 	TotalPages int   `json:"total_pages"`
 }
 
-func NewConsultationHandler(consultRepo *database.ConsultationRepository) *ConsultationHandler {
+func NewConsultationHandler(consultRepo database.ConsultationStore, auditor *Auditor) *ConsultationHandler {
 	return &ConsultationHandler{
 		consultRepo: consultRepo,
+		auditor:     auditor,
 	}
 }
 
@@ -50,25 +54,30 @@ func (consultationHandler *ConsultationHandler) CreateConsultationHandler(w http
 	var consultationRequest ConsultationRequest
 	err := json.NewDecoder(r.Body).Decode(&consultationRequest)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apierror.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
 	if consultationRequest.Reason == "" {
-		http.Error(w, "Reason is required", http.StatusBadRequest)
+		apierror.Validation(w, "reason", "Reason is required")
 		return
 	}
 	if !isValidSeverity(consultationRequest.Severity) {
-		http.Error(w, "Invalid severity. Must be LOW, MEDIUM, HIGH, or CRITICAL", http.StatusBadRequest)
+		apierror.Validation(w, "severity", "Invalid severity. Must be LOW, MEDIUM, HIGH, or CRITICAL")
+		return
+	}
+	if (consultationRequest.Diagnosis != "" || consultationRequest.Treatment != "") && !canWriteClinicalNotes(r) {
+		apierror.Error(w, "Forbidden: Only veterinarians can set diagnosis or treatment", http.StatusForbidden)
 		return
 	}
 
 	consultation := domain.NewConsultation(consultationRequest.PatientID, consultationRequest.Reason, consultationRequest.Diagnosis, consultationRequest.Treatment, consultationRequest.Severity)
-	err = consultationHandler.consultRepo.CreateConsultation(consultation)
+	err = consultationHandler.consultRepo.CreateConsultation(r.Context(), consultation)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.Respond(w, err)
 		return
 	}
+	consultationHandler.auditor.Record(r, domain.AuditCreate, domain.AuditEntityConsultation, consultation.ID, nil, consultation)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(consultation)
@@ -77,21 +86,21 @@ func (consultationHandler *ConsultationHandler) CreateConsultationHandler(w http
 func (consultationHandler *ConsultationHandler) GetConsultationByIDHandler(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("consultation_id")
 	if id == "" {
-		http.Error(w, "No id passed", http.StatusBadRequest)
+		apierror.Error(w, "No id passed", http.StatusBadRequest)
 		return
 	}
 	idValue, err := strconv.ParseInt(id, 10, 64)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apierror.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	consultation, err := consultationHandler.consultRepo.GetConsultationByID(idValue)
+	consultation, err := consultationHandler.consultRepo.GetConsultationByID(r.Context(), idValue)
 	if err == database.ErrConsultationNotFound {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		apierror.Error(w, err.Error(), http.StatusNotFound)
 		return
 	}
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.Respond(w, err)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
@@ -100,28 +109,28 @@ func (consultationHandler *ConsultationHandler) GetConsultationByIDHandler(w htt
 }
 
 func (consultationHandler *ConsultationHandler) GetConsultationsByClientIDHandler(w http.ResponseWriter, r *http.Request) {
-	id := r.PathValue("client-id")
+	id := r.PathValue("client_id")
 	if id == "" {
-		http.Error(w, "No id passed", http.StatusBadRequest)
+		apierror.Error(w, "No id passed", http.StatusBadRequest)
 		return
 	}
 	idValue, err := strconv.ParseInt(id, 10, 64)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apierror.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 	limit, offset := utils.Pagination(r)
-	total, err := consultationHandler.consultRepo.GetConsultationsByClientIDCount(idValue)
+	total, err := consultationHandler.consultRepo.GetConsultationsByClientIDCount(r.Context(), idValue)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.Respond(w, err)
 		return
 	}
 	totalPages := int((total + int64(limit) - 1) / int64(limit))
 	page := (offset / limit) + 1
 
-	consultations, err := consultationHandler.consultRepo.GetConsultationsByClientID(idValue, limit, offset)
+	consultations, err := consultationHandler.consultRepo.GetConsultationsByClientID(r.Context(), idValue, limit, offset)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.Respond(w, err)
 		return
 	}
 	response := PaginatedResponse{
@@ -140,26 +149,26 @@ func (consultationHandler *ConsultationHandler) GetConsultationsByClientIDHandle
 func (consultationHandler *ConsultationHandler) GetConsultationsByPatientIDHandler(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("patient_id")
 	if id == "" {
-		http.Error(w, "No id passed", http.StatusBadRequest)
+		apierror.Error(w, "No id passed", http.StatusBadRequest)
 		return
 	}
 	idValue, err := strconv.ParseInt(id, 10, 64)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apierror.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 	limit, offset := utils.Pagination(r)
-	total, err := consultationHandler.consultRepo.GetConsultationsByPatientIDCount(idValue)
+	total, err := consultationHandler.consultRepo.GetConsultationsByPatientIDCount(r.Context(), idValue)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.Respond(w, err)
 		return
 	}
 	totalPages := int((total + int64(limit) - 1) / int64(limit))
 	page := (offset / limit) + 1
 
-	consultations, err := consultationHandler.consultRepo.GetConsultationsByPatientID(idValue, limit, offset)
+	consultations, err := consultationHandler.consultRepo.GetConsultationsByPatientID(r.Context(), idValue, limit, offset)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.Respond(w, err)
 		return
 	}
 	response := PaginatedResponse{
@@ -185,26 +194,26 @@ func (consultationHandler *ConsultationHandler) GetAllConsultationsHandler(w htt
 	if isCompletedParam != "" {
 		isCompletedValue, parseErr := strconv.ParseBool(isCompletedParam)
 		if parseErr != nil {
-			http.Error(w, "Invalid is_completed parameter. Use 'true' or 'false'", http.StatusBadRequest)
+			apierror.Validation(w, "is_completed", "Invalid is_completed parameter. Use 'true' or 'false'")
 			return
 		}
-		total, err = consultationHandler.consultRepo.GetConsultationsByIsCompletedCount(isCompletedValue)
+		total, err = consultationHandler.consultRepo.GetConsultationsByIsCompletedCount(r.Context(), isCompletedValue)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			apierror.Respond(w, err)
 			return
 		}
-		consultations, err = consultationHandler.consultRepo.GetConsultationsByIsCompleted(isCompletedValue, limit, offset)
+		consultations, err = consultationHandler.consultRepo.GetConsultationsByIsCompleted(r.Context(), isCompletedValue, limit, offset)
 	} else {
-		total, err = consultationHandler.consultRepo.GetAllConsultationsCount()
+		total, err = consultationHandler.consultRepo.GetAllConsultationsCount(r.Context())
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			apierror.Respond(w, err)
 			return
 		}
-		consultations, err = consultationHandler.consultRepo.GetAllConsultations(limit, offset)
+		consultations, err = consultationHandler.consultRepo.GetAllConsultations(r.Context(), limit, offset)
 	}
 
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.Respond(w, err)
 		return
 	}
 	totalPages := int((total + int64(limit) - 1) / int64(limit))
@@ -225,45 +234,50 @@ func (consultationHandler *ConsultationHandler) GetAllConsultationsHandler(w htt
 func (consultationHandler *ConsultationHandler) UpdateConsultationHandler(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("consultation_id")
 	if id == "" {
-		http.Error(w, "No id passed", http.StatusBadRequest)
+		apierror.Error(w, "No id passed", http.StatusBadRequest)
 		return
 	}
 	idValue, err := strconv.ParseInt(id, 10, 64)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apierror.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 	var consultationUpdate ConsultationUpdate
 	err = json.NewDecoder(r.Body).Decode(&consultationUpdate)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apierror.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	consultation, err := consultationHandler.consultRepo.GetConsultationByID(idValue)
+	consultation, err := consultationHandler.consultRepo.GetConsultationByID(r.Context(), idValue)
 	if err == database.ErrConsultationNotFound {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		apierror.Error(w, err.Error(), http.StatusNotFound)
 		return
 	}
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.Respond(w, err)
 		return
 	}
+	before := *consultation
 
 	if consultationUpdate.PatientID != nil {
 		if *consultationUpdate.PatientID <= 0 {
-			http.Error(w, "PatientID must be greater than 0", http.StatusBadRequest)
+			apierror.Validation(w, "patient_id", "PatientID must be greater than 0")
 			return
 		}
 		consultation.PatientID = *consultationUpdate.PatientID
 	}
 	if consultationUpdate.Reason != nil {
 		if *consultationUpdate.Reason == "" {
-			http.Error(w, "Reason cannot be empty", http.StatusBadRequest)
+			apierror.Validation(w, "reason", "Reason cannot be empty")
 			return
 		}
 		consultation.Reason = *consultationUpdate.Reason
 	}
+	if (consultationUpdate.Diagnosis != nil || consultationUpdate.Treatment != nil) && !canWriteClinicalNotes(r) {
+		apierror.Error(w, "Forbidden: Only veterinarians can set diagnosis or treatment", http.StatusForbidden)
+		return
+	}
 	if consultationUpdate.Diagnosis != nil {
 		consultation.Diagnosis = *consultationUpdate.Diagnosis
 	}
@@ -272,7 +286,7 @@ func (consultationHandler *ConsultationHandler) UpdateConsultationHandler(w http
 	}
 	if consultationUpdate.Severity != nil {
 		if !isValidSeverity(*consultationUpdate.Severity) {
-			http.Error(w, "Invalid severity. Must be LOW, MEDIUM, HIGH, or CRITICAL", http.StatusBadRequest)
+			apierror.Validation(w, "severity", "Invalid severity. Must be LOW, MEDIUM, HIGH, or CRITICAL")
 			return
 		}
 		consultation.Severity = *consultationUpdate.Severity
@@ -283,37 +297,52 @@ func (consultationHandler *ConsultationHandler) UpdateConsultationHandler(w http
 
 	consultation.UpdatedAt = time.Now()
 
-	err = consultationHandler.consultRepo.UpdateConsultation(consultation)
+	err = consultationHandler.consultRepo.UpdateConsultation(r.Context(), consultation)
 	if err == database.ErrConsultationNotFound {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		apierror.Error(w, err.Error(), http.StatusNotFound)
 		return
 	}
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.Respond(w, err)
 		return
 	}
+	consultationHandler.auditor.Record(r, domain.AuditUpdate, domain.AuditEntityConsultation, consultation.ID, &before, consultation)
 	w.WriteHeader(http.StatusNoContent)
 }
 func (consultationHandler *ConsultationHandler) DeleteConsultationHandler(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("consultation_id")
 	if id == "" {
-		http.Error(w, "No id passed", http.StatusBadRequest)
+		apierror.Error(w, "No id passed", http.StatusBadRequest)
 		return
 	}
 	idValue, err := strconv.ParseInt(id, 10, 64)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apierror.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	consultation, err := consultationHandler.consultRepo.GetConsultationByID(r.Context(), idValue)
+	if err == database.ErrConsultationNotFound {
+		apierror.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		apierror.Respond(w, err)
 		return
 	}
-	err = consultationHandler.consultRepo.DeleteConsultation(idValue)
+	err = consultationHandler.consultRepo.DeleteConsultation(r.Context(), idValue)
 	if err == database.ErrConsultationNotFound {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		apierror.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err == database.ErrRecordInUse {
+		apierror.Error(w, err.Error(), http.StatusConflict)
 		return
 	}
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.Respond(w, err)
 		return
 	}
+	consultationHandler.auditor.Record(r, domain.AuditDelete, domain.AuditEntityConsultation, consultation.ID, consultation, nil)
 	w.WriteHeader(http.StatusNoContent)
 }
 
@@ -323,3 +352,10 @@ func isValidSeverity(severity domain.Severity) bool {
 		severity == domain.SeverityHigh ||
 		severity == domain.SeverityCritical
 }
+
+// canWriteClinicalNotes reports whether the authenticated user may set a
+// consultation's diagnosis or treatment.
+func canWriteClinicalNotes(r *http.Request) bool {
+	role, ok := middleware.GetUserRole(r.Context())
+	return ok && role.Can(domain.PermClinicalNotesWrite)
+}
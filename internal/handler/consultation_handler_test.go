@@ -0,0 +1,91 @@
+package handler_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"vetsys/internal/apierror"
+	"vetsys/internal/domain"
+	"vetsys/internal/handler"
+)
+
+func TestConsultationHandler_ClinicalNotesRequireVet(t *testing.T) {
+	app := newTestApp(t)
+	_, consultation := seedConsultation(t, app)
+	request := map[string]any{
+		"patient_id": consultation.PatientID, "reason": "Limping",
+		"diagnosis": "Sprain", "severity": domain.SeverityMedium,
+	}
+
+	receptionist := app.signIn(t, domain.RoleReceptionist)
+	expectStatus(t, app.do(t, receptionist, http.MethodPost, "/api/consultations", request), http.StatusForbidden)
+
+	vet := app.signIn(t, domain.RoleVeterinarian)
+	rec := app.do(t, vet, http.MethodPost, "/api/consultations", request)
+	expectStatus(t, rec, http.StatusCreated)
+	var created domain.Consultation
+	decodeBody(t, rec, &created)
+	if created.Diagnosis != "Sprain" {
+		t.Errorf("Expected diagnosis Sprain, got %q", created.Diagnosis)
+	}
+}
+
+func TestConsultationHandler_ListByClientPaginates(t *testing.T) {
+	app := newTestApp(t)
+	cookie := app.signIn(t, domain.RoleReadOnly)
+	client, consultation := seedConsultation(t, app)
+	for range 2 {
+		extra := domain.NewConsultation(consultation.PatientID, "Follow-up", "", "", domain.SeverityLow)
+		if err := app.db.ConsultationRepo.CreateConsultation(context.Background(), extra); err != nil {
+			t.Fatalf("Failed to create consultation: %v", err)
+		}
+	}
+
+	rec := app.do(t, cookie, http.MethodGet, fmt.Sprintf("/api/clients/consultations/%d?page=2&limit=2", client.ID), nil)
+	expectStatus(t, rec, http.StatusOK)
+	var response struct {
+		handler.PaginatedResponse
+		Data []domain.Consultation `json:"data"`
+	}
+	decodeBody(t, rec, &response)
+	if response.Total != 3 || response.TotalPages != 2 || response.Page != 2 {
+		t.Errorf("Expected page 2 of 2 with 3 total, got %+v", response.PaginatedResponse)
+	}
+	if len(response.Data) != 1 {
+		t.Errorf("Expected 1 consultation on the last page, got %d", len(response.Data))
+	}
+}
+
+func TestConsultationHandler_DeleteBilledConsultation(t *testing.T) {
+	app := newTestApp(t)
+	vet := app.signIn(t, domain.RoleVeterinarian)
+	_, consultation := seedConsultation(t, app)
+	path := fmt.Sprintf("/api/consultations/%d", consultation.ID)
+
+	rec := app.do(t, vet, http.MethodPost, path+"/items", map[string]any{
+		"kind": domain.ItemService, "description": "Checkup", "quantity": 1, "unit_price_cents": 3000,
+	})
+	expectStatus(t, rec, http.StatusCreated)
+
+	expectStatus(t, app.do(t, vet, http.MethodDelete, path, nil), http.StatusConflict)
+}
+
+func TestConsultationHandler_UnknownPatient(t *testing.T) {
+	app := newTestApp(t)
+	cookie := app.signIn(t, domain.RoleVeterinarian)
+
+	rec := app.do(t, cookie, http.MethodPost, "/api/consultations", map[string]any{
+		"patient_id": 99999, "reason": "Check-up", "severity": "LOW",
+	})
+	expectStatus(t, rec, http.StatusUnprocessableEntity)
+	var response apierror.Response
+	decodeBody(t, rec, &response)
+	if response.Error.Code != apierror.CodeInvalidReference {
+		t.Errorf("Expected code %s, got %+v", apierror.CodeInvalidReference, response.Error)
+	}
+	if strings.Contains(response.Error.Message, "foreign key") {
+		t.Errorf("Expected no constraint details in the message, got %q", response.Error.Message)
+	}
+}
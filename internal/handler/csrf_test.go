@@ -0,0 +1,84 @@
+package handler_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"vetsys/internal/domain"
+	"vetsys/internal/middleware"
+)
+
+func TestCSRF_StateChangingRequestsNeedToken(t *testing.T) {
+	app := newTestApp(t)
+	app.createUser(t, "12345678A", "Secret123!")
+	rec := app.logIn(t, "198.51.100.1", "12345678A", "Secret123!")
+	expectStatus(t, rec, http.StatusOK)
+	var session, csrf *http.Cookie
+	for _, c := range rec.Result().Cookies() {
+		switch c.Name {
+		case "session_id":
+			session = c
+		case middleware.CSRFCookie:
+			csrf = c
+		}
+	}
+	if session == nil || csrf == nil {
+		t.Fatal("Expected session and CSRF cookies")
+	}
+	if csrf.HttpOnly || csrf.Value == "" || csrf.Value == session.Value {
+		t.Fatalf("Expected a script-readable cookie with a token of its own, got %+v", csrf)
+	}
+
+	send := func(method string, path string, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.AddCookie(session)
+		if token != "" {
+			req.Header.Set(middleware.CSRFHeader, token)
+		}
+		rec := httptest.NewRecorder()
+		app.handler.ServeHTTP(rec, req)
+		return rec
+	}
+	expectStatus(t, send(http.MethodGet, "/api/auth/me", ""), http.StatusOK)
+	expectStatus(t, send(http.MethodPost, "/api/auth/logout", ""), http.StatusForbidden)
+	expectStatus(t, send(http.MethodPost, "/api/auth/logout", "forged"), http.StatusForbidden)
+	// Another session's token does not do either.
+	other := app.signIn(t, domain.RoleAdmin)
+	otherSession, err := app.db.SessionRepo.GetSession(context.Background(), other.Value)
+	if err != nil {
+		t.Fatalf("Failed to get session: %v", err)
+	}
+	expectStatus(t, send(http.MethodDelete, "/api/auth/sessions", otherSession.CSRFToken), http.StatusForbidden)
+	expectStatus(t, send(http.MethodGet, "/api/auth/me", ""), http.StatusOK)
+
+	rec = send(http.MethodPost, "/api/auth/logout", csrf.Value)
+	expectStatus(t, rec, http.StatusOK)
+	cleared := false
+	for _, c := range rec.Result().Cookies() {
+		if c.Name == middleware.CSRFCookie && c.MaxAge < 0 {
+			cleared = true
+		}
+	}
+	if !cleared {
+		t.Error("Expected logout to clear the CSRF cookie")
+	}
+}
+
+func TestCSRF_SessionWithoutToken(t *testing.T) {
+	app := newTestApp(t)
+	user := app.createUser(t, "12345678A", "Secret123!")
+	session, err := domain.NewSession(user.ID, time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	session.CSRFToken = ""
+	if err := app.db.SessionRepo.CreateSession(context.Background(), session); err != nil {
+		t.Fatalf("Failed to store session: %v", err)
+	}
+	cookie := &http.Cookie{Name: "session_id", Value: session.ID}
+
+	expectStatus(t, app.do(t, cookie, http.MethodGet, "/api/auth/me", nil), http.StatusOK)
+	expectStatus(t, app.do(t, cookie, http.MethodPost, "/api/auth/logout", nil), http.StatusForbidden)
+}
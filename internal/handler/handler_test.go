@@ -0,0 +1,197 @@
+package handler_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+	"vetsys/internal/database"
+	"vetsys/internal/database/memory"
+	"vetsys/internal/domain"
+	"vetsys/internal/handler"
+	"vetsys/internal/health"
+	"vetsys/internal/middleware"
+	"vetsys/internal/notify"
+	"vetsys/internal/router"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testApp serves the full router over in-memory stores, so handler tests
+// exercise routing, authentication and permissions without Postgres.
+type testApp struct {
+	db          *memory.DataBase
+	health      *health.Health
+	userHandler *handler.UserHandler
+	handler     http.Handler
+	users       int
+	// outbox holds the messages sent to users, newest last.
+	outbox *outbox
+}
+
+// outbox is a notify.Notifier that keeps what it is sent.
+type outbox struct {
+	mu       sync.Mutex
+	messages []notify.Message
+}
+
+func (o *outbox) Notify(ctx context.Context, message notify.Message) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.messages = append(o.messages, message)
+	return nil
+}
+
+func (o *outbox) last(t *testing.T) notify.Message {
+	t.Helper()
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if len(o.messages) == 0 {
+		t.Fatal("Expected a message to have been sent")
+	}
+	return o.messages[len(o.messages)-1]
+}
+
+func newTestApp(t *testing.T) *testApp {
+	t.Helper()
+	return newTestAppWithClients(t, nil)
+}
+
+// newTestAppWithClients is newTestApp with clients, if not nil, serving the
+// client routes in place of the in-memory store.
+func newTestAppWithClients(t *testing.T, clients database.ClientStore) *testApp {
+	t.Helper()
+	db := memory.NewDataBase()
+	if clients == nil {
+		clients = db.ClientRepo
+	}
+	checks := health.New()
+
+	auditor := handler.NewAuditor(db.AuditRepo)
+	sent := &outbox{}
+	userHandler := handler.NewUserHandler(db.UserRepo, db.SessionRepo, db.AllowedRegistrationsRepo, db.LoginLockoutRepo, db.TwoFactorRepo, db.LoginChallengeRepo, sent, auditor)
+	resetHandler := handler.NewPasswordResetHandler(db.UserRepo, db.SessionRepo, db.PasswordResetRepo, sent, auditor)
+	resetHandler.BcryptCost = bcrypt.MinCost
+	r := router.NewRouter(
+		handler.NewClientHandler(clients, auditor),
+		handler.NewConsultationHandler(db.ConsultationRepo, auditor),
+		handler.NewPatientHandler(db.PatientRepo, auditor),
+		userHandler,
+		handler.NewAppointmentHandler(db.AppointmentRepo, db.PatientRepo, auditor),
+		handler.NewRegistrationHandler(db.AllowedRegistrationsRepo, db.UserRepo),
+		handler.NewAuditHandler(db.AuditRepo),
+		handler.NewSearchHandler(db.SearchRepo),
+		handler.NewVaccinationHandler(db.VaccinationRepo, auditor),
+		handler.NewPrescriptionHandler(db.PrescriptionRepo, db.ConsultationRepo, db.PatientRepo, auditor),
+		handler.NewVitalsHandler(db.VitalsRepo, db.PatientRepo, db.ConsultationRepo, auditor),
+		handler.NewBillingHandler(db.BillingRepo, db.ClientRepo, db.ConsultationRepo, db.PrescriptionRepo, db.InventoryRepo, auditor),
+		handler.NewInventoryHandler(db.InventoryRepo, db.PrescriptionRepo),
+		handler.NewIntakeHandler(db.UnitOfWork, auditor),
+		resetHandler,
+		checks,
+		middleware.NewRateLimitMiddleware(3),
+	)
+	return &testApp{db: db, health: checks, userHandler: userHandler, handler: r.SetupRoutes(), outbox: sent}
+}
+
+// signIn creates a user with role and a session for it, returning the
+// session cookie.
+func (app *testApp) signIn(t *testing.T, role domain.Role) *http.Cookie {
+	t.Helper()
+	app.users++
+	dni := fmt.Sprintf("%08dU", app.users)
+	user := domain.NewUser(dni, dni+"@example.com", "", "Test "+string(role), "")
+	user.Role = role
+	if err := app.db.UserRepo.CreateUser(context.Background(), user); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	session, err := domain.NewSession(user.ID, time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	if err := app.db.SessionRepo.CreateSession(context.Background(), session); err != nil {
+		t.Fatalf("Failed to store session: %v", err)
+	}
+	return &http.Cookie{Name: "session_id", Value: session.ID}
+}
+
+// do sends a request through the router. body, if not nil, is encoded as
+// JSON; cookie may be nil for anonymous requests. Requests with the cookie of
+// a session carry the CSRF token issued to it, as the web pages' do.
+func (app *testApp) do(t *testing.T, cookie *http.Cookie, method string, path string, body any) *httptest.ResponseRecorder {
+	t.Helper()
+	var payload bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&payload).Encode(body); err != nil {
+			t.Fatalf("Failed to encode request body: %v", err)
+		}
+	}
+	req := httptest.NewRequest(method, path, &payload)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if cookie != nil {
+		req.AddCookie(cookie)
+		if session, err := app.db.SessionRepo.GetSession(context.Background(), cookie.Value); err == nil {
+			req.Header.Set(middleware.CSRFHeader, session.CSRFToken)
+		}
+	}
+	rec := httptest.NewRecorder()
+	app.handler.ServeHTTP(rec, req)
+	return rec
+}
+
+// post sends an anonymous JSON request from ip. Rate-limited routes allow
+// few requests per IP, so tests that make many spread them over several.
+func (app *testApp) post(t *testing.T, ip string, path string, body any) *httptest.ResponseRecorder {
+	t.Helper()
+	payload, err := json.Marshal(body)
+	if err != nil {
+		t.Fatalf("Failed to encode request body: %v", err)
+	}
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	req.RemoteAddr = ip + ":1234"
+	rec := httptest.NewRecorder()
+	app.handler.ServeHTTP(rec, req)
+	return rec
+}
+
+// logIn posts a login from ip.
+func (app *testApp) logIn(t *testing.T, ip string, dni string, password string) *httptest.ResponseRecorder {
+	t.Helper()
+	return app.post(t, ip, "/api/auth/login", map[string]string{"dni": dni, "password": password})
+}
+
+// createUser creates a user who can log in with password.
+func (app *testApp) createUser(t *testing.T, dni string, password string) *domain.User {
+	t.Helper()
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("Failed to hash password: %v", err)
+	}
+	user := domain.NewUser(dni, dni+"@example.com", string(hashedPassword), "Jane Doe", "")
+	if err := app.db.UserRepo.CreateUser(context.Background(), user); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	return user
+}
+
+func expectStatus(t *testing.T, rec *httptest.ResponseRecorder, status int) {
+	t.Helper()
+	if rec.Code != status {
+		t.Fatalf("Expected status %d, got %d: %s", status, rec.Code, rec.Body.String())
+	}
+}
+
+func decodeBody(t *testing.T, rec *httptest.ResponseRecorder, v any) {
+	t.Helper()
+	if err := json.NewDecoder(rec.Body).Decode(v); err != nil {
+		t.Fatalf("Failed to decode response body: %v", err)
+	}
+}
@@ -0,0 +1,30 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"vetsys/internal/health"
+)
+
+func TestHealthEndpoints(t *testing.T) {
+	app := newTestApp(t)
+	app.health.AddCheck("database", func(ctx context.Context) error { return nil })
+
+	expectStatus(t, app.do(t, nil, http.MethodGet, "/healthz", nil), http.StatusOK)
+	rec := app.do(t, nil, http.MethodGet, "/readyz", nil)
+	expectStatus(t, rec, http.StatusOK)
+	var response health.Response
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.Checks["database"].Status != health.StatusOK {
+		t.Errorf("Expected the database check to pass, got %+v", response.Checks)
+	}
+
+	app.health.AddCheck("database", func(ctx context.Context) error { return errors.New("connection refused") })
+	expectStatus(t, app.do(t, nil, http.MethodGet, "/readyz", nil), http.StatusServiceUnavailable)
+	expectStatus(t, app.do(t, nil, http.MethodGet, "/healthz", nil), http.StatusOK)
+}
@@ -0,0 +1,191 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+	"vetsys/internal/apierror"
+	"vetsys/internal/database"
+	"vetsys/internal/domain"
+	"vetsys/internal/middleware"
+)
+
+// errClientExists aborts an intake whose DNI is already registered.
+var errClientExists = errors.New("A client with this DNI already exists")
+
+// IntakeHandler registers a walk-in: a new client, their patients and
+// optionally a first consultation, all in one transaction.
+type IntakeHandler struct {
+	unitOfWork database.UnitOfWork
+	auditor    *Auditor
+}
+
+func NewIntakeHandler(unitOfWork database.UnitOfWork, auditor *Auditor) *IntakeHandler {
+	return &IntakeHandler{
+		unitOfWork: unitOfWork,
+		auditor:    auditor,
+	}
+}
+
+type IntakePatient struct {
+	Name             string    `json:"name"`
+	Species          string    `json:"species"`
+	Breed            string    `json:"breed"`
+	AproxDateOfBirth time.Time `json:"aproxDateOfBirth"`
+}
+
+// IntakeConsultation is the first consultation of an intake. PatientIndex
+// points into the request's patients, which have no IDs yet.
+type IntakeConsultation struct {
+	PatientIndex int             `json:"patient_index"`
+	Reason       string          `json:"reason"`
+	Diagnosis    string          `json:"diagnosis"`
+	Treatment    string          `json:"treatment"`
+	Severity     domain.Severity `json:"severity"`
+}
+
+type IntakeRequest struct {
+	Client       domain.Client       `json:"client"`
+	Patients     []IntakePatient     `json:"patients"`
+	Consultation *IntakeConsultation `json:"consultation"`
+}
+
+type IntakeResponse struct {
+	Client       *domain.Client       `json:"client"`
+	Patients     []*domain.Patient    `json:"patients"`
+	Consultation *domain.Consultation `json:"consultation,omitempty"`
+}
+
+func (intakeHandler *IntakeHandler) CreateIntakeHandler(w http.ResponseWriter, r *http.Request) {
+	var intakeRequest IntakeRequest
+	err := json.NewDecoder(r.Body).Decode(&intakeRequest)
+	if err != nil {
+		apierror.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	client := intakeRequest.Client
+	if client.DNI == "" {
+		apierror.Validation(w, "dni", "DNI is required")
+		return
+	}
+	if client.Name == "" {
+		apierror.Validation(w, "name", "Name is required")
+		return
+	}
+	if client.PhoneNumber == "" {
+		apierror.Validation(w, "phoneNumber", "Phone number is required")
+		return
+	}
+	if len(intakeRequest.Patients) == 0 {
+		apierror.Validation(w, "patients", "At least one patient is required")
+		return
+	}
+	if !hasPermission(r, domain.PermPatientsWrite) {
+		apierror.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+	for i, patient := range intakeRequest.Patients {
+		if patient.Name == "" {
+			apierror.Validation(w, fmt.Sprintf("patients[%d].name", i), "Name is required")
+			return
+		}
+		if patient.Species == "" {
+			apierror.Validation(w, fmt.Sprintf("patients[%d].species", i), "Species is required")
+			return
+		}
+		if patient.Breed == "" {
+			apierror.Validation(w, fmt.Sprintf("patients[%d].breed", i), "Breed is required")
+			return
+		}
+		if patient.AproxDateOfBirth.IsZero() {
+			apierror.Validation(w, fmt.Sprintf("patients[%d].aproxDateOfBirth", i), "Approximate date of birth is required")
+			return
+		}
+	}
+	if consultationRequest := intakeRequest.Consultation; consultationRequest != nil {
+		if !hasPermission(r, domain.PermConsultationsWrite) {
+			apierror.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		if consultationRequest.PatientIndex < 0 || consultationRequest.PatientIndex >= len(intakeRequest.Patients) {
+			apierror.Validation(w, "consultation.patient_index", "Consultation patient_index does not match a patient")
+			return
+		}
+		if consultationRequest.Reason == "" {
+			apierror.Validation(w, "reason", "Reason is required")
+			return
+		}
+		if !isValidSeverity(consultationRequest.Severity) {
+			apierror.Validation(w, "severity", "Invalid severity. Must be LOW, MEDIUM, HIGH, or CRITICAL")
+			return
+		}
+		if (consultationRequest.Diagnosis != "" || consultationRequest.Treatment != "") && !canWriteClinicalNotes(r) {
+			apierror.Error(w, "Forbidden: Only veterinarians can set diagnosis or treatment", http.StatusForbidden)
+			return
+		}
+	}
+
+	var response IntakeResponse
+	err = intakeHandler.unitOfWork.Do(r.Context(), func(stores database.TxStores) error {
+		_, err := stores.Clients.GetClientByDNI(r.Context(), client.DNI)
+		if err == nil {
+			return errClientExists
+		}
+		if err != database.ErrClientNotFound {
+			return err
+		}
+		if err := stores.Clients.CreateClient(r.Context(), &client); err != nil {
+			return err
+		}
+
+		patients := make([]*domain.Patient, 0, len(intakeRequest.Patients))
+		for _, p := range intakeRequest.Patients {
+			patient := domain.NewPatient(p.Name, p.Species, p.Breed, p.AproxDateOfBirth, client.ID)
+			if err := stores.Patients.CreatePatient(r.Context(), patient); err != nil {
+				return err
+			}
+			patients = append(patients, patient)
+		}
+
+		var consultation *domain.Consultation
+		if c := intakeRequest.Consultation; c != nil {
+			consultation = domain.NewConsultation(patients[c.PatientIndex].ID, c.Reason, c.Diagnosis, c.Treatment, c.Severity)
+			if err := stores.Consultations.CreateConsultation(r.Context(), consultation); err != nil {
+				return err
+			}
+		}
+
+		response = IntakeResponse{Client: &client, Patients: patients, Consultation: consultation}
+		return nil
+	})
+	if err == errClientExists {
+		apierror.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+
+	intakeHandler.auditor.Record(r, domain.AuditCreate, domain.AuditEntityClient, response.Client.ID, nil, response.Client)
+	for _, patient := range response.Patients {
+		intakeHandler.auditor.Record(r, domain.AuditCreate, domain.AuditEntityPatient, patient.ID, nil, patient)
+	}
+	if response.Consultation != nil {
+		intakeHandler.auditor.Record(r, domain.AuditCreate, domain.AuditEntityConsultation, response.Consultation.ID, nil, response.Consultation)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(response)
+}
+
+// hasPermission reports whether the authenticated user's role grants perm.
+// Routes check one permission; handlers that write several kinds of record
+// check the rest with it.
+func hasPermission(r *http.Request, perm domain.Permission) bool {
+	role, ok := middleware.GetUserRole(r.Context())
+	return ok && role.Can(perm)
+}
@@ -0,0 +1,129 @@
+package handler_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"vetsys/internal/domain"
+)
+
+func intakeBody(dni string, consultation map[string]any) map[string]any {
+	body := map[string]any{
+		"client": map[string]string{"dni": dni, "name": "John Doe", "phoneNumber": "+34600111222"},
+		"patients": []map[string]string{
+			{"name": "Luna", "species": "Dog", "breed": "Beagle", "aproxDateOfBirth": "2020-03-01T00:00:00Z"},
+			{"name": "Milo", "species": "Cat", "breed": "Siamese", "aproxDateOfBirth": "2021-06-01T00:00:00Z"},
+		},
+	}
+	if consultation != nil {
+		body["consultation"] = consultation
+	}
+	return body
+}
+
+type intakeResponse struct {
+	Client       domain.Client        `json:"client"`
+	Patients     []domain.Patient     `json:"patients"`
+	Consultation *domain.Consultation `json:"consultation"`
+}
+
+func TestIntakeHandler_CreatesEverything(t *testing.T) {
+	app := newTestApp(t)
+	cookie := app.signIn(t, domain.RoleVeterinarian)
+
+	rec := app.do(t, cookie, http.MethodPost, "/api/intake", intakeBody("12345678A", map[string]any{
+		"patient_index": 1, "reason": "Limping", "diagnosis": "Sprain", "severity": "MEDIUM",
+	}))
+	expectStatus(t, rec, http.StatusCreated)
+	var created intakeResponse
+	decodeBody(t, rec, &created)
+
+	if created.Client.ID == 0 {
+		t.Fatal("Expected client ID to be set")
+	}
+	patients, err := app.db.PatientRepo.GetPatientsByOwner(context.Background(), created.Client.ID)
+	if err != nil {
+		t.Fatalf("Failed to list patients: %v", err)
+	}
+	if len(patients) != 2 || len(created.Patients) != 2 {
+		t.Fatalf("Expected 2 patients, got %d stored and %d returned", len(patients), len(created.Patients))
+	}
+	if created.Consultation == nil || created.Consultation.PatientID != created.Patients[1].ID {
+		t.Fatalf("Expected a consultation for Milo, got %+v", created.Consultation)
+	}
+	if _, err := app.db.ConsultationRepo.GetConsultationByID(context.Background(), created.Consultation.ID); err != nil {
+		t.Errorf("Expected consultation to be stored, got %v", err)
+	}
+
+	entries, err := app.db.AuditRepo.GetEntries(context.Background(), databaseAuditFilter(domain.AuditEntityPatient, created.Patients[0].ID), 10, 0)
+	if err != nil {
+		t.Fatalf("Failed to get audit entries: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Action != domain.AuditCreate {
+		t.Errorf("Expected one create entry for the patient, got %+v", entries)
+	}
+}
+
+func TestIntakeHandler_WithoutConsultation(t *testing.T) {
+	app := newTestApp(t)
+	cookie := app.signIn(t, domain.RoleReceptionist)
+
+	rec := app.do(t, cookie, http.MethodPost, "/api/intake", intakeBody("12345678A", nil))
+	expectStatus(t, rec, http.StatusCreated)
+	var created intakeResponse
+	decodeBody(t, rec, &created)
+	if created.Consultation != nil {
+		t.Errorf("Expected no consultation, got %+v", created.Consultation)
+	}
+}
+
+func TestIntakeHandler_DuplicateDNICreatesNothing(t *testing.T) {
+	app := newTestApp(t)
+	cookie := app.signIn(t, domain.RoleReceptionist)
+	existing := domain.NewClient("12345678A", "Jane Smith", "+34600222333")
+	if err := app.db.ClientRepo.CreateClient(context.Background(), existing); err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	rec := app.do(t, cookie, http.MethodPost, "/api/intake", intakeBody("12345678A", map[string]any{
+		"patient_index": 0, "reason": "Check-up", "severity": "LOW",
+	}))
+	expectStatus(t, rec, http.StatusConflict)
+
+	patients, err := app.db.PatientRepo.GetPatientsByOwner(context.Background(), existing.ID)
+	if err != nil {
+		t.Fatalf("Failed to list patients: %v", err)
+	}
+	if len(patients) != 0 {
+		t.Errorf("Expected no patients after a failed intake, got %d", len(patients))
+	}
+}
+
+func TestIntakeHandler_Validation(t *testing.T) {
+	app := newTestApp(t)
+	cookie := app.signIn(t, domain.RoleVeterinarian)
+
+	noPatients := intakeBody("12345678A", nil)
+	noPatients["patients"] = []any{}
+	expectStatus(t, app.do(t, cookie, http.MethodPost, "/api/intake", noPatients), http.StatusBadRequest)
+
+	badIndex := intakeBody("12345678A", map[string]any{"patient_index": 2, "reason": "Check-up", "severity": "LOW"})
+	expectStatus(t, app.do(t, cookie, http.MethodPost, "/api/intake", badIndex), http.StatusBadRequest)
+
+	if _, err := app.db.ClientRepo.GetClientByDNI(context.Background(), "12345678A"); err == nil {
+		t.Error("Expected no client after rejected intakes")
+	}
+}
+
+func TestIntakeHandler_Permissions(t *testing.T) {
+	app := newTestApp(t)
+
+	readOnly := app.signIn(t, domain.RoleReadOnly)
+	expectStatus(t, app.do(t, readOnly, http.MethodPost, "/api/intake", intakeBody("12345678A", nil)), http.StatusForbidden)
+
+	receptionist := app.signIn(t, domain.RoleReceptionist)
+	rec := app.do(t, receptionist, http.MethodPost, "/api/intake", intakeBody("12345678A", map[string]any{
+		"patient_index": 0, "reason": "Check-up", "diagnosis": "Healthy", "severity": "LOW",
+	}))
+	expectStatus(t, rec, http.StatusForbidden)
+}
@@ -0,0 +1,326 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+	"vetsys/internal/apierror"
+	"vetsys/internal/database"
+	"vetsys/internal/domain"
+	"vetsys/internal/middleware"
+	"vetsys/internal/utils"
+)
+
+const (
+	defaultExpiringWithinDays = 30
+	maxExpiringWithinDays     = 365
+)
+
+type InventoryHandler struct {
+	inventoryRepo    database.InventoryStore
+	prescriptionRepo database.PrescriptionStore
+}
+
+type ProductRequest struct {
+	SKU          string `json:"sku"`
+	Name         string `json:"name"`
+	Unit         string `json:"unit"`
+	MedicationID *int64 `json:"medication_id"`
+	ReorderLevel int    `json:"reorder_level"`
+}
+
+// StockMovementRequest records stock received, corrected or written off for
+// one lot. ExpiryDate is a YYYY-MM-DD date and only used by purchases.
+type StockMovementRequest struct {
+	Kind       domain.StockMovementKind `json:"kind"`
+	LotNumber  string                   `json:"lot_number"`
+	ExpiryDate string                   `json:"expiry_date"`
+	Quantity   int                      `json:"quantity"`
+	Reason     string                   `json:"reason"`
+}
+
+func NewInventoryHandler(inventoryRepo database.InventoryStore, prescriptionRepo database.PrescriptionStore) *InventoryHandler {
+	return &InventoryHandler{
+		inventoryRepo:    inventoryRepo,
+		prescriptionRepo: prescriptionRepo,
+	}
+}
+
+func (inventoryHandler *InventoryHandler) CreateProductHandler(w http.ResponseWriter, r *http.Request) {
+	var productRequest ProductRequest
+	err := json.NewDecoder(r.Body).Decode(&productRequest)
+	if err != nil {
+		apierror.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	product := domain.NewProduct(productRequest.SKU, productRequest.Name, productRequest.Unit, productRequest.MedicationID, productRequest.ReorderLevel)
+	if product.SKU == "" {
+		apierror.Validation(w, "sku", "SKU is required")
+		return
+	}
+	if !inventoryHandler.validateProduct(w, r, product) {
+		return
+	}
+
+	err = inventoryHandler.inventoryRepo.CreateProduct(r.Context(), product)
+	if err == database.ErrProductExists {
+		apierror.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(product)
+}
+
+func (inventoryHandler *InventoryHandler) GetProductsHandler(w http.ResponseWriter, r *http.Request) {
+	limit, offset := utils.Pagination(r)
+	total, err := inventoryHandler.inventoryRepo.GetProductsCount(r.Context())
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	totalPages := int((total + int64(limit) - 1) / int64(limit))
+	page := (offset / limit) + 1
+
+	products, err := inventoryHandler.inventoryRepo.GetProducts(r.Context(), limit, offset)
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	response := PaginatedResponse{
+		Data:       products,
+		Page:       page,
+		Limit:      limit,
+		Total:      total,
+		TotalPages: totalPages,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (inventoryHandler *InventoryHandler) GetProductByIDHandler(w http.ResponseWriter, r *http.Request) {
+	productID, ok := pathID(w, r, "product_id")
+	if !ok {
+		return
+	}
+	product, err := inventoryHandler.inventoryRepo.GetProductByID(r.Context(), productID)
+	if err == database.ErrProductNotFound {
+		apierror.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(product)
+}
+
+// UpdateProductHandler edits a product's details. The SKU cannot change.
+func (inventoryHandler *InventoryHandler) UpdateProductHandler(w http.ResponseWriter, r *http.Request) {
+	productID, ok := pathID(w, r, "product_id")
+	if !ok {
+		return
+	}
+	var productRequest ProductRequest
+	err := json.NewDecoder(r.Body).Decode(&productRequest)
+	if err != nil {
+		apierror.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	product, err := inventoryHandler.inventoryRepo.GetProductByID(r.Context(), productID)
+	if err == database.ErrProductNotFound {
+		apierror.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	product.Name = productRequest.Name
+	product.Unit = productRequest.Unit
+	product.MedicationID = productRequest.MedicationID
+	product.ReorderLevel = productRequest.ReorderLevel
+	if !inventoryHandler.validateProduct(w, r, product) {
+		return
+	}
+
+	err = inventoryHandler.inventoryRepo.UpdateProduct(r.Context(), product)
+	if err == database.ErrProductNotFound {
+		apierror.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(product)
+}
+
+// CreateStockMovementHandler records a purchase, adjustment or write-off.
+// Write-off quantities are given as positive numbers; adjustments are signed.
+func (inventoryHandler *InventoryHandler) CreateStockMovementHandler(w http.ResponseWriter, r *http.Request) {
+	productID, ok := pathID(w, r, "product_id")
+	if !ok {
+		return
+	}
+	var movementRequest StockMovementRequest
+	err := json.NewDecoder(r.Body).Decode(&movementRequest)
+	if err != nil {
+		apierror.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !movementRequest.Kind.IsManual() {
+		apierror.Validation(w, "kind", "Invalid kind. Must be PURCHASE, ADJUSTMENT or WRITE_OFF")
+		return
+	}
+	if movementRequest.LotNumber == "" {
+		apierror.Validation(w, "lot_number", "Lot number is required")
+		return
+	}
+	quantity := movementRequest.Quantity
+	switch movementRequest.Kind {
+	case domain.MovementPurchase, domain.MovementWriteOff:
+		if quantity <= 0 {
+			apierror.Validation(w, "quantity", "Quantity must be greater than 0")
+			return
+		}
+		if movementRequest.Kind == domain.MovementWriteOff {
+			quantity = -quantity
+		}
+	case domain.MovementAdjustment:
+		if quantity == 0 {
+			apierror.Validation(w, "quantity", "Quantity cannot be 0")
+			return
+		}
+	}
+	if movementRequest.Kind != domain.MovementPurchase && movementRequest.Reason == "" {
+		apierror.Validation(w, "reason", "Reason is required for adjustments and write-offs")
+		return
+	}
+	var expiryDate *time.Time
+	if movementRequest.ExpiryDate != "" {
+		value, err := time.Parse(time.DateOnly, movementRequest.ExpiryDate)
+		if err != nil {
+			apierror.Validation(w, "expiry_date", "Invalid expiry_date. Must be YYYY-MM-DD")
+			return
+		}
+		expiryDate = &value
+	}
+	recordedBy, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		apierror.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	movement := domain.NewStockMovement(movementRequest.Kind, quantity, movementRequest.Reason, recordedBy)
+	err = inventoryHandler.inventoryRepo.RecordMovement(r.Context(), productID, movementRequest.LotNumber, expiryDate, movement)
+	if err == database.ErrProductNotFound || err == database.ErrBatchNotFound {
+		apierror.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err == database.ErrInsufficientStock {
+		apierror.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(movement)
+}
+
+func (inventoryHandler *InventoryHandler) GetStockMovementsHandler(w http.ResponseWriter, r *http.Request) {
+	productID, ok := pathID(w, r, "product_id")
+	if !ok {
+		return
+	}
+	limit, offset := utils.Pagination(r)
+	total, err := inventoryHandler.inventoryRepo.GetMovementsCountByProductID(r.Context(), productID)
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	totalPages := int((total + int64(limit) - 1) / int64(limit))
+	page := (offset / limit) + 1
+
+	movements, err := inventoryHandler.inventoryRepo.GetMovementsByProductID(r.Context(), productID, limit, offset)
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	response := PaginatedResponse{
+		Data:       movements,
+		Page:       page,
+		Limit:      limit,
+		Total:      total,
+		TotalPages: totalPages,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetInventoryReportHandler lists low-stock products and batches expiring
+// within within_days (default 30), including those already expired.
+func (inventoryHandler *InventoryHandler) GetInventoryReportHandler(w http.ResponseWriter, r *http.Request) {
+	withinDays := defaultExpiringWithinDays
+	if within := r.URL.Query().Get("within_days"); within != "" {
+		value, err := strconv.Atoi(within)
+		if err != nil || value < 0 || value > maxExpiringWithinDays {
+			apierror.Validation(w, "within_days", "Invalid within_days parameter. Must be between 0 and "+strconv.Itoa(maxExpiringWithinDays))
+			return
+		}
+		withinDays = value
+	}
+	until := time.Now().UTC().AddDate(0, 0, withinDays)
+
+	report, err := inventoryHandler.inventoryRepo.GetInventoryReport(r.Context(), until)
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(report)
+}
+
+func (inventoryHandler *InventoryHandler) validateProduct(w http.ResponseWriter, r *http.Request, product *domain.Product) bool {
+	if product.Name == "" {
+		apierror.Validation(w, "name", "Name is required")
+		return false
+	}
+	if product.Unit == "" {
+		apierror.Validation(w, "unit", "Unit is required")
+		return false
+	}
+	if product.ReorderLevel < 0 {
+		apierror.Validation(w, "reorder_level", "Reorder level cannot be negative")
+		return false
+	}
+	if product.MedicationID != nil {
+		_, err := inventoryHandler.prescriptionRepo.GetMedicationByID(r.Context(), *product.MedicationID)
+		if err == database.ErrMedicationNotFound {
+			apierror.Error(w, err.Error(), http.StatusBadRequest)
+			return false
+		}
+		if err != nil {
+			apierror.Respond(w, err)
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+	"vetsys/internal/apierror"
+	"vetsys/internal/database"
+	"vetsys/internal/domain"
+)
+
+// LockoutResponse is a DNI or IP with failed logins on record.
+type LockoutResponse struct {
+	domain.LoginLockout
+	Locked bool `json:"locked"`
+}
+
+// lockedUntil returns when logins for dni or from the client's IP unlock, or
+// nil if neither is locked.
+func (userHandler *UserHandler) lockedUntil(r *http.Request, dni string, now time.Time) (*time.Time, error) {
+	var until *time.Time
+	for scope, key := range map[domain.LockoutScope]string{domain.LockoutScopeDNI: dni, domain.LockoutScopeIP: clientIP(r)} {
+		lockout, err := userHandler.LockoutRepo.GetLockout(r.Context(), scope, key)
+		if err == database.ErrLockoutNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if lockout.IsLocked(now) && (until == nil || lockout.LockedUntil.After(*until)) {
+			until = lockout.LockedUntil
+		}
+	}
+	return until, nil
+}
+
+// loginFailed counts a failed login against dni and the client's IP. user is
+// the DNI's user, or nil if there is none. It answers 429 if the failure
+// locked either of them and 401 otherwise, so an unknown DNI locks like a
+// known one and reveals nothing.
+func (userHandler *UserHandler) loginFailed(w http.ResponseWriter, r *http.Request, dni string, user *domain.User, now time.Time) {
+	until, err := userHandler.recordLoginFailure(r, dni, user, now)
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	if until != nil {
+		respondLocked(w, *until, now)
+		return
+	}
+	apierror.Error(w, "Invalid credentials", http.StatusUnauthorized)
+}
+
+// recordLoginFailure counts a failed login against dni and the client's IP,
+// auditing any lockout it causes, and returns when they unlock if it locked
+// either of them.
+func (userHandler *UserHandler) recordLoginFailure(r *http.Request, dni string, user *domain.User, now time.Time) (*time.Time, error) {
+	var until *time.Time
+	for _, attempt := range []struct {
+		scope  domain.LockoutScope
+		key    string
+		policy domain.LockoutPolicy
+	}{
+		{domain.LockoutScopeDNI, dni, userHandler.DNILockout},
+		{domain.LockoutScopeIP, clientIP(r), userHandler.IPLockout},
+	} {
+		lockout, err := userHandler.LockoutRepo.RecordFailure(r.Context(), attempt.scope, attempt.key, attempt.policy, now)
+		if err != nil {
+			return nil, err
+		}
+		// A lockout starts the failure count over, so only the failure that
+		// locked leaves it at zero.
+		if lockout.Failures != 0 || !lockout.IsLocked(now) {
+			continue
+		}
+		userHandler.Auditor.Record(r, domain.AuditLock, domain.AuditEntityLogin, lockoutEntityID(lockout, user), nil, lockout)
+		if until == nil || lockout.LockedUntil.After(*until) {
+			until = lockout.LockedUntil
+		}
+	}
+	return until, nil
+}
+
+func respondLocked(w http.ResponseWriter, until time.Time, now time.Time) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(until.Sub(now).Seconds()))))
+	apierror.Error(w, "Too many failed logins, try again later", http.StatusTooManyRequests)
+}
+
+// lockoutEntityID is the audit entity ID of a lockout: the user's ID for the
+// DNI of user, 0 otherwise.
+func lockoutEntityID(lockout *domain.LoginLockout, user *domain.User) int64 {
+	if lockout.Scope == domain.LockoutScopeDNI && user != nil {
+		return user.ID
+	}
+	return 0
+}
+
+func (userHandler *UserHandler) GetLockoutsHandler(w http.ResponseWriter, r *http.Request) {
+	lockouts, err := userHandler.LockoutRepo.GetLockouts(r.Context())
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	now := time.Now().UTC()
+	response := make([]LockoutResponse, 0, len(lockouts))
+	for _, lockout := range lockouts {
+		response = append(response, LockoutResponse{LoginLockout: lockout, Locked: lockout.IsLocked(now)})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// UnlockHandler forgets the failed logins of a DNI or IP, lifting any
+// lockout and resetting its backoff.
+func (userHandler *UserHandler) UnlockHandler(w http.ResponseWriter, r *http.Request) {
+	scope := domain.LockoutScope(r.PathValue("scope"))
+	if !scope.IsValid() {
+		apierror.Validation(w, "scope", "Invalid scope. Must be dni or ip")
+		return
+	}
+	key := r.PathValue("key")
+
+	lockout, err := userHandler.LockoutRepo.GetLockout(r.Context(), scope, key)
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	err = userHandler.LockoutRepo.DeleteLockout(r.Context(), scope, key)
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	var user *domain.User
+	if scope == domain.LockoutScopeDNI {
+		user, _ = userHandler.UserRepo.GetUserByDNI(r.Context(), key)
+	}
+	userHandler.Auditor.Record(r, domain.AuditUnlock, domain.AuditEntityLogin, lockoutEntityID(lockout, user), lockout, nil)
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,128 @@
+package handler_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+	"vetsys/internal/database"
+	"vetsys/internal/database/memory"
+	"vetsys/internal/domain"
+	"vetsys/internal/handler"
+)
+
+func auditActions(t *testing.T, app *testApp, entityID int64) []domain.AuditAction {
+	t.Helper()
+	entity := domain.AuditEntityLogin
+	entries, err := app.db.AuditRepo.GetEntries(context.Background(), database.AuditFilter{Entity: &entity, EntityID: &entityID}, 10, 0)
+	if err != nil {
+		t.Fatalf("Failed to get audit entries: %v", err)
+	}
+	var actions []domain.AuditAction
+	for _, entry := range entries {
+		actions = append(actions, entry.Action)
+	}
+	return actions
+}
+
+func TestLoginLockout_DNIAcrossIPs(t *testing.T) {
+	app := newTestApp(t)
+	app.userHandler.DNILockout = domain.LockoutPolicy{Threshold: 3, Duration: time.Minute, MaxDuration: time.Hour}
+	user := app.createUser(t, "12345678A", "Secret123!")
+	admin := app.signIn(t, domain.RoleAdmin)
+
+	expectStatus(t, app.logIn(t, "203.0.113.1", "12345678A", "wrong"), http.StatusUnauthorized)
+	expectStatus(t, app.logIn(t, "203.0.113.2", "12345678A", "wrong"), http.StatusUnauthorized)
+	rec := app.logIn(t, "203.0.113.3", "12345678A", "wrong")
+	expectStatus(t, rec, http.StatusTooManyRequests)
+	if rec.Header().Get("Retry-After") != "60" {
+		t.Errorf("Expected Retry-After 60, got %q", rec.Header().Get("Retry-After"))
+	}
+
+	// Locked even with the right password, from anywhere.
+	expectStatus(t, app.logIn(t, "203.0.113.4", "12345678A", "Secret123!"), http.StatusTooManyRequests)
+	if actions := auditActions(t, app, user.ID); len(actions) != 1 || actions[0] != domain.AuditLock {
+		t.Errorf("Expected the lockout to be audited on the user, got %v", actions)
+	}
+
+	rec = app.do(t, admin, http.MethodGet, "/api/auth/lockouts", nil)
+	expectStatus(t, rec, http.StatusOK)
+	var lockouts []handler.LockoutResponse
+	decodeBody(t, rec, &lockouts)
+	if len(lockouts) == 0 || lockouts[0].Scope != domain.LockoutScopeDNI || lockouts[0].Key != "12345678A" ||
+		!lockouts[0].Locked || lockouts[0].Lockouts != 1 {
+		t.Fatalf("Expected the DNI to be listed as locked first, got %+v", lockouts)
+	}
+
+	expectStatus(t, app.do(t, admin, http.MethodDelete, "/api/auth/lockouts/dni/12345678A", nil), http.StatusNoContent)
+	expectStatus(t, app.do(t, admin, http.MethodDelete, "/api/auth/lockouts/dni/12345678A", nil), http.StatusNotFound)
+	if actions := auditActions(t, app, user.ID); len(actions) != 2 {
+		t.Errorf("Expected the unlock to be audited, got %v", actions)
+	}
+	expectStatus(t, app.logIn(t, "203.0.113.5", "12345678A", "Secret123!"), http.StatusOK)
+}
+
+func TestLoginLockout_IP(t *testing.T) {
+	app := newTestApp(t)
+	app.userHandler.IPLockout = domain.LockoutPolicy{Threshold: 2, Duration: time.Minute, MaxDuration: time.Hour}
+	app.createUser(t, "12345678A", "Secret123!")
+
+	// Unknown DNIs count too, and lock the IP whatever DNIs it tries.
+	expectStatus(t, app.logIn(t, "203.0.113.1", "00000001X", "guess"), http.StatusUnauthorized)
+	expectStatus(t, app.logIn(t, "203.0.113.1", "00000002X", "guess"), http.StatusTooManyRequests)
+	expectStatus(t, app.logIn(t, "203.0.113.1", "12345678A", "Secret123!"), http.StatusTooManyRequests)
+	if actions := auditActions(t, app, 0); len(actions) != 1 || actions[0] != domain.AuditLock {
+		t.Errorf("Expected the IP lockout to be audited, got %v", actions)
+	}
+
+	expectStatus(t, app.logIn(t, "203.0.113.2", "12345678A", "Secret123!"), http.StatusOK)
+}
+
+func TestLoginLockout_SuccessResetsDNIFailures(t *testing.T) {
+	app := newTestApp(t)
+	app.createUser(t, "12345678A", "Secret123!")
+
+	expectStatus(t, app.logIn(t, "203.0.113.1", "12345678A", "wrong"), http.StatusUnauthorized)
+	expectStatus(t, app.logIn(t, "203.0.113.1", "12345678A", "Secret123!"), http.StatusOK)
+
+	if _, err := app.db.LoginLockoutRepo.GetLockout(context.Background(), domain.LockoutScopeDNI, "12345678A"); err != database.ErrLockoutNotFound {
+		t.Errorf("Expected the DNI's failures to be forgotten, got %v", err)
+	}
+	ip, err := app.db.LoginLockoutRepo.GetLockout(context.Background(), domain.LockoutScopeIP, "203.0.113.1")
+	if err != nil || ip.Failures != 1 {
+		t.Errorf("Expected the IP's failure to stand, got %+v, %v", ip, err)
+	}
+}
+
+func TestLoginLockout_UnlockRequiresAdmin(t *testing.T) {
+	app := newTestApp(t)
+	vet := app.signIn(t, domain.RoleVeterinarian)
+	admin := app.signIn(t, domain.RoleAdmin)
+
+	expectStatus(t, app.do(t, vet, http.MethodGet, "/api/auth/lockouts", nil), http.StatusForbidden)
+	expectStatus(t, app.do(t, vet, http.MethodDelete, "/api/auth/lockouts/ip/203.0.113.1", nil), http.StatusForbidden)
+	expectStatus(t, app.do(t, admin, http.MethodDelete, "/api/auth/lockouts/email/203.0.113.1", nil), http.StatusBadRequest)
+	expectStatus(t, app.do(t, admin, http.MethodDelete, "/api/auth/lockouts/ip/203.0.113.1", nil), http.StatusNotFound)
+}
+
+// brokenUsers is a user store whose lookups fail as if the database were down.
+type brokenUsers struct {
+	*memory.UserRepository
+}
+
+func (brokenUsers) GetUserByDNI(ctx context.Context, dni string) (*domain.User, error) {
+	return nil, errors.New("connection reset by peer")
+}
+
+func TestLoginLockout_StoreErrorsAreNotFailures(t *testing.T) {
+	app := newTestApp(t)
+	app.userHandler.DNILockout = domain.LockoutPolicy{Threshold: 1, Duration: time.Minute, MaxDuration: time.Hour}
+	app.createUser(t, "12345678A", "Secret123!")
+	app.userHandler.UserRepo = brokenUsers{app.db.UserRepo}
+
+	expectStatus(t, app.logIn(t, "203.0.113.1", "12345678A", "Secret123!"), http.StatusInternalServerError)
+	if _, err := app.db.LoginLockoutRepo.GetLockout(context.Background(), domain.LockoutScopeDNI, "12345678A"); err != database.ErrLockoutNotFound {
+		t.Errorf("Expected no failure on record for the DNI, got %v", err)
+	}
+}
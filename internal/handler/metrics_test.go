@@ -0,0 +1,65 @@
+package handler_test
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"vetsys/internal/database"
+	"vetsys/internal/domain"
+	"vetsys/internal/metrics"
+)
+
+func TestMetricsEndpoint(t *testing.T) {
+	app := newTestApp(t)
+	cookie := app.signIn(t, domain.RoleReceptionist)
+	expectStatus(t, app.do(t, cookie, http.MethodGet, "/api/clients/99999", nil), http.StatusNotFound)
+
+	rec := app.do(t, nil, http.MethodGet, "/metrics", nil)
+	expectStatus(t, rec, http.StatusOK)
+	if contentType := rec.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/plain; version=0.0.4") {
+		t.Errorf("Expected the Prometheus text format, got %q", contentType)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{
+		`vetsys_http_requests_total{pattern="GET /api/clients/{client_id}",status="404"}`,
+		`vetsys_http_request_duration_seconds_bucket{pattern="GET /api/clients/{client_id}",status="404",le="+Inf"}`,
+		`# TYPE vetsys_rate_limit_rejections_total counter`,
+		`# TYPE vetsys_db_query_duration_seconds histogram`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected metrics to contain %s", want)
+		}
+	}
+}
+
+func TestMetrics_RateLimitRejections(t *testing.T) {
+	app := newTestApp(t)
+
+	// Fresh limiters allow three logins a minute per IP.
+	for range 4 {
+		app.do(t, nil, http.MethodPost, "/api/auth/login", map[string]string{"dni": "00000000X", "password": "wrong"})
+	}
+
+	body := app.do(t, nil, http.MethodGet, "/metrics", nil).Body.String()
+	if !strings.Contains(body, `vetsys_rate_limit_rejections_total{pattern="POST /api/auth/login"}`) {
+		t.Errorf("Expected a rate limit rejection to be counted, got:\n%s", body)
+	}
+}
+
+func TestActiveSessionsGauge(t *testing.T) {
+	app := newTestApp(t)
+	app.signIn(t, domain.RoleReceptionist)
+	app.signIn(t, domain.RoleVeterinarian)
+	if err := app.db.SessionRepo.DeleteOldSessions(context.Background()); err != nil {
+		t.Fatalf("Failed to delete old sessions: %v", err)
+	}
+
+	registry := metrics.NewRegistry()
+	registry.MustRegister(database.NewActiveSessionsGauge(app.db.SessionRepo))
+	var b strings.Builder
+	registry.WriteTo(&b)
+	if !strings.Contains(b.String(), "vetsys_active_sessions 2\n") {
+		t.Errorf("Expected 2 active sessions, got:\n%s", b.String())
+	}
+}
@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+	"vetsys/internal/apierror"
+	"vetsys/internal/database"
+	"vetsys/internal/domain"
+	"vetsys/internal/middleware"
+	"vetsys/internal/notify"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const DefaultPasswordResetTTL = time.Hour
+
+// PasswordResetHandler lets users who cannot sign in choose a new password
+// through a single-use link sent by the notifier.
+type PasswordResetHandler struct {
+	UserRepo    database.UserStore
+	SessionRepo database.SessionStore
+	ResetRepo   database.PasswordResetStore
+	Notifier    notify.Notifier
+	Auditor     *Auditor
+	// TokenTTL is how long a reset link stays valid.
+	TokenTTL time.Duration
+	// ResetURL is the page reset links open. The token goes in the URL
+	// fragment, which browsers never send to a server.
+	ResetURL   string
+	BcryptCost int
+}
+
+func NewPasswordResetHandler(userRepo database.UserStore, sessionRepo database.SessionStore, resetRepo database.PasswordResetStore, notifier notify.Notifier, auditor *Auditor) *PasswordResetHandler {
+	return &PasswordResetHandler{
+		UserRepo:    userRepo,
+		SessionRepo: sessionRepo,
+		ResetRepo:   resetRepo,
+		Notifier:    notifier,
+		Auditor:     auditor,
+		TokenTTL:    DefaultPasswordResetTTL,
+		ResetURL:    "http://localhost:8888/reset-password",
+		BcryptCost:  DefaultBcryptCost,
+	}
+}
+
+type PasswordResetRequest struct {
+	DNI string `json:"dni"`
+}
+type PasswordResetConfirmRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+// RequestResetHandler sends a reset link to the email of the DNI's user. It
+// answers the same whether or not the DNI has an account, so it cannot be
+// used to find out.
+func (resetHandler *PasswordResetHandler) RequestResetHandler(w http.ResponseWriter, r *http.Request) {
+	var req PasswordResetRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		apierror.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if req.DNI == "" {
+		apierror.Validation(w, "dni", "DNI is required")
+		return
+	}
+
+	user, err := resetHandler.UserRepo.GetUserByDNI(r.Context(), req.DNI)
+	if err == nil {
+		err = resetHandler.sendResetLink(r, user)
+	}
+	if err != nil && err != database.ErrUserNotFound {
+		requestID, _ := middleware.GetRequestID(r.Context())
+		slog.ErrorContext(r.Context(), "password reset: failed to send link", "request_id", requestID, "error", err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	w.Write([]byte(`{"message":"if the DNI has an account, a reset link was sent to its email"}`))
+}
+
+func (resetHandler *PasswordResetHandler) sendResetLink(r *http.Request, user *domain.User) error {
+	token, record, err := domain.NewPasswordResetToken(user.ID, resetHandler.TokenTTL)
+	if err != nil {
+		return err
+	}
+	if err := resetHandler.ResetRepo.CreateResetToken(r.Context(), record); err != nil {
+		return err
+	}
+	return resetHandler.Notifier.Notify(r.Context(), notify.Message{
+		To:      user.Email,
+		Subject: "Restablecer tu contraseña",
+		Body: fmt.Sprintf("Hola %s:\n\nPara elegir una nueva contraseña abre este enlace, válido durante %d minutos:\n\n%s#token=%s\n\nSi no lo pediste, ignora este mensaje; tu contraseña no cambiará.",
+			user.Name, int(resetHandler.TokenTTL.Minutes()), resetHandler.ResetURL, token),
+	})
+}
+
+// ConfirmResetHandler sets a new password with a reset token, using it up,
+// and signs the user out everywhere.
+func (resetHandler *PasswordResetHandler) ConfirmResetHandler(w http.ResponseWriter, r *http.Request) {
+	var req PasswordResetConfirmRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		apierror.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if req.Token == "" {
+		apierror.Validation(w, "token", "Token is required")
+		return
+	}
+	// Checked before the token is used up, so a rejected password can be retried.
+	if !isValidPassword(req.Password) {
+		apierror.Validation(w, "password", "Invalid password")
+		return
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), resetHandler.BcryptCost)
+	if err != nil {
+		apierror.Error(w, "Failed to process password", http.StatusInternalServerError)
+		return
+	}
+
+	userID, err := resetHandler.ResetRepo.ConsumeResetToken(r.Context(), domain.HashToken(req.Token))
+	if err == database.ErrResetTokenInvalid {
+		apierror.Validation(w, "token", err.Error())
+		return
+	}
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	err = resetHandler.UserRepo.UpdatePassword(r.Context(), userID, string(hashedPassword))
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	revoked, err := resetHandler.SessionRepo.DeleteSessionsByUserID(r.Context(), userID, "")
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	resetHandler.Auditor.Record(r, domain.AuditUpdate, domain.AuditEntityUser, userID, nil, map[string]any{"password": "reset", "sessions_revoked": revoked})
+	w.WriteHeader(http.StatusNoContent)
+}
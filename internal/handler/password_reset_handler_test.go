@@ -0,0 +1,105 @@
+package handler_test
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+	"vetsys/internal/database"
+	"vetsys/internal/domain"
+)
+
+// resetToken extracts the token from the reset link in the last message sent.
+func resetToken(t *testing.T, app *testApp) string {
+	t.Helper()
+	_, rest, ok := strings.Cut(app.outbox.last(t).Body, "/reset-password#token=")
+	if !ok {
+		t.Fatalf("Expected a reset link, got %q", app.outbox.last(t).Body)
+	}
+	return strings.Fields(rest)[0]
+}
+
+func TestPasswordReset(t *testing.T) {
+	app := newTestApp(t)
+	user := app.createUser(t, "12345678A", "Secret123!")
+	session, err := domain.NewSession(user.ID, time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	if err := app.db.SessionRepo.CreateSession(context.Background(), session); err != nil {
+		t.Fatalf("Failed to store session: %v", err)
+	}
+
+	rec := app.post(t, "198.51.100.1", "/api/auth/password-reset", map[string]string{"dni": "12345678A"})
+	expectStatus(t, rec, http.StatusAccepted)
+	if to := app.outbox.last(t).To; to != user.Email {
+		t.Errorf("Expected the link to go to %s, got %s", user.Email, to)
+	}
+	token := resetToken(t, app)
+
+	// A weak password does not use up the token.
+	rec = app.post(t, "198.51.100.2", "/api/auth/password-reset/confirm", map[string]string{"token": token, "password": "weak"})
+	expectStatus(t, rec, http.StatusBadRequest)
+
+	rec = app.post(t, "198.51.100.3", "/api/auth/password-reset/confirm", map[string]string{"token": token, "password": "NewSecret456!"})
+	expectStatus(t, rec, http.StatusNoContent)
+
+	expectStatus(t, app.do(t, &http.Cookie{Name: "session_id", Value: session.ID}, http.MethodGet, "/api/auth/me", nil), http.StatusUnauthorized)
+	expectStatus(t, app.logIn(t, "203.0.113.1", "12345678A", "Secret123!"), http.StatusUnauthorized)
+	expectStatus(t, app.logIn(t, "203.0.113.1", "12345678A", "NewSecret456!"), http.StatusOK)
+
+	// Single use.
+	rec = app.post(t, "198.51.100.4", "/api/auth/password-reset/confirm", map[string]string{"token": token, "password": "Another789!"})
+	expectStatus(t, rec, http.StatusBadRequest)
+
+	entity := domain.AuditEntityUser
+	entries, err := app.db.AuditRepo.GetEntries(context.Background(), database.AuditFilter{Entity: &entity, EntityID: &user.ID}, 10, 0)
+	if err != nil {
+		t.Fatalf("Failed to get audit entries: %v", err)
+	}
+	if len(entries) != 1 || entries[0].ActorID != nil || strings.Contains(string(entries[0].Changes), "NewSecret456!") {
+		t.Errorf("Expected one anonymous audit entry without the password, got %+v", entries)
+	}
+}
+
+func TestPasswordReset_OnlyLatestLinkWorks(t *testing.T) {
+	app := newTestApp(t)
+	app.createUser(t, "12345678A", "Secret123!")
+
+	expectStatus(t, app.post(t, "198.51.100.5", "/api/auth/password-reset", map[string]string{"dni": "12345678A"}), http.StatusAccepted)
+	first := resetToken(t, app)
+	expectStatus(t, app.post(t, "198.51.100.6", "/api/auth/password-reset", map[string]string{"dni": "12345678A"}), http.StatusAccepted)
+	second := resetToken(t, app)
+
+	rec := app.post(t, "198.51.100.7", "/api/auth/password-reset/confirm", map[string]string{"token": first, "password": "NewSecret456!"})
+	expectStatus(t, rec, http.StatusBadRequest)
+	rec = app.post(t, "198.51.100.8", "/api/auth/password-reset/confirm", map[string]string{"token": second, "password": "NewSecret456!"})
+	expectStatus(t, rec, http.StatusNoContent)
+}
+
+func TestPasswordReset_UnknownDNI(t *testing.T) {
+	app := newTestApp(t)
+
+	rec := app.post(t, "198.51.100.9", "/api/auth/password-reset", map[string]string{"dni": "99999999Z"})
+	expectStatus(t, rec, http.StatusAccepted)
+	if len(app.outbox.messages) != 0 {
+		t.Errorf("Expected no message for an unknown DNI, got %+v", app.outbox.messages)
+	}
+}
+
+func TestPasswordReset_ExpiredToken(t *testing.T) {
+	app := newTestApp(t)
+	user := app.createUser(t, "12345678A", "Secret123!")
+	token, record, err := domain.NewPasswordResetToken(user.ID, time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to create token: %v", err)
+	}
+	record.ExpiresAt = time.Now().UTC().Add(-time.Minute)
+	if err := app.db.PasswordResetRepo.CreateResetToken(context.Background(), record); err != nil {
+		t.Fatalf("Failed to store token: %v", err)
+	}
+
+	rec := app.post(t, "198.51.100.10", "/api/auth/password-reset/confirm", map[string]string{"token": token, "password": "NewSecret456!"})
+	expectStatus(t, rec, http.StatusBadRequest)
+}
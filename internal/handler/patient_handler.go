@@ -5,12 +5,14 @@ import (
 	"net/http"
 	"strconv"
 	"time"
+	"vetsys/internal/apierror"
 	"vetsys/internal/database"
 	"vetsys/internal/domain"
 )
 
 type PatientHandler struct {
-	patientRepo *database.PatientRepository
+	patientRepo database.PatientStore
+	auditor     *Auditor
 }
 
 type PatientUpdate struct {
@@ -20,40 +22,42 @@ type PatientUpdate struct {
 	AproxDateOfBirth *time.Time `json:"aproxDateOfBirth"`
 }
 
-func NewPatientHandler(patientRepo *database.PatientRepository) *PatientHandler {
+func NewPatientHandler(patientRepo database.PatientStore, auditor *Auditor) *PatientHandler {
 	return &PatientHandler{
 		patientRepo: patientRepo,
+		auditor:     auditor,
 	}
 }
 func (patientHandler *PatientHandler) CreatePatientHandler(w http.ResponseWriter, r *http.Request) {
 	var patient domain.Patient
 	err := json.NewDecoder(r.Body).Decode(&patient)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apierror.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 	if patient.Name == "" {
-		http.Error(w, "Name is required", http.StatusBadRequest)
+		apierror.Validation(w, "name", "Name is required")
 		return
 	}
 	if patient.Species == "" {
-		http.Error(w, "Species is required", http.StatusBadRequest)
+		apierror.Validation(w, "species", "Species is required")
 		return
 	}
 	if patient.Breed == "" {
-		http.Error(w, "Breed is required", http.StatusBadRequest)
+		apierror.Validation(w, "breed", "Breed is required")
 		return
 	}
 	if patient.AproxDateOfBirth.IsZero() {
-		http.Error(w, "Approximate date of birth is required", http.StatusBadRequest)
+		apierror.Validation(w, "aproxDateOfBirth", "Approximate date of birth is required")
 		return
 	}
 
-	err = patientHandler.patientRepo.CreatePatient(&patient)
+	err = patientHandler.patientRepo.CreatePatient(r.Context(), &patient)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.Respond(w, err)
 		return
 	}
+	patientHandler.auditor.Record(r, domain.AuditCreate, domain.AuditEntityPatient, patient.ID, nil, &patient)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(patient)
@@ -62,21 +66,21 @@ func (patientHandler *PatientHandler) CreatePatientHandler(w http.ResponseWriter
 func (patientHandler *PatientHandler) GetPatientByIDHandler(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("patient_id")
 	if id == "" {
-		http.Error(w, "No id passed", http.StatusBadRequest)
+		apierror.Error(w, "No id passed", http.StatusBadRequest)
 		return
 	}
 	idValue, err := strconv.ParseInt(id, 10, 64)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apierror.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	patient, err := patientHandler.patientRepo.GetPatientByID(idValue)
+	patient, err := patientHandler.patientRepo.GetPatientByID(r.Context(), idValue)
 	if err == database.ErrPatientNotFound {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		apierror.Error(w, err.Error(), http.StatusNotFound)
 		return
 	}
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.Respond(w, err)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
@@ -86,17 +90,17 @@ func (patientHandler *PatientHandler) GetPatientByIDHandler(w http.ResponseWrite
 func (patientHandler *PatientHandler) GetPatientByOwnerIDHandler(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("owner_id")
 	if id == "" {
-		http.Error(w, "No id passed", http.StatusBadRequest)
+		apierror.Error(w, "No id passed", http.StatusBadRequest)
 		return
 	}
 	idValue, err := strconv.ParseInt(id, 10, 64)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apierror.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	patients, err := patientHandler.patientRepo.GetPatientsByOwner(idValue)
+	patients, err := patientHandler.patientRepo.GetPatientsByOwner(r.Context(), idValue)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.Respond(w, err)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
@@ -107,49 +111,50 @@ func (patientHandler *PatientHandler) GetPatientByOwnerIDHandler(w http.Response
 func (patientHandler *PatientHandler) UpdatePatientHandler(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("patient_id")
 	if id == "" {
-		http.Error(w, "No id passed", http.StatusBadRequest)
+		apierror.Error(w, "No id passed", http.StatusBadRequest)
 		return
 	}
 	idValue, err := strconv.ParseInt(id, 10, 64)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apierror.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
 	var patientUpdate PatientUpdate
 	err = json.NewDecoder(r.Body).Decode(&patientUpdate)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apierror.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	patient, err := patientHandler.patientRepo.GetPatientByID(idValue)
+	patient, err := patientHandler.patientRepo.GetPatientByID(r.Context(), idValue)
 	if err == database.ErrPatientNotFound {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		apierror.Error(w, err.Error(), http.StatusNotFound)
 		return
 	}
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.Respond(w, err)
 		return
 	}
+	before := *patient
 
 	if patientUpdate.Name != nil {
 		if *patientUpdate.Name == "" {
-			http.Error(w, "Name cannot be empty", http.StatusBadRequest)
+			apierror.Validation(w, "name", "Name cannot be empty")
 			return
 		}
 		patient.Name = *patientUpdate.Name
 	}
 	if patientUpdate.Species != nil {
 		if *patientUpdate.Species == "" {
-			http.Error(w, "Species cannot be empty", http.StatusBadRequest)
+			apierror.Validation(w, "species", "Species cannot be empty")
 			return
 		}
 		patient.Species = *patientUpdate.Species
 	}
 	if patientUpdate.Breed != nil {
 		if *patientUpdate.Breed == "" {
-			http.Error(w, "Breed cannot be empty", http.StatusBadRequest)
+			apierror.Validation(w, "breed", "Breed cannot be empty")
 			return
 		}
 		patient.Breed = *patientUpdate.Breed
@@ -158,38 +163,53 @@ func (patientHandler *PatientHandler) UpdatePatientHandler(w http.ResponseWriter
 		patient.AproxDateOfBirth = *patientUpdate.AproxDateOfBirth
 	}
 
-	err = patientHandler.patientRepo.UpdatePatient(patient)
+	err = patientHandler.patientRepo.UpdatePatient(r.Context(), patient)
 	if err == database.ErrPatientNotFound {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		apierror.Error(w, err.Error(), http.StatusNotFound)
 		return
 	}
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.Respond(w, err)
 		return
 	}
+	patientHandler.auditor.Record(r, domain.AuditUpdate, domain.AuditEntityPatient, patient.ID, &before, patient)
 	w.WriteHeader(http.StatusNoContent)
 }
 
 func (patientHandler *PatientHandler) DeletePatientHandler(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("patient_id")
 	if id == "" {
-		http.Error(w, "No id passed", http.StatusBadRequest)
+		apierror.Error(w, "No id passed", http.StatusBadRequest)
 		return
 	}
 	idValue, err := strconv.ParseInt(id, 10, 64)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apierror.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	err = patientHandler.patientRepo.DeletePatientByID(idValue)
+	patient, err := patientHandler.patientRepo.GetPatientByID(r.Context(), idValue)
 	if err == database.ErrPatientNotFound {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		apierror.Error(w, err.Error(), http.StatusNotFound)
 		return
 	}
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.Respond(w, err)
 		return
 	}
+	err = patientHandler.patientRepo.DeletePatientByID(r.Context(), idValue)
+	if err == database.ErrPatientNotFound {
+		apierror.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err == database.ErrRecordInUse {
+		apierror.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	patientHandler.auditor.Record(r, domain.AuditDelete, domain.AuditEntityPatient, patient.ID, patient, nil)
 	w.WriteHeader(http.StatusNoContent)
 }
@@ -0,0 +1,95 @@
+package handler_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+	"vetsys/internal/database"
+	"vetsys/internal/domain"
+)
+
+// seedConsultation stores a client with one patient and one consultation.
+func seedConsultation(t *testing.T, app *testApp) (*domain.Client, *domain.Consultation) {
+	t.Helper()
+	client := domain.NewClient("87654321X", "Ana Torres", "+34600999888")
+	if err := app.db.ClientRepo.CreateClient(context.Background(), client); err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	patient := domain.NewPatient("Luna", "Dog", "Beagle", time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC), client.ID)
+	if err := app.db.PatientRepo.CreatePatient(context.Background(), patient); err != nil {
+		t.Fatalf("Failed to create patient: %v", err)
+	}
+	consultation := domain.NewConsultation(patient.ID, "Vaccination", "", "", domain.SeverityLow)
+	if err := app.db.ConsultationRepo.CreateConsultation(context.Background(), consultation); err != nil {
+		t.Fatalf("Failed to create consultation: %v", err)
+	}
+	return client, consultation
+}
+
+func databaseAuditFilter(entity string, entityID int64) database.AuditFilter {
+	return database.AuditFilter{Entity: &entity, EntityID: &entityID}
+}
+
+func TestPatientHandler_CreateAndListByOwner(t *testing.T) {
+	app := newTestApp(t)
+	cookie := app.signIn(t, domain.RoleReceptionist)
+	client := domain.NewClient("12345678A", "John Doe", "+34600111222")
+	if err := app.db.ClientRepo.CreateClient(context.Background(), client); err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	for _, name := range []string{"Luna", "Milo"} {
+		rec := app.do(t, cookie, http.MethodPost, "/api/patients", map[string]any{
+			"name": name, "species": "Cat", "breed": "Siamese",
+			"aproxDateOfBirth": "2021-06-01T00:00:00Z", "ownerId": client.ID,
+		})
+		expectStatus(t, rec, http.StatusCreated)
+	}
+
+	rec := app.do(t, cookie, http.MethodGet, fmt.Sprintf("/api/patients/owner/%d", client.ID), nil)
+	expectStatus(t, rec, http.StatusOK)
+	var patients []domain.Patient
+	decodeBody(t, rec, &patients)
+	if len(patients) != 2 {
+		t.Fatalf("Expected 2 patients, got %d", len(patients))
+	}
+	if patients[0].Name != "Luna" || patients[1].Name != "Milo" {
+		t.Errorf("Expected Luna and Milo in creation order, got %s and %s", patients[0].Name, patients[1].Name)
+	}
+}
+
+func TestPatientHandler_GetNotFound(t *testing.T) {
+	app := newTestApp(t)
+	cookie := app.signIn(t, domain.RoleReadOnly)
+
+	expectStatus(t, app.do(t, cookie, http.MethodGet, "/api/patients/99999", nil), http.StatusNotFound)
+	expectStatus(t, app.do(t, cookie, http.MethodGet, "/api/patients/abc", nil), http.StatusBadRequest)
+	expectStatus(t, app.do(t, cookie, http.MethodGet, "/api/patients/unknown/1", nil), http.StatusNotFound)
+}
+
+func TestPatientHandler_LookupsBesideSubResources(t *testing.T) {
+	app := newTestApp(t)
+	cookie := app.signIn(t, domain.RoleVeterinarian)
+	_, consultation := seedConsultation(t, app)
+
+	for _, path := range []string{
+		fmt.Sprintf("/api/patients/consultations/%d", consultation.PatientID),
+		fmt.Sprintf("/api/patients/vaccinations/%d", consultation.PatientID),
+		fmt.Sprintf("/api/patients/%d/vitals", consultation.PatientID),
+	} {
+		expectStatus(t, app.do(t, cookie, http.MethodGet, path, nil), http.StatusOK)
+	}
+}
+
+func TestPatientHandler_DeleteCascades(t *testing.T) {
+	app := newTestApp(t)
+	vet := app.signIn(t, domain.RoleVeterinarian)
+	_, consultation := seedConsultation(t, app)
+
+	rec := app.do(t, vet, http.MethodDelete, fmt.Sprintf("/api/patients/%d", consultation.PatientID), nil)
+	expectStatus(t, rec, http.StatusNoContent)
+
+	expectStatus(t, app.do(t, vet, http.MethodGet, fmt.Sprintf("/api/consultations/%d", consultation.ID), nil), http.StatusNotFound)
+}
@@ -0,0 +1,334 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"vetsys/internal/apierror"
+	"vetsys/internal/database"
+	"vetsys/internal/domain"
+	"vetsys/internal/middleware"
+	"vetsys/internal/utils"
+)
+
+type PrescriptionHandler struct {
+	prescriptionRepo database.PrescriptionStore
+	consultRepo      database.ConsultationStore
+	patientRepo      database.PatientStore
+	auditor          *Auditor
+}
+
+type MedicationRequest struct {
+	Name        string             `json:"name"`
+	Description string             `json:"description"`
+	DoseUnit    string             `json:"dose_unit"`
+	DoseRanges  []DoseRangeRequest `json:"dose_ranges"`
+}
+
+type DoseRangeRequest struct {
+	Species      string  `json:"species"`
+	MinDosePerKg float64 `json:"min_dose_per_kg"`
+	MaxDosePerKg float64 `json:"max_dose_per_kg"`
+}
+
+type PrescriptionRequest struct {
+	MedicationID    int64        `json:"medication_id"`
+	Dose            float64      `json:"dose"`
+	DoseUnit        string       `json:"dose_unit"`
+	FrequencyHours  int          `json:"frequency_hours"`
+	DurationDays    int          `json:"duration_days"`
+	Route           domain.Route `json:"route"`
+	PatientWeightKg *float64     `json:"patient_weight_kg"`
+	Notes           string       `json:"notes"`
+}
+
+func NewPrescriptionHandler(prescriptionRepo database.PrescriptionStore, consultRepo database.ConsultationStore, patientRepo database.PatientStore, auditor *Auditor) *PrescriptionHandler {
+	return &PrescriptionHandler{
+		prescriptionRepo: prescriptionRepo,
+		consultRepo:      consultRepo,
+		patientRepo:      patientRepo,
+		auditor:          auditor,
+	}
+}
+
+func (prescriptionHandler *PrescriptionHandler) CreateMedicationHandler(w http.ResponseWriter, r *http.Request) {
+	var medicationRequest MedicationRequest
+	err := json.NewDecoder(r.Body).Decode(&medicationRequest)
+	if err != nil {
+		apierror.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if medicationRequest.Name == "" {
+		apierror.Validation(w, "name", "Name is required")
+		return
+	}
+	if medicationRequest.DoseUnit == "" {
+		apierror.Validation(w, "dose_unit", "Dose unit is required")
+		return
+	}
+
+	medication := domain.NewMedication(medicationRequest.Name, medicationRequest.Description, medicationRequest.DoseUnit)
+	seen := make(map[string]bool, len(medicationRequest.DoseRanges))
+	for _, doseRangeRequest := range medicationRequest.DoseRanges {
+		doseRange := domain.NewMedicationDoseRange(0, doseRangeRequest.Species, doseRangeRequest.MinDosePerKg, doseRangeRequest.MaxDosePerKg)
+		if !validateDoseRange(w, doseRange) {
+			return
+		}
+		if seen[doseRange.Species] {
+			apierror.Validation(w, "dose_ranges", "Duplicate dose range for species "+doseRange.Species)
+			return
+		}
+		seen[doseRange.Species] = true
+		medication.DoseRanges = append(medication.DoseRanges, *doseRange)
+	}
+
+	err = prescriptionHandler.prescriptionRepo.CreateMedication(r.Context(), medication)
+	if err == database.ErrMedicationExists {
+		apierror.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(medication)
+}
+
+func (prescriptionHandler *PrescriptionHandler) GetMedicationsHandler(w http.ResponseWriter, r *http.Request) {
+	limit, offset := utils.Pagination(r)
+	total, err := prescriptionHandler.prescriptionRepo.GetMedicationsCount(r.Context())
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	totalPages := int((total + int64(limit) - 1) / int64(limit))
+	page := (offset / limit) + 1
+
+	medications, err := prescriptionHandler.prescriptionRepo.GetMedications(r.Context(), limit, offset)
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	response := PaginatedResponse{
+		Data:       medications,
+		Page:       page,
+		Limit:      limit,
+		Total:      total,
+		TotalPages: totalPages,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (prescriptionHandler *PrescriptionHandler) GetMedicationByIDHandler(w http.ResponseWriter, r *http.Request) {
+	medicationID, ok := pathID(w, r, "medication_id")
+	if !ok {
+		return
+	}
+	medication, err := prescriptionHandler.prescriptionRepo.GetMedicationByID(r.Context(), medicationID)
+	if err == database.ErrMedicationNotFound {
+		apierror.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(medication)
+}
+
+func (prescriptionHandler *PrescriptionHandler) SetDoseRangeHandler(w http.ResponseWriter, r *http.Request) {
+	medicationID, ok := pathID(w, r, "medication_id")
+	if !ok {
+		return
+	}
+	var doseRangeRequest DoseRangeRequest
+	err := json.NewDecoder(r.Body).Decode(&doseRangeRequest)
+	if err != nil {
+		apierror.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	doseRange := domain.NewMedicationDoseRange(medicationID, r.PathValue("species"), doseRangeRequest.MinDosePerKg, doseRangeRequest.MaxDosePerKg)
+	if !validateDoseRange(w, doseRange) {
+		return
+	}
+
+	err = prescriptionHandler.prescriptionRepo.SetDoseRange(r.Context(), doseRange)
+	if err == database.ErrMedicationNotFound {
+		apierror.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (prescriptionHandler *PrescriptionHandler) DeleteDoseRangeHandler(w http.ResponseWriter, r *http.Request) {
+	medicationID, ok := pathID(w, r, "medication_id")
+	if !ok {
+		return
+	}
+	err := prescriptionHandler.prescriptionRepo.DeleteDoseRange(r.Context(), medicationID, domain.NormalizeSpecies(r.PathValue("species")))
+	if err == database.ErrDoseRangeNotFound {
+		apierror.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// CreatePrescriptionHandler prescribes a medication in a consultation. Doses
+// outside the medication's range for the patient's species are accepted but
+// come back with warnings.
+func (prescriptionHandler *PrescriptionHandler) CreatePrescriptionHandler(w http.ResponseWriter, r *http.Request) {
+	consultationID, ok := pathID(w, r, "consultation_id")
+	if !ok {
+		return
+	}
+	var prescriptionRequest PrescriptionRequest
+	err := json.NewDecoder(r.Body).Decode(&prescriptionRequest)
+	if err != nil {
+		apierror.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if prescriptionRequest.MedicationID <= 0 {
+		apierror.Validation(w, "medication_id", "MedicationID must be greater than 0")
+		return
+	}
+	if prescriptionRequest.Dose <= 0 {
+		apierror.Validation(w, "dose", "Dose must be greater than 0")
+		return
+	}
+	if prescriptionRequest.FrequencyHours <= 0 {
+		apierror.Validation(w, "frequency_hours", "Frequency hours must be greater than 0")
+		return
+	}
+	if prescriptionRequest.DurationDays <= 0 {
+		apierror.Validation(w, "duration_days", "Duration days must be greater than 0")
+		return
+	}
+	if !prescriptionRequest.Route.IsValid() {
+		apierror.Validation(w, "route", "Invalid route. Must be ORAL, SUBCUTANEOUS, INTRAMUSCULAR, INTRAVENOUS, TOPICAL, OPHTHALMIC, OTIC or INHALED")
+		return
+	}
+	if prescriptionRequest.PatientWeightKg != nil && *prescriptionRequest.PatientWeightKg <= 0 {
+		apierror.Validation(w, "patient_weight_kg", "Patient weight must be greater than 0")
+		return
+	}
+	prescribedBy, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		apierror.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	consultation, err := prescriptionHandler.consultRepo.GetConsultationByID(r.Context(), consultationID)
+	if err == database.ErrConsultationNotFound {
+		apierror.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	patient, err := prescriptionHandler.patientRepo.GetPatientByID(r.Context(), consultation.PatientID)
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	medication, err := prescriptionHandler.prescriptionRepo.GetMedicationByID(r.Context(), prescriptionRequest.MedicationID)
+	if err == database.ErrMedicationNotFound {
+		apierror.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+
+	doseUnit := prescriptionRequest.DoseUnit
+	if doseUnit == "" {
+		doseUnit = medication.DoseUnit
+	}
+	prescription := domain.NewPrescription(consultationID, medication.ID, prescribedBy, prescriptionRequest.Dose, doseUnit,
+		prescriptionRequest.FrequencyHours, prescriptionRequest.DurationDays, prescriptionRequest.Route, prescriptionRequest.PatientWeightKg, prescriptionRequest.Notes)
+	prescription.Warnings = prescription.CheckDose(medication, patient.Species)
+
+	err = prescriptionHandler.prescriptionRepo.CreatePrescription(r.Context(), prescription)
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	prescriptionHandler.auditor.Record(r, domain.AuditCreate, domain.AuditEntityPrescription, prescription.ID, nil, prescription)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(prescription)
+}
+
+func (prescriptionHandler *PrescriptionHandler) GetConsultationPrescriptionsHandler(w http.ResponseWriter, r *http.Request) {
+	consultationID, ok := pathID(w, r, "consultation_id")
+	if !ok {
+		return
+	}
+	prescriptions, err := prescriptionHandler.prescriptionRepo.GetPrescriptionsByConsultationID(r.Context(), consultationID)
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(prescriptions)
+}
+
+func (prescriptionHandler *PrescriptionHandler) DeletePrescriptionHandler(w http.ResponseWriter, r *http.Request) {
+	prescriptionID, ok := pathID(w, r, "prescription_id")
+	if !ok {
+		return
+	}
+	prescription, err := prescriptionHandler.prescriptionRepo.GetPrescriptionByID(r.Context(), prescriptionID)
+	if err == database.ErrPrescriptionNotFound {
+		apierror.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	err = prescriptionHandler.prescriptionRepo.DeletePrescriptionByID(r.Context(), prescriptionID)
+	if err == database.ErrPrescriptionNotFound {
+		apierror.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	prescriptionHandler.auditor.Record(r, domain.AuditDelete, domain.AuditEntityPrescription, prescription.ID, prescription, nil)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func validateDoseRange(w http.ResponseWriter, doseRange *domain.MedicationDoseRange) bool {
+	if doseRange.Species == "" {
+		apierror.Validation(w, "species", "Species is required")
+		return false
+	}
+	if doseRange.MinDosePerKg < 0 {
+		apierror.Validation(w, "min_dose_per_kg", "Minimum dose cannot be negative")
+		return false
+	}
+	if doseRange.MaxDosePerKg <= 0 || doseRange.MaxDosePerKg < doseRange.MinDosePerKg {
+		apierror.Validation(w, "max_dose_per_kg", "Maximum dose must be greater than 0 and not below the minimum")
+		return false
+	}
+	return true
+}
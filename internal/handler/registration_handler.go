@@ -0,0 +1,238 @@
+package handler
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+	"vetsys/internal/apierror"
+	"vetsys/internal/database"
+	"vetsys/internal/domain"
+	"vetsys/internal/middleware"
+	"vetsys/internal/utils"
+)
+
+// defaultInvitationTTL applies when an invitation is created without an explicit expiry.
+const defaultInvitationTTL = 7 * 24 * time.Hour
+
+// maxInvitationsPerRequest bounds bulk invitation requests.
+const maxInvitationsPerRequest = 100
+
+type RegistrationHandler struct {
+	allowedRegistRepo database.AllowedRegistrationStore
+	userRepo          database.UserStore
+}
+
+type CreateRegistrationsRequest struct {
+	DNIs      []string    `json:"dnis"`
+	Role      domain.Role `json:"role"`
+	ExpiresAt *time.Time  `json:"expiresAt"`
+}
+
+type CreateRegistrationsResponse struct {
+	Created []string `json:"created"`
+	Skipped []string `json:"skipped"`
+}
+
+type ReopenRegistrationRequest struct {
+	ExpiresAt *time.Time `json:"expiresAt"`
+}
+
+func NewRegistrationHandler(allowedRegistRepo database.AllowedRegistrationStore, userRepo database.UserStore) *RegistrationHandler {
+	return &RegistrationHandler{
+		allowedRegistRepo: allowedRegistRepo,
+		userRepo:          userRepo,
+	}
+}
+
+func (registrationHandler *RegistrationHandler) GetRegistrationsHandler(w http.ResponseWriter, r *http.Request) {
+	var used *bool
+	if usedParam := r.URL.Query().Get("used"); usedParam != "" {
+		usedValue, err := strconv.ParseBool(usedParam)
+		if err != nil {
+			apierror.Validation(w, "used", "Invalid used parameter. Use 'true' or 'false'")
+			return
+		}
+		used = &usedValue
+	}
+
+	limit, offset := utils.Pagination(r)
+	total, err := registrationHandler.allowedRegistRepo.GetRegistrationsCount(r.Context(), used)
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	totalPages := int((total + int64(limit) - 1) / int64(limit))
+	page := (offset / limit) + 1
+
+	registrations, err := registrationHandler.allowedRegistRepo.GetRegistrations(r.Context(), used, limit, offset)
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	response := PaginatedResponse{
+		Data:       registrations,
+		Page:       page,
+		Limit:      limit,
+		Total:      total,
+		TotalPages: totalPages,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (registrationHandler *RegistrationHandler) CreateRegistrationsHandler(w http.ResponseWriter, r *http.Request) {
+	var req CreateRegistrationsRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		apierror.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if len(req.DNIs) == 0 {
+		apierror.Validation(w, "dnis", "At least one DNI is required")
+		return
+	}
+	if len(req.DNIs) > maxInvitationsPerRequest {
+		apierror.Validation(w, "dnis", "Too many DNIs. Send at most "+strconv.Itoa(maxInvitationsPerRequest)+" per request")
+		return
+	}
+	if req.Role == "" {
+		req.Role = domain.RoleVeterinarian
+	}
+	if !req.Role.IsValid() {
+		apierror.Validation(w, "role", "Invalid role. Must be ADMIN, VETERINARIAN, RECEPTIONIST or READ_ONLY")
+		return
+	}
+	expiresAt, ok := invitationExpiry(w, req.ExpiresAt)
+	if !ok {
+		return
+	}
+	invitedBy, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		apierror.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	registrations := make([]domain.AllowedRegistration, 0, len(req.DNIs))
+	requested := make(map[string]bool, len(req.DNIs))
+	for _, dni := range req.DNIs {
+		if dni == "" {
+			apierror.Validation(w, "dni", "DNI cannot be empty")
+			return
+		}
+		if requested[dni] {
+			continue
+		}
+		requested[dni] = true
+		registrations = append(registrations, *domain.NewAllowedRegistration(dni, req.Role, invitedBy, expiresAt))
+	}
+
+	created, err := registrationHandler.allowedRegistRepo.InsertRegistrations(r.Context(), registrations)
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+
+	response := CreateRegistrationsResponse{Created: created, Skipped: []string{}}
+	createdSet := make(map[string]bool, len(created))
+	for _, dni := range created {
+		createdSet[dni] = true
+	}
+	// Each DNI is reported once per occurrence: a repeat of one just created
+	// is skipped like any other existing invitation.
+	for _, dni := range req.DNIs {
+		if createdSet[dni] {
+			createdSet[dni] = false
+			continue
+		}
+		response.Skipped = append(response.Skipped, dni)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (registrationHandler *RegistrationHandler) RevokeRegistrationHandler(w http.ResponseWriter, r *http.Request) {
+	dni := r.PathValue("dni")
+	if dni == "" {
+		apierror.Error(w, "No dni passed", http.StatusBadRequest)
+		return
+	}
+	err := registrationHandler.allowedRegistRepo.RevokeDNI(r.Context(), dni)
+	if err == database.ErrDNINotFound {
+		apierror.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err == database.ErrDNIInvalidOrUsed {
+		apierror.Error(w, "Invitation is already used or revoked", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (registrationHandler *RegistrationHandler) ReopenRegistrationHandler(w http.ResponseWriter, r *http.Request) {
+	dni := r.PathValue("dni")
+	if dni == "" {
+		apierror.Error(w, "No dni passed", http.StatusBadRequest)
+		return
+	}
+	// The body is optional; without one the invitation gets the default expiry.
+	var req ReopenRegistrationRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil && err != io.EOF {
+		apierror.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	expiresAt, ok := invitationExpiry(w, req.ExpiresAt)
+	if !ok {
+		return
+	}
+	invitedBy, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		apierror.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	_, err = registrationHandler.userRepo.GetUserByDNI(r.Context(), dni)
+	if err == nil {
+		apierror.Error(w, "An account with this DNI already exists", http.StatusConflict)
+		return
+	}
+	if err != database.ErrUserNotFound {
+		apierror.Respond(w, err)
+		return
+	}
+
+	err = registrationHandler.allowedRegistRepo.ReopenDNI(r.Context(), dni, invitedBy, expiresAt)
+	if err == database.ErrDNINotFound {
+		apierror.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func invitationExpiry(w http.ResponseWriter, expiresAt *time.Time) (*time.Time, bool) {
+	if expiresAt == nil {
+		defaultExpiry := time.Now().UTC().Add(defaultInvitationTTL)
+		return &defaultExpiry, true
+	}
+	if !expiresAt.After(time.Now()) {
+		apierror.Validation(w, "expiresAt", "Expiry must be in the future")
+		return nil, false
+	}
+	utc := expiresAt.UTC()
+	return &utc, true
+}
@@ -0,0 +1,43 @@
+package handler_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+	"vetsys/internal/domain"
+	"vetsys/internal/handler"
+)
+
+func TestRegistrations_CreateReportsRepeatsAsSkipped(t *testing.T) {
+	app := newTestApp(t)
+	admin := app.signIn(t, domain.RoleAdmin)
+
+	rec := app.do(t, admin, http.MethodPost, "/api/registrations", map[string]any{"dnis": []string{"11111111A", "22222222B", "11111111A"}})
+	expectStatus(t, rec, http.StatusCreated)
+	var response handler.CreateRegistrationsResponse
+	decodeBody(t, rec, &response)
+	if len(response.Created) != 2 || len(response.Skipped) != 1 || response.Skipped[0] != "11111111A" {
+		t.Errorf("Expected two created and the repeat skipped, got %+v", response)
+	}
+}
+
+func TestRegistrations_ReopenWithoutBody(t *testing.T) {
+	app := newTestApp(t)
+	admin := app.signIn(t, domain.RoleAdmin)
+	expectStatus(t, app.do(t, admin, http.MethodPost, "/api/registrations", map[string]any{"dnis": []string{"11111111A"}}), http.StatusCreated)
+	expectStatus(t, app.do(t, admin, http.MethodDelete, "/api/registrations/11111111A", nil), http.StatusNoContent)
+
+	expectStatus(t, app.do(t, admin, http.MethodPost, "/api/registrations/11111111A/reopen", nil), http.StatusNoContent)
+	registrations, err := app.db.AllowedRegistrationsRepo.GetRegistrations(context.Background(), nil, 10, 0)
+	if err != nil {
+		t.Fatalf("Failed to get registrations: %v", err)
+	}
+	if len(registrations) != 1 || registrations[0].RevokedAt != nil || registrations[0].ExpiresAt == nil ||
+		registrations[0].ExpiresAt.Before(time.Now().Add(6*24*time.Hour)) {
+		t.Errorf("Expected the invitation reopened with the default expiry, got %+v", registrations)
+	}
+
+	rec := app.do(t, admin, http.MethodPost, "/api/registrations/11111111A/reopen", "{")
+	expectStatus(t, rec, http.StatusBadRequest)
+}
@@ -0,0 +1,124 @@
+package handler_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"testing"
+	"vetsys/internal/apierror"
+	"vetsys/internal/database/memory"
+	"vetsys/internal/domain"
+)
+
+// captureLogs sends the default logger's output to a buffer for the rest of
+// the test. Call it before newTestApp, which builds the request logger.
+func captureLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, nil)))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+	return &buf
+}
+
+func requestLogLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var lines []map[string]any
+	scanner := bufio.NewScanner(buf)
+	for scanner.Scan() {
+		var line map[string]any
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("Failed to decode log line %q: %v", scanner.Text(), err)
+		}
+		if line["msg"] == "request" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+func TestRequestLog(t *testing.T) {
+	logs := captureLogs(t)
+	app := newTestApp(t)
+	cookie := app.signIn(t, domain.RoleReceptionist)
+	logs.Reset()
+
+	rec := app.do(t, cookie, http.MethodPost, "/api/clients", map[string]string{
+		"dni": "12345678A", "name": "John Doe", "phoneNumber": "+34600111222",
+	})
+	expectStatus(t, rec, http.StatusCreated)
+	var client domain.Client
+	decodeBody(t, rec, &client)
+	requestID := rec.Header().Get(apierror.RequestIDHeader)
+
+	lines := requestLogLines(t, logs)
+	if len(lines) != 1 {
+		t.Fatalf("Expected one request log line, got %d", len(lines))
+	}
+	line := lines[0]
+	if line["request_id"] != requestID || line["method"] != "POST" || line["pattern"] != "POST /api/clients" {
+		t.Errorf("Expected POST /api/clients for request %s, got %v", requestID, line)
+	}
+	if line["status"] != float64(http.StatusCreated) || line["bytes"] == float64(0) {
+		t.Errorf("Expected status 201 with a body, got %v", line)
+	}
+	if _, ok := line["user_id"]; !ok {
+		t.Errorf("Expected the authenticated user ID, got %v", line)
+	}
+
+	entries, err := app.db.AuditRepo.GetEntries(context.Background(), databaseAuditFilter(domain.AuditEntityClient, client.ID), 10, 0)
+	if err != nil {
+		t.Fatalf("Failed to get audit entries: %v", err)
+	}
+	if len(entries) != 1 || entries[0].RequestID != requestID {
+		t.Errorf("Expected the audit entry to carry request ID %s, got %+v", requestID, entries)
+	}
+}
+
+// brokenClients is a client store whose lookups fail as if the database were down.
+type brokenClients struct {
+	*memory.ClientRepository
+}
+
+func (brokenClients) GetClientByID(ctx context.Context, id int64) (*domain.Client, error) {
+	return nil, errors.New("connection reset by peer")
+}
+
+func TestRequestLog_InternalError(t *testing.T) {
+	logs := captureLogs(t)
+	app := newTestAppWithClients(t, brokenClients{})
+	cookie := app.signIn(t, domain.RoleReceptionist)
+	logs.Reset()
+
+	expectStatus(t, app.do(t, cookie, http.MethodGet, "/api/clients/1", nil), http.StatusInternalServerError)
+
+	lines := requestLogLines(t, logs)
+	if len(lines) != 1 {
+		t.Fatalf("Expected one request log line, got %d", len(lines))
+	}
+	if lines[0]["level"] != "ERROR" || lines[0]["error"] != "connection reset by peer" {
+		t.Errorf("Expected the error on the request's log line, got %v", lines[0])
+	}
+}
+
+func TestRequestLog_Unauthenticated(t *testing.T) {
+	logs := captureLogs(t)
+	app := newTestApp(t)
+
+	expectStatus(t, app.do(t, nil, http.MethodGet, "/api/clients/1", nil), http.StatusUnauthorized)
+
+	lines := requestLogLines(t, logs)
+	if len(lines) != 1 {
+		t.Fatalf("Expected one request log line, got %d", len(lines))
+	}
+	if _, ok := lines[0]["user_id"]; ok {
+		t.Errorf("Expected no user ID before authentication, got %v", lines[0])
+	}
+	if lines[0]["status"] != float64(http.StatusUnauthorized) {
+		t.Errorf("Expected status 401, got %v", lines[0]["status"])
+	}
+}
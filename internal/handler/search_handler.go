@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"vetsys/internal/apierror"
+	"vetsys/internal/database"
+	"vetsys/internal/domain"
+	"vetsys/internal/middleware"
+	"vetsys/internal/utils"
+)
+
+// maxSearchQueryLength bounds the q parameter of /api/search.
+const maxSearchQueryLength = 200
+
+type SearchHandler struct {
+	searchRepo database.SearchStore
+}
+
+// SearchResponse groups results by entity type. A group is omitted when the
+// caller's role cannot read that entity or a type filter excludes it.
+type SearchResponse struct {
+	Clients       *PaginatedResponse `json:"clients,omitempty"`
+	Patients      *PaginatedResponse `json:"patients,omitempty"`
+	Consultations *PaginatedResponse `json:"consultations,omitempty"`
+}
+
+func NewSearchHandler(searchRepo database.SearchStore) *SearchHandler {
+	return &SearchHandler{
+		searchRepo: searchRepo,
+	}
+}
+
+func (searchHandler *SearchHandler) SearchHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query().Get("q")
+	if q == "" {
+		apierror.Validation(w, "q", "q parameter is required")
+		return
+	}
+	if len(q) > maxSearchQueryLength {
+		apierror.Validation(w, "q", "q parameter is too long")
+		return
+	}
+	entityType := r.URL.Query().Get("type")
+	if _, ok := searchPermissions[entityType]; entityType != "" && !ok {
+		apierror.Validation(w, "type", "Invalid type. Must be clients, patients or consultations")
+		return
+	}
+	role, ok := middleware.GetUserRole(r.Context())
+	if !ok {
+		apierror.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	include := func(name string) bool {
+		return (entityType == "" || entityType == name) && role.Can(searchPermissions[name])
+	}
+	if entityType != "" && !include(entityType) {
+		apierror.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	ctx := r.Context()
+	limit, offset := utils.Pagination(r)
+	var response SearchResponse
+	var err error
+
+	if include("clients") {
+		response.Clients, err = searchGroup(limit, offset,
+			func() (int64, error) { return searchHandler.searchRepo.SearchClientsCount(ctx, q) },
+			func() (any, error) { return searchHandler.searchRepo.SearchClients(ctx, q, limit, offset) })
+		if err != nil {
+			writeSearchError(w, err)
+			return
+		}
+	}
+	if include("patients") {
+		response.Patients, err = searchGroup(limit, offset,
+			func() (int64, error) { return searchHandler.searchRepo.SearchPatientsCount(ctx, q) },
+			func() (any, error) { return searchHandler.searchRepo.SearchPatients(ctx, q, limit, offset) })
+		if err != nil {
+			writeSearchError(w, err)
+			return
+		}
+	}
+	if include("consultations") {
+		response.Consultations, err = searchGroup(limit, offset,
+			func() (int64, error) { return searchHandler.searchRepo.SearchConsultationsCount(ctx, q) },
+			func() (any, error) { return searchHandler.searchRepo.SearchConsultations(ctx, q, limit, offset) })
+		if err != nil {
+			writeSearchError(w, err)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+var searchPermissions = map[string]domain.Permission{
+	"clients":       domain.PermClientsRead,
+	"patients":      domain.PermPatientsRead,
+	"consultations": domain.PermConsultationsRead,
+}
+
+// searchGroup builds one page of results for an entity type. The listing is
+// skipped when nothing matches so empty groups cost a single query.
+func searchGroup(limit int, offset int, count func() (int64, error), list func() (any, error)) (*PaginatedResponse, error) {
+	total, err := count()
+	if err != nil {
+		return nil, err
+	}
+	response := &PaginatedResponse{
+		Data:       []any{},
+		Page:       (offset / limit) + 1,
+		Limit:      limit,
+		Total:      total,
+		TotalPages: int((total + int64(limit) - 1) / int64(limit)),
+	}
+	if total == 0 {
+		return response, nil
+	}
+	data, err := list()
+	if err != nil {
+		return nil, err
+	}
+	response.Data = data
+	return response, nil
+}
+
+func writeSearchError(w http.ResponseWriter, err error) {
+	if err == database.ErrEmptySearchQuery {
+		apierror.Validation(w, "q", "q must contain at least one letter or digit")
+		return
+	}
+	apierror.Respond(w, err)
+}
@@ -0,0 +1,179 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+	"vetsys/internal/domain"
+	"vetsys/internal/handler"
+)
+
+func TestSessionHandler_ListAndRevoke(t *testing.T) {
+	app := newTestApp(t)
+	current := app.signIn(t, domain.RoleVeterinarian)
+	me, err := app.db.SessionRepo.GetSession(context.Background(), current.Value)
+	if err != nil {
+		t.Fatalf("Failed to get session: %v", err)
+	}
+	other, err := domain.NewSession(me.UserID, time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	other.IP = "203.0.113.7"
+	other.UserAgent = "Firefox"
+	if err := app.db.SessionRepo.CreateSession(context.Background(), other); err != nil {
+		t.Fatalf("Failed to store session: %v", err)
+	}
+	stranger := app.signIn(t, domain.RoleVeterinarian)
+
+	rec := app.do(t, current, http.MethodGet, "/api/auth/sessions", nil)
+	expectStatus(t, rec, http.StatusOK)
+	var sessions []handler.SessionResponse
+	decodeBody(t, rec, &sessions)
+	if len(sessions) != 2 {
+		t.Fatalf("Expected 2 sessions, got %+v", sessions)
+	}
+	var otherID string
+	for _, session := range sessions {
+		if session.ID == current.Value || session.ID == other.ID {
+			t.Fatal("Expected sessions to be listed by public ID, not by cookie value")
+		}
+		if session.Current != (session.ID == me.PublicID()) {
+			t.Errorf("Expected only the requesting session to be current, got %+v", session)
+		}
+		if session.UserAgent == "Firefox" {
+			otherID = session.ID
+			if session.IP != "203.0.113.7" {
+				t.Errorf("Expected the session IP, got %q", session.IP)
+			}
+		}
+	}
+
+	// Sessions of other users are not found.
+	expectStatus(t, app.do(t, stranger, http.MethodDelete, "/api/auth/sessions/"+otherID, nil), http.StatusNotFound)
+
+	expectStatus(t, app.do(t, current, http.MethodDelete, "/api/auth/sessions/"+otherID, nil), http.StatusNoContent)
+	if _, err := app.db.SessionRepo.GetSession(context.Background(), other.ID); err == nil {
+		t.Error("Expected the revoked session to be gone")
+	}
+	expectStatus(t, app.do(t, current, http.MethodDelete, "/api/auth/sessions/"+otherID, nil), http.StatusNotFound)
+	expectStatus(t, app.do(t, current, http.MethodGet, "/api/auth/me", nil), http.StatusOK)
+}
+
+func TestSessionHandler_RevokeOtherSessions(t *testing.T) {
+	app := newTestApp(t)
+	current := app.signIn(t, domain.RoleReceptionist)
+	me, err := app.db.SessionRepo.GetSession(context.Background(), current.Value)
+	if err != nil {
+		t.Fatalf("Failed to get session: %v", err)
+	}
+	var others []*domain.Session
+	for range 2 {
+		session, _ := domain.NewSession(me.UserID, time.Hour)
+		if err := app.db.SessionRepo.CreateSession(context.Background(), session); err != nil {
+			t.Fatalf("Failed to store session: %v", err)
+		}
+		others = append(others, session)
+	}
+	stranger := app.signIn(t, domain.RoleReceptionist)
+
+	rec := app.do(t, current, http.MethodDelete, "/api/auth/sessions", nil)
+	expectStatus(t, rec, http.StatusOK)
+	var response map[string]int64
+	decodeBody(t, rec, &response)
+	if response["revoked"] != 2 {
+		t.Errorf("Expected 2 sessions revoked, got %v", response)
+	}
+	for _, session := range others {
+		cookie := &http.Cookie{Name: "session_id", Value: session.ID}
+		expectStatus(t, app.do(t, cookie, http.MethodGet, "/api/auth/me", nil), http.StatusUnauthorized)
+	}
+	expectStatus(t, app.do(t, current, http.MethodGet, "/api/auth/me", nil), http.StatusOK)
+	expectStatus(t, app.do(t, stranger, http.MethodGet, "/api/auth/me", nil), http.StatusOK)
+}
+
+func TestSessionHandler_SlidingExpiry(t *testing.T) {
+	app := newTestApp(t)
+	cookie := app.signIn(t, domain.RoleReadOnly)
+	session, err := app.db.SessionRepo.GetSession(context.Background(), cookie.Value)
+	if err != nil {
+		t.Fatalf("Failed to get session: %v", err)
+	}
+
+	// Used an hour ago and about to expire: activity renews it.
+	now := time.Now().UTC()
+	if err := app.db.SessionRepo.TouchSession(context.Background(), session.ID, now.Add(-time.Hour), now.Add(time.Minute)); err != nil {
+		t.Fatalf("Failed to touch session: %v", err)
+	}
+	expectStatus(t, app.do(t, cookie, http.MethodGet, "/api/auth/me", nil), http.StatusOK)
+	renewed, err := app.db.SessionRepo.GetSession(context.Background(), session.ID)
+	if err != nil {
+		t.Fatalf("Failed to get session: %v", err)
+	}
+	if renewed.ExpiresAt.Before(now.Add(handler.DefaultSessionDuration - time.Minute)) {
+		t.Errorf("Expected the expiry to slide forward, got %v", renewed.ExpiresAt)
+	}
+	if !renewed.LastSeenAt.After(now.Add(-time.Minute)) {
+		t.Errorf("Expected the last use to be recorded, got %v", renewed.LastSeenAt)
+	}
+
+	// Never past the maximum lifetime.
+	old, _ := domain.NewSession(session.UserID, time.Hour)
+	old.CreatedAt = now.Add(-handler.DefaultSessionMaxLifetime + time.Hour)
+	old.LastSeenAt = now.Add(-time.Hour)
+	if err := app.db.SessionRepo.CreateSession(context.Background(), old); err != nil {
+		t.Fatalf("Failed to store session: %v", err)
+	}
+	expectStatus(t, app.do(t, &http.Cookie{Name: "session_id", Value: old.ID}, http.MethodGet, "/api/auth/me", nil), http.StatusOK)
+	capped, err := app.db.SessionRepo.GetSession(context.Background(), old.ID)
+	if err != nil {
+		t.Fatalf("Failed to get session: %v", err)
+	}
+	if !capped.ExpiresAt.Equal(old.CreatedAt.Add(handler.DefaultSessionMaxLifetime)) {
+		t.Errorf("Expected the expiry to stop at the maximum lifetime, got %v", capped.ExpiresAt)
+	}
+}
+
+func TestSessionHandler_PasswordChangeRevokesAll(t *testing.T) {
+	app := newTestApp(t)
+	current := app.signIn(t, domain.RoleVeterinarian)
+	me, err := app.db.SessionRepo.GetSession(context.Background(), current.Value)
+	if err != nil {
+		t.Fatalf("Failed to get session: %v", err)
+	}
+	other, err := domain.NewSession(me.UserID, time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	if err := app.db.SessionRepo.CreateSession(context.Background(), other); err != nil {
+		t.Fatalf("Failed to store session: %v", err)
+	}
+	bystander := app.signIn(t, domain.RoleVeterinarian)
+
+	path := fmt.Sprintf("/api/users/%d/password", me.UserID)
+	expectStatus(t, app.do(t, current, http.MethodPut, path, map[string]string{"password": "NewSecret123!"}), http.StatusOK)
+	for _, id := range []string{current.Value, other.ID} {
+		if _, err := app.db.SessionRepo.GetSession(context.Background(), id); err == nil {
+			t.Errorf("Expected session %s to be revoked", id)
+		}
+	}
+	expectStatus(t, app.do(t, bystander, http.MethodGet, "/api/auth/me", nil), http.StatusOK)
+
+	entries, err := app.db.AuditRepo.GetEntries(context.Background(), databaseAuditFilter(domain.AuditEntityUser, me.UserID), 10, 0)
+	if err != nil {
+		t.Fatalf("Failed to get audit entries: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("Expected the password change to be audited, got %+v", entries)
+	}
+	var changes map[string]domain.FieldChange
+	if err := json.Unmarshal(entries[0].Changes, &changes); err != nil {
+		t.Fatalf("Failed to decode changes: %v", err)
+	}
+	if changes["sessions_revoked"].After != float64(2) {
+		t.Errorf("Expected 2 sessions revoked, got %s", entries[0].Changes)
+	}
+}
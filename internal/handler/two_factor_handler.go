@@ -0,0 +1,610 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+	"vetsys/internal/apierror"
+	"vetsys/internal/database"
+	"vetsys/internal/domain"
+	"vetsys/internal/middleware"
+	"vetsys/internal/notify"
+	"vetsys/internal/totp"
+)
+
+// MaxChallengeAttempts is how many wrong codes a login challenge takes before
+// it is dropped and the login must start over with the password.
+const MaxChallengeAttempts = 5
+
+// TwoFactorChallengeResponse answers a login that passed the password check
+// but still needs its second factor. Token is presented with the code to
+// finish signing in.
+type TwoFactorChallengeResponse struct {
+	TwoFactorRequired bool `json:"twoFactorRequired"`
+	// EnrollmentRequired is set for users whose role requires two-factor
+	// authentication but who have not set it up: they enrol with the token,
+	// and their first code signs them in.
+	EnrollmentRequired bool      `json:"enrollmentRequired"`
+	Token              string    `json:"token"`
+	ExpiresAt          time.Time `json:"expiresAt"`
+}
+type TwoFactorLoginRequest struct {
+	Token        string `json:"token"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recoveryCode"`
+}
+type TwoFactorEnrollLoginRequest struct {
+	Token string `json:"token"`
+}
+type TwoFactorCodeRequest struct {
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recoveryCode"`
+}
+
+// TwoFactorEnrollmentResponse is a pending enrolment. URI is what
+// authenticator apps import, usually shown as a QR code; Secret is for typing
+// in by hand.
+type TwoFactorEnrollmentResponse struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+type TwoFactorStatusResponse struct {
+	Enabled           bool       `json:"enabled"`
+	Pending           bool       `json:"pending"`
+	Required          bool       `json:"required"`
+	EnabledAt         *time.Time `json:"enabledAt"`
+	RecoveryCodesLeft int64      `json:"recoveryCodesLeft"`
+}
+
+// RecoveryCodesResponse carries newly issued recovery codes. They are only
+// ever shown this once.
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+type TwoFactorPolicy struct {
+	Roles []domain.Role `json:"roles"`
+}
+
+// twoFactorStatus reports whether user has two-factor authentication enabled
+// and whether their role requires it.
+func (userHandler *UserHandler) twoFactorStatus(r *http.Request, user *domain.User) (bool, bool, error) {
+	enabled := false
+	twoFactor, err := userHandler.TwoFactorRepo.GetTwoFactor(r.Context(), user.ID)
+	if err == nil {
+		enabled = twoFactor.IsEnabled()
+	} else if err != database.ErrTwoFactorNotFound {
+		return false, false, err
+	}
+	roles, err := userHandler.TwoFactorRepo.GetRequiredRoles(r.Context())
+	if err != nil {
+		return false, false, err
+	}
+	return enabled, slices.Contains(roles, user.Role), nil
+}
+
+// startChallenge answers a login that passed the password check with a
+// short-lived token for the second step, instead of a session.
+func (userHandler *UserHandler) startChallenge(w http.ResponseWriter, r *http.Request, user *domain.User, enroll bool) {
+	token, challenge, err := domain.NewLoginChallenge(user.ID, userHandler.ChallengeTTL)
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	err = userHandler.ChallengeRepo.CreateChallenge(r.Context(), challenge)
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(TwoFactorChallengeResponse{
+		TwoFactorRequired:  true,
+		EnrollmentRequired: enroll,
+		Token:              token,
+		ExpiresAt:          challenge.ExpiresAt,
+	})
+}
+
+// challengeUser returns the user of the login challenge of token, answering
+// 401 if it is unknown or expired.
+func (userHandler *UserHandler) challengeUser(w http.ResponseWriter, r *http.Request, token string) (*domain.User, bool) {
+	if token == "" {
+		apierror.Validation(w, "token", "Token is required")
+		return nil, false
+	}
+	challenge, err := userHandler.ChallengeRepo.GetChallenge(r.Context(), domain.HashToken(token))
+	if err == database.ErrChallengeInvalid {
+		apierror.Error(w, err.Error(), http.StatusUnauthorized)
+		return nil, false
+	}
+	if err != nil {
+		apierror.Respond(w, err)
+		return nil, false
+	}
+	user, err := userHandler.UserRepo.GetUserByID(r.Context(), challenge.UserID)
+	if err != nil {
+		apierror.Respond(w, err)
+		return nil, false
+	}
+	return user, true
+}
+
+// LogInTwoFactorHandler finishes a login with a code from the user's
+// authenticator app or one of their recovery codes. For users enrolling at
+// login, the first code confirms the enrolment and the response carries
+// their recovery codes.
+func (userHandler *UserHandler) LogInTwoFactorHandler(w http.ResponseWriter, r *http.Request) {
+	var req TwoFactorLoginRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		apierror.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if req.Code == "" && req.RecoveryCode == "" {
+		apierror.Validation(w, "code", "Code or recovery code is required")
+		return
+	}
+	user, ok := userHandler.challengeUser(w, r, req.Token)
+	if !ok {
+		return
+	}
+	now := time.Now().UTC()
+	lockedUntil, err := userHandler.lockedUntil(r, user.DNI, now)
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	if lockedUntil != nil {
+		respondLocked(w, *lockedUntil, now)
+		return
+	}
+	tokenHash := domain.HashToken(req.Token)
+	twoFactor, err := userHandler.TwoFactorRepo.GetTwoFactor(r.Context(), user.ID)
+	if err == database.ErrTwoFactorNotFound {
+		apierror.Error(w, "Set up two-factor authentication before signing in", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+
+	var recoveryCodes []string
+	if twoFactor.IsEnabled() {
+		valid, err := userHandler.verifySecondFactor(r, twoFactor, req.Code, req.RecoveryCode)
+		if err != nil {
+			apierror.Respond(w, err)
+			return
+		}
+		if !valid {
+			userHandler.challengeFailed(w, r, tokenHash, user, now)
+			return
+		}
+	} else {
+		step, valid := totp.Validate(twoFactor.Secret, req.Code, now)
+		if !valid {
+			userHandler.challengeFailed(w, r, tokenHash, user, now)
+			return
+		}
+		recoveryCodes, ok = userHandler.enableTwoFactor(w, r, user, step, twoFactorEnabledAtLogin)
+		if !ok {
+			return
+		}
+	}
+
+	err = userHandler.ChallengeRepo.DeleteChallenge(r.Context(), tokenHash)
+	if err == database.ErrChallengeInvalid {
+		apierror.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	userHandler.startSession(w, r, user, recoveryCodes)
+}
+
+// challengeFailed counts a wrong code against the login challenge and, like a
+// wrong password, against the user's DNI and the client's IP, so that new
+// challenges do not give unlimited guesses at the second factor.
+func (userHandler *UserHandler) challengeFailed(w http.ResponseWriter, r *http.Request, tokenHash string, user *domain.User, now time.Time) {
+	err := userHandler.ChallengeRepo.FailChallenge(r.Context(), tokenHash, MaxChallengeAttempts)
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	until, err := userHandler.recordLoginFailure(r, user.DNI, user, now)
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	if until != nil {
+		respondLocked(w, *until, now)
+		return
+	}
+	apierror.Error(w, "Invalid code", http.StatusUnauthorized)
+}
+
+// LogInEnrollHandler starts the two-factor enrolment of a user whose role
+// requires it, with the token of their login challenge.
+func (userHandler *UserHandler) LogInEnrollHandler(w http.ResponseWriter, r *http.Request) {
+	var req TwoFactorEnrollLoginRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		apierror.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	user, ok := userHandler.challengeUser(w, r, req.Token)
+	if !ok {
+		return
+	}
+	userHandler.beginEnrollment(w, r, user)
+}
+
+// beginEnrollment gives user a new secret, replacing any pending enrolment.
+func (userHandler *UserHandler) beginEnrollment(w http.ResponseWriter, r *http.Request, user *domain.User) {
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	err = userHandler.TwoFactorRepo.SaveTwoFactorSecret(r.Context(), user.ID, secret)
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(TwoFactorEnrollmentResponse{
+		Secret: secret,
+		URI:    totp.URI(userHandler.TwoFactorIssuer, user.Email, secret),
+	})
+}
+
+// Where a user turned two-factor authentication on, as recorded in the audit
+// log. At login the user has only shown their password.
+const (
+	twoFactorEnabledAtLogin   = "login"
+	twoFactorEnabledInAccount = "account"
+)
+
+// enableTwoFactor confirms the user's pending enrolment with the code of step
+// and returns their new recovery codes. The user is told by the notifier, so
+// that someone who enrolled with a stolen password does not go unnoticed.
+func (userHandler *UserHandler) enableTwoFactor(w http.ResponseWriter, r *http.Request, user *domain.User, step int64, via string) ([]string, bool) {
+	codes, hashes, err := domain.NewRecoveryCodes(domain.RecoveryCodeCount)
+	if err != nil {
+		apierror.Respond(w, err)
+		return nil, false
+	}
+	err = userHandler.TwoFactorRepo.EnableTwoFactor(r.Context(), user.ID, step, hashes)
+	if err != nil {
+		apierror.Respond(w, err)
+		return nil, false
+	}
+	userHandler.Auditor.Record(r, domain.AuditUpdate, domain.AuditEntityUser, user.ID, nil, map[string]string{"two_factor": "enabled", "via": via})
+	err = userHandler.Notifier.Notify(r.Context(), notify.Message{
+		To:      user.Email,
+		Subject: "Verificación en dos pasos activada",
+		Body: fmt.Sprintf("Hola %s:\n\nSe ha activado la verificación en dos pasos en tu cuenta desde la IP %s.\n\nSi no fuiste tú, alguien conoce tu contraseña: pide a un administrador que la restablezca y cambia tu contraseña.",
+			user.Name, clientIP(r)),
+	})
+	if err != nil {
+		requestID, _ := middleware.GetRequestID(r.Context())
+		slog.ErrorContext(r.Context(), "two-factor: failed to notify user", "request_id", requestID, "user_id", user.ID, "error", err)
+	}
+	return codes, true
+}
+
+// verifySecondFactor checks a code from the authenticator app of an enabled
+// enrolment, or else a recovery code, using it up.
+func (userHandler *UserHandler) verifySecondFactor(r *http.Request, twoFactor *domain.TwoFactor, code string, recoveryCode string) (bool, error) {
+	var err error
+	switch {
+	case code != "":
+		step, valid := totp.Validate(twoFactor.Secret, code, time.Now())
+		if !valid {
+			return false, nil
+		}
+		err = userHandler.TwoFactorRepo.UseTOTPStep(r.Context(), twoFactor.UserID, step)
+		if err == database.ErrTOTPCodeUsed {
+			return false, nil
+		}
+	case recoveryCode != "":
+		err = userHandler.TwoFactorRepo.UseRecoveryCode(r.Context(), twoFactor.UserID, domain.HashRecoveryCode(recoveryCode))
+		if err == database.ErrRecoveryCodeInvalid {
+			return false, nil
+		}
+	default:
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// currentUser returns the signed-in user.
+func (userHandler *UserHandler) currentUser(w http.ResponseWriter, r *http.Request) (*domain.User, bool) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		apierror.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return nil, false
+	}
+	user, err := userHandler.UserRepo.GetUserByID(r.Context(), userID)
+	if err != nil {
+		apierror.Respond(w, err)
+		return nil, false
+	}
+	return user, true
+}
+
+func (userHandler *UserHandler) GetTwoFactorHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := userHandler.currentUser(w, r)
+	if !ok {
+		return
+	}
+	_, required, err := userHandler.twoFactorStatus(r, user)
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	response := TwoFactorStatusResponse{Required: required}
+	twoFactor, err := userHandler.TwoFactorRepo.GetTwoFactor(r.Context(), user.ID)
+	if err != nil && err != database.ErrTwoFactorNotFound {
+		apierror.Respond(w, err)
+		return
+	}
+	if err == nil {
+		response.Enabled = twoFactor.IsEnabled()
+		response.Pending = !twoFactor.IsEnabled()
+		response.EnabledAt = twoFactor.EnabledAt
+	}
+	response.RecoveryCodesLeft, err = userHandler.TwoFactorRepo.CountRecoveryCodes(r.Context(), user.ID)
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// EnrollTwoFactorHandler starts a two-factor enrolment for the signed-in
+// user. It takes effect once confirmed with a first code.
+func (userHandler *UserHandler) EnrollTwoFactorHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := userHandler.currentUser(w, r)
+	if !ok {
+		return
+	}
+	userHandler.beginEnrollment(w, r, user)
+}
+
+// ConfirmTwoFactorHandler enables the signed-in user's pending enrolment with
+// a first code from their authenticator app, returning their recovery codes.
+func (userHandler *UserHandler) ConfirmTwoFactorHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := userHandler.currentUser(w, r)
+	if !ok {
+		return
+	}
+	var req TwoFactorCodeRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		apierror.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	twoFactor, err := userHandler.TwoFactorRepo.GetTwoFactor(r.Context(), user.ID)
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	if twoFactor.IsEnabled() {
+		apierror.Respond(w, database.ErrTwoFactorEnabled)
+		return
+	}
+	now := time.Now().UTC()
+	if !userHandler.checkCodeLockout(w, r, user, now) {
+		return
+	}
+	step, valid := totp.Validate(twoFactor.Secret, req.Code, now)
+	if !valid {
+		userHandler.codeFailed(w, r, user, now)
+		return
+	}
+	codes, ok := userHandler.enableTwoFactor(w, r, user, step, twoFactorEnabledInAccount)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// RegenerateRecoveryCodesHandler replaces the signed-in user's recovery
+// codes, voiding the old ones.
+func (userHandler *UserHandler) RegenerateRecoveryCodesHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := userHandler.currentUser(w, r)
+	if !ok {
+		return
+	}
+	twoFactor, ok := userHandler.verifyRequest(w, r, user)
+	if !ok {
+		return
+	}
+	codes, hashes, err := domain.NewRecoveryCodes(domain.RecoveryCodeCount)
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	err = userHandler.TwoFactorRepo.ReplaceRecoveryCodes(r.Context(), twoFactor.UserID, hashes)
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	userHandler.Auditor.Record(r, domain.AuditUpdate, domain.AuditEntityUser, user.ID, nil, map[string]string{"recovery_codes": "regenerated"})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// DisableTwoFactorHandler turns off two-factor authentication for the
+// signed-in user, unless their role requires it.
+func (userHandler *UserHandler) DisableTwoFactorHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := userHandler.currentUser(w, r)
+	if !ok {
+		return
+	}
+	_, required, err := userHandler.twoFactorStatus(r, user)
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	if required {
+		apierror.Error(w, "Forbidden: Your role requires two-factor authentication", http.StatusForbidden)
+		return
+	}
+	twoFactor, ok := userHandler.verifyRequest(w, r, user)
+	if !ok {
+		return
+	}
+	err = userHandler.TwoFactorRepo.DeleteTwoFactor(r.Context(), twoFactor.UserID)
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	userHandler.Auditor.Record(r, domain.AuditUpdate, domain.AuditEntityUser, user.ID, nil, map[string]string{"two_factor": "disabled"})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// verifyRequest returns the enabled enrolment of user after checking the
+// code or recovery code in the request body against it.
+func (userHandler *UserHandler) verifyRequest(w http.ResponseWriter, r *http.Request, user *domain.User) (*domain.TwoFactor, bool) {
+	var req TwoFactorCodeRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		apierror.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return nil, false
+	}
+	if req.Code == "" && req.RecoveryCode == "" {
+		apierror.Validation(w, "code", "Code or recovery code is required")
+		return nil, false
+	}
+	twoFactor, err := userHandler.TwoFactorRepo.GetTwoFactor(r.Context(), user.ID)
+	if err == nil && !twoFactor.IsEnabled() {
+		err = database.ErrTwoFactorNotFound
+	}
+	if err != nil {
+		apierror.Respond(w, err)
+		return nil, false
+	}
+	now := time.Now().UTC()
+	if !userHandler.checkCodeLockout(w, r, user, now) {
+		return nil, false
+	}
+	valid, err := userHandler.verifySecondFactor(r, twoFactor, req.Code, req.RecoveryCode)
+	if err != nil {
+		apierror.Respond(w, err)
+		return nil, false
+	}
+	if !valid {
+		userHandler.codeFailed(w, r, user, now)
+		return nil, false
+	}
+	return twoFactor, true
+}
+
+// checkCodeLockout answers 429 and returns false if the signed-in user's DNI
+// or the client's IP is locked out, before any code of theirs is checked.
+func (userHandler *UserHandler) checkCodeLockout(w http.ResponseWriter, r *http.Request, user *domain.User, now time.Time) bool {
+	lockedUntil, err := userHandler.lockedUntil(r, user.DNI, now)
+	if err != nil {
+		apierror.Respond(w, err)
+		return false
+	}
+	if lockedUntil != nil {
+		respondLocked(w, *lockedUntil, now)
+		return false
+	}
+	return true
+}
+
+// codeFailed counts a wrong code sent by a signed-in user like a failed
+// login, so that a stolen session cannot guess the second factor without
+// limit.
+func (userHandler *UserHandler) codeFailed(w http.ResponseWriter, r *http.Request, user *domain.User, now time.Time) {
+	until, err := userHandler.recordLoginFailure(r, user.DNI, user, now)
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	if until != nil {
+		respondLocked(w, *until, now)
+		return
+	}
+	apierror.Validation(w, "code", "Invalid code")
+}
+
+// ResetTwoFactorHandler removes a user's two-factor enrolment, for users who
+// lost both their authenticator and their recovery codes. Users whose role
+// requires it enrol again at their next login.
+func (userHandler *UserHandler) ResetTwoFactorHandler(w http.ResponseWriter, r *http.Request) {
+	idValue, err := strconv.ParseInt(r.PathValue("user_id"), 10, 64)
+	if err != nil {
+		apierror.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	err = userHandler.TwoFactorRepo.DeleteTwoFactor(r.Context(), idValue)
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	userHandler.Auditor.Record(r, domain.AuditUpdate, domain.AuditEntityUser, idValue, nil, map[string]string{"two_factor": "reset"})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (userHandler *UserHandler) GetTwoFactorPolicyHandler(w http.ResponseWriter, r *http.Request) {
+	roles, err := userHandler.TwoFactorRepo.GetRequiredRoles(r.Context())
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(TwoFactorPolicy{Roles: roles})
+}
+
+// UpdateTwoFactorPolicyHandler sets the roles whose users must sign in with
+// a second factor. Users of those roles without it enrol at their next login,
+// on the strength of their password alone: until they have, the policy does
+// not protect them from someone who knows it, who is only found out by the
+// notice the user gets when it is enabled.
+func (userHandler *UserHandler) UpdateTwoFactorPolicyHandler(w http.ResponseWriter, r *http.Request) {
+	var req TwoFactorPolicy
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		apierror.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if req.Roles == nil {
+		req.Roles = []domain.Role{}
+	}
+	for _, role := range req.Roles {
+		if !role.IsValid() {
+			apierror.Validation(w, "roles", "Invalid role. Must be ADMIN, VETERINARIAN, RECEPTIONIST or READ_ONLY")
+			return
+		}
+	}
+	before, err := userHandler.TwoFactorRepo.GetRequiredRoles(r.Context())
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	err = userHandler.TwoFactorRepo.SetRequiredRoles(r.Context(), req.Roles)
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	userHandler.Auditor.Record(r, domain.AuditUpdate, domain.AuditEntityTwoFactorPolicy, 0, TwoFactorPolicy{Roles: before}, req)
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,260 @@
+package handler_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"vetsys/internal/domain"
+	"vetsys/internal/handler"
+	"vetsys/internal/totp"
+)
+
+func sessionCookie(rec *httptest.ResponseRecorder) *http.Cookie {
+	for _, c := range rec.Result().Cookies() {
+		if c.Name == "session_id" {
+			return c
+		}
+	}
+	return nil
+}
+
+// totpCode returns the code of secret at time step step.
+func totpCode(t *testing.T, secret string, step int64) string {
+	t.Helper()
+	code, err := totp.Code(secret, step)
+	if err != nil {
+		t.Fatalf("Failed to compute code: %v", err)
+	}
+	return code
+}
+
+// challenge logs in from ip and returns the challenge the login answers with.
+func (app *testApp) challenge(t *testing.T, ip string, dni string, password string) handler.TwoFactorChallengeResponse {
+	t.Helper()
+	rec := app.logIn(t, ip, dni, password)
+	expectStatus(t, rec, http.StatusOK)
+	if sessionCookie(rec) != nil {
+		t.Fatal("Expected no session before the second factor")
+	}
+	var challenge handler.TwoFactorChallengeResponse
+	decodeBody(t, rec, &challenge)
+	if !challenge.TwoFactorRequired || challenge.Token == "" {
+		t.Fatalf("Expected a two-factor challenge, got %+v", challenge)
+	}
+	return challenge
+}
+
+func TestTwoFactor_EnrollAndLogIn(t *testing.T) {
+	app := newTestApp(t)
+	app.createUser(t, "12345678A", "Secret123!")
+	rec := app.logIn(t, "198.51.100.1", "12345678A", "Secret123!")
+	expectStatus(t, rec, http.StatusOK)
+	cookie := sessionCookie(rec)
+
+	rec = app.do(t, cookie, http.MethodPost, "/api/auth/2fa/enroll", nil)
+	expectStatus(t, rec, http.StatusOK)
+	var enrollment handler.TwoFactorEnrollmentResponse
+	decodeBody(t, rec, &enrollment)
+	if enrollment.Secret == "" || enrollment.URI == "" {
+		t.Fatalf("Expected a secret and URI, got %+v", enrollment)
+	}
+	step := totp.Step(time.Now())
+	expectStatus(t, app.do(t, cookie, http.MethodPost, "/api/auth/2fa/enroll/confirm", map[string]string{"code": "000000"}), http.StatusBadRequest)
+	rec = app.do(t, cookie, http.MethodPost, "/api/auth/2fa/enroll/confirm", map[string]string{"code": totpCode(t, enrollment.Secret, step)})
+	expectStatus(t, rec, http.StatusOK)
+	var codes handler.RecoveryCodesResponse
+	decodeBody(t, rec, &codes)
+	if len(codes.RecoveryCodes) != domain.RecoveryCodeCount {
+		t.Fatalf("Expected %d recovery codes, got %v", domain.RecoveryCodeCount, codes.RecoveryCodes)
+	}
+	expectStatus(t, app.do(t, cookie, http.MethodPost, "/api/auth/2fa/enroll", nil), http.StatusConflict)
+
+	// The code that confirmed the enrolment cannot be used again.
+	challenge := app.challenge(t, "198.51.100.2", "12345678A", "Secret123!")
+	rec = app.post(t, "198.51.100.2", "/api/auth/login/2fa", map[string]string{"token": challenge.Token, "code": totpCode(t, enrollment.Secret, step)})
+	expectStatus(t, rec, http.StatusUnauthorized)
+	rec = app.post(t, "198.51.100.2", "/api/auth/login/2fa", map[string]string{"token": challenge.Token, "code": totpCode(t, enrollment.Secret, step+1)})
+	expectStatus(t, rec, http.StatusOK)
+	if sessionCookie(rec) == nil {
+		t.Fatal("Expected a session_id cookie after the second factor")
+	}
+	rec = app.post(t, "198.51.100.3", "/api/auth/login/2fa", map[string]string{"token": challenge.Token, "code": totpCode(t, enrollment.Secret, step+1)})
+	expectStatus(t, rec, http.StatusUnauthorized)
+
+	// Recovery codes work once each, typed however.
+	recoveryCode := codes.RecoveryCodes[0]
+	challenge = app.challenge(t, "198.51.100.4", "12345678A", "Secret123!")
+	rec = app.post(t, "198.51.100.4", "/api/auth/login/2fa", map[string]string{"token": challenge.Token, "recoveryCode": " " + recoveryCode[:4] + recoveryCode[5:] + " "})
+	expectStatus(t, rec, http.StatusOK)
+	challenge = app.challenge(t, "198.51.100.5", "12345678A", "Secret123!")
+	rec = app.post(t, "198.51.100.5", "/api/auth/login/2fa", map[string]string{"token": challenge.Token, "recoveryCode": recoveryCode})
+	expectStatus(t, rec, http.StatusUnauthorized)
+
+	rec = app.do(t, cookie, http.MethodGet, "/api/auth/2fa", nil)
+	expectStatus(t, rec, http.StatusOK)
+	var status handler.TwoFactorStatusResponse
+	decodeBody(t, rec, &status)
+	if !status.Enabled || status.Required || status.RecoveryCodesLeft != domain.RecoveryCodeCount-1 {
+		t.Errorf("Expected 2FA enabled with one recovery code used, got %+v", status)
+	}
+
+	expectStatus(t, app.do(t, cookie, http.MethodDelete, "/api/auth/2fa", map[string]string{"code": "000000"}), http.StatusBadRequest)
+	expectStatus(t, app.do(t, cookie, http.MethodDelete, "/api/auth/2fa", map[string]string{"recoveryCode": codes.RecoveryCodes[1]}), http.StatusNoContent)
+	rec = app.logIn(t, "198.51.100.6", "12345678A", "Secret123!")
+	expectStatus(t, rec, http.StatusOK)
+	if sessionCookie(rec) == nil {
+		t.Error("Expected a password-only login once 2FA is disabled")
+	}
+}
+
+func TestTwoFactor_RequiredByRole(t *testing.T) {
+	app := newTestApp(t)
+	admin := app.signIn(t, domain.RoleAdmin)
+	vet := app.createUser(t, "12345678A", "Secret123!")
+	vet.Role = domain.RoleVeterinarian
+	if err := app.db.UserRepo.UpdateUserRole(context.Background(), vet.ID, vet.Role); err != nil {
+		t.Fatalf("Failed to set role: %v", err)
+	}
+
+	expectStatus(t, app.do(t, admin, http.MethodPut, "/api/auth/2fa/policy", map[string]any{"roles": []string{"NOBODY"}}), http.StatusBadRequest)
+	expectStatus(t, app.do(t, admin, http.MethodPut, "/api/auth/2fa/policy", map[string]any{"roles": []string{"VETERINARIAN"}}), http.StatusNoContent)
+	rec := app.do(t, admin, http.MethodGet, "/api/auth/2fa/policy", nil)
+	expectStatus(t, rec, http.StatusOK)
+	var policy handler.TwoFactorPolicy
+	decodeBody(t, rec, &policy)
+	if len(policy.Roles) != 1 || policy.Roles[0] != domain.RoleVeterinarian {
+		t.Fatalf("Expected the policy to require VETERINARIAN, got %+v", policy)
+	}
+
+	challenge := app.challenge(t, "198.51.100.1", "12345678A", "Secret123!")
+	if !challenge.EnrollmentRequired {
+		t.Fatalf("Expected enrolment to be required, got %+v", challenge)
+	}
+	rec = app.post(t, "198.51.100.1", "/api/auth/login/2fa", map[string]string{"token": challenge.Token, "code": "000000"})
+	expectStatus(t, rec, http.StatusConflict)
+	rec = app.post(t, "198.51.100.1", "/api/auth/login/2fa/enroll", map[string]string{"token": challenge.Token})
+	expectStatus(t, rec, http.StatusOK)
+	var enrollment handler.TwoFactorEnrollmentResponse
+	decodeBody(t, rec, &enrollment)
+	step := totp.Step(time.Now())
+
+	rec = app.post(t, "198.51.100.2", "/api/auth/login/2fa", map[string]string{"token": challenge.Token, "code": totpCode(t, enrollment.Secret, step)})
+	expectStatus(t, rec, http.StatusOK)
+	cookie := sessionCookie(rec)
+	if cookie == nil {
+		t.Fatal("Expected the first code to sign in")
+	}
+	var login handler.LoginResponse
+	decodeBody(t, rec, &login)
+	if login.User == nil || login.ID != vet.ID || len(login.RecoveryCodes) != domain.RecoveryCodeCount {
+		t.Fatalf("Expected the user and their recovery codes, got %+v", login)
+	}
+	// Enrolling takes only the password, so the user is told about it.
+	if message := app.outbox.last(t); message.To != vet.Email || !strings.Contains(message.Body, "198.51.100.2") {
+		t.Errorf("Expected the user to be notified of the enrolment, got %+v", message)
+	}
+	entries, err := app.db.AuditRepo.GetEntries(context.Background(), databaseAuditFilter(domain.AuditEntityUser, vet.ID), 10, 0)
+	if err != nil {
+		t.Fatalf("Failed to get audit entries: %v", err)
+	}
+	if len(entries) == 0 || !strings.Contains(string(entries[0].Changes), `"login"`) {
+		t.Errorf("Expected the enrolment at login to be audited, got %+v", entries)
+	}
+
+	rec = app.do(t, cookie, http.MethodDelete, "/api/auth/2fa", map[string]string{"code": totpCode(t, enrollment.Secret, step+1)})
+	expectStatus(t, rec, http.StatusForbidden)
+
+	// An admin reset makes the user enrol again.
+	expectStatus(t, app.do(t, cookie, http.MethodDelete, fmt.Sprintf("/api/users/%d/2fa", vet.ID), nil), http.StatusForbidden)
+	expectStatus(t, app.do(t, admin, http.MethodDelete, fmt.Sprintf("/api/users/%d/2fa", vet.ID), nil), http.StatusNoContent)
+	expectStatus(t, app.do(t, admin, http.MethodDelete, fmt.Sprintf("/api/users/%d/2fa", vet.ID), nil), http.StatusNotFound)
+	if challenge := app.challenge(t, "198.51.100.3", "12345678A", "Secret123!"); !challenge.EnrollmentRequired {
+		t.Errorf("Expected enrolment to be required again, got %+v", challenge)
+	}
+}
+
+// enableTwoFactor turns two-factor authentication on for user and returns
+// the secret.
+func (app *testApp) enableTwoFactor(t *testing.T, user *domain.User) string {
+	t.Helper()
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		t.Fatalf("Failed to generate secret: %v", err)
+	}
+	if err := app.db.TwoFactorRepo.SaveTwoFactorSecret(context.Background(), user.ID, secret); err != nil {
+		t.Fatalf("Failed to save secret: %v", err)
+	}
+	if err := app.db.TwoFactorRepo.EnableTwoFactor(context.Background(), user.ID, 0, nil); err != nil {
+		t.Fatalf("Failed to enable 2FA: %v", err)
+	}
+	return secret
+}
+
+func TestTwoFactor_WrongAccountCodesCountTowardsLockout(t *testing.T) {
+	app := newTestApp(t)
+	app.userHandler.DNILockout = domain.LockoutPolicy{Threshold: 3, Duration: time.Minute, MaxDuration: time.Hour}
+	user := app.createUser(t, "12345678A", "Secret123!")
+	secret := app.enableTwoFactor(t, user)
+	session, err := domain.NewSession(user.ID, time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	if err := app.db.SessionRepo.CreateSession(context.Background(), session); err != nil {
+		t.Fatalf("Failed to store session: %v", err)
+	}
+	cookie := &http.Cookie{Name: "session_id", Value: session.ID}
+
+	expectStatus(t, app.do(t, cookie, http.MethodDelete, "/api/auth/2fa", map[string]string{"code": "000000"}), http.StatusBadRequest)
+	expectStatus(t, app.do(t, cookie, http.MethodPost, "/api/auth/2fa/recovery-codes", map[string]string{"recoveryCode": "wrong"}), http.StatusBadRequest)
+	expectStatus(t, app.do(t, cookie, http.MethodDelete, "/api/auth/2fa", map[string]string{"recoveryCode": "wrong"}), http.StatusTooManyRequests)
+	// Not even the right code gets through until the lockout ends.
+	rec := app.do(t, cookie, http.MethodDelete, "/api/auth/2fa", map[string]string{"code": totpCode(t, secret, totp.Step(time.Now()))})
+	expectStatus(t, rec, http.StatusTooManyRequests)
+	if rec.Header().Get("Retry-After") == "" {
+		t.Error("Expected a Retry-After header")
+	}
+	expectStatus(t, app.do(t, cookie, http.MethodGet, "/api/auth/2fa", nil), http.StatusOK)
+	expectStatus(t, app.logIn(t, "198.51.100.3", "12345678A", "Secret123!"), http.StatusTooManyRequests)
+}
+
+func TestTwoFactor_ChallengeAttempts(t *testing.T) {
+	app := newTestApp(t)
+	app.userHandler.DNILockout = domain.LockoutPolicy{Threshold: 100, Duration: time.Minute, MaxDuration: time.Hour}
+	user := app.createUser(t, "12345678A", "Secret123!")
+	secret := app.enableTwoFactor(t, user)
+
+	challenge := app.challenge(t, "198.51.100.1", "12345678A", "Secret123!")
+	for i := range handler.MaxChallengeAttempts {
+		ip := fmt.Sprintf("198.51.100.%d", 10+i)
+		rec := app.post(t, ip, "/api/auth/login/2fa", map[string]string{"token": challenge.Token, "recoveryCode": "wrong"})
+		expectStatus(t, rec, http.StatusUnauthorized)
+	}
+	// The challenge is gone, right code or not.
+	rec := app.post(t, "198.51.100.2", "/api/auth/login/2fa", map[string]string{"token": challenge.Token, "code": totpCode(t, secret, totp.Step(time.Now()))})
+	expectStatus(t, rec, http.StatusUnauthorized)
+}
+
+func TestTwoFactor_WrongCodesCountTowardsLockout(t *testing.T) {
+	app := newTestApp(t)
+	app.userHandler.DNILockout = domain.LockoutPolicy{Threshold: 3, Duration: time.Minute, MaxDuration: time.Hour}
+	user := app.createUser(t, "12345678A", "Secret123!")
+	secret := app.enableTwoFactor(t, user)
+
+	// A fresh challenge does not start the count over.
+	for i, ip := range []string{"198.51.100.1", "198.51.100.2"} {
+		challenge := app.challenge(t, ip, "12345678A", "Secret123!")
+		rec := app.post(t, ip, "/api/auth/login/2fa", map[string]string{"token": challenge.Token, "recoveryCode": "wrong"})
+		expectStatus(t, rec, http.StatusUnauthorized)
+		if i == 1 {
+			rec = app.post(t, ip, "/api/auth/login/2fa", map[string]string{"token": challenge.Token, "recoveryCode": "wrong"})
+			expectStatus(t, rec, http.StatusTooManyRequests)
+			rec = app.post(t, ip, "/api/auth/login/2fa", map[string]string{"token": challenge.Token, "code": totpCode(t, secret, totp.Step(time.Now()))})
+			expectStatus(t, rec, http.StatusTooManyRequests)
+		}
+	}
+	expectStatus(t, app.logIn(t, "198.51.100.3", "12345678A", "Secret123!"), http.StatusTooManyRequests)
+}
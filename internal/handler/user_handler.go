@@ -3,33 +3,85 @@ package handler
 import (
 	"encoding/json"
 	"net/http"
-	"os"
 	"regexp"
 	"strconv"
 	"time"
+	"vetsys/internal/apierror"
 	"vetsys/internal/database"
 	"vetsys/internal/domain"
 	"vetsys/internal/middleware"
+	"vetsys/internal/notify"
 
 	"golang.org/x/crypto/bcrypt"
 )
 
+// Defaults of the settings NewUserHandler does not take as arguments.
+const (
+	DefaultSessionDuration    = 24 * time.Hour
+	DefaultSessionMaxLifetime = 7 * 24 * time.Hour
+	DefaultBcryptCost         = 14
+	DefaultLoginChallengeTTL  = 5 * time.Minute
+	DefaultTwoFactorIssuer    = "VetSys"
+)
+
+var (
+	DefaultDNILockout = domain.LockoutPolicy{Threshold: 5, Duration: time.Minute, MaxDuration: time.Hour}
+	// DefaultIPLockout allows more failures than DefaultDNILockout because a
+	// whole clinic often signs in from one IP.
+	DefaultIPLockout = domain.LockoutPolicy{Threshold: 20, Duration: time.Minute, MaxDuration: time.Hour}
+)
+
 type UserHandler struct {
-	UserRepo          *database.UserRepository
-	SessionRepo       *database.SessionRepository
-	AllowedRegistRepo *database.AllowedRegistrationRepository
+	UserRepo          database.UserStore
+	SessionRepo       database.SessionStore
+	AllowedRegistRepo database.AllowedRegistrationStore
+	LockoutRepo       database.LoginLockoutStore
+	TwoFactorRepo     database.TwoFactorStore
+	ChallengeRepo     database.LoginChallengeStore
+	// Notifier tells users when two-factor authentication is turned on for
+	// their account.
+	Notifier notify.Notifier
+	Auditor  *Auditor
+	// SessionDuration is how long a session lasts unused; activity extends it
+	// up to SessionMaxLifetime after sign-in.
+	SessionDuration    time.Duration
+	SessionMaxLifetime time.Duration
+	BcryptCost         int
+	// SecureCookies marks session cookies Secure; only localhost development
+	// over plain HTTP turns it off.
+	SecureCookies bool
+	// DNILockout and IPLockout lock logins after repeated failures for the
+	// same DNI or from the same client IP.
+	DNILockout domain.LockoutPolicy
+	IPLockout  domain.LockoutPolicy
+	// ChallengeTTL is how long a login that passed the password check has to
+	// present its second factor.
+	ChallengeTTL time.Duration
+	// TwoFactorIssuer names the service in authenticator apps.
+	TwoFactorIssuer string
 }
 
-func NewUserHandler(userRepo *database.UserRepository, sessionRepo *database.SessionRepository, allowedRegistrationsRepo *database.AllowedRegistrationRepository) *UserHandler {
+func NewUserHandler(userRepo database.UserStore, sessionRepo database.SessionStore, allowedRegistrationsRepo database.AllowedRegistrationStore, lockoutRepo database.LoginLockoutStore, twoFactorRepo database.TwoFactorStore, challengeRepo database.LoginChallengeStore, notifier notify.Notifier, auditor *Auditor) *UserHandler {
 	return &UserHandler{
-		UserRepo:          userRepo,
-		SessionRepo:       sessionRepo,
-		AllowedRegistRepo: allowedRegistrationsRepo,
+		UserRepo:           userRepo,
+		SessionRepo:        sessionRepo,
+		AllowedRegistRepo:  allowedRegistrationsRepo,
+		LockoutRepo:        lockoutRepo,
+		TwoFactorRepo:      twoFactorRepo,
+		ChallengeRepo:      challengeRepo,
+		Notifier:           notifier,
+		Auditor:            auditor,
+		SessionDuration:    DefaultSessionDuration,
+		SessionMaxLifetime: DefaultSessionMaxLifetime,
+		BcryptCost:         DefaultBcryptCost,
+		SecureCookies:      true,
+		DNILockout:         DefaultDNILockout,
+		IPLockout:          DefaultIPLockout,
+		ChallengeTTL:       DefaultLoginChallengeTTL,
+		TwoFactorIssuer:    DefaultTwoFactorIssuer,
 	}
 }
 
-var isProduction bool = os.Getenv("ENV") == "production"
-
 type CreateUserRequest struct {
 	DNI            string `json:"dni"`
 	Email          string `json:"email"`
@@ -41,9 +93,19 @@ type LoginRequest struct {
 	DNI      string `json:"dni"`
 	Password string `json:"password"`
 }
+
+// LoginResponse is the signed-in user. RecoveryCodes are only set by a login
+// that completed a two-factor enrolment, and are not shown again.
+type LoginResponse struct {
+	*domain.User
+	RecoveryCodes []string `json:"recoveryCodes,omitempty"`
+}
 type UpdatePasswordRequest struct {
 	Password string `json:"password"`
 }
+type UpdateRoleRequest struct {
+	Role domain.Role `json:"role"`
+}
 type UserUpdate struct {
 	Email          *string `json:"email"`
 	Name           *string `json:"name"`
@@ -54,27 +116,65 @@ func (UserHandler *UserHandler) LogInHandler(w http.ResponseWriter, r *http.Requ
 	var loginRequest LoginRequest
 	err := json.NewDecoder(r.Body).Decode(&loginRequest)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apierror.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	user, err := UserHandler.UserRepo.GetUserByDNI(loginRequest.DNI)
+	now := time.Now().UTC()
+	lockedUntil, err := UserHandler.lockedUntil(r, loginRequest.DNI, now)
 	if err != nil {
-		http.Error(w, "Invalid credentials", http.StatusUnauthorized)
+		apierror.Respond(w, err)
+		return
+	}
+	if lockedUntil != nil {
+		respondLocked(w, *lockedUntil, now)
+		return
+	}
+	user, err := UserHandler.UserRepo.GetUserByDNI(r.Context(), loginRequest.DNI)
+	if err == database.ErrUserNotFound {
+		UserHandler.loginFailed(w, r, loginRequest.DNI, nil, now)
+		return
+	}
+	if err != nil {
+		apierror.Respond(w, err)
 		return
 	}
 	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(loginRequest.Password))
 	if err != nil {
-		http.Error(w, "Invalid credentials", http.StatusUnauthorized)
+		UserHandler.loginFailed(w, r, loginRequest.DNI, user, now)
+		return
+	}
+	enabled, required, err := UserHandler.twoFactorStatus(r, user)
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	if enabled || required {
+		UserHandler.startChallenge(w, r, user, !enabled)
+		return
+	}
+	UserHandler.startSession(w, r, user, nil)
+}
+
+// startSession signs user in, setting the session cookie, and answers with
+// the user and any recovery codes just issued to them. It forgets the failed
+// logins of the user's DNI, but not of the IP: one valid account must not
+// reset them.
+func (userHandler *UserHandler) startSession(w http.ResponseWriter, r *http.Request, user *domain.User, recoveryCodes []string) {
+	err := userHandler.LockoutRepo.DeleteLockout(r.Context(), domain.LockoutScopeDNI, user.DNI)
+	if err != nil && err != database.ErrLockoutNotFound {
+		apierror.Respond(w, err)
 		return
 	}
-	session, err := domain.NewSession(user.ID, 24*time.Hour)
+	session, err := domain.NewSession(user.ID, userHandler.SessionDuration)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.Respond(w, err)
 		return
 	}
-	err = UserHandler.SessionRepo.CreateSession(session)
+	session.IP = clientIP(r)
+	session.UserAgent = truncate(r.UserAgent(), maxUserAgentLength)
+	err = userHandler.SessionRepo.CreateSession(r.Context(), session)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.Respond(w, err)
 		return
 	}
 	http.SetCookie(w, &http.Cookie{
@@ -82,67 +182,90 @@ func (UserHandler *UserHandler) LogInHandler(w http.ResponseWriter, r *http.Requ
 		Value:    session.ID,
 		Path:     "/",
 		HttpOnly: true,
-		Secure:   isProduction, // false only in localhost dev
+		Secure:   userHandler.SecureCookies,
+		SameSite: http.SameSiteStrictMode,
+		// The server ends idle sessions; the cookie lasts as long as activity can keep one alive.
+		Expires: session.CreatedAt.Add(userHandler.SessionMaxLifetime),
+	})
+	// Readable by the page's scripts, which echo it in a header on
+	// state-changing requests.
+	http.SetCookie(w, &http.Cookie{
+		Name:     middleware.CSRFCookie,
+		Value:    session.CSRFToken,
+		Path:     "/",
+		Secure:   userHandler.SecureCookies,
 		SameSite: http.SameSiteStrictMode,
-		Expires:  session.ExpiresAt,
+		Expires:  session.CreatedAt.Add(userHandler.SessionMaxLifetime),
 	})
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(user)
+	json.NewEncoder(w).Encode(LoginResponse{User: user, RecoveryCodes: recoveryCodes})
 }
 
 func (userHandler *UserHandler) LogOutHandler(w http.ResponseWriter, r *http.Request) {
 	cookie, err := r.Cookie("session_id")
 	if err != nil {
-		http.Error(w, "No active session", http.StatusUnauthorized)
+		apierror.Error(w, "No active session", http.StatusUnauthorized)
 		return
 	}
 
-	err = userHandler.SessionRepo.DeleteSessionByID(cookie.Value)
+	err = userHandler.SessionRepo.DeleteSessionByID(r.Context(), cookie.Value)
 	if err != nil && err != database.ErrSessionNotFound {
-		http.Error(w, "Server error", http.StatusInternalServerError)
+		apierror.Error(w, "Server error", http.StatusInternalServerError)
 		return
 	}
 
+	userHandler.clearSessionCookies(w)
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(`{"message":"logged out"}`))
+}
+
+// clearSessionCookies tells the browser to drop the session and CSRF cookies.
+func (userHandler *UserHandler) clearSessionCookies(w http.ResponseWriter) {
 	http.SetCookie(w, &http.Cookie{
 		Name:     "session_id",
 		Value:    "",
 		Path:     "/",
 		HttpOnly: true,
-		Secure:   isProduction,
+		Secure:   userHandler.SecureCookies,
+		SameSite: http.SameSiteStrictMode,
+		MaxAge:   -1,
+	})
+	http.SetCookie(w, &http.Cookie{
+		Name:     middleware.CSRFCookie,
+		Value:    "",
+		Path:     "/",
+		Secure:   userHandler.SecureCookies,
 		SameSite: http.SameSiteStrictMode,
 		MaxAge:   -1,
 	})
-
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte(`{"message":"logged out"}`))
 }
 
 func (userHandler *UserHandler) CreateUserHandler(w http.ResponseWriter, r *http.Request) {
 	var req CreateUserRequest
 	err := json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apierror.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
 	if req.Name == "" {
-		http.Error(w, "Name is required", http.StatusBadRequest)
+		apierror.Validation(w, "name", "Name is required")
 		return
 	}
 
 	if req.Email == "" {
-		http.Error(w, "Email is required", http.StatusBadRequest)
+		apierror.Validation(w, "email", "Email is required")
 		return
 	}
 
 	if !isValidEmail(req.Email) {
-		http.Error(w, "Invalid email format", http.StatusBadRequest)
+		apierror.Validation(w, "email", "Invalid email format")
 		return
 	}
 
 	if req.DNI == "" {
-		http.Error(w, "DNI is required", http.StatusBadRequest)
+		apierror.Validation(w, "dni", "DNI is required")
 		return
 	}
 
@@ -151,33 +274,36 @@ func (userHandler *UserHandler) CreateUserHandler(w http.ResponseWriter, r *http
 	}
 
 	if !isValidPassword(req.Password) {
-		http.Error(w, "Invalid password", http.StatusBadRequest)
+		apierror.Validation(w, "password", "Invalid password")
 		return
 	}
-	if err := userHandler.AllowedRegistRepo.UseDNI(req.DNI); err != nil {
-		http.Error(w, "Invalid DNI", http.StatusBadRequest)
+	role, err := userHandler.AllowedRegistRepo.UseDNI(r.Context(), req.DNI)
+	if err != nil {
+		apierror.Validation(w, "dni", "Invalid DNI")
 		return
 	}
 
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), 14)
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), userHandler.BcryptCost)
 	if err != nil {
-		http.Error(w, "Failed to process password", http.StatusInternalServerError)
+		apierror.Error(w, "Failed to process password", http.StatusInternalServerError)
 		return
 	}
 	req.Password = string(hashedPassword)
 
 	user := domain.NewUser(req.DNI, req.Email, req.Password, req.Name, req.ProfilePicture)
+	user.Role = role
 
-	err = userHandler.UserRepo.CreateUser(user)
+	err = userHandler.UserRepo.CreateUser(r.Context(), user)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.Respond(w, err)
 		return
 	}
+	userHandler.Auditor.Record(r, domain.AuditCreate, domain.AuditEntityUser, user.ID, nil, user)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	err = json.NewEncoder(w).Encode(user)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.Respond(w, err)
 		return
 	}
 
@@ -188,15 +314,25 @@ func (userHandler *UserHandler) DeleteUserHandler(w http.ResponseWriter, r *http
 	if !ok {
 		return
 	}
-	err := userHandler.UserRepo.DeleteUserByID(idValue)
+	user, err := userHandler.UserRepo.GetUserByID(r.Context(), idValue)
+	if err == database.ErrUserNotFound {
+		apierror.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	err = userHandler.UserRepo.DeleteUserByID(r.Context(), idValue)
 	if err == database.ErrUserNotFound {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		apierror.Error(w, err.Error(), http.StatusNotFound)
 		return
 	}
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.Respond(w, err)
 		return
 	}
+	userHandler.Auditor.Record(r, domain.AuditDelete, domain.AuditEntityUser, user.ID, user, nil)
 
 	w.WriteHeader(http.StatusNoContent)
 }
@@ -209,33 +345,34 @@ func (userHandler *UserHandler) UpdateUserHandler(w http.ResponseWriter, r *http
 	var userUpdate UserUpdate
 	err := json.NewDecoder(r.Body).Decode(&userUpdate)
 	if err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		apierror.Error(w, "Invalid JSON", http.StatusBadRequest)
 		return
 	}
 
-	user, err := userHandler.UserRepo.GetUserByID(idValue)
+	user, err := userHandler.UserRepo.GetUserByID(r.Context(), idValue)
 	if err == database.ErrUserNotFound {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		apierror.Error(w, err.Error(), http.StatusNotFound)
 		return
 	}
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.Respond(w, err)
 		return
 	}
+	before := *user
 	if userUpdate.Email != nil {
 		if *userUpdate.Email == "" {
-			http.Error(w, "Email cannot be empty", http.StatusBadRequest)
+			apierror.Validation(w, "email", "Email cannot be empty")
 			return
 		}
 		if !isValidEmail(*userUpdate.Email) {
-			http.Error(w, "Invalid email format", http.StatusBadRequest)
+			apierror.Validation(w, "email", "Invalid email format")
 			return
 		}
 		user.Email = *userUpdate.Email
 	}
 	if userUpdate.Name != nil {
 		if *userUpdate.Name == "" {
-			http.Error(w, "Name cannot be empty", http.StatusBadRequest)
+			apierror.Validation(w, "name", "Name cannot be empty")
 			return
 		}
 		user.Name = *userUpdate.Name
@@ -244,15 +381,16 @@ func (userHandler *UserHandler) UpdateUserHandler(w http.ResponseWriter, r *http
 		user.ProfilePicture = *userUpdate.ProfilePicture
 	}
 
-	err = userHandler.UserRepo.UpdateUser(user)
+	err = userHandler.UserRepo.UpdateUser(r.Context(), user)
 	if err == database.ErrUserNotFound {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		apierror.Error(w, err.Error(), http.StatusNotFound)
 		return
 	}
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.Respond(w, err)
 		return
 	}
+	userHandler.Auditor.Record(r, domain.AuditUpdate, domain.AuditEntityUser, user.ID, &before, user)
 	w.WriteHeader(http.StatusNoContent)
 }
 
@@ -265,41 +403,110 @@ func (userHandler *UserHandler) UpdatePasswordHandler(w http.ResponseWriter, r *
 	var req UpdatePasswordRequest
 	err := json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		apierror.Error(w, "Invalid JSON", http.StatusBadRequest)
 		return
 	}
 
 	if !isValidPassword(req.Password) {
-		http.Error(w, "Invalid Password", http.StatusBadRequest)
+		apierror.Validation(w, "password", "Invalid Password")
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), userHandler.BcryptCost)
+	if err != nil {
+		apierror.Error(w, "Failed to process password", http.StatusInternalServerError)
+		return
+	}
+	err = userHandler.UserRepo.UpdatePassword(r.Context(), idValue, string(hashedPassword))
+	if err == database.ErrUserNotFound {
+		apierror.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	// A new password signs the user out everywhere, this session included, as
+	// a reset does.
+	revoked, err := userHandler.SessionRepo.DeleteSessionsByUserID(r.Context(), idValue, "")
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	// The hash itself never goes in the audit log, only the fact that it changed.
+	userHandler.Auditor.Record(r, domain.AuditUpdate, domain.AuditEntityUser, idValue, nil, map[string]any{"password": "changed", "sessions_revoked": revoked})
+	userHandler.clearSessionCookies(w)
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(`{"message":"logged out"}`))
+}
+
+func (userHandler *UserHandler) UpdateUserRoleHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("user_id")
+	if id == "" {
+		apierror.Error(w, "No id passed", http.StatusBadRequest)
+		return
+	}
+	idValue, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		apierror.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), 14)
+	var req UpdateRoleRequest
+	err = json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
-		http.Error(w, "Failed to process password", http.StatusInternalServerError)
+		apierror.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if !req.Role.IsValid() {
+		apierror.Validation(w, "role", "Invalid role. Must be ADMIN, VETERINARIAN, RECEPTIONIST or READ_ONLY")
+		return
+	}
+
+	sessionUserID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		apierror.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
-	err = userHandler.UserRepo.UpdatePassword(idValue, string(hashedPassword))
+	if sessionUserID == idValue {
+		apierror.Error(w, "Forbidden: You cannot change your own role", http.StatusForbidden)
+		return
+	}
+
+	user, err := userHandler.UserRepo.GetUserByID(r.Context(), idValue)
 	if err == database.ErrUserNotFound {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		apierror.Error(w, err.Error(), http.StatusNotFound)
 		return
 	}
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.Respond(w, err)
 		return
 	}
-	userHandler.LogOutHandler(w, r)
+	before := *user
+
+	err = userHandler.UserRepo.UpdateUserRole(r.Context(), idValue, req.Role)
+	if err == database.ErrUserNotFound {
+		apierror.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	user.Role = req.Role
+	userHandler.Auditor.Record(r, domain.AuditUpdate, domain.AuditEntityUser, user.ID, &before, user)
+	w.WriteHeader(http.StatusNoContent)
 }
 
 func (userHandler *UserHandler) MeHandler(w http.ResponseWriter, r *http.Request) {
 	userID, ok := middleware.GetUserID(r.Context())
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		apierror.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
-	user, err := userHandler.UserRepo.GetUserByID(userID)
+	user, err := userHandler.UserRepo.GetUserByID(r.Context(), userID)
 	if err != nil {
-		http.Error(w, "User not found", http.StatusNotFound)
+		apierror.Error(w, "User not found", http.StatusNotFound)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
@@ -309,24 +516,24 @@ func (userHandler *UserHandler) MeHandler(w http.ResponseWriter, r *http.Request
 func (userHandler *UserHandler) authorizeUserAccess(w http.ResponseWriter, r *http.Request) (int64, bool) {
 	id := r.PathValue("user_id")
 	if id == "" {
-		http.Error(w, "No id passed", http.StatusBadRequest)
+		apierror.Error(w, "No id passed", http.StatusBadRequest)
 		return 0, false
 	}
 
 	pathUserID, err := strconv.ParseInt(id, 10, 64)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apierror.Error(w, err.Error(), http.StatusBadRequest)
 		return 0, false
 	}
 
 	sessionUserID, ok := middleware.GetUserID(r.Context())
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		apierror.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return 0, false
 	}
 
 	if sessionUserID != pathUserID {
-		http.Error(w, "Forbidden: You can only modify your own account", http.StatusForbidden)
+		apierror.Error(w, "Forbidden: You can only modify your own account", http.StatusForbidden)
 		return 0, false
 	}
 	return pathUserID, true
@@ -0,0 +1,96 @@
+package handler_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"vetsys/internal/domain"
+	"vetsys/internal/handler"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestUserHandler_LogInAndOut(t *testing.T) {
+	app := newTestApp(t)
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte("Secret123!"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("Failed to hash password: %v", err)
+	}
+	user := domain.NewUser("12345678A", "john@example.com", string(hashedPassword), "John Doe", "")
+	if err := app.db.UserRepo.CreateUser(context.Background(), user); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	rec := app.do(t, nil, http.MethodPost, "/api/auth/login", map[string]string{"dni": "12345678A", "password": "wrong"})
+	expectStatus(t, rec, http.StatusUnauthorized)
+
+	rec = app.do(t, nil, http.MethodPost, "/api/auth/login", map[string]string{"dni": "12345678A", "password": "Secret123!"})
+	expectStatus(t, rec, http.StatusOK)
+	var cookie *http.Cookie
+	for _, c := range rec.Result().Cookies() {
+		if c.Name == "session_id" {
+			cookie = c
+		}
+	}
+	if cookie == nil {
+		t.Fatal("Expected a session_id cookie")
+	}
+
+	rec = app.do(t, cookie, http.MethodGet, "/api/auth/me", nil)
+	expectStatus(t, rec, http.StatusOK)
+	var me domain.User
+	decodeBody(t, rec, &me)
+	if me.ID != user.ID {
+		t.Errorf("Expected user ID %d, got %d", user.ID, me.ID)
+	}
+
+	rec = app.do(t, cookie, http.MethodGet, "/api/auth/sessions", nil)
+	expectStatus(t, rec, http.StatusOK)
+	var sessions []handler.SessionResponse
+	decodeBody(t, rec, &sessions)
+	if len(sessions) != 1 || !sessions[0].Current || sessions[0].IP != "192.0.2.1" {
+		t.Errorf("Expected the login's session with its IP, got %+v", sessions)
+	}
+
+	expectStatus(t, app.do(t, cookie, http.MethodPost, "/api/auth/logout", nil), http.StatusOK)
+	expectStatus(t, app.do(t, cookie, http.MethodGet, "/api/auth/me", nil), http.StatusUnauthorized)
+}
+
+func TestUserHandler_OnlyOwnAccount(t *testing.T) {
+	app := newTestApp(t)
+	cookie := app.signIn(t, domain.RoleVeterinarian)
+	other := app.signIn(t, domain.RoleReceptionist)
+	session, err := app.db.SessionRepo.GetSession(context.Background(), other.Value)
+	if err != nil {
+		t.Fatalf("Failed to get session: %v", err)
+	}
+
+	rec := app.do(t, cookie, http.MethodDelete, fmt.Sprintf("/api/users/%d", session.UserID), nil)
+	expectStatus(t, rec, http.StatusForbidden)
+}
+
+func TestUserHandler_UpdateRoleRequiresAdmin(t *testing.T) {
+	app := newTestApp(t)
+	target := app.signIn(t, domain.RoleReadOnly)
+	session, err := app.db.SessionRepo.GetSession(context.Background(), target.Value)
+	if err != nil {
+		t.Fatalf("Failed to get session: %v", err)
+	}
+	path := fmt.Sprintf("/api/users/%d/role", session.UserID)
+	request := map[string]domain.Role{"role": domain.RoleVeterinarian}
+
+	vet := app.signIn(t, domain.RoleVeterinarian)
+	expectStatus(t, app.do(t, vet, http.MethodPut, path, request), http.StatusForbidden)
+
+	admin := app.signIn(t, domain.RoleAdmin)
+	expectStatus(t, app.do(t, admin, http.MethodPut, path, request), http.StatusNoContent)
+
+	user, err := app.db.UserRepo.GetUserByID(context.Background(), session.UserID)
+	if err != nil {
+		t.Fatalf("Failed to get user: %v", err)
+	}
+	if user.Role != domain.RoleVeterinarian {
+		t.Errorf("Expected role %s, got %s", domain.RoleVeterinarian, user.Role)
+	}
+}
@@ -0,0 +1,377 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+	"vetsys/internal/apierror"
+	"vetsys/internal/database"
+	"vetsys/internal/domain"
+	"vetsys/internal/middleware"
+	"vetsys/internal/utils"
+)
+
+// Reminder windows for GET /api/vaccinations/due, in days.
+const (
+	defaultDueWithinDays = 30
+	maxDueWithinDays     = 365
+)
+
+type VaccinationHandler struct {
+	vaccinationRepo database.VaccinationStore
+	auditor         *Auditor
+}
+
+type VaccineRequest struct {
+	Name        string           `json:"name"`
+	Description string           `json:"description"`
+	Boosters    []BoosterRequest `json:"boosters"`
+}
+
+type BoosterRequest struct {
+	Species      string `json:"species"`
+	IntervalDays int    `json:"interval_days"`
+}
+
+type VaccinationRequest struct {
+	PatientID      int64      `json:"patient_id"`
+	VaccineID      int64      `json:"vaccine_id"`
+	VetID          *int64     `json:"vet_id"`
+	LotNumber      string     `json:"lot_number"`
+	AdministeredAt *time.Time `json:"administered_at"`
+	NextDueAt      *time.Time `json:"next_due_at"`
+	Notes          string     `json:"notes"`
+}
+
+func NewVaccinationHandler(vaccinationRepo database.VaccinationStore, auditor *Auditor) *VaccinationHandler {
+	return &VaccinationHandler{
+		vaccinationRepo: vaccinationRepo,
+		auditor:         auditor,
+	}
+}
+
+func (vaccinationHandler *VaccinationHandler) CreateVaccineHandler(w http.ResponseWriter, r *http.Request) {
+	var vaccineRequest VaccineRequest
+	err := json.NewDecoder(r.Body).Decode(&vaccineRequest)
+	if err != nil {
+		apierror.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if vaccineRequest.Name == "" {
+		apierror.Validation(w, "name", "Name is required")
+		return
+	}
+
+	vaccine := domain.NewVaccine(vaccineRequest.Name, vaccineRequest.Description)
+	seen := make(map[string]bool, len(vaccineRequest.Boosters))
+	for _, boosterRequest := range vaccineRequest.Boosters {
+		booster := domain.NewVaccineBooster(0, boosterRequest.Species, boosterRequest.IntervalDays)
+		if !validateBooster(w, booster) {
+			return
+		}
+		if seen[booster.Species] {
+			apierror.Validation(w, "boosters", "Duplicate booster interval for species "+booster.Species)
+			return
+		}
+		seen[booster.Species] = true
+		vaccine.Boosters = append(vaccine.Boosters, *booster)
+	}
+
+	err = vaccinationHandler.vaccinationRepo.CreateVaccine(r.Context(), vaccine)
+	if err == database.ErrVaccineExists {
+		apierror.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(vaccine)
+}
+
+func (vaccinationHandler *VaccinationHandler) GetVaccinesHandler(w http.ResponseWriter, r *http.Request) {
+	limit, offset := utils.Pagination(r)
+	total, err := vaccinationHandler.vaccinationRepo.GetVaccinesCount(r.Context())
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	totalPages := int((total + int64(limit) - 1) / int64(limit))
+	page := (offset / limit) + 1
+
+	vaccines, err := vaccinationHandler.vaccinationRepo.GetVaccines(r.Context(), limit, offset)
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	response := PaginatedResponse{
+		Data:       vaccines,
+		Page:       page,
+		Limit:      limit,
+		Total:      total,
+		TotalPages: totalPages,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (vaccinationHandler *VaccinationHandler) GetVaccineByIDHandler(w http.ResponseWriter, r *http.Request) {
+	vaccineID, ok := pathID(w, r, "vaccine_id")
+	if !ok {
+		return
+	}
+	vaccine, err := vaccinationHandler.vaccinationRepo.GetVaccineByID(r.Context(), vaccineID)
+	if err == database.ErrVaccineNotFound {
+		apierror.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(vaccine)
+}
+
+func (vaccinationHandler *VaccinationHandler) SetVaccineBoosterHandler(w http.ResponseWriter, r *http.Request) {
+	vaccineID, ok := pathID(w, r, "vaccine_id")
+	if !ok {
+		return
+	}
+	var boosterRequest BoosterRequest
+	err := json.NewDecoder(r.Body).Decode(&boosterRequest)
+	if err != nil {
+		apierror.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	booster := domain.NewVaccineBooster(vaccineID, r.PathValue("species"), boosterRequest.IntervalDays)
+	if !validateBooster(w, booster) {
+		return
+	}
+
+	err = vaccinationHandler.vaccinationRepo.SetVaccineBooster(r.Context(), booster)
+	if err == database.ErrVaccineNotFound {
+		apierror.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (vaccinationHandler *VaccinationHandler) DeleteVaccineBoosterHandler(w http.ResponseWriter, r *http.Request) {
+	vaccineID, ok := pathID(w, r, "vaccine_id")
+	if !ok {
+		return
+	}
+	err := vaccinationHandler.vaccinationRepo.DeleteVaccineBooster(r.Context(), vaccineID, domain.NormalizeSpecies(r.PathValue("species")))
+	if err == database.ErrVaccineBoosterNotFound {
+		apierror.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (vaccinationHandler *VaccinationHandler) CreateVaccinationHandler(w http.ResponseWriter, r *http.Request) {
+	var vaccinationRequest VaccinationRequest
+	err := json.NewDecoder(r.Body).Decode(&vaccinationRequest)
+	if err != nil {
+		apierror.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if vaccinationRequest.PatientID <= 0 {
+		apierror.Validation(w, "patient_id", "PatientID must be greater than 0")
+		return
+	}
+	if vaccinationRequest.VaccineID <= 0 {
+		apierror.Validation(w, "vaccine_id", "VaccineID must be greater than 0")
+		return
+	}
+	if vaccinationRequest.LotNumber == "" {
+		apierror.Validation(w, "lot_number", "Lot number is required")
+		return
+	}
+	vetID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		apierror.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if vaccinationRequest.VetID != nil {
+		vetID = *vaccinationRequest.VetID
+	}
+	administeredAt := time.Now().UTC()
+	if vaccinationRequest.AdministeredAt != nil {
+		if vaccinationRequest.AdministeredAt.After(administeredAt) {
+			apierror.Validation(w, "administered_at", "Administration time cannot be in the future")
+			return
+		}
+		administeredAt = vaccinationRequest.AdministeredAt.UTC()
+	}
+
+	vaccination := domain.NewVaccination(vaccinationRequest.PatientID, vaccinationRequest.VaccineID, vetID, vaccinationRequest.LotNumber, administeredAt, vaccinationRequest.Notes)
+	if vaccinationRequest.NextDueAt != nil {
+		if !vaccinationRequest.NextDueAt.After(administeredAt) {
+			apierror.Validation(w, "next_due_at", "Next due date must be after the administration time")
+			return
+		}
+		nextDueAt := vaccinationRequest.NextDueAt.UTC()
+		vaccination.NextDueAt = &nextDueAt
+	}
+
+	err = vaccinationHandler.vaccinationRepo.CreateVaccination(r.Context(), vaccination)
+	switch err {
+	case nil:
+	case database.ErrPatientNotFound, database.ErrVaccineNotFound, database.ErrVetNotFound:
+		apierror.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	default:
+		apierror.Respond(w, err)
+		return
+	}
+	vaccinationHandler.auditor.Record(r, domain.AuditCreate, domain.AuditEntityVaccination, vaccination.ID, nil, vaccination)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(vaccination)
+}
+
+func (vaccinationHandler *VaccinationHandler) GetPatientVaccinationsHandler(w http.ResponseWriter, r *http.Request) {
+	patientID, ok := pathID(w, r, "patient_id")
+	if !ok {
+		return
+	}
+	limit, offset := utils.Pagination(r)
+	total, err := vaccinationHandler.vaccinationRepo.GetVaccinationsCountByPatientID(r.Context(), patientID)
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	totalPages := int((total + int64(limit) - 1) / int64(limit))
+	page := (offset / limit) + 1
+
+	vaccinations, err := vaccinationHandler.vaccinationRepo.GetVaccinationsByPatientID(r.Context(), patientID, limit, offset)
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	response := PaginatedResponse{
+		Data:       vaccinations,
+		Page:       page,
+		Limit:      limit,
+		Total:      total,
+		TotalPages: totalPages,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (vaccinationHandler *VaccinationHandler) DeleteVaccinationHandler(w http.ResponseWriter, r *http.Request) {
+	vaccinationID, ok := pathID(w, r, "vaccination_id")
+	if !ok {
+		return
+	}
+	vaccination, err := vaccinationHandler.vaccinationRepo.GetVaccinationByID(r.Context(), vaccinationID)
+	if err == database.ErrVaccinationNotFound {
+		apierror.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	err = vaccinationHandler.vaccinationRepo.DeleteVaccinationByID(r.Context(), vaccinationID)
+	if err == database.ErrVaccinationNotFound {
+		apierror.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	vaccinationHandler.auditor.Record(r, domain.AuditDelete, domain.AuditEntityVaccination, vaccination.ID, vaccination, nil)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetDueVaccinationsHandler lists boosters that are overdue or fall due within
+// within_days, with the owner's phone number for reminder calls.
+func (vaccinationHandler *VaccinationHandler) GetDueVaccinationsHandler(w http.ResponseWriter, r *http.Request) {
+	withinDays := defaultDueWithinDays
+	if within := r.URL.Query().Get("within_days"); within != "" {
+		value, err := strconv.Atoi(within)
+		if err != nil || value < 0 || value > maxDueWithinDays {
+			apierror.Validation(w, "within_days", "Invalid within_days parameter. Must be between 0 and "+strconv.Itoa(maxDueWithinDays))
+			return
+		}
+		withinDays = value
+	}
+	now := time.Now().UTC()
+	until := now.AddDate(0, 0, withinDays)
+
+	limit, offset := utils.Pagination(r)
+	total, err := vaccinationHandler.vaccinationRepo.GetDueVaccinationsCount(r.Context(), until)
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	totalPages := int((total + int64(limit) - 1) / int64(limit))
+	page := (offset / limit) + 1
+
+	due, err := vaccinationHandler.vaccinationRepo.GetDueVaccinations(r.Context(), until, now, limit, offset)
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	response := PaginatedResponse{
+		Data:       due,
+		Page:       page,
+		Limit:      limit,
+		Total:      total,
+		TotalPages: totalPages,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+func validateBooster(w http.ResponseWriter, booster *domain.VaccineBooster) bool {
+	if booster.Species == "" {
+		apierror.Validation(w, "species", "Species is required")
+		return false
+	}
+	if booster.IntervalDays <= 0 {
+		apierror.Validation(w, "interval_days", "Interval days must be greater than 0")
+		return false
+	}
+	return true
+}
+
+// pathID parses a numeric path value, writing a 400 if it is missing or
+// malformed.
+func pathID(w http.ResponseWriter, r *http.Request, name string) (int64, bool) {
+	id := r.PathValue(name)
+	if id == "" {
+		apierror.Error(w, "No id passed", http.StatusBadRequest)
+		return 0, false
+	}
+	value, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		apierror.Error(w, err.Error(), http.StatusBadRequest)
+		return 0, false
+	}
+	return value, true
+}
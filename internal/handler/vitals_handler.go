@@ -0,0 +1,347 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+	"vetsys/internal/apierror"
+	"vetsys/internal/database"
+	"vetsys/internal/domain"
+	"vetsys/internal/middleware"
+	"vetsys/internal/utils"
+)
+
+// Plausibility limits for recorded vitals. They only catch typing mistakes
+// such as a weight entered in grams; they are not clinical ranges.
+const (
+	maxWeightKg           = 5000
+	minTemperatureC       = 20
+	maxTemperatureC       = 50
+	maxHeartRateBPM       = 1000
+	maxRespiratoryRateBPM = 300
+)
+
+type VitalsHandler struct {
+	vitalsRepo  database.VitalsStore
+	patientRepo database.PatientStore
+	consultRepo database.ConsultationStore
+	auditor     *Auditor
+}
+
+type VitalsRequest struct {
+	ConsultationID     *int64     `json:"consultation_id"`
+	RecordedAt         *time.Time `json:"recorded_at"`
+	WeightKg           *float64   `json:"weight_kg"`
+	TemperatureC       *float64   `json:"temperature_c"`
+	HeartRateBPM       *int       `json:"heart_rate_bpm"`
+	RespiratoryRateBPM *int       `json:"respiratory_rate_bpm"`
+	Notes              *string    `json:"notes"`
+}
+
+type VitalsTrendResponse struct {
+	PatientID int64                                       `json:"patient_id"`
+	Series    map[domain.VitalsMetric][]domain.TrendPoint `json:"series"`
+}
+
+func NewVitalsHandler(vitalsRepo database.VitalsStore, patientRepo database.PatientStore, consultRepo database.ConsultationStore, auditor *Auditor) *VitalsHandler {
+	return &VitalsHandler{
+		vitalsRepo:  vitalsRepo,
+		patientRepo: patientRepo,
+		consultRepo: consultRepo,
+		auditor:     auditor,
+	}
+}
+
+func (vitalsHandler *VitalsHandler) CreateVitalsHandler(w http.ResponseWriter, r *http.Request) {
+	patient, ok := vitalsHandler.patientFromPath(w, r)
+	if !ok {
+		return
+	}
+	var vitalsRequest VitalsRequest
+	err := json.NewDecoder(r.Body).Decode(&vitalsRequest)
+	if err != nil {
+		apierror.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	recordedBy, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		apierror.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	vitals := domain.NewVitals(patient.ID, nil, recordedBy, time.Now(), "")
+	if !vitalsHandler.applyVitalsRequest(w, r, vitals, &vitalsRequest) {
+		return
+	}
+
+	err = vitalsHandler.vitalsRepo.CreateVitals(r.Context(), vitals)
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	vitalsHandler.auditor.Record(r, domain.AuditCreate, domain.AuditEntityVitals, vitals.ID, nil, vitals)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(vitals)
+}
+
+func (vitalsHandler *VitalsHandler) GetPatientVitalsHandler(w http.ResponseWriter, r *http.Request) {
+	patient, ok := vitalsHandler.patientFromPath(w, r)
+	if !ok {
+		return
+	}
+	from, to, ok := timeRangeParams(w, r)
+	if !ok {
+		return
+	}
+
+	limit, offset := utils.Pagination(r)
+	total, err := vitalsHandler.vitalsRepo.GetVitalsCountByPatientID(r.Context(), patient.ID, from, to)
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	totalPages := int((total + int64(limit) - 1) / int64(limit))
+	page := (offset / limit) + 1
+
+	vitals, err := vitalsHandler.vitalsRepo.GetVitalsByPatientID(r.Context(), patient.ID, from, to, limit, offset)
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	response := PaginatedResponse{
+		Data:       vitals,
+		Page:       page,
+		Limit:      limit,
+		Total:      total,
+		TotalPages: totalPages,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (vitalsHandler *VitalsHandler) GetVitalsByIDHandler(w http.ResponseWriter, r *http.Request) {
+	vitals, ok := vitalsHandler.vitalsFromPath(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(vitals)
+}
+
+func (vitalsHandler *VitalsHandler) UpdateVitalsHandler(w http.ResponseWriter, r *http.Request) {
+	vitals, ok := vitalsHandler.vitalsFromPath(w, r)
+	if !ok {
+		return
+	}
+	var vitalsRequest VitalsRequest
+	err := json.NewDecoder(r.Body).Decode(&vitalsRequest)
+	if err != nil {
+		apierror.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	before := *vitals
+	if !vitalsHandler.applyVitalsRequest(w, r, vitals, &vitalsRequest) {
+		return
+	}
+
+	err = vitalsHandler.vitalsRepo.UpdateVitals(r.Context(), vitals)
+	if err == database.ErrVitalsNotFound {
+		apierror.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	vitalsHandler.auditor.Record(r, domain.AuditUpdate, domain.AuditEntityVitals, vitals.ID, &before, vitals)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (vitalsHandler *VitalsHandler) DeleteVitalsHandler(w http.ResponseWriter, r *http.Request) {
+	vitals, ok := vitalsHandler.vitalsFromPath(w, r)
+	if !ok {
+		return
+	}
+	err := vitalsHandler.vitalsRepo.DeleteVitalsByID(r.Context(), vitals.ID)
+	if err == database.ErrVitalsNotFound {
+		apierror.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	vitalsHandler.auditor.Record(r, domain.AuditDelete, domain.AuditEntityVitals, vitals.ID, vitals, nil)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetVitalsTrendHandler returns one oldest-first time series per metric for
+// charting. metric takes a comma-separated list and defaults to all metrics.
+func (vitalsHandler *VitalsHandler) GetVitalsTrendHandler(w http.ResponseWriter, r *http.Request) {
+	patient, ok := vitalsHandler.patientFromPath(w, r)
+	if !ok {
+		return
+	}
+	from, to, ok := timeRangeParams(w, r)
+	if !ok {
+		return
+	}
+	metrics := domain.VitalsMetrics
+	if metricParam := r.URL.Query().Get("metric"); metricParam != "" {
+		metrics = nil
+		for _, name := range strings.Split(metricParam, ",") {
+			metric := domain.VitalsMetric(strings.TrimSpace(name))
+			if !metric.IsValid() {
+				apierror.Validation(w, "metric", "Invalid metric. Must be weight_kg, temperature_c, heart_rate_bpm or respiratory_rate_bpm")
+				return
+			}
+			metrics = append(metrics, metric)
+		}
+	}
+
+	records, err := vitalsHandler.vitalsRepo.GetVitalsTrend(r.Context(), patient.ID, from, to)
+	if err != nil {
+		apierror.Respond(w, err)
+		return
+	}
+	response := VitalsTrendResponse{
+		PatientID: patient.ID,
+		Series:    domain.VitalsTrend(records, metrics),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// applyVitalsRequest copies the fields set in vitalsRequest onto vitals and
+// validates the result, writing a 400 if it is invalid.
+func (vitalsHandler *VitalsHandler) applyVitalsRequest(w http.ResponseWriter, r *http.Request, vitals *domain.Vitals, vitalsRequest *VitalsRequest) bool {
+	if vitalsRequest.ConsultationID != nil {
+		consultation, err := vitalsHandler.consultRepo.GetConsultationByID(r.Context(), *vitalsRequest.ConsultationID)
+		if err == database.ErrConsultationNotFound {
+			apierror.Error(w, err.Error(), http.StatusBadRequest)
+			return false
+		}
+		if err != nil {
+			apierror.Respond(w, err)
+			return false
+		}
+		if consultation.PatientID != vitals.PatientID {
+			apierror.Validation(w, "consultation_id", "Consultation belongs to a different patient")
+			return false
+		}
+		vitals.ConsultationID = vitalsRequest.ConsultationID
+	}
+	if vitalsRequest.RecordedAt != nil {
+		if vitalsRequest.RecordedAt.After(time.Now()) {
+			apierror.Validation(w, "recorded_at", "Recorded time cannot be in the future")
+			return false
+		}
+		vitals.RecordedAt = vitalsRequest.RecordedAt.UTC()
+	}
+	if vitalsRequest.WeightKg != nil {
+		if *vitalsRequest.WeightKg <= 0 || *vitalsRequest.WeightKg > maxWeightKg {
+			apierror.Validation(w, "weight_kg", "Weight must be greater than 0 and at most 5000 kg")
+			return false
+		}
+		vitals.WeightKg = vitalsRequest.WeightKg
+	}
+	if vitalsRequest.TemperatureC != nil {
+		if *vitalsRequest.TemperatureC < minTemperatureC || *vitalsRequest.TemperatureC > maxTemperatureC {
+			apierror.Validation(w, "temperature_c", "Temperature must be between 20 and 50 °C")
+			return false
+		}
+		vitals.TemperatureC = vitalsRequest.TemperatureC
+	}
+	if vitalsRequest.HeartRateBPM != nil {
+		if *vitalsRequest.HeartRateBPM <= 0 || *vitalsRequest.HeartRateBPM > maxHeartRateBPM {
+			apierror.Validation(w, "heart_rate_bpm", "Heart rate must be greater than 0 and at most 1000 bpm")
+			return false
+		}
+		vitals.HeartRateBPM = vitalsRequest.HeartRateBPM
+	}
+	if vitalsRequest.RespiratoryRateBPM != nil {
+		if *vitalsRequest.RespiratoryRateBPM <= 0 || *vitalsRequest.RespiratoryRateBPM > maxRespiratoryRateBPM {
+			apierror.Validation(w, "respiratory_rate_bpm", "Respiratory rate must be greater than 0 and at most 300 bpm")
+			return false
+		}
+		vitals.RespiratoryRateBPM = vitalsRequest.RespiratoryRateBPM
+	}
+	if vitalsRequest.Notes != nil {
+		vitals.Notes = *vitalsRequest.Notes
+	}
+	if !vitals.HasMeasurement() {
+		apierror.Error(w, "At least one of weight, temperature, heart rate or respiratory rate is required", http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+func (vitalsHandler *VitalsHandler) patientFromPath(w http.ResponseWriter, r *http.Request) (*domain.Patient, bool) {
+	patientID, ok := pathID(w, r, "patient_id")
+	if !ok {
+		return nil, false
+	}
+	patient, err := vitalsHandler.patientRepo.GetPatientByID(r.Context(), patientID)
+	if err == database.ErrPatientNotFound {
+		apierror.Error(w, err.Error(), http.StatusNotFound)
+		return nil, false
+	}
+	if err != nil {
+		apierror.Respond(w, err)
+		return nil, false
+	}
+	return patient, true
+}
+
+// vitalsFromPath loads the vitals named in the path, answering 404 if they
+// belong to a different patient than the one in the path.
+func (vitalsHandler *VitalsHandler) vitalsFromPath(w http.ResponseWriter, r *http.Request) (*domain.Vitals, bool) {
+	patientID, ok := pathID(w, r, "patient_id")
+	if !ok {
+		return nil, false
+	}
+	vitalsID, ok := pathID(w, r, "vitals_id")
+	if !ok {
+		return nil, false
+	}
+	vitals, err := vitalsHandler.vitalsRepo.GetVitalsByID(r.Context(), vitalsID)
+	if err == nil && vitals.PatientID != patientID {
+		err = database.ErrVitalsNotFound
+	}
+	if err == database.ErrVitalsNotFound {
+		apierror.Error(w, err.Error(), http.StatusNotFound)
+		return nil, false
+	}
+	if err != nil {
+		apierror.Respond(w, err)
+		return nil, false
+	}
+	return vitals, true
+}
+
+// timeRangeParams parses the optional from and to query parameters as
+// RFC 3339, writing a 400 if either is malformed.
+func timeRangeParams(w http.ResponseWriter, r *http.Request) (*time.Time, *time.Time, bool) {
+	var bounds [2]*time.Time
+	for i, name := range []string{"from", "to"} {
+		param := r.URL.Query().Get(name)
+		if param == "" {
+			continue
+		}
+		value, err := time.Parse(time.RFC3339, param)
+		if err != nil {
+			apierror.Validation(w, name, "Invalid "+name+" parameter. Use RFC 3339")
+			return nil, nil, false
+		}
+		value = value.UTC()
+		bounds[i] = &value
+	}
+	return bounds[0], bounds[1], true
+}
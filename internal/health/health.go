@@ -0,0 +1,148 @@
+// Package health serves the liveness and readiness probes. Liveness only says
+// the process is serving HTTP; readiness runs the registered dependency
+// checks and fails while the server is shutting down, so an orchestrator
+// stops routing traffic before connections are refused.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusOK          = "ok"
+	StatusFailing     = "failing"
+	StatusUnavailable = "unavailable"
+)
+
+// defaultCheckTimeout bounds each readiness check.
+const defaultCheckTimeout = 2 * time.Second
+
+// Check reports whether a dependency is usable; a nil error means it is.
+type Check func(ctx context.Context) error
+
+type CheckResult struct {
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+type Response struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+type Health struct {
+	CheckTimeout time.Duration
+	// HideErrors replaces the error of a failing check with a generic message
+	// and logs it instead, since the probe is unauthenticated.
+	HideErrors bool
+
+	mu           sync.Mutex
+	checks       map[string]Check
+	shuttingDown atomic.Bool
+}
+
+func New() *Health {
+	return &Health{
+		CheckTimeout: defaultCheckTimeout,
+		checks:       make(map[string]Check),
+	}
+}
+
+// AddCheck registers a readiness check under name, replacing any check
+// already registered with that name.
+func (health *Health) AddCheck(name string, check Check) {
+	health.mu.Lock()
+	defer health.mu.Unlock()
+
+	health.checks[name] = check
+}
+
+// SetShuttingDown makes readiness fail from now on.
+func (health *Health) SetShuttingDown() {
+	health.shuttingDown.Store(true)
+}
+
+// LiveHandler answers 200 for as long as the process can serve requests.
+func (health *Health) LiveHandler(w http.ResponseWriter, r *http.Request) {
+	writeResponse(w, http.StatusOK, Response{Status: StatusOK})
+}
+
+// ReadyHandler runs every check concurrently and answers 200 if all pass and
+// 503 otherwise, with each check's status and latency.
+func (health *Health) ReadyHandler(w http.ResponseWriter, r *http.Request) {
+	response := health.Ready(r.Context())
+	status := http.StatusOK
+	if response.Status != StatusOK {
+		status = http.StatusServiceUnavailable
+	}
+	writeResponse(w, status, response)
+}
+
+// Ready runs the readiness checks.
+func (health *Health) Ready(ctx context.Context) Response {
+	health.mu.Lock()
+	checks := make(map[string]Check, len(health.checks))
+	for name, check := range health.checks {
+		checks[name] = check
+	}
+	health.mu.Unlock()
+
+	response := Response{Status: StatusOK, Checks: make(map[string]CheckResult, len(checks))}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result := health.run(ctx, name, check)
+			mu.Lock()
+			defer mu.Unlock()
+			response.Checks[name] = result
+			if result.Status != StatusOK {
+				response.Status = StatusUnavailable
+			}
+		}()
+	}
+	wg.Wait()
+
+	if health.shuttingDown.Load() {
+		response.Status = StatusUnavailable
+		response.Checks["shutdown"] = CheckResult{Status: StatusFailing, Error: "server is shutting down"}
+	}
+	return response
+}
+
+func (health *Health) run(ctx context.Context, name string, check Check) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, health.CheckTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check(ctx)
+	result := CheckResult{
+		Status:    StatusOK,
+		LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = StatusFailing
+		result.Error = err.Error()
+		if health.HideErrors {
+			slog.WarnContext(ctx, "readiness check failed", "check", name, "error", err)
+			result.Error = "check failed"
+		}
+	}
+	return result
+}
+
+func writeResponse(w http.ResponseWriter, status int, response Response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
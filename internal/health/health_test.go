@@ -0,0 +1,96 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func ready(t *testing.T, health *Health) (int, Response) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	health.ReadyHandler(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	var response Response
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	return rec.Code, response
+}
+
+func TestReady_AllChecksPass(t *testing.T) {
+	health := New()
+	health.AddCheck("database", func(ctx context.Context) error { return nil })
+	health.AddCheck("migrations", func(ctx context.Context) error { return nil })
+
+	status, response := ready(t, health)
+	if status != http.StatusOK || response.Status != StatusOK {
+		t.Fatalf("Expected 200 ok, got %d %s", status, response.Status)
+	}
+	if len(response.Checks) != 2 {
+		t.Fatalf("Expected 2 checks, got %+v", response.Checks)
+	}
+	for name, result := range response.Checks {
+		if result.Status != StatusOK || result.Error != "" {
+			t.Errorf("Expected %s to pass, got %+v", name, result)
+		}
+	}
+}
+
+func TestReady_FailingCheck(t *testing.T) {
+	health := New()
+	health.AddCheck("database", func(ctx context.Context) error { return nil })
+	health.AddCheck("migrations", func(ctx context.Context) error { return errors.New("2 migrations pending") })
+
+	status, response := ready(t, health)
+	if status != http.StatusServiceUnavailable || response.Status != StatusUnavailable {
+		t.Fatalf("Expected 503 unavailable, got %d %s", status, response.Status)
+	}
+	if result := response.Checks["migrations"]; result.Status != StatusFailing || result.Error != "2 migrations pending" {
+		t.Errorf("Expected the migrations check to fail with its error, got %+v", result)
+	}
+	if result := response.Checks["database"]; result.Status != StatusOK {
+		t.Errorf("Expected the database check to pass, got %+v", result)
+	}
+}
+
+func TestReady_CheckTimeout(t *testing.T) {
+	health := New()
+	health.CheckTimeout = 10 * time.Millisecond
+	health.AddCheck("database", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	_, response := ready(t, health)
+	result := response.Checks["database"]
+	if result.Status != StatusFailing {
+		t.Fatalf("Expected a hung check to fail, got %+v", result)
+	}
+	if result.LatencyMS < 10 {
+		t.Errorf("Expected the latency to cover the timeout, got %vms", result.LatencyMS)
+	}
+}
+
+func TestReady_ShuttingDown(t *testing.T) {
+	health := New()
+	health.AddCheck("database", func(ctx context.Context) error { return nil })
+	health.SetShuttingDown()
+
+	status, response := ready(t, health)
+	if status != http.StatusServiceUnavailable {
+		t.Fatalf("Expected 503 while shutting down, got %d", status)
+	}
+	if result := response.Checks["shutdown"]; result.Status != StatusFailing {
+		t.Errorf("Expected a failing shutdown check, got %+v", response.Checks)
+	}
+
+	rec := httptest.NewRecorder()
+	health.LiveHandler(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected liveness to keep passing while shutting down, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,21 @@
+package jobs
+
+import (
+	"context"
+	"time"
+	"vetsys/internal/database"
+	"vetsys/internal/domain"
+)
+
+// PurgeLoginLockouts forgets, every hour, the DNIs and IPs whose failed
+// logins no longer count towards a lockout.
+func PurgeLoginLockouts(lockouts database.LoginLockoutStore) Job {
+	return Job{
+		Name:     "purge_login_lockouts",
+		Schedule: Every(time.Hour),
+		Jitter:   5 * time.Minute,
+		Run: func(ctx context.Context) error {
+			return lockouts.DeleteStaleLockouts(ctx, time.Now().UTC().Add(-domain.LockoutResetAfter))
+		},
+	}
+}
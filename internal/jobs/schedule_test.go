@@ -0,0 +1,54 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEvery_Next(t *testing.T) {
+	schedule := Every(time.Hour)
+	now := time.Date(2026, 3, 1, 10, 17, 5, 0, time.UTC)
+
+	next := schedule.Next(now)
+	if want := time.Date(2026, 3, 1, 11, 0, 0, 0, time.UTC); !next.Equal(want) {
+		t.Errorf("Expected %v, got %v", want, next)
+	}
+	if again := schedule.Next(next); !again.Equal(next.Add(time.Hour)) {
+		t.Errorf("Expected the occurrence after %v to be an hour later, got %v", next, again)
+	}
+}
+
+func TestCron_Next(t *testing.T) {
+	// A Sunday.
+	now := time.Date(2026, 3, 1, 10, 17, 5, 0, time.UTC)
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"*/15 * * * *", time.Date(2026, 3, 1, 10, 30, 0, 0, time.UTC)},
+		{"0 3 * * *", time.Date(2026, 3, 2, 3, 0, 0, 0, time.UTC)},
+		{"30 9 * * 1-5", time.Date(2026, 3, 2, 9, 30, 0, 0, time.UTC)},
+		{"0 8 * * 6,7", time.Date(2026, 3, 7, 8, 0, 0, 0, time.UTC)},
+		{"0 0 15 * 3", time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC)},
+		{"0 12 1 */3 *", time.Date(2026, 4, 1, 12, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+	}
+	for _, test := range tests {
+		schedule, err := Cron(test.spec)
+		if err != nil {
+			t.Fatalf("Failed to parse %q: %v", test.spec, err)
+		}
+		if next := schedule.Next(now); !next.Equal(test.want) {
+			t.Errorf("%q: expected %v, got %v", test.spec, test.want, next)
+		}
+	}
+}
+
+func TestCron_Invalid(t *testing.T) {
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 8", "*/0 * * * *", "5-1 * * * *", "a * * * *", "@yearly"} {
+		if _, err := Cron(spec); err == nil {
+			t.Errorf("Expected %q to be rejected", spec)
+		}
+	}
+}
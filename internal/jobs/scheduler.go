@@ -0,0 +1,211 @@
+// Package jobs runs recurring background work inside the server process.
+// Every replica runs the scheduler; each occurrence of a job is claimed
+// through a database.JobRunStore, so only one replica runs it.
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"sync"
+	"time"
+	"vetsys/internal/database"
+	"vetsys/internal/metrics"
+)
+
+const (
+	resultOK      = "ok"
+	resultError   = "error"
+	resultSkipped = "skipped"
+)
+
+var (
+	jobRuns = metrics.NewCounterVec(
+		"vetsys_job_runs_total",
+		"Scheduled job occurrences, by job and result (ok, error or skipped when another replica claimed it).",
+		"job", "result",
+	)
+	jobDuration = metrics.NewHistogramVec(
+		"vetsys_job_duration_seconds",
+		"Duration of scheduled job runs, by job.",
+		metrics.DefBuckets,
+		"job",
+	)
+)
+
+func init() {
+	metrics.Default.MustRegister(jobRuns, jobDuration)
+}
+
+var ErrNotRunning = errors.New("job scheduler is not running")
+
+type Job struct {
+	// Name identifies the job in logs, metrics and the claim other replicas
+	// check, so it must not change between releases.
+	Name     string
+	Schedule Schedule
+	// Jitter delays each run by a random duration up to Jitter, so replicas
+	// and jobs sharing a schedule do not all hit the database at once.
+	Jitter time.Duration
+	Run    func(ctx context.Context) error
+}
+
+type Scheduler struct {
+	runs database.JobRunStore
+	jobs []Job
+
+	mu         sync.Mutex
+	running    bool
+	stopLoops  context.CancelFunc
+	cancelRuns context.CancelFunc
+	wg         sync.WaitGroup
+}
+
+func NewScheduler(runs database.JobRunStore) *Scheduler {
+	return &Scheduler{runs: runs}
+}
+
+// Register adds a job. Registering after Start, or twice under one name, is a
+// programming error and panics.
+func (scheduler *Scheduler) Register(job Job) {
+	scheduler.mu.Lock()
+	defer scheduler.mu.Unlock()
+
+	if scheduler.running {
+		panic("jobs: register " + job.Name + " after Start")
+	}
+	for _, registered := range scheduler.jobs {
+		if registered.Name == job.Name {
+			panic("jobs: duplicate job " + job.Name)
+		}
+	}
+	scheduler.jobs = append(scheduler.jobs, job)
+}
+
+// Start schedules every registered job until Stop is called.
+func (scheduler *Scheduler) Start() {
+	scheduler.mu.Lock()
+	defer scheduler.mu.Unlock()
+
+	if scheduler.running {
+		return
+	}
+	loopCtx, stopLoops := context.WithCancel(context.Background())
+	runCtx, cancelRuns := context.WithCancel(context.Background())
+	scheduler.running = true
+	scheduler.stopLoops = stopLoops
+	scheduler.cancelRuns = cancelRuns
+	for _, job := range scheduler.jobs {
+		scheduler.wg.Add(1)
+		go func() {
+			defer scheduler.wg.Done()
+			scheduler.loop(loopCtx, runCtx, job)
+		}()
+	}
+	slog.Info("job scheduler started", "jobs", len(scheduler.jobs))
+}
+
+// Stop schedules no further runs and waits for the ones in progress until ctx
+// is done, then cancels them.
+func (scheduler *Scheduler) Stop(ctx context.Context) error {
+	scheduler.mu.Lock()
+	if !scheduler.running {
+		scheduler.mu.Unlock()
+		return nil
+	}
+	scheduler.running = false
+	scheduler.mu.Unlock()
+
+	scheduler.stopLoops()
+	defer scheduler.cancelRuns()
+
+	done := make(chan struct{})
+	go func() {
+		scheduler.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		slog.Info("job scheduler stopped")
+		return nil
+	case <-ctx.Done():
+		scheduler.cancelRuns()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// Check is the readiness check for the scheduler: it fails unless the
+// scheduler has been started and not stopped.
+func (scheduler *Scheduler) Check(ctx context.Context) error {
+	scheduler.mu.Lock()
+	defer scheduler.mu.Unlock()
+
+	if !scheduler.running {
+		return ErrNotRunning
+	}
+	return nil
+}
+
+func (scheduler *Scheduler) loop(loopCtx context.Context, runCtx context.Context, job Job) {
+	for {
+		due := job.Schedule.Next(time.Now())
+		if due.IsZero() {
+			slog.Warn("job has no further runs", "job", job.Name)
+			return
+		}
+		delay := time.Until(due)
+		if job.Jitter > 0 {
+			delay += rand.N(job.Jitter)
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-loopCtx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		scheduler.run(runCtx, job, due)
+	}
+}
+
+// run claims the occurrence due at due and runs it if no other replica has.
+func (scheduler *Scheduler) run(ctx context.Context, job Job, due time.Time) {
+	release, ok, err := scheduler.runs.ClaimRun(ctx, job.Name, due)
+	if err != nil {
+		jobRuns.Inc(job.Name, resultError)
+		slog.Error("job claim failed", "job", job.Name, "due", due, "error", err)
+		return
+	}
+	if !ok {
+		jobRuns.Inc(job.Name, resultSkipped)
+		slog.Debug("job run claimed elsewhere", "job", job.Name, "due", due)
+		return
+	}
+	defer release()
+
+	start := time.Now()
+	err = runJob(ctx, job)
+	elapsed := time.Since(start)
+	jobDuration.Observe(elapsed.Seconds(), job.Name)
+	if err != nil {
+		jobRuns.Inc(job.Name, resultError)
+		slog.Error("job failed", "job", job.Name, "due", due, "duration_ms", elapsed.Milliseconds(), "error", err)
+		return
+	}
+	jobRuns.Inc(job.Name, resultOK)
+	slog.Info("job finished", "job", job.Name, "due", due, "duration_ms", elapsed.Milliseconds())
+}
+
+// runJob turns a panic in the job into an error, so one faulty job cannot
+// take the server down.
+func runJob(ctx context.Context, job Job) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("panic: %v", recovered)
+		}
+	}()
+	return job.Run(ctx)
+}
@@ -0,0 +1,133 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+	"vetsys/internal/database/memory"
+)
+
+func TestScheduler_RunsEachOccurrenceOnce(t *testing.T) {
+	runs := memory.NewDataBase().JobRunRepo
+	var count atomic.Int64
+	job := Job{
+		Name:     "count",
+		Schedule: Every(20 * time.Millisecond),
+		Run: func(ctx context.Context) error {
+			count.Add(1)
+			return nil
+		},
+	}
+
+	// Two replicas sharing one job run store.
+	first, second := NewScheduler(runs), NewScheduler(runs)
+	first.Register(job)
+	second.Register(job)
+	start := time.Now()
+	first.Start()
+	second.Start()
+	time.Sleep(110 * time.Millisecond)
+	first.Stop(context.Background())
+	second.Stop(context.Background())
+
+	occurrences := int64(time.Since(start)/(20*time.Millisecond)) + 1
+	if got := count.Load(); got == 0 || got > occurrences {
+		t.Errorf("Expected between 1 and %d runs, got %d", occurrences, got)
+	}
+}
+
+func TestScheduler_StopWaitsForRunningJob(t *testing.T) {
+	started := make(chan struct{})
+	var finished atomic.Bool
+	scheduler := NewScheduler(memory.NewDataBase().JobRunRepo)
+	scheduler.Register(Job{
+		Name:     "slow",
+		Schedule: Every(10 * time.Millisecond),
+		Run: func(ctx context.Context) error {
+			if finished.Load() {
+				return nil
+			}
+			close(started)
+			time.Sleep(50 * time.Millisecond)
+			finished.Store(true)
+			return nil
+		},
+	})
+	scheduler.Start()
+	<-started
+
+	if err := scheduler.Stop(context.Background()); err != nil {
+		t.Fatalf("Failed to stop: %v", err)
+	}
+	if !finished.Load() {
+		t.Error("Expected Stop to wait for the running job")
+	}
+}
+
+func TestScheduler_StopCancelsJobsAfterDeadline(t *testing.T) {
+	started := make(chan struct{})
+	var cancelled atomic.Bool
+	scheduler := NewScheduler(memory.NewDataBase().JobRunRepo)
+	scheduler.Register(Job{
+		Name:     "hung",
+		Schedule: Every(10 * time.Millisecond),
+		Run: func(ctx context.Context) error {
+			close(started)
+			<-ctx.Done()
+			cancelled.Store(true)
+			return ctx.Err()
+		},
+	})
+	scheduler.Start()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := scheduler.Stop(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected %v, got %v", context.DeadlineExceeded, err)
+	}
+	if !cancelled.Load() {
+		t.Error("Expected the job's context to be cancelled")
+	}
+}
+
+func TestScheduler_RecoversFromPanic(t *testing.T) {
+	var count atomic.Int64
+	failures := jobRuns.Value("panics", resultError)
+	scheduler := NewScheduler(memory.NewDataBase().JobRunRepo)
+	scheduler.Register(Job{
+		Name:     "panics",
+		Schedule: Every(10 * time.Millisecond),
+		Run: func(ctx context.Context) error {
+			count.Add(1)
+			panic("boom")
+		},
+	})
+	scheduler.Start()
+	time.Sleep(50 * time.Millisecond)
+	scheduler.Stop(context.Background())
+
+	if count.Load() < 2 {
+		t.Errorf("Expected the job to keep running after a panic, ran %d times", count.Load())
+	}
+	if got := jobRuns.Value("panics", resultError) - failures; got != float64(count.Load()) {
+		t.Errorf("Expected %d failed runs counted, got %v", count.Load(), got)
+	}
+}
+
+func TestScheduler_Check(t *testing.T) {
+	scheduler := NewScheduler(memory.NewDataBase().JobRunRepo)
+	if err := scheduler.Check(context.Background()); err != ErrNotRunning {
+		t.Errorf("Expected %v before Start, got %v", ErrNotRunning, err)
+	}
+	scheduler.Start()
+	if err := scheduler.Check(context.Background()); err != nil {
+		t.Errorf("Expected no error while running, got %v", err)
+	}
+	scheduler.Stop(context.Background())
+	if err := scheduler.Check(context.Background()); err != ErrNotRunning {
+		t.Errorf("Expected %v after Stop, got %v", ErrNotRunning, err)
+	}
+}
@@ -0,0 +1,36 @@
+package jobs
+
+import (
+	"time"
+	"vetsys/internal/database"
+)
+
+// PurgeSessions deletes expired sessions every hour.
+func PurgeSessions(sessions database.SessionStore) Job {
+	return Job{
+		Name:     "purge_sessions",
+		Schedule: Every(time.Hour),
+		Jitter:   5 * time.Minute,
+		Run:      sessions.DeleteOldSessions,
+	}
+}
+
+// PurgePasswordResetTokens deletes expired password reset tokens every hour.
+func PurgePasswordResetTokens(resets database.PasswordResetStore) Job {
+	return Job{
+		Name:     "purge_password_reset_tokens",
+		Schedule: Every(time.Hour),
+		Jitter:   5 * time.Minute,
+		Run:      resets.DeleteExpiredResetTokens,
+	}
+}
+
+// PurgeLoginChallenges deletes expired two-factor login challenges every hour.
+func PurgeLoginChallenges(challenges database.LoginChallengeStore) Job {
+	return Job{
+		Name:     "purge_login_challenges",
+		Schedule: Every(time.Hour),
+		Jitter:   5 * time.Minute,
+		Run:      challenges.DeleteExpiredChallenges,
+	}
+}
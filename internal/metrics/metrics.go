@@ -0,0 +1,337 @@
+// Package metrics keeps counters, histograms and gauges in process and serves
+// them in the Prometheus text exposition format, so any Prometheus-compatible
+// scraper can read them without a client library.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefBuckets are latency buckets in seconds suited to HTTP requests and
+// database queries.
+var DefBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Default is the registry /metrics serves. Packages register their metrics on
+// it when they are initialised.
+var Default = NewRegistry()
+
+// Collector is a metric family a Registry can expose.
+type Collector interface {
+	name() string
+	write(w *bufio.Writer)
+}
+
+type Registry struct {
+	mu         sync.Mutex
+	collectors map[string]Collector
+}
+
+func NewRegistry() *Registry {
+	return &Registry{collectors: make(map[string]Collector)}
+}
+
+// MustRegister adds collectors to the registry. Registering two metrics with
+// the same name is a programming error and panics.
+func (registry *Registry) MustRegister(collectors ...Collector) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	for _, collector := range collectors {
+		if _, exists := registry.collectors[collector.name()]; exists {
+			panic("metrics: duplicate metric " + collector.name())
+		}
+		registry.collectors[collector.name()] = collector
+	}
+}
+
+// WriteTo writes every registered metric, sorted by name.
+func (registry *Registry) WriteTo(w io.Writer) (int64, error) {
+	registry.mu.Lock()
+	collectors := make([]Collector, 0, len(registry.collectors))
+	for _, collector := range registry.collectors {
+		collectors = append(collectors, collector)
+	}
+	registry.mu.Unlock()
+	sort.Slice(collectors, func(i, j int) bool { return collectors[i].name() < collectors[j].name() })
+
+	counter := &countingWriter{w: w}
+	buffered := bufio.NewWriter(counter)
+	for _, collector := range collectors {
+		collector.write(buffered)
+	}
+	err := buffered.Flush()
+	return counter.n, err
+}
+
+// Handler serves the registry in the Prometheus text format.
+func (registry *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		registry.WriteTo(w)
+	})
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (counter *countingWriter) Write(b []byte) (int, error) {
+	n, err := counter.w.Write(b)
+	counter.n += int64(n)
+	return n, err
+}
+
+// family is what every metric type shares: its name, help text and label
+// names.
+type family struct {
+	metricName string
+	help       string
+	labels     []string
+}
+
+func (f *family) name() string {
+	return f.metricName
+}
+
+func (f *family) writeHeader(w *bufio.Writer, kind string) {
+	fmt.Fprintf(w, "# HELP %s %s\n", f.metricName, strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(f.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", f.metricName, kind)
+}
+
+// key joins label values into a map key. The separator cannot appear in
+// valid UTF-8 text.
+func key(values []string) string {
+	return strings.Join(values, "\xff")
+}
+
+func (f *family) checkValues(values []string) {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("metrics: %s takes %d label values, got %d", f.metricName, len(f.labels), len(values)))
+	}
+}
+
+// formatLabels renders {a="x",b="y"}, with extra appended after the family's
+// labels.
+func formatLabels(names []string, values []string, extra ...string) string {
+	if len(names) == 0 && len(extra) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, "%s=\"%s\"", name, escapeLabel(values[i]))
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		if i > 0 || len(names) > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, "%s=\"%s\"", extra[i], escapeLabel(extra[i+1]))
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(value string) string {
+	return labelEscaper.Replace(value)
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// CounterVec is a counter partitioned by labels.
+type CounterVec struct {
+	family
+	mu     sync.Mutex
+	series map[string]*counterSeries
+}
+
+type counterSeries struct {
+	values []string
+	value  float64
+}
+
+func NewCounterVec(name string, help string, labels ...string) *CounterVec {
+	return &CounterVec{
+		family: family{metricName: name, help: help, labels: labels},
+		series: make(map[string]*counterSeries),
+	}
+}
+
+func (counter *CounterVec) Inc(values ...string) {
+	counter.Add(1, values...)
+}
+
+// Add increases the counter for the label values by delta, which must not be
+// negative.
+func (counter *CounterVec) Add(delta float64, values ...string) {
+	counter.checkValues(values)
+	if delta < 0 {
+		panic("metrics: counters cannot decrease")
+	}
+	counter.mu.Lock()
+	defer counter.mu.Unlock()
+
+	k := key(values)
+	series, ok := counter.series[k]
+	if !ok {
+		series = &counterSeries{values: append([]string(nil), values...)}
+		counter.series[k] = series
+	}
+	series.value += delta
+}
+
+// Value returns the current count for the label values.
+func (counter *CounterVec) Value(values ...string) float64 {
+	counter.mu.Lock()
+	defer counter.mu.Unlock()
+
+	if series, ok := counter.series[key(values)]; ok {
+		return series.value
+	}
+	return 0
+}
+
+func (counter *CounterVec) write(w *bufio.Writer) {
+	counter.mu.Lock()
+	defer counter.mu.Unlock()
+
+	counter.writeHeader(w, "counter")
+	for _, k := range sortedKeys(counter.series) {
+		series := counter.series[k]
+		fmt.Fprintf(w, "%s%s %s\n", counter.metricName, formatLabels(counter.labels, series.values), formatValue(series.value))
+	}
+}
+
+// HistogramVec is a histogram partitioned by labels.
+type HistogramVec struct {
+	family
+	buckets []float64
+	mu      sync.Mutex
+	series  map[string]*histogramSeries
+}
+
+type histogramSeries struct {
+	values []string
+	counts []uint64 // per bucket, not cumulative
+	count  uint64
+	sum    float64
+}
+
+// NewHistogramVec counts observations into buckets, given as sorted upper
+// bounds; the +Inf bucket is implied.
+func NewHistogramVec(name string, help string, buckets []float64, labels ...string) *HistogramVec {
+	if !sort.Float64sAreSorted(buckets) {
+		panic("metrics: histogram buckets must be sorted")
+	}
+	return &HistogramVec{
+		family:  family{metricName: name, help: help, labels: labels},
+		buckets: buckets,
+		series:  make(map[string]*histogramSeries),
+	}
+}
+
+func (histogram *HistogramVec) Observe(v float64, values ...string) {
+	histogram.checkValues(values)
+	histogram.mu.Lock()
+	defer histogram.mu.Unlock()
+
+	k := key(values)
+	series, ok := histogram.series[k]
+	if !ok {
+		series = &histogramSeries{
+			values: append([]string(nil), values...),
+			counts: make([]uint64, len(histogram.buckets)),
+		}
+		histogram.series[k] = series
+	}
+	if i := sort.SearchFloat64s(histogram.buckets, v); i < len(histogram.buckets) {
+		series.counts[i]++
+	}
+	series.count++
+	series.sum += v
+}
+
+// Count returns how many observations were made for the label values.
+func (histogram *HistogramVec) Count(values ...string) uint64 {
+	histogram.mu.Lock()
+	defer histogram.mu.Unlock()
+
+	if series, ok := histogram.series[key(values)]; ok {
+		return series.count
+	}
+	return 0
+}
+
+func (histogram *HistogramVec) write(w *bufio.Writer) {
+	histogram.mu.Lock()
+	defer histogram.mu.Unlock()
+
+	histogram.writeHeader(w, "histogram")
+	for _, k := range sortedKeys(histogram.series) {
+		series := histogram.series[k]
+		var cumulative uint64
+		for i, bound := range histogram.buckets {
+			cumulative += series.counts[i]
+			fmt.Fprintf(w, "%s_bucket%s %d\n", histogram.metricName, formatLabels(histogram.labels, series.values, "le", formatValue(bound)), cumulative)
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", histogram.metricName, formatLabels(histogram.labels, series.values, "le", "+Inf"), series.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", histogram.metricName, formatLabels(histogram.labels, series.values), formatValue(series.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", histogram.metricName, formatLabels(histogram.labels, series.values), series.count)
+	}
+}
+
+// ValueFunc is a gauge or counter read at scrape time, for values something
+// else already keeps, such as connection pool statistics.
+type ValueFunc struct {
+	family
+	kind string
+	fn   func() float64
+}
+
+// NewGaugeFunc exposes fn as a gauge. fn runs on every scrape and should
+// return NaN when the value is unavailable.
+func NewGaugeFunc(name string, help string, fn func() float64) *ValueFunc {
+	return &ValueFunc{family: family{metricName: name, help: help}, kind: "gauge", fn: fn}
+}
+
+// NewCounterFunc exposes fn, which must never decrease, as a counter.
+func NewCounterFunc(name string, help string, fn func() float64) *ValueFunc {
+	return &ValueFunc{family: family{metricName: name, help: help}, kind: "counter", fn: fn}
+}
+
+func (value *ValueFunc) write(w *bufio.Writer) {
+	value.writeHeader(w, value.kind)
+	fmt.Fprintf(w, "%s %s\n", value.metricName, formatValue(value.fn()))
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
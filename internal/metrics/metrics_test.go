@@ -0,0 +1,71 @@
+package metrics
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func scrape(t *testing.T, registry *Registry) string {
+	t.Helper()
+	var b strings.Builder
+	if _, err := registry.WriteTo(&b); err != nil {
+		t.Fatalf("Failed to write metrics: %v", err)
+	}
+	return b.String()
+}
+
+func TestRegistry_TextFormat(t *testing.T) {
+	registry := NewRegistry()
+	requests := NewCounterVec("requests_total", "Requests served.", "pattern", "status")
+	latency := NewHistogramVec("latency_seconds", "Request latency.", []float64{0.1, 1}, "pattern")
+	sessions := NewGaugeFunc("sessions", "Active sessions.", func() float64 { return 3 })
+	registry.MustRegister(requests, latency, sessions)
+
+	requests.Inc("GET /api/clients/{client_id}", "200")
+	requests.Add(2, "GET /api/clients/{client_id}", "200")
+	requests.Inc(`GET /say "hi"`, "404")
+	latency.Observe(0.05, "GET /")
+	latency.Observe(0.5, "GET /")
+	latency.Observe(5, "GET /")
+
+	want := `# HELP latency_seconds Request latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{pattern="GET /",le="0.1"} 1
+latency_seconds_bucket{pattern="GET /",le="1"} 2
+latency_seconds_bucket{pattern="GET /",le="+Inf"} 3
+latency_seconds_sum{pattern="GET /"} 5.55
+latency_seconds_count{pattern="GET /"} 3
+# HELP requests_total Requests served.
+# TYPE requests_total counter
+requests_total{pattern="GET /api/clients/{client_id}",status="200"} 3
+requests_total{pattern="GET /say \"hi\"",status="404"} 1
+# HELP sessions Active sessions.
+# TYPE sessions gauge
+sessions 3
+`
+	if got := scrape(t, registry); got != want {
+		t.Errorf("Unexpected exposition:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestValueFunc_NaN(t *testing.T) {
+	registry := NewRegistry()
+	registry.MustRegister(NewGaugeFunc("unavailable", "Unavailable value.", math.NaN))
+
+	if got := scrape(t, registry); !strings.Contains(got, "unavailable NaN\n") {
+		t.Errorf("Expected NaN sample, got:\n%s", got)
+	}
+}
+
+func TestRegistry_DuplicatePanics(t *testing.T) {
+	registry := NewRegistry()
+	registry.MustRegister(NewCounterVec("requests_total", "Requests served."))
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected registering a duplicate name to panic")
+		}
+	}()
+	registry.MustRegister(NewCounterVec("requests_total", "Requests served."))
+}
@@ -2,42 +2,151 @@ package middleware
 
 import (
 	"context"
+	"crypto/subtle"
 	"net/http"
+	"time"
+	"vetsys/internal/apierror"
 	"vetsys/internal/database"
+	"vetsys/internal/domain"
 )
 
 type AuthMiddleware struct {
-	SessionRepo *database.SessionRepository
+	SessionRepo database.SessionStore
+	UserRepo    database.UserStore
+	// SessionDuration, if set, slides a session's expiry forward on use, up
+	// to SessionMaxLifetime after it was created.
+	SessionDuration    time.Duration
+	SessionMaxLifetime time.Duration
 }
 
 type contextKey string
 
-const UserIDKey contextKey = "userID"
+const (
+	UserIDKey    contextKey = "userID"
+	UserRoleKey  contextKey = "userRole"
+	SessionIDKey contextKey = "sessionID"
+	CSRFTokenKey contextKey = "csrfToken"
+)
+
+// CSRFHeader carries the session's CSRF token on state-changing requests.
+// Login hands the token to the browser in the CSRFCookie cookie, which
+// scripts can read and other sites cannot.
+const (
+	CSRFHeader = "X-CSRF-Token"
+	CSRFCookie = "csrf_token"
+)
+
+// sessionTouchInterval limits how often a session's last use is written, so
+// a burst of requests costs one update rather than one each.
+const sessionTouchInterval = time.Minute
 
 func (auth *AuthMiddleware) Authenticate(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		cookie, err := r.Cookie("session_id")
 		if err != nil {
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			apierror.Error(w, "Unauthorized", http.StatusUnauthorized)
 			return
 		}
-		session, err := auth.SessionRepo.GetSession(cookie.Value)
+		session, err := auth.SessionRepo.GetSession(r.Context(), cookie.Value)
 		if err == database.ErrSessionNotFound {
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			apierror.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if err != nil {
+			apierror.Respond(w, err)
+			return
+		}
+		if now := time.Now().UTC(); auth.SessionDuration > 0 && now.Sub(session.LastSeenAt) >= sessionTouchInterval {
+			expiresAt := session.SlidingExpiry(now, auth.SessionDuration, auth.SessionMaxLifetime)
+			err = auth.SessionRepo.TouchSession(r.Context(), session.ID, now, expiresAt)
+			if err == database.ErrSessionNotFound {
+				apierror.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+			if err != nil {
+				apierror.Respond(w, err)
+				return
+			}
+		}
+		user, err := auth.UserRepo.GetUserByID(r.Context(), session.UserID)
+		if err == database.ErrUserNotFound {
+			apierror.Error(w, "Unauthorized", http.StatusUnauthorized)
 			return
 		}
 		if err != nil {
-			http.Error(w, "Server error", http.StatusInternalServerError)
+			apierror.Respond(w, err)
 			return
 		}
+		setLoggedUser(r.Context(), session.UserID)
 		ctx := context.WithValue(r.Context(), UserIDKey, session.UserID)
+		ctx = context.WithValue(ctx, UserRoleKey, user.Role)
+		ctx = context.WithValue(ctx, SessionIDKey, session.ID)
+		ctx = context.WithValue(ctx, CSRFTokenKey, session.CSRFToken)
 		r = r.WithContext(ctx)
 
 		next(w, r)
 	}
 }
 
+// Authorize rejects requests whose user role lacks permission. It must run
+// inside Authenticate.
+func (auth *AuthMiddleware) Authorize(permission domain.Permission, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		role, ok := GetUserRole(r.Context())
+		if !ok {
+			apierror.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if !role.Can(permission) {
+			apierror.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// CSRF rejects state-changing requests that do not carry the session's CSRF
+// token in the CSRFHeader header. A cross-site form or fetch sends the session
+// cookie but cannot read the token. It must run inside Authenticate.
+func (auth *AuthMiddleware) CSRF(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			next(w, r)
+			return
+		}
+		expected, ok := GetCSRFToken(r.Context())
+		if !ok {
+			apierror.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		// Sessions opened before tokens were issued have none to match.
+		if expected == "" || subtle.ConstantTimeCompare([]byte(r.Header.Get(CSRFHeader)), []byte(expected)) != 1 {
+			apierror.Error(w, "Forbidden: missing or invalid CSRF token", http.StatusForbidden)
+			return
+		}
+
+		next(w, r)
+	}
+}
+
 func GetUserID(ctx context.Context) (int64, bool) {
 	userID, ok := ctx.Value(UserIDKey).(int64)
 	return userID, ok
 }
+
+func GetUserRole(ctx context.Context) (domain.Role, bool) {
+	role, ok := ctx.Value(UserRoleKey).(domain.Role)
+	return role, ok
+}
+
+func GetSessionID(ctx context.Context) (string, bool) {
+	sessionID, ok := ctx.Value(SessionIDKey).(string)
+	return sessionID, ok
+}
+
+func GetCSRFToken(ctx context.Context) (string, bool) {
+	token, ok := ctx.Value(CSRFTokenKey).(string)
+	return token, ok
+}
@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+const requestLogKey contextKey = "requestLog"
+
+// requestLog collects what inner middleware and handlers learn about a
+// request for the log line written once it completes.
+type requestLog struct {
+	userID *int64
+}
+
+// Logging writes one log line per request with its ID, method, route
+// pattern, status, latency, response size and, once authenticated, user ID.
+// Server errors are logged at error level along with the error handlers
+// reported. It must run inside RequestID.
+func Logging(logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			entry := &requestLog{}
+			recorder := recordResponse(w)
+			r = r.WithContext(context.WithValue(r.Context(), requestLogKey, entry))
+
+			next.ServeHTTP(recorder, r)
+
+			// ServeMux sets Pattern on the request it was handed, which is r.
+			pattern := r.Pattern
+			if pattern == "" {
+				pattern = "unmatched"
+			}
+			requestID, _ := GetRequestID(r.Context())
+			attrs := []slog.Attr{
+				slog.String("request_id", requestID),
+				slog.String("method", r.Method),
+				slog.String("pattern", pattern),
+				slog.Int("status", recorder.status),
+				slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+				slog.Int("bytes", recorder.bytes),
+			}
+			if entry.userID != nil {
+				attrs = append(attrs, slog.Int64("user_id", *entry.userID))
+			}
+			level := slog.LevelInfo
+			if recorder.status >= http.StatusInternalServerError {
+				level = slog.LevelError
+			}
+			if recorder.err != nil {
+				attrs = append(attrs, slog.String("error", recorder.err.Error()))
+			}
+			logger.LogAttrs(r.Context(), level, "request", attrs...)
+		})
+	}
+}
+
+// setLoggedUser records the authenticated user for the request's log line.
+func setLoggedUser(ctx context.Context, userID int64) {
+	if entry, ok := ctx.Value(requestLogKey).(*requestLog); ok {
+		entry.userID = &userID
+	}
+}
+
+// responseRecorder captures the status and size of a response, and the error
+// behind it when a handler reports one through RecordError.
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	bytes       int
+	err         error
+	wroteHeader bool
+}
+
+// recordResponse returns a responseRecorder for w, reusing w if an outer
+// middleware already wrapped it so that errors handlers record reach every
+// middleware that reads them.
+func recordResponse(w http.ResponseWriter) *responseRecorder {
+	if recorder, ok := w.(*responseRecorder); ok {
+		return recorder
+	}
+	return &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+}
+
+func (recorder *responseRecorder) WriteHeader(status int) {
+	if !recorder.wroteHeader {
+		recorder.status = status
+		recorder.wroteHeader = true
+	}
+	recorder.ResponseWriter.WriteHeader(status)
+}
+
+func (recorder *responseRecorder) Write(b []byte) (int, error) {
+	recorder.wroteHeader = true
+	n, err := recorder.ResponseWriter.Write(b)
+	recorder.bytes += n
+	return n, err
+}
+
+func (recorder *responseRecorder) RecordError(err error) {
+	recorder.err = err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (recorder *responseRecorder) Unwrap() http.ResponseWriter {
+	return recorder.ResponseWriter
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+	"vetsys/internal/metrics"
+)
+
+var (
+	httpRequests = metrics.NewCounterVec(
+		"vetsys_http_requests_total",
+		"HTTP requests served, by route pattern and status.",
+		"pattern", "status",
+	)
+	httpRequestDuration = metrics.NewHistogramVec(
+		"vetsys_http_request_duration_seconds",
+		"Time to serve HTTP requests, by route pattern and status.",
+		metrics.DefBuckets,
+		"pattern", "status",
+	)
+	rateLimitRejections = metrics.NewCounterVec(
+		"vetsys_rate_limit_rejections_total",
+		"Requests refused by the rate limiter, by route pattern.",
+		"pattern",
+	)
+)
+
+func init() {
+	metrics.Default.MustRegister(httpRequests, httpRequestDuration, rateLimitRejections)
+}
+
+// Metrics counts requests and observes their latency by route pattern and
+// status. Labelling by pattern rather than path keeps IDs out of the series.
+func Metrics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := recordResponse(w)
+
+		next.ServeHTTP(recorder, r)
+
+		// ServeMux sets Pattern on the request it was handed, which is r.
+		pattern := r.Pattern
+		if pattern == "" {
+			pattern = "unmatched"
+		}
+		status := strconv.Itoa(recorder.status)
+		httpRequests.Inc(pattern, status)
+		httpRequestDuration.Observe(time.Since(start).Seconds(), pattern, status)
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"sync"
 	"time"
+	"vetsys/internal/apierror"
 )
 
 type RateLimitMiddleware struct {
@@ -12,8 +13,9 @@ type RateLimitMiddleware struct {
 }
 
 type IPRateLimiter struct {
-	limiters map[string]*RateLimiter
-	mutex    sync.Mutex
+	limiters  map[string]*RateLimiter
+	perMinute int
+	mutex     sync.Mutex
 }
 
 type RateLimiter struct {
@@ -32,15 +34,19 @@ func NewRateLimiter(maxTokens, refillRate float64) *RateLimiter {
 		lastRefillTime: time.Now(),
 	}
 }
-func NewIPRateLimiter() *IPRateLimiter {
+
+// NewIPRateLimiter allows each IP perMinute requests a minute, all of which
+// it may make at once.
+func NewIPRateLimiter(perMinute int) *IPRateLimiter {
 	return &IPRateLimiter{
-		limiters: make(map[string]*RateLimiter),
+		limiters:  make(map[string]*RateLimiter),
+		perMinute: perMinute,
 	}
 }
 
-func NewRateLimitMiddleware() *RateLimitMiddleware {
+func NewRateLimitMiddleware(perMinute int) *RateLimitMiddleware {
 	return &RateLimitMiddleware{
-		ipRateLimiter: NewIPRateLimiter(),
+		ipRateLimiter: NewIPRateLimiter(perMinute),
 	}
 }
 
@@ -50,8 +56,7 @@ func (i *IPRateLimiter) GetLimiter(ip string) *RateLimiter {
 
 	limiter, exists := i.limiters[ip]
 	if !exists {
-		// Allow 3 requests per minute
-		limiter = NewRateLimiter(3, 0.05)
+		limiter = NewRateLimiter(float64(i.perMinute), float64(i.perMinute)/60)
 		i.limiters[ip] = limiter
 	}
 
@@ -86,7 +91,7 @@ func (rateLimitMiddleware *RateLimitMiddleware) RateLimit(next http.HandlerFunc)
 	return func(w http.ResponseWriter, r *http.Request) {
 		ip, _, err := net.SplitHostPort(r.RemoteAddr)
 		if err != nil {
-			http.Error(w, "Invalid IP", http.StatusInternalServerError)
+			apierror.Error(w, "Invalid IP", http.StatusInternalServerError)
 			return
 		}
 
@@ -94,7 +99,8 @@ func (rateLimitMiddleware *RateLimitMiddleware) RateLimit(next http.HandlerFunc)
 		if limiter.Allow() {
 			next(w, r)
 		} else {
-			http.Error(w, "Rate Limit Exceeded", http.StatusTooManyRequests)
+			rateLimitRejections.Inc(r.Pattern)
+			apierror.Error(w, "Rate Limit Exceeded", http.StatusTooManyRequests)
 		}
 	}
 }
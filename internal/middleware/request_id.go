@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"vetsys/internal/apierror"
+)
+
+const RequestIDKey contextKey = "requestID"
+
+// maxRequestIDLength bounds incoming IDs, which end up in logs and responses.
+const maxRequestIDLength = 128
+
+// RequestID gives every request an ID, keeping a well-formed X-Request-ID
+// from the caller so a proxy's ID can be followed end to end. The ID is put
+// in the request context and echoed in the response header.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(apierror.RequestIDHeader)
+		if !isValidRequestID(id) {
+			id = newRequestID()
+		}
+		w.Header().Set(apierror.RequestIDHeader, id)
+		ctx := context.WithValue(r.Context(), RequestIDKey, id)
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func GetRequestID(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(RequestIDKey).(string)
+	return id, ok
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// isValidRequestID accepts IDs made of printable ASCII without spaces, which
+// covers UUIDs and the usual tracing formats.
+func isValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,68 @@
+// Package notify delivers messages to users outside the app, such as
+// password reset links. The server depends on the Notifier interface only;
+// the implementations here are for local use, where no mail server is
+// available.
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+type Message struct {
+	// To is the recipient's email address.
+	To      string
+	Subject string
+	Body    string
+}
+
+// Notifier delivers messages. Implementations must be safe for concurrent
+// use.
+type Notifier interface {
+	Notify(ctx context.Context, message Message) error
+}
+
+// FileNotifier appends each message to the file at Path, like a mailbox.
+type FileNotifier struct {
+	Path string
+
+	mu sync.Mutex
+}
+
+func NewFileNotifier(path string) *FileNotifier {
+	return &FileNotifier{Path: path}
+}
+
+func (notifier *FileNotifier) Notify(ctx context.Context, message Message) error {
+	notifier.mu.Lock()
+	defer notifier.mu.Unlock()
+
+	// Messages can carry credentials such as reset links.
+	f, err := os.OpenFile(notifier.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("notify: %w", err)
+	}
+	_, err = fmt.Fprintf(f, "Date: %s\nTo: %s\nSubject: %s\n\n%s\n\n", time.Now().UTC().Format(time.RFC1123Z), message.To, message.Subject, message.Body)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("notify: %w", err)
+	}
+	return nil
+}
+
+// LogNotifier writes each message to Logger. Anyone who can read the logs
+// can read the messages, so it is only fit for development.
+type LogNotifier struct {
+	Logger *slog.Logger
+}
+
+func (notifier LogNotifier) Notify(ctx context.Context, message Message) error {
+	notifier.Logger.InfoContext(ctx, "notification", "to", message.To, "subject", message.Subject, "body", message.Body)
+	return nil
+}
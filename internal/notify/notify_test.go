@@ -0,0 +1,52 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileNotifier_AppendsMessages(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox.txt")
+	notifier := NewFileNotifier(path)
+
+	for _, subject := range []string{"First", "Second"} {
+		err := notifier.Notify(context.Background(), Message{To: "vet@example.com", Subject: subject, Body: "Hello"})
+		if err != nil {
+			t.Fatalf("Failed to notify: %v", err)
+		}
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read outbox: %v", err)
+	}
+	out := string(content)
+	if strings.Count(out, "To: vet@example.com\n") != 2 || !strings.Contains(out, "Subject: First\n\nHello\n") ||
+		strings.Index(out, "Subject: First") > strings.Index(out, "Subject: Second") {
+		t.Errorf("Expected both messages in order, got:\n%s", out)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Failed to stat outbox: %v", err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("Expected the outbox to be private, got %v", info.Mode().Perm())
+	}
+}
+
+func TestLogNotifier(t *testing.T) {
+	var b bytes.Buffer
+	notifier := LogNotifier{Logger: slog.New(slog.NewTextHandler(&b, nil))}
+
+	if err := notifier.Notify(context.Background(), Message{To: "vet@example.com", Subject: "Reset", Body: "Link"}); err != nil {
+		t.Fatalf("Failed to notify: %v", err)
+	}
+	if !strings.Contains(b.String(), "to=vet@example.com") || !strings.Contains(b.String(), "body=Link") {
+		t.Errorf("Expected the message in the log, got %q", b.String())
+	}
+}
@@ -1,8 +1,12 @@
 package router
 
 import (
+	"log/slog"
 	"net/http"
+	"vetsys/internal/domain"
 	"vetsys/internal/handler"
+	"vetsys/internal/health"
+	"vetsys/internal/metrics"
 	"vetsys/internal/middleware"
 )
 
@@ -12,6 +16,18 @@ type Router struct {
 	consultationHandler *handler.ConsultationHandler
 	patientHandler      *handler.PatientHandler
 	userHandler         *handler.UserHandler
+	appointmentHandler  *handler.AppointmentHandler
+	registrationHandler *handler.RegistrationHandler
+	auditHandler        *handler.AuditHandler
+	searchHandler       *handler.SearchHandler
+	vaccinationHandler  *handler.VaccinationHandler
+	prescriptionHandler *handler.PrescriptionHandler
+	vitalsHandler       *handler.VitalsHandler
+	billingHandler      *handler.BillingHandler
+	inventoryHandler    *handler.InventoryHandler
+	intakeHandler       *handler.IntakeHandler
+	resetHandler        *handler.PasswordResetHandler
+	health              *health.Health
 	authMiddleware      *middleware.AuthMiddleware
 	rateLimitMiddleware *middleware.RateLimitMiddleware
 }
@@ -21,6 +37,19 @@ func NewRouter(
 	consultationHandler *handler.ConsultationHandler,
 	patientHandler *handler.PatientHandler,
 	userHandler *handler.UserHandler,
+	appointmentHandler *handler.AppointmentHandler,
+	registrationHandler *handler.RegistrationHandler,
+	auditHandler *handler.AuditHandler,
+	searchHandler *handler.SearchHandler,
+	vaccinationHandler *handler.VaccinationHandler,
+	prescriptionHandler *handler.PrescriptionHandler,
+	vitalsHandler *handler.VitalsHandler,
+	billingHandler *handler.BillingHandler,
+	inventoryHandler *handler.InventoryHandler,
+	intakeHandler *handler.IntakeHandler,
+	resetHandler *handler.PasswordResetHandler,
+	health *health.Health,
+	rateLimitMiddleware *middleware.RateLimitMiddleware,
 ) *Router {
 	return &Router{
 		mux:                 http.NewServeMux(),
@@ -28,12 +57,31 @@ func NewRouter(
 		consultationHandler: consultationHandler,
 		patientHandler:      patientHandler,
 		userHandler:         userHandler,
-		authMiddleware:      &middleware.AuthMiddleware{SessionRepo: userHandler.SessionRepo},
-		rateLimitMiddleware: middleware.NewRateLimitMiddleware(),
+		appointmentHandler:  appointmentHandler,
+		registrationHandler: registrationHandler,
+		auditHandler:        auditHandler,
+		searchHandler:       searchHandler,
+		vaccinationHandler:  vaccinationHandler,
+		prescriptionHandler: prescriptionHandler,
+		vitalsHandler:       vitalsHandler,
+		billingHandler:      billingHandler,
+		inventoryHandler:    inventoryHandler,
+		intakeHandler:       intakeHandler,
+		resetHandler:        resetHandler,
+		health:              health,
+		authMiddleware: &middleware.AuthMiddleware{
+			SessionRepo:        userHandler.SessionRepo,
+			UserRepo:           userHandler.UserRepo,
+			SessionDuration:    userHandler.SessionDuration,
+			SessionMaxLifetime: userHandler.SessionMaxLifetime,
+		},
+		rateLimitMiddleware: rateLimitMiddleware,
 	}
 }
 
-func (r *Router) SetupRoutes() *http.ServeMux {
+// SetupRoutes registers every route and returns the mux wrapped in the
+// middleware that applies to all requests.
+func (r *Router) SetupRoutes() http.Handler {
 	r.mux.Handle("GET /", http.FileServer(http.Dir("web")))
 	r.mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
 		http.ServeFile(w, r, "web/index.html")
@@ -44,41 +92,186 @@ func (r *Router) SetupRoutes() *http.ServeMux {
 	r.mux.HandleFunc("GET /login", func(w http.ResponseWriter, r *http.Request) {
 		http.ServeFile(w, r, "web/login.html")
 	})
+	r.mux.HandleFunc("GET /reset-password", func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, "web/reset-password.html")
+	})
+
+	r.mux.Handle("GET /metrics", metrics.Default.Handler())
+	r.mux.HandleFunc("GET /healthz", r.health.LiveHandler)
+	r.mux.HandleFunc("GET /readyz", r.health.ReadyHandler)
 
-	r.mux.HandleFunc("GET /home", r.authMiddleware.Authenticate(func(w http.ResponseWriter, r *http.Request) {
+	r.mux.HandleFunc("GET /home", r.authenticate(func(w http.ResponseWriter, r *http.Request) {
 		http.ServeFile(w, r, "web/home.html")
 	}))
 
 	//USERS
 	r.mux.HandleFunc("POST /api/auth/login", r.rateLimitMiddleware.RateLimit(r.userHandler.LogInHandler))
+	r.mux.HandleFunc("POST /api/auth/login/2fa", r.rateLimitMiddleware.RateLimit(r.userHandler.LogInTwoFactorHandler))
+	r.mux.HandleFunc("POST /api/auth/login/2fa/enroll", r.rateLimitMiddleware.RateLimit(r.userHandler.LogInEnrollHandler))
 	r.mux.HandleFunc("POST /api/users", r.rateLimitMiddleware.RateLimit(r.userHandler.CreateUserHandler))
-	r.mux.HandleFunc("POST /api/auth/logout", r.authMiddleware.Authenticate(r.userHandler.LogOutHandler))
-	r.mux.HandleFunc("DELETE /api/users/{user_id}", r.authMiddleware.Authenticate(r.userHandler.DeleteUserHandler))
-	r.mux.HandleFunc("PUT /api/users/{user_id}", r.authMiddleware.Authenticate(r.userHandler.UpdateUserHandler))
-	r.mux.HandleFunc("PUT /api/users/{user_id}/password", r.authMiddleware.Authenticate(r.userHandler.UpdatePasswordHandler))
-	r.mux.HandleFunc("GET /api/auth/me", r.authMiddleware.Authenticate(r.userHandler.MeHandler))
+	r.mux.HandleFunc("POST /api/auth/password-reset", r.rateLimitMiddleware.RateLimit(r.resetHandler.RequestResetHandler))
+	r.mux.HandleFunc("POST /api/auth/password-reset/confirm", r.rateLimitMiddleware.RateLimit(r.resetHandler.ConfirmResetHandler))
+	r.mux.HandleFunc("POST /api/auth/logout", r.authenticate(r.userHandler.LogOutHandler))
+	r.mux.HandleFunc("DELETE /api/users/{user_id}", r.authenticate(r.userHandler.DeleteUserHandler))
+	r.mux.HandleFunc("PUT /api/users/{user_id}", r.authenticate(r.userHandler.UpdateUserHandler))
+	r.mux.HandleFunc("PUT /api/users/{user_id}/password", r.authenticate(r.userHandler.UpdatePasswordHandler))
+	r.mux.HandleFunc("GET /api/auth/me", r.authenticate(r.userHandler.MeHandler))
+	r.mux.HandleFunc("GET /api/auth/sessions", r.authenticate(r.userHandler.GetSessionsHandler))
+	r.mux.HandleFunc("DELETE /api/auth/sessions", r.authenticate(r.userHandler.RevokeOtherSessionsHandler))
+	r.mux.HandleFunc("DELETE /api/auth/sessions/{session_id}", r.authenticate(r.userHandler.RevokeSessionHandler))
+	r.mux.HandleFunc("GET /api/auth/2fa", r.authenticate(r.userHandler.GetTwoFactorHandler))
+	r.mux.HandleFunc("DELETE /api/auth/2fa", r.authenticate(r.userHandler.DisableTwoFactorHandler))
+	r.mux.HandleFunc("POST /api/auth/2fa/enroll", r.authenticate(r.userHandler.EnrollTwoFactorHandler))
+	r.mux.HandleFunc("POST /api/auth/2fa/enroll/confirm", r.authenticate(r.userHandler.ConfirmTwoFactorHandler))
+	r.mux.HandleFunc("POST /api/auth/2fa/recovery-codes", r.authenticate(r.userHandler.RegenerateRecoveryCodesHandler))
+	r.mux.HandleFunc("PUT /api/users/{user_id}/role", r.authorize(domain.PermUsersManage, r.userHandler.UpdateUserRoleHandler))
+	r.mux.HandleFunc("GET /api/auth/lockouts", r.authorize(domain.PermUsersManage, r.userHandler.GetLockoutsHandler))
+	r.mux.HandleFunc("DELETE /api/auth/lockouts/{scope}/{key}", r.authorize(domain.PermUsersManage, r.userHandler.UnlockHandler))
+	r.mux.HandleFunc("DELETE /api/users/{user_id}/2fa", r.authorize(domain.PermUsersManage, r.userHandler.ResetTwoFactorHandler))
+	r.mux.HandleFunc("GET /api/auth/2fa/policy", r.authorize(domain.PermUsersManage, r.userHandler.GetTwoFactorPolicyHandler))
+	r.mux.HandleFunc("PUT /api/auth/2fa/policy", r.authorize(domain.PermUsersManage, r.userHandler.UpdateTwoFactorPolicyHandler))
 	//CLIENTS
-	r.mux.HandleFunc("POST /api/clients", r.authMiddleware.Authenticate(r.clientHandler.CreateClient))
-	r.mux.HandleFunc("GET /api/clients/{client_id}", r.authMiddleware.Authenticate(r.clientHandler.GetClientByIDHandler))
-	r.mux.HandleFunc("GET /api/clients/dni/{client_dni}", r.authMiddleware.Authenticate(r.clientHandler.GetClientByDNIHandler))
-	r.mux.HandleFunc("PUT /api/clients/{client_id}", r.authMiddleware.Authenticate(r.clientHandler.UpdateClientHandler))
-	r.mux.HandleFunc("DELETE /api/clients/{client_id}", r.authMiddleware.Authenticate(r.clientHandler.DeleteClientHandler))
+	r.mux.HandleFunc("POST /api/clients", r.authorize(domain.PermClientsWrite, r.clientHandler.CreateClient))
+	r.mux.HandleFunc("GET /api/clients/{client_id}", r.authorize(domain.PermClientsRead, r.clientHandler.GetClientByIDHandler))
+	r.mux.HandleFunc("GET /api/clients/{lookup}/{value}", lookups(map[string]lookup{
+		"dni":           {"client_dni", r.authorize(domain.PermClientsRead, r.clientHandler.GetClientByDNIHandler)},
+		"consultations": {"client_id", r.authorize(domain.PermConsultationsRead, r.consultationHandler.GetConsultationsByClientIDHandler)},
+	}))
+	r.mux.HandleFunc("PUT /api/clients/{client_id}", r.authorize(domain.PermClientsWrite, r.clientHandler.UpdateClientHandler))
+	r.mux.HandleFunc("DELETE /api/clients/{client_id}", r.authorize(domain.PermClientsDelete, r.clientHandler.DeleteClientHandler))
 
 	//PATIENTS
-	r.mux.HandleFunc("POST /api/patients", r.authMiddleware.Authenticate(r.patientHandler.CreatePatientHandler))
-	r.mux.HandleFunc("GET /api/patients/{patient_id}", r.authMiddleware.Authenticate(r.patientHandler.GetPatientByIDHandler))
-	r.mux.HandleFunc("GET /api/patients/owner/{owner_id}", r.authMiddleware.Authenticate(r.patientHandler.GetPatientByOwnerIDHandler))
-	r.mux.HandleFunc("PUT /api/patients/{patient_id}", r.authMiddleware.Authenticate(r.patientHandler.UpdatePatientHandler))
-	r.mux.HandleFunc("DELETE /api/patients/{patient_id}", r.authMiddleware.Authenticate(r.patientHandler.DeletePatientHandler))
+	r.mux.HandleFunc("POST /api/patients", r.authorize(domain.PermPatientsWrite, r.patientHandler.CreatePatientHandler))
+	r.mux.HandleFunc("GET /api/patients/{patient_id}", r.authorize(domain.PermPatientsRead, r.patientHandler.GetPatientByIDHandler))
+	r.mux.HandleFunc("GET /api/patients/{lookup}/{value}", lookups(map[string]lookup{
+		"owner":         {"owner_id", r.authorize(domain.PermPatientsRead, r.patientHandler.GetPatientByOwnerIDHandler)},
+		"consultations": {"patient_id", r.authorize(domain.PermConsultationsRead, r.consultationHandler.GetConsultationsByPatientIDHandler)},
+		"vaccinations":  {"patient_id", r.authorize(domain.PermVaccinationsRead, r.vaccinationHandler.GetPatientVaccinationsHandler)},
+	}))
+	r.mux.HandleFunc("PUT /api/patients/{patient_id}", r.authorize(domain.PermPatientsWrite, r.patientHandler.UpdatePatientHandler))
+	r.mux.HandleFunc("DELETE /api/patients/{patient_id}", r.authorize(domain.PermPatientsDelete, r.patientHandler.DeletePatientHandler))
 
 	//CONSULTATIONS
-	r.mux.HandleFunc("POST /api/consultations", r.authMiddleware.Authenticate(r.consultationHandler.CreateConsultationHandler))
-	r.mux.HandleFunc("GET /api/consultations/{consultation_id}", r.authMiddleware.Authenticate(r.consultationHandler.GetConsultationByIDHandler))
-	r.mux.HandleFunc("GET /api/clients/consultations/{client_id}", r.authMiddleware.Authenticate(r.consultationHandler.GetConsultationsByClientIDHandler))
-	r.mux.HandleFunc("GET /api/patients/consultations/{patient_id}", r.authMiddleware.Authenticate(r.consultationHandler.GetConsultationsByPatientIDHandler))
-	r.mux.HandleFunc("GET /api/consultations", r.authMiddleware.Authenticate(r.consultationHandler.GetAllConsultationsHandler))
-	r.mux.HandleFunc("PUT /api/consultations/{consultation_id}", r.authMiddleware.Authenticate(r.consultationHandler.UpdateConsultationHandler))
-	r.mux.HandleFunc("DELETE /api/consultations/{consultation_id}", r.authMiddleware.Authenticate(r.consultationHandler.DeleteConsultationHandler))
-
-	return r.mux
+	r.mux.HandleFunc("POST /api/consultations", r.authorize(domain.PermConsultationsWrite, r.consultationHandler.CreateConsultationHandler))
+	r.mux.HandleFunc("GET /api/consultations/{consultation_id}", r.authorize(domain.PermConsultationsRead, r.consultationHandler.GetConsultationByIDHandler))
+	r.mux.HandleFunc("GET /api/consultations", r.authorize(domain.PermConsultationsRead, r.consultationHandler.GetAllConsultationsHandler))
+	r.mux.HandleFunc("PUT /api/consultations/{consultation_id}", r.authorize(domain.PermConsultationsWrite, r.consultationHandler.UpdateConsultationHandler))
+	r.mux.HandleFunc("DELETE /api/consultations/{consultation_id}", r.authorize(domain.PermConsultationsDelete, r.consultationHandler.DeleteConsultationHandler))
+
+	//INTAKE
+	r.mux.HandleFunc("POST /api/intake", r.authorize(domain.PermClientsWrite, r.intakeHandler.CreateIntakeHandler))
+
+	//APPOINTMENTS
+	r.mux.HandleFunc("POST /api/appointments", r.authorize(domain.PermAppointmentsWrite, r.appointmentHandler.CreateAppointmentHandler))
+	r.mux.HandleFunc("GET /api/appointments", r.authorize(domain.PermAppointmentsRead, r.appointmentHandler.GetAppointmentsHandler))
+	r.mux.HandleFunc("GET /api/appointments/{appointment_id}", r.authorize(domain.PermAppointmentsRead, r.appointmentHandler.GetAppointmentByIDHandler))
+	r.mux.HandleFunc("PUT /api/appointments/{appointment_id}", r.authorize(domain.PermAppointmentsWrite, r.appointmentHandler.UpdateAppointmentHandler))
+	r.mux.HandleFunc("PUT /api/appointments/{appointment_id}/status", r.authorize(domain.PermAppointmentsWrite, r.appointmentHandler.UpdateAppointmentStatusHandler))
+	r.mux.HandleFunc("POST /api/appointments/{appointment_id}/consultation", r.authorize(domain.PermAppointmentsWrite, r.appointmentHandler.ConvertAppointmentHandler))
+
+	//ALLOWED REGISTRATIONS
+	r.mux.HandleFunc("GET /api/registrations", r.authorize(domain.PermRegistrationsManage, r.registrationHandler.GetRegistrationsHandler))
+	r.mux.HandleFunc("POST /api/registrations", r.authorize(domain.PermRegistrationsManage, r.registrationHandler.CreateRegistrationsHandler))
+	r.mux.HandleFunc("DELETE /api/registrations/{dni}", r.authorize(domain.PermRegistrationsManage, r.registrationHandler.RevokeRegistrationHandler))
+	r.mux.HandleFunc("POST /api/registrations/{dni}/reopen", r.authorize(domain.PermRegistrationsManage, r.registrationHandler.ReopenRegistrationHandler))
+
+	//VACCINATIONS
+	r.mux.HandleFunc("GET /api/vaccines", r.authorize(domain.PermVaccinationsRead, r.vaccinationHandler.GetVaccinesHandler))
+	r.mux.HandleFunc("POST /api/vaccines", r.authorize(domain.PermVaccinesManage, r.vaccinationHandler.CreateVaccineHandler))
+	r.mux.HandleFunc("GET /api/vaccines/{vaccine_id}", r.authorize(domain.PermVaccinationsRead, r.vaccinationHandler.GetVaccineByIDHandler))
+	r.mux.HandleFunc("PUT /api/vaccines/{vaccine_id}/boosters/{species}", r.authorize(domain.PermVaccinesManage, r.vaccinationHandler.SetVaccineBoosterHandler))
+	r.mux.HandleFunc("DELETE /api/vaccines/{vaccine_id}/boosters/{species}", r.authorize(domain.PermVaccinesManage, r.vaccinationHandler.DeleteVaccineBoosterHandler))
+	r.mux.HandleFunc("POST /api/vaccinations", r.authorize(domain.PermVaccinationsWrite, r.vaccinationHandler.CreateVaccinationHandler))
+	r.mux.HandleFunc("DELETE /api/vaccinations/{vaccination_id}", r.authorize(domain.PermVaccinationsWrite, r.vaccinationHandler.DeleteVaccinationHandler))
+	r.mux.HandleFunc("GET /api/vaccinations/due", r.authorize(domain.PermVaccinationsRead, r.vaccinationHandler.GetDueVaccinationsHandler))
+
+	//VITALS
+	r.mux.HandleFunc("POST /api/patients/{patient_id}/vitals", r.authorize(domain.PermVitalsWrite, r.vitalsHandler.CreateVitalsHandler))
+	r.mux.HandleFunc("GET /api/patients/{patient_id}/vitals", r.authorize(domain.PermVitalsRead, r.vitalsHandler.GetPatientVitalsHandler))
+	r.mux.HandleFunc("GET /api/patients/{patient_id}/vitals/trend", r.authorize(domain.PermVitalsRead, r.vitalsHandler.GetVitalsTrendHandler))
+	r.mux.HandleFunc("GET /api/patients/{patient_id}/vitals/{vitals_id}", r.authorize(domain.PermVitalsRead, r.vitalsHandler.GetVitalsByIDHandler))
+	r.mux.HandleFunc("PUT /api/patients/{patient_id}/vitals/{vitals_id}", r.authorize(domain.PermVitalsWrite, r.vitalsHandler.UpdateVitalsHandler))
+	r.mux.HandleFunc("DELETE /api/patients/{patient_id}/vitals/{vitals_id}", r.authorize(domain.PermVitalsWrite, r.vitalsHandler.DeleteVitalsHandler))
+
+	//PRESCRIPTIONS
+	r.mux.HandleFunc("GET /api/medications", r.authorize(domain.PermPrescriptionsRead, r.prescriptionHandler.GetMedicationsHandler))
+	r.mux.HandleFunc("POST /api/medications", r.authorize(domain.PermMedicationsManage, r.prescriptionHandler.CreateMedicationHandler))
+	r.mux.HandleFunc("GET /api/medications/{medication_id}", r.authorize(domain.PermPrescriptionsRead, r.prescriptionHandler.GetMedicationByIDHandler))
+	r.mux.HandleFunc("PUT /api/medications/{medication_id}/dose-ranges/{species}", r.authorize(domain.PermMedicationsManage, r.prescriptionHandler.SetDoseRangeHandler))
+	r.mux.HandleFunc("DELETE /api/medications/{medication_id}/dose-ranges/{species}", r.authorize(domain.PermMedicationsManage, r.prescriptionHandler.DeleteDoseRangeHandler))
+	r.mux.HandleFunc("POST /api/consultations/{consultation_id}/prescriptions", r.authorize(domain.PermPrescriptionsWrite, r.prescriptionHandler.CreatePrescriptionHandler))
+	r.mux.HandleFunc("GET /api/consultations/{consultation_id}/prescriptions", r.authorize(domain.PermPrescriptionsRead, r.prescriptionHandler.GetConsultationPrescriptionsHandler))
+	r.mux.HandleFunc("DELETE /api/prescriptions/{prescription_id}", r.authorize(domain.PermPrescriptionsWrite, r.prescriptionHandler.DeletePrescriptionHandler))
+
+	//BILLING
+	r.mux.HandleFunc("POST /api/consultations/{consultation_id}/items", r.authorize(domain.PermBillingWrite, r.billingHandler.CreateBillableItemHandler))
+	r.mux.HandleFunc("GET /api/consultations/{consultation_id}/items", r.authorize(domain.PermBillingRead, r.billingHandler.GetConsultationItemsHandler))
+	r.mux.HandleFunc("DELETE /api/billable-items/{item_id}", r.authorize(domain.PermBillingWrite, r.billingHandler.DeleteBillableItemHandler))
+	r.mux.HandleFunc("POST /api/clients/{client_id}/invoices", r.authorize(domain.PermBillingWrite, r.billingHandler.CreateInvoiceHandler))
+	r.mux.HandleFunc("GET /api/clients/{client_id}/invoices", r.authorize(domain.PermBillingRead, r.billingHandler.GetClientInvoicesHandler))
+	r.mux.HandleFunc("GET /api/clients/{client_id}/balance", r.authorize(domain.PermBillingRead, r.billingHandler.GetClientBalanceHandler))
+	r.mux.HandleFunc("GET /api/invoices/{invoice_id}", r.authorize(domain.PermBillingRead, r.billingHandler.GetInvoiceByIDHandler))
+	r.mux.HandleFunc("POST /api/invoices/{invoice_id}/payments", r.authorize(domain.PermBillingWrite, r.billingHandler.CreatePaymentHandler))
+	r.mux.HandleFunc("POST /api/invoices/{invoice_id}/void", r.authorize(domain.PermBillingWrite, r.billingHandler.VoidInvoiceHandler))
+
+	//INVENTORY
+	r.mux.HandleFunc("GET /api/products", r.authorize(domain.PermInventoryRead, r.inventoryHandler.GetProductsHandler))
+	r.mux.HandleFunc("POST /api/products", r.authorize(domain.PermInventoryWrite, r.inventoryHandler.CreateProductHandler))
+	r.mux.HandleFunc("GET /api/products/{product_id}", r.authorize(domain.PermInventoryRead, r.inventoryHandler.GetProductByIDHandler))
+	r.mux.HandleFunc("PUT /api/products/{product_id}", r.authorize(domain.PermInventoryWrite, r.inventoryHandler.UpdateProductHandler))
+	r.mux.HandleFunc("POST /api/products/{product_id}/movements", r.authorize(domain.PermInventoryWrite, r.inventoryHandler.CreateStockMovementHandler))
+	r.mux.HandleFunc("GET /api/products/{product_id}/movements", r.authorize(domain.PermInventoryRead, r.inventoryHandler.GetStockMovementsHandler))
+	r.mux.HandleFunc("GET /api/inventory/report", r.authorize(domain.PermInventoryRead, r.inventoryHandler.GetInventoryReportHandler))
+
+	//SEARCH
+	r.mux.HandleFunc("GET /api/search", r.authenticate(r.searchHandler.SearchHandler))
+
+	//AUDIT
+	r.mux.HandleFunc("GET /api/audit", r.authorize(domain.PermAuditRead, r.auditHandler.GetAuditEntriesHandler))
+
+	return chain(r.mux, middleware.RequestID, middleware.Logging(slog.Default()), middleware.Metrics)
+}
+
+// chain wraps h in middlewares, the first being the outermost.
+func chain(h http.Handler, middlewares ...func(http.Handler) http.Handler) http.Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		h = middlewares[i](h)
+	}
+	return h
+}
+
+// lookup is the handler for one name of a lookups route, and the wildcard it
+// reads the looked-up ID from.
+type lookup struct {
+	wildcard string
+	handler  http.HandlerFunc
+}
+
+// lookups serves "/api/<resource>/<name>/{id}" routes such as
+// /api/patients/owner/{owner_id}. The mux refuses to register them next to the
+// "/api/<resource>/{id}/<sub-resource>" routes, which overlap them with neither
+// more specific, so one "{lookup}/{value}" pattern takes them all and hands the
+// request to the lookup of that name.
+func lookups(byName map[string]lookup) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lookup, ok := byName[r.PathValue("lookup")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		r.SetPathValue(lookup.wildcard, r.PathValue("value"))
+		lookup.handler(w, r)
+	}
+}
+
+// authenticate wraps next so it only runs for signed-in users, and for
+// state-changing methods only with the session's CSRF token.
+func (r *Router) authenticate(next http.HandlerFunc) http.HandlerFunc {
+	return r.authMiddleware.Authenticate(r.authMiddleware.CSRF(next))
+}
+
+// authorize wraps next so it only runs for authenticated users whose role
+// grants permission.
+func (r *Router) authorize(permission domain.Permission, next http.HandlerFunc) http.HandlerFunc {
+	return r.authenticate(r.authMiddleware.Authorize(permission, next))
 }
@@ -3,28 +3,42 @@ package server
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
+	"vetsys/internal/health"
 	"vetsys/internal/router"
 )
 
 type Server struct {
-	httpServer *http.Server
-	router     *router.Router
+	httpServer     *http.Server
+	router         *router.Router
+	health         *health.Health
+	drainDelay     time.Duration
+	cancelRequests context.CancelFunc
 }
 
-func NewServer(port string, router *router.Router) *Server {
+// NewServer derives every request context from one base context, so requests
+// still running when graceful shutdown gives up can be cancelled, along with
+// their queries. drainDelay is how long the server keeps serving with
+// readiness failing before it stops accepting connections.
+func NewServer(port string, router *router.Router, health *health.Health, drainDelay time.Duration) *Server {
+	baseCtx, cancelRequests := context.WithCancel(context.Background())
 	return &Server{
-		router: router,
+		router:         router,
+		health:         health,
+		drainDelay:     drainDelay,
+		cancelRequests: cancelRequests,
 		httpServer: &http.Server{
 			Addr:         fmt.Sprintf(":%s", port),
 			ReadTimeout:  15 * time.Second,
 			WriteTimeout: 15 * time.Second,
 			IdleTimeout:  60 * time.Second,
+			BaseContext:  func(net.Listener) context.Context { return baseCtx },
 		},
 	}
 }
@@ -32,12 +46,22 @@ func NewServer(port string, router *router.Router) *Server {
 func (s *Server) Initialize() error {
 	s.httpServer.Handler = s.router.SetupRoutes()
 
-	log.Printf("Server starting on %s", s.httpServer.Addr)
+	slog.Info("server starting", "addr", s.httpServer.Addr)
 	return s.httpServer.ListenAndServe()
 }
 
+// Shutdown fails readiness and keeps serving for the drain delay, so the
+// orchestrator stops routing new traffic here, then waits for in-flight
+// requests until ctx is done and cancels the ones left.
 func (s *Server) Shutdown(ctx context.Context) error {
-	log.Println("Shutting down server...")
+	slog.Info("shutting down server", "drain_delay", s.drainDelay)
+	defer s.cancelRequests()
+
+	s.health.SetShuttingDown()
+	select {
+	case <-time.After(s.drainDelay):
+	case <-ctx.Done():
+	}
 	return s.httpServer.Shutdown(ctx)
 }
 
@@ -47,22 +71,24 @@ func (s *Server) StartServer(router router.Router) {
 	signal.Notify(done, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
 
 	go func() {
-		if err := s.Initialize(); err != nil {
-			log.Fatalf("Server failed to start: %v", err)
+		if err := s.Initialize(); err != nil && err != http.ErrServerClosed {
+			slog.Error("server failed to start", "error", err)
+			os.Exit(1)
 		}
 	}()
 
-	log.Println("Server started successfully")
+	slog.Info("server started")
 
 	<-done
-	log.Println("Server stopping...")
+	slog.Info("server stopping")
 
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
 	if err := s.Shutdown(ctx); err != nil {
-		log.Fatalf("Server forced to shutdown: %v", err)
+		slog.Error("server forced to shutdown", "error", err)
+		os.Exit(1)
 	}
 
-	log.Println("Server stopped")
+	slog.Info("server stopped")
 }
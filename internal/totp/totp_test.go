@@ -0,0 +1,62 @@
+package totp
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The SHA-1 test vectors of RFC 6238, appendix B, cut to 6 digits.
+func TestCode_RFC6238(t *testing.T) {
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+	for _, test := range []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	} {
+		code, err := Code(secret, Step(time.Unix(test.unix, 0)))
+		if err != nil {
+			t.Fatalf("Failed to compute code: %v", err)
+		}
+		if code != test.code {
+			t.Errorf("At %d: expected %s, got %s", test.unix, test.code, code)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatalf("Failed to generate secret: %v", err)
+	}
+	now := time.Date(2026, 3, 1, 12, 0, 10, 0, time.UTC)
+	code, _ := Code(secret, Step(now))
+
+	if step, ok := Validate(secret, code, now); !ok || step != Step(now) {
+		t.Errorf("Expected the current code to be valid at step %d, got %d, %v", Step(now), step, ok)
+	}
+	if _, ok := Validate(secret, code[:3]+" "+code[3:], now.Add(Period)); !ok {
+		t.Error("Expected the code to be valid one step later, spaces and all")
+	}
+	if _, ok := Validate(secret, code, now.Add(2*Period)); ok {
+		t.Error("Expected the code to be invalid two steps later")
+	}
+	if _, ok := Validate(secret, "12345", now); ok {
+		t.Error("Expected a short code to be invalid")
+	}
+}
+
+func TestURI(t *testing.T) {
+	uri := URI("VetSys", "vet@example.com", "JBSWY3DPEHPK3PXP")
+	if !strings.HasPrefix(uri, "otpauth://totp/VetSys:vet@example.com?") ||
+		!strings.Contains(uri, "secret=JBSWY3DPEHPK3PXP") || !strings.Contains(uri, "issuer=VetSys") {
+		t.Errorf("Unexpected URI %s", uri)
+	}
+}
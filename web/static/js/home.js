@@ -9,6 +9,14 @@ document.addEventListener("DOMContentLoaded", () => {
     fetchConsultations();
 });
 
+// Logging out changes state, so it is a POST carrying the CSRF token rather
+// than a plain link.
+document.querySelector('a[href="/api/auth/logout"]').addEventListener("click", async (event) => {
+    event.preventDefault();
+    await apiFetch("/api/auth/logout", { method: "POST" });
+    window.location.href = "/login";
+});
+
 async function fetchConsultations(page = 1) {
     const formData = new FormData(form);
 
@@ -29,7 +37,7 @@ async function fetchConsultations(page = 1) {
     params.append("page", page);
 
     try {
-        const response = await fetch(`/api/consultations?${params.toString()}`);
+        const response = await apiFetch(`/api/consultations?${params.toString()}`);
         if (!response.ok) {
             throw new Error("Error fetching consultations");
         }
@@ -1,10 +1,15 @@
 const $RegisterButton = document.querySelector(".register-button")
+const $ResetButton = document.querySelector(".reset-button")
 const $form = document.querySelector(".form-login")
 
 $RegisterButton.addEventListener("click", () => {
     window.location.href = '/register';
 })
 
+$ResetButton.addEventListener("click", () => {
+    window.location.href = '/reset-password';
+})
+
 $form.addEventListener("submit", async (event) => {
     event.preventDefault()
 
@@ -15,7 +20,7 @@ $form.addEventListener("submit", async (event) => {
     }
 
     try {
-        const response = await fetch("/api/auth/login", {
+        const response = await apiFetch("/api/auth/login", {
             method: "POST",
             headers: {
                 "Content-Type": "application/json"
@@ -24,9 +29,15 @@ $form.addEventListener("submit", async (event) => {
         })
 
         if (response.ok) {
+            const body = await response.json()
+            if (body.twoFactorRequired) {
+                await completeTwoFactor(body)
+                return
+            }
             window.location.href = "/home"
         } else {
-            const errorText = await response.text()
+            const { error } = await response.json().catch(() => ({ error: { message: response.statusText } }))
+            const errorText = error.message
             
             switch (response.status) {
                 case 400:
@@ -50,3 +61,66 @@ $form.addEventListener("submit", async (event) => {
         console.error(error)
     }
 })
+
+// completeTwoFactor finishes a login that asks for a second factor, enrolling
+// first if the user's role requires it and they have not set it up.
+async function completeTwoFactor(challenge) {
+    if (challenge.enrollmentRequired) {
+        const response = await apiFetch("/api/auth/login/2fa/enroll", {
+            method: "POST",
+            headers: {
+                "Content-Type": "application/json"
+            },
+            body: JSON.stringify({ token: challenge.token })
+        })
+        if (!response.ok) {
+            alert("Error: No se pudo iniciar la verificación en dos pasos. Vuelve a iniciar sesión")
+            return
+        }
+        const enrollment = await response.json()
+        alert("Tu rol requiere verificación en dos pasos.\n\n" +
+            "Agrega esta cuenta en tu aplicación de autenticación (Google Authenticator, Authy, etc.) con la clave:\n\n" +
+            enrollment.secret + "\n\no con el enlace:\n\n" + enrollment.uri)
+    }
+
+    const input = prompt(challenge.enrollmentRequired
+        ? "Ingresa el código de 6 dígitos que muestra tu aplicación"
+        : "Ingresa el código de 6 dígitos de tu aplicación o un código de recuperación")
+    if (input === null) {
+        return
+    }
+    const value = input.trim()
+    const data = { token: challenge.token }
+    if (/^[0-9 ]+$/.test(value)) {
+        data.code = value
+    } else {
+        data.recoveryCode = value
+    }
+
+    const response = await apiFetch("/api/auth/login/2fa", {
+        method: "POST",
+        headers: {
+            "Content-Type": "application/json"
+        },
+        body: JSON.stringify(data)
+    })
+    if (response.ok) {
+        const user = await response.json()
+        if (user.recoveryCodes) {
+            alert("Guarda estos códigos de recuperación en un lugar seguro. Cada uno sirve una sola vez si pierdes tu aplicación, y no se volverán a mostrar:\n\n" +
+                user.recoveryCodes.join("\n"))
+        }
+        window.location.href = "/home"
+        return
+    }
+    switch (response.status) {
+        case 401:
+            alert("Error: Código incorrecto o vencido. Vuelve a iniciar sesión si el problema continúa")
+            break
+        case 429:
+            alert("Error: Demasiados intentos. Espera un momento antes de intentar nuevamente")
+            break
+        default:
+            alert("Error: No se pudo completar la verificación en dos pasos")
+    }
+}
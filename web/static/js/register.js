@@ -18,7 +18,7 @@ $form.addEventListener("submit", async (event) => {
     }
 
     try {
-        const response = await fetch("/api/users", {
+        const response = await apiFetch("/api/users", {
             method: "POST",
             headers: {
                 "Content-Type": "application/json"
@@ -30,7 +30,8 @@ $form.addEventListener("submit", async (event) => {
             alert("Registrado correctamente, ahora inicie sesión.")
             window.location.href = "/"
         } else {
-            const errorText = await response.text()
+            const { error } = await response.json().catch(() => ({ error: { message: response.statusText } }))
+            const errorText = error.message
             
             switch (response.status) {
                 case 400:
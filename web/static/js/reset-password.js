@@ -0,0 +1,87 @@
+const $requestForm = document.querySelector(".form-request")
+const $confirmForm = document.querySelector(".form-confirm")
+
+// The reset link carries the token in the URL fragment, which is never sent to the server.
+const token = new URLSearchParams(window.location.hash.slice(1)).get("token")
+if (token) {
+    $requestForm.hidden = true
+    $confirmForm.hidden = false
+    history.replaceState(null, "", window.location.pathname)
+}
+
+document.querySelectorAll(".login-button").forEach($button => {
+    $button.addEventListener("click", () => {
+        window.location.href = "/login"
+    })
+})
+
+async function post(url, data) {
+    const response = await apiFetch(url, {
+        method: "POST",
+        headers: {
+            "Content-Type": "application/json"
+        },
+        body: JSON.stringify(data)
+    })
+    if (response.ok) {
+        return { response }
+    }
+    const { error } = await response.json().catch(() => ({ error: { message: response.statusText } }))
+    return { response, error }
+}
+
+$requestForm.addEventListener("submit", async (event) => {
+    event.preventDefault()
+
+    const formData = new FormData($requestForm)
+    try {
+        const { response, error } = await post("/api/auth/password-reset", { dni: formData.get("DNI") })
+        if (response.ok) {
+            alert("Si el DNI tiene una cuenta, enviamos un enlace a su email. Revisa tu correo.")
+            window.location.href = "/login"
+            return
+        }
+        switch (response.status) {
+            case 429:
+                alert("Error: Demasiados intentos. Espera un momento antes de intentar nuevamente")
+                break
+            default:
+                alert("Error inesperado: " + error.message)
+        }
+    } catch (error) {
+        alert("Error al conectar con el servidor. Verifica tu conexión a internet")
+        console.error(error)
+    }
+})
+
+$confirmForm.addEventListener("submit", async (event) => {
+    event.preventDefault()
+
+    const formData = new FormData($confirmForm)
+    try {
+        const { response, error } = await post("/api/auth/password-reset/confirm", { token, password: formData.get("password") })
+        if (response.ok) {
+            alert("Contraseña actualizada, ahora inicie sesión.")
+            window.location.href = "/login"
+            return
+        }
+        const field = error.details?.[0]?.field
+        switch (response.status) {
+            case 400:
+                if (field === "password") {
+                    alert("Error: La contraseña no cumple con los requisitos:\n- Mínimo 8 caracteres, máximo 72\n- Al menos una mayúscula\n- Al menos una minúscula\n- Al menos un número\n- Al menos un símbolo especial (! @ # $ % ^ & *)")
+                } else {
+                    alert("Error: El enlace no es válido o expiró. Solicita uno nuevo")
+                }
+                break
+            case 429:
+                alert("Error: Demasiados intentos. Espera un momento antes de intentar nuevamente")
+                break
+            default:
+                alert("Error inesperado: " + error.message)
+        }
+    } catch (error) {
+        alert("Error al conectar con el servidor. Verifica tu conexión a internet")
+        console.error(error)
+    }
+})